@@ -79,6 +79,121 @@ func (ExecutionStatus) EnumDescriptor() ([]byte, []int) {
 	return file_executor_v1_executor_proto_rawDescGZIP(), []int{0}
 }
 
+// 执行节点拒绝执行的原因
+type RejectReason int32
+
+const (
+	RejectReason_NOT_REJECTED RejectReason = 0 // 未拒绝
+	RejectReason_BUSY         RejectReason = 1 // 并发已满且等待队列已满
+	RejectReason_DRAINING     RejectReason = 2 // 执行节点排空中
+)
+
+// Enum value maps for RejectReason.
+var (
+	RejectReason_name = map[int32]string{
+		0: "NOT_REJECTED",
+		1: "BUSY",
+		2: "DRAINING",
+	}
+	RejectReason_value = map[string]int32{
+		"NOT_REJECTED": 0,
+		"BUSY":         1,
+		"DRAINING":     2,
+	}
+)
+
+func (x RejectReason) Enum() *RejectReason {
+	p := new(RejectReason)
+	*p = x
+	return p
+}
+
+func (x RejectReason) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (RejectReason) Descriptor() protoreflect.EnumDescriptor {
+	return file_executor_v1_executor_proto_enumTypes[1].Descriptor()
+}
+
+func (RejectReason) Type() protoreflect.EnumType {
+	return &file_executor_v1_executor_proto_enumTypes[1]
+}
+
+func (x RejectReason) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use RejectReason.Descriptor instead.
+func (RejectReason) EnumDescriptor() ([]byte, []int) {
+	return file_executor_v1_executor_proto_rawDescGZIP(), []int{1}
+}
+
+// 任务失败的错误分类
+type ErrorCategory int32
+
+const (
+	ErrorCategory_UNCATEGORIZED     ErrorCategory = 0 // 未分类
+	ErrorCategory_HANDLER_ERROR     ErrorCategory = 1 // 处理函数返回的普通错误
+	ErrorCategory_TIMEOUT           ErrorCategory = 2 // 超过最大执行时间
+	ErrorCategory_INTERRUPTED       ErrorCategory = 3 // 被调度节点中断或执行节点排空
+	ErrorCategory_HANDLER_NOT_FOUND ErrorCategory = 4 // 执行节点未注册对应的处理函数
+	ErrorCategory_SCRIPT_EXIT       ErrorCategory = 5 // 脚本以非零退出码退出
+	ErrorCategory_INVALID_PARAMS    ErrorCategory = 6 // 参数非法
+	ErrorCategory_PANIC             ErrorCategory = 7 // 处理函数 panic
+)
+
+// Enum value maps for ErrorCategory.
+var (
+	ErrorCategory_name = map[int32]string{
+		0: "UNCATEGORIZED",
+		1: "HANDLER_ERROR",
+		2: "TIMEOUT",
+		3: "INTERRUPTED",
+		4: "HANDLER_NOT_FOUND",
+		5: "SCRIPT_EXIT",
+		6: "INVALID_PARAMS",
+		7: "PANIC",
+	}
+	ErrorCategory_value = map[string]int32{
+		"UNCATEGORIZED":     0,
+		"HANDLER_ERROR":     1,
+		"TIMEOUT":           2,
+		"INTERRUPTED":       3,
+		"HANDLER_NOT_FOUND": 4,
+		"SCRIPT_EXIT":       5,
+		"INVALID_PARAMS":    6,
+		"PANIC":             7,
+	}
+)
+
+func (x ErrorCategory) Enum() *ErrorCategory {
+	p := new(ErrorCategory)
+	*p = x
+	return p
+}
+
+func (x ErrorCategory) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ErrorCategory) Descriptor() protoreflect.EnumDescriptor {
+	return file_executor_v1_executor_proto_enumTypes[2].Descriptor()
+}
+
+func (ErrorCategory) Type() protoreflect.EnumType {
+	return &file_executor_v1_executor_proto_enumTypes[2]
+}
+
+func (x ErrorCategory) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ErrorCategory.Descriptor instead.
+func (ErrorCategory) EnumDescriptor() ([]byte, []int) {
+	return file_executor_v1_executor_proto_rawDescGZIP(), []int{2}
+}
+
 type ExecutionState struct {
 	state    protoimpl.MessageState `protogen:"open.v1"`
 	Id       int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
@@ -101,8 +216,17 @@ type ExecutionState struct {
 	RescheduledParams map[string]string `protobuf:"bytes,7,rep,name=rescheduled_params,json=rescheduledParams,proto3" json:"rescheduled_params,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
 	// 执行节点的 nodeID，用于记录是哪个节点处理了任务
 	ExecutorNodeId string `protobuf:"bytes,8,opt,name=executor_node_id,json=executorNodeId,proto3" json:"executor_node_id,omitempty"`
-	unknownFields  protoimpl.UnknownFields
-	sizeCache      protoimpl.SizeCache
+	// 执行节点拒绝执行的原因，此时 status 为 FAILED_RESCHEDULABLE，
+	// 调度节点应立即排除该节点，转移到其他执行节点
+	RejectReason RejectReason `protobuf:"varint,9,opt,name=reject_reason,json=rejectReason,proto3,enum=executor.v1.RejectReason" json:"reject_reason,omitempty"`
+	// 上报序号，同一执行节点内单调递增，调度节点据此丢弃重复或乱序到达的旧状态
+	Sequence int64 `protobuf:"varint,10,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	// 执行结果，任务结束时由执行节点填充
+	Result *ExecutionResult `protobuf:"bytes,11,opt,name=result,proto3" json:"result,omitempty"`
+	// 尝试序号，原样回传 ExecuteRequest.attempt，调度节点据此把上报记录到对应的尝试上
+	Attempt       int64 `protobuf:"varint,12,opt,name=attempt,proto3" json:"attempt,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *ExecutionState) Reset() {
@@ -191,6 +315,125 @@ func (x *ExecutionState) GetExecutorNodeId() string {
 	return ""
 }
 
+func (x *ExecutionState) GetRejectReason() RejectReason {
+	if x != nil {
+		return x.RejectReason
+	}
+	return RejectReason_NOT_REJECTED
+}
+
+func (x *ExecutionState) GetSequence() int64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *ExecutionState) GetResult() *ExecutionResult {
+	if x != nil {
+		return x.Result
+	}
+	return nil
+}
+
+func (x *ExecutionState) GetAttempt() int64 {
+	if x != nil {
+		return x.Attempt
+	}
+	return 0
+}
+
+// 执行结果
+type ExecutionResult struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// 结果数据，通常为 JSON，大小受限
+	Payload []byte `protobuf:"bytes,1,opt,name=payload,proto3" json:"payload,omitempty"`
+	// 错误信息，任务失败时填充
+	ErrorMessage string `protobuf:"bytes,2,opt,name=error_message,json=errorMessage,proto3" json:"error_message,omitempty"`
+	// 错误码，由处理函数返回的错误决定
+	ErrorCode string `protobuf:"bytes,3,opt,name=error_code,json=errorCode,proto3" json:"error_code,omitempty"`
+	// 错误分类
+	ErrorCategory ErrorCategory `protobuf:"varint,4,opt,name=error_category,json=errorCategory,proto3,enum=executor.v1.ErrorCategory" json:"error_category,omitempty"`
+	// 执行节点侧的错误详情，如 panic 堆栈、脚本输出的末尾部分，大小受限
+	ErrorDetail string `protobuf:"bytes,5,opt,name=error_detail,json=errorDetail,proto3" json:"error_detail,omitempty"`
+	// 脚本退出码，仅 SCRIPT_EXIT 时有意义
+	ExitCode      int32 `protobuf:"varint,6,opt,name=exit_code,json=exitCode,proto3" json:"exit_code,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExecutionResult) Reset() {
+	*x = ExecutionResult{}
+	mi := &file_executor_v1_executor_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExecutionResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExecutionResult) ProtoMessage() {}
+
+func (x *ExecutionResult) ProtoReflect() protoreflect.Message {
+	mi := &file_executor_v1_executor_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExecutionResult.ProtoReflect.Descriptor instead.
+func (*ExecutionResult) Descriptor() ([]byte, []int) {
+	return file_executor_v1_executor_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ExecutionResult) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *ExecutionResult) GetErrorMessage() string {
+	if x != nil {
+		return x.ErrorMessage
+	}
+	return ""
+}
+
+func (x *ExecutionResult) GetErrorCode() string {
+	if x != nil {
+		return x.ErrorCode
+	}
+	return ""
+}
+
+func (x *ExecutionResult) GetErrorCategory() ErrorCategory {
+	if x != nil {
+		return x.ErrorCategory
+	}
+	return ErrorCategory_UNCATEGORIZED
+}
+
+func (x *ExecutionResult) GetErrorDetail() string {
+	if x != nil {
+		return x.ErrorDetail
+	}
+	return ""
+}
+
+func (x *ExecutionResult) GetExitCode() int32 {
+	if x != nil {
+		return x.ExitCode
+	}
+	return 0
+}
+
 type ExecuteRequest struct {
 	state           protoimpl.MessageState `protogen:"open.v1"`
 	Eid             int64                  `protobuf:"varint,1,opt,name=eid,proto3" json:"eid,omitempty"` // execution id
@@ -201,14 +444,16 @@ type ExecuteRequest struct {
 	// 1 一部分是通过管理后台，业务方自己搞的参数
 	// 2. 另外一部分是我们调度用的，比如说 offset, limit
 	// 即包含了业务参数和调度参数 (e.g., offset, limit)
-	Params        map[string]string `protobuf:"bytes,5,rep,name=params,proto3" json:"params,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Params map[string]string `protobuf:"bytes,5,rep,name=params,proto3" json:"params,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// 本次尝试的序号，执行节点在上报的 ExecutionState 中原样回传
+	Attempt       int64 `protobuf:"varint,6,opt,name=attempt,proto3" json:"attempt,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *ExecuteRequest) Reset() {
 	*x = ExecuteRequest{}
-	mi := &file_executor_v1_executor_proto_msgTypes[1]
+	mi := &file_executor_v1_executor_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -220,7 +465,7 @@ func (x *ExecuteRequest) String() string {
 func (*ExecuteRequest) ProtoMessage() {}
 
 func (x *ExecuteRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_executor_v1_executor_proto_msgTypes[1]
+	mi := &file_executor_v1_executor_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -233,7 +478,7 @@ func (x *ExecuteRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ExecuteRequest.ProtoReflect.Descriptor instead.
 func (*ExecuteRequest) Descriptor() ([]byte, []int) {
-	return file_executor_v1_executor_proto_rawDescGZIP(), []int{1}
+	return file_executor_v1_executor_proto_rawDescGZIP(), []int{2}
 }
 
 func (x *ExecuteRequest) GetEid() int64 {
@@ -271,6 +516,13 @@ func (x *ExecuteRequest) GetParams() map[string]string {
 	return nil
 }
 
+func (x *ExecuteRequest) GetAttempt() int64 {
+	if x != nil {
+		return x.Attempt
+	}
+	return 0
+}
+
 type ExecuteResponse struct {
 	state          protoimpl.MessageState `protogen:"open.v1"`
 	ExecutionState *ExecutionState        `protobuf:"bytes,1,opt,name=execution_state,json=executionState,proto3" json:"execution_state,omitempty"`
@@ -280,7 +532,7 @@ type ExecuteResponse struct {
 
 func (x *ExecuteResponse) Reset() {
 	*x = ExecuteResponse{}
-	mi := &file_executor_v1_executor_proto_msgTypes[2]
+	mi := &file_executor_v1_executor_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -292,7 +544,7 @@ func (x *ExecuteResponse) String() string {
 func (*ExecuteResponse) ProtoMessage() {}
 
 func (x *ExecuteResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_executor_v1_executor_proto_msgTypes[2]
+	mi := &file_executor_v1_executor_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -305,7 +557,7 @@ func (x *ExecuteResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ExecuteResponse.ProtoReflect.Descriptor instead.
 func (*ExecuteResponse) Descriptor() ([]byte, []int) {
-	return file_executor_v1_executor_proto_rawDescGZIP(), []int{2}
+	return file_executor_v1_executor_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *ExecuteResponse) GetExecutionState() *ExecutionState {
@@ -324,7 +576,7 @@ type InterruptRequest struct {
 
 func (x *InterruptRequest) Reset() {
 	*x = InterruptRequest{}
-	mi := &file_executor_v1_executor_proto_msgTypes[3]
+	mi := &file_executor_v1_executor_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -336,7 +588,7 @@ func (x *InterruptRequest) String() string {
 func (*InterruptRequest) ProtoMessage() {}
 
 func (x *InterruptRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_executor_v1_executor_proto_msgTypes[3]
+	mi := &file_executor_v1_executor_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -349,7 +601,7 @@ func (x *InterruptRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use InterruptRequest.ProtoReflect.Descriptor instead.
 func (*InterruptRequest) Descriptor() ([]byte, []int) {
-	return file_executor_v1_executor_proto_rawDescGZIP(), []int{3}
+	return file_executor_v1_executor_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *InterruptRequest) GetEid() int64 {
@@ -369,7 +621,7 @@ type InterruptResponse struct {
 
 func (x *InterruptResponse) Reset() {
 	*x = InterruptResponse{}
-	mi := &file_executor_v1_executor_proto_msgTypes[4]
+	mi := &file_executor_v1_executor_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -381,7 +633,7 @@ func (x *InterruptResponse) String() string {
 func (*InterruptResponse) ProtoMessage() {}
 
 func (x *InterruptResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_executor_v1_executor_proto_msgTypes[4]
+	mi := &file_executor_v1_executor_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -394,7 +646,7 @@ func (x *InterruptResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use InterruptResponse.ProtoReflect.Descriptor instead.
 func (*InterruptResponse) Descriptor() ([]byte, []int) {
-	return file_executor_v1_executor_proto_rawDescGZIP(), []int{4}
+	return file_executor_v1_executor_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *InterruptResponse) GetSuccess() bool {
@@ -420,7 +672,7 @@ type QueryRequest struct {
 
 func (x *QueryRequest) Reset() {
 	*x = QueryRequest{}
-	mi := &file_executor_v1_executor_proto_msgTypes[5]
+	mi := &file_executor_v1_executor_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -432,7 +684,7 @@ func (x *QueryRequest) String() string {
 func (*QueryRequest) ProtoMessage() {}
 
 func (x *QueryRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_executor_v1_executor_proto_msgTypes[5]
+	mi := &file_executor_v1_executor_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -445,7 +697,7 @@ func (x *QueryRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use QueryRequest.ProtoReflect.Descriptor instead.
 func (*QueryRequest) Descriptor() ([]byte, []int) {
-	return file_executor_v1_executor_proto_rawDescGZIP(), []int{5}
+	return file_executor_v1_executor_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *QueryRequest) GetEid() int64 {
@@ -464,7 +716,7 @@ type QueryResponse struct {
 
 func (x *QueryResponse) Reset() {
 	*x = QueryResponse{}
-	mi := &file_executor_v1_executor_proto_msgTypes[6]
+	mi := &file_executor_v1_executor_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -476,7 +728,7 @@ func (x *QueryResponse) String() string {
 func (*QueryResponse) ProtoMessage() {}
 
 func (x *QueryResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_executor_v1_executor_proto_msgTypes[6]
+	mi := &file_executor_v1_executor_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -489,7 +741,7 @@ func (x *QueryResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use QueryResponse.ProtoReflect.Descriptor instead.
 func (*QueryResponse) Descriptor() ([]byte, []int) {
-	return file_executor_v1_executor_proto_rawDescGZIP(), []int{6}
+	return file_executor_v1_executor_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *QueryResponse) GetExecutionState() *ExecutionState {
@@ -515,7 +767,7 @@ type PrepareRequest struct {
 
 func (x *PrepareRequest) Reset() {
 	*x = PrepareRequest{}
-	mi := &file_executor_v1_executor_proto_msgTypes[7]
+	mi := &file_executor_v1_executor_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -527,7 +779,7 @@ func (x *PrepareRequest) String() string {
 func (*PrepareRequest) ProtoMessage() {}
 
 func (x *PrepareRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_executor_v1_executor_proto_msgTypes[7]
+	mi := &file_executor_v1_executor_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -540,7 +792,7 @@ func (x *PrepareRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PrepareRequest.ProtoReflect.Descriptor instead.
 func (*PrepareRequest) Descriptor() ([]byte, []int) {
-	return file_executor_v1_executor_proto_rawDescGZIP(), []int{7}
+	return file_executor_v1_executor_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *PrepareRequest) GetEid() int64 {
@@ -580,7 +832,7 @@ type PrepareResponse struct {
 
 func (x *PrepareResponse) Reset() {
 	*x = PrepareResponse{}
-	mi := &file_executor_v1_executor_proto_msgTypes[8]
+	mi := &file_executor_v1_executor_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -592,7 +844,7 @@ func (x *PrepareResponse) String() string {
 func (*PrepareResponse) ProtoMessage() {}
 
 func (x *PrepareResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_executor_v1_executor_proto_msgTypes[8]
+	mi := &file_executor_v1_executor_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -605,7 +857,7 @@ func (x *PrepareResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PrepareResponse.ProtoReflect.Descriptor instead.
 func (*PrepareResponse) Descriptor() ([]byte, []int) {
-	return file_executor_v1_executor_proto_rawDescGZIP(), []int{8}
+	return file_executor_v1_executor_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *PrepareResponse) GetParams() map[string]string {
@@ -619,7 +871,7 @@ var File_executor_v1_executor_proto protoreflect.FileDescriptor
 
 const file_executor_v1_executor_proto_rawDesc = "" +
 	"\n" +
-	"\x1aexecutor/v1/executor.proto\x12\vexecutor.v1\"\xb9\x03\n" +
+	"\x1aexecutor/v1/executor.proto\x12\vexecutor.v1\"\xe5\x04\n" +
 	"\x0eExecutionState\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x17\n" +
 	"\atask_id\x18\x02 \x01(\x03R\x06taskId\x12\x1b\n" +
@@ -628,16 +880,30 @@ const file_executor_v1_executor_proto_rawDesc = "" +
 	"\x10running_progress\x18\x05 \x01(\x05R\x0frunningProgress\x12-\n" +
 	"\x12request_reschedule\x18\x06 \x01(\bR\x11requestReschedule\x12a\n" +
 	"\x12rescheduled_params\x18\a \x03(\v22.executor.v1.ExecutionState.RescheduledParamsEntryR\x11rescheduledParams\x12(\n" +
-	"\x10executor_node_id\x18\b \x01(\tR\x0eexecutorNodeId\x1aD\n" +
+	"\x10executor_node_id\x18\b \x01(\tR\x0eexecutorNodeId\x12>\n" +
+	"\rreject_reason\x18\t \x01(\x0e2\x19.executor.v1.RejectReasonR\frejectReason\x12\x1a\n" +
+	"\bsequence\x18\n" +
+	" \x01(\x03R\bsequence\x124\n" +
+	"\x06result\x18\v \x01(\v2\x1c.executor.v1.ExecutionResultR\x06result\x12\x18\n" +
+	"\aattempt\x18\f \x01(\x03R\aattempt\x1aD\n" +
 	"\x16RescheduledParamsEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x80\x02\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xf2\x01\n" +
+	"\x0fExecutionResult\x12\x18\n" +
+	"\apayload\x18\x01 \x01(\fR\apayload\x12#\n" +
+	"\rerror_message\x18\x02 \x01(\tR\ferrorMessage\x12\x1d\n" +
+	"\n" +
+	"error_code\x18\x03 \x01(\tR\terrorCode\x12A\n" +
+	"\x0eerror_category\x18\x04 \x01(\x0e2\x1a.executor.v1.ErrorCategoryR\rerrorCategory\x12!\n" +
+	"\ferror_detail\x18\x05 \x01(\tR\verrorDetail\x12\x1b\n" +
+	"\texit_code\x18\x06 \x01(\x05R\bexitCode\"\x9a\x02\n" +
 	"\x0eExecuteRequest\x12\x10\n" +
 	"\x03eid\x18\x01 \x01(\x03R\x03eid\x12\x17\n" +
 	"\atask_id\x18\x02 \x01(\x03R\x06taskId\x12\x1b\n" +
 	"\ttask_name\x18\x03 \x01(\tR\btaskName\x12*\n" +
 	"\x11task_handler_name\x18\x04 \x01(\tR\x0ftaskHandlerName\x12?\n" +
-	"\x06params\x18\x05 \x03(\v2'.executor.v1.ExecuteRequest.ParamsEntryR\x06params\x1a9\n" +
+	"\x06params\x18\x05 \x03(\v2'.executor.v1.ExecuteRequest.ParamsEntryR\x06params\x12\x18\n" +
+	"\aattempt\x18\x06 \x01(\x03R\aattempt\x1a9\n" +
 	"\vParamsEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
 	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"W\n" +
@@ -672,7 +938,20 @@ const file_executor_v1_executor_proto_rawDesc = "" +
 	"\x14FAILED_RESCHEDULABLE\x10\x03\x12\n" +
 	"\n" +
 	"\x06FAILED\x10\x04\x12\v\n" +
-	"\aSUCCESS\x10\x052\xa9\x02\n" +
+	"\aSUCCESS\x10\x05*8\n" +
+	"\fRejectReason\x12\x10\n" +
+	"\fNOT_REJECTED\x10\x00\x12\b\n" +
+	"\x04BUSY\x10\x01\x12\f\n" +
+	"\bDRAINING\x10\x02*\x9a\x01\n" +
+	"\rErrorCategory\x12\x11\n" +
+	"\rUNCATEGORIZED\x10\x00\x12\x11\n" +
+	"\rHANDLER_ERROR\x10\x01\x12\v\n" +
+	"\aTIMEOUT\x10\x02\x12\x0f\n" +
+	"\vINTERRUPTED\x10\x03\x12\x15\n" +
+	"\x11HANDLER_NOT_FOUND\x10\x04\x12\x0f\n" +
+	"\vSCRIPT_EXIT\x10\x05\x12\x12\n" +
+	"\x0eINVALID_PARAMS\x10\x06\x12\t\n" +
+	"\x05PANIC\x10\a2\xa9\x02\n" +
 	"\x0fExecutorService\x12D\n" +
 	"\aExecute\x12\x1b.executor.v1.ExecuteRequest\x1a\x1c.executor.v1.ExecuteResponse\x12J\n" +
 	"\tInterrupt\x12\x1d.executor.v1.InterruptRequest\x1a\x1e.executor.v1.InterruptResponse\x12>\n" +
@@ -692,46 +971,52 @@ func file_executor_v1_executor_proto_rawDescGZIP() []byte {
 	return file_executor_v1_executor_proto_rawDescData
 }
 
-var file_executor_v1_executor_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_executor_v1_executor_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
+var file_executor_v1_executor_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
+var file_executor_v1_executor_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
 var file_executor_v1_executor_proto_goTypes = []any{
 	(ExecutionStatus)(0),      // 0: executor.v1.ExecutionStatus
-	(*ExecutionState)(nil),    // 1: executor.v1.ExecutionState
-	(*ExecuteRequest)(nil),    // 2: executor.v1.ExecuteRequest
-	(*ExecuteResponse)(nil),   // 3: executor.v1.ExecuteResponse
-	(*InterruptRequest)(nil),  // 4: executor.v1.InterruptRequest
-	(*InterruptResponse)(nil), // 5: executor.v1.InterruptResponse
-	(*QueryRequest)(nil),      // 6: executor.v1.QueryRequest
-	(*QueryResponse)(nil),     // 7: executor.v1.QueryResponse
-	(*PrepareRequest)(nil),    // 8: executor.v1.PrepareRequest
-	(*PrepareResponse)(nil),   // 9: executor.v1.PrepareResponse
-	nil,                       // 10: executor.v1.ExecutionState.RescheduledParamsEntry
-	nil,                       // 11: executor.v1.ExecuteRequest.ParamsEntry
-	nil,                       // 12: executor.v1.PrepareRequest.ParamsEntry
-	nil,                       // 13: executor.v1.PrepareResponse.ParamsEntry
+	(RejectReason)(0),         // 1: executor.v1.RejectReason
+	(ErrorCategory)(0),        // 2: executor.v1.ErrorCategory
+	(*ExecutionState)(nil),    // 3: executor.v1.ExecutionState
+	(*ExecutionResult)(nil),   // 4: executor.v1.ExecutionResult
+	(*ExecuteRequest)(nil),    // 5: executor.v1.ExecuteRequest
+	(*ExecuteResponse)(nil),   // 6: executor.v1.ExecuteResponse
+	(*InterruptRequest)(nil),  // 7: executor.v1.InterruptRequest
+	(*InterruptResponse)(nil), // 8: executor.v1.InterruptResponse
+	(*QueryRequest)(nil),      // 9: executor.v1.QueryRequest
+	(*QueryResponse)(nil),     // 10: executor.v1.QueryResponse
+	(*PrepareRequest)(nil),    // 11: executor.v1.PrepareRequest
+	(*PrepareResponse)(nil),   // 12: executor.v1.PrepareResponse
+	nil,                       // 13: executor.v1.ExecutionState.RescheduledParamsEntry
+	nil,                       // 14: executor.v1.ExecuteRequest.ParamsEntry
+	nil,                       // 15: executor.v1.PrepareRequest.ParamsEntry
+	nil,                       // 16: executor.v1.PrepareResponse.ParamsEntry
 }
 var file_executor_v1_executor_proto_depIdxs = []int32{
 	0,  // 0: executor.v1.ExecutionState.status:type_name -> executor.v1.ExecutionStatus
-	10, // 1: executor.v1.ExecutionState.rescheduled_params:type_name -> executor.v1.ExecutionState.RescheduledParamsEntry
-	11, // 2: executor.v1.ExecuteRequest.params:type_name -> executor.v1.ExecuteRequest.ParamsEntry
-	1,  // 3: executor.v1.ExecuteResponse.execution_state:type_name -> executor.v1.ExecutionState
-	1,  // 4: executor.v1.InterruptResponse.execution_state:type_name -> executor.v1.ExecutionState
-	1,  // 5: executor.v1.QueryResponse.execution_state:type_name -> executor.v1.ExecutionState
-	12, // 6: executor.v1.PrepareRequest.params:type_name -> executor.v1.PrepareRequest.ParamsEntry
-	13, // 7: executor.v1.PrepareResponse.params:type_name -> executor.v1.PrepareResponse.ParamsEntry
-	2,  // 8: executor.v1.ExecutorService.Execute:input_type -> executor.v1.ExecuteRequest
-	4,  // 9: executor.v1.ExecutorService.Interrupt:input_type -> executor.v1.InterruptRequest
-	6,  // 10: executor.v1.ExecutorService.Query:input_type -> executor.v1.QueryRequest
-	8,  // 11: executor.v1.ExecutorService.Prepare:input_type -> executor.v1.PrepareRequest
-	3,  // 12: executor.v1.ExecutorService.Execute:output_type -> executor.v1.ExecuteResponse
-	5,  // 13: executor.v1.ExecutorService.Interrupt:output_type -> executor.v1.InterruptResponse
-	7,  // 14: executor.v1.ExecutorService.Query:output_type -> executor.v1.QueryResponse
-	9,  // 15: executor.v1.ExecutorService.Prepare:output_type -> executor.v1.PrepareResponse
-	12, // [12:16] is the sub-list for method output_type
-	8,  // [8:12] is the sub-list for method input_type
-	8,  // [8:8] is the sub-list for extension type_name
-	8,  // [8:8] is the sub-list for extension extendee
-	0,  // [0:8] is the sub-list for field type_name
+	13, // 1: executor.v1.ExecutionState.rescheduled_params:type_name -> executor.v1.ExecutionState.RescheduledParamsEntry
+	1,  // 2: executor.v1.ExecutionState.reject_reason:type_name -> executor.v1.RejectReason
+	4,  // 3: executor.v1.ExecutionState.result:type_name -> executor.v1.ExecutionResult
+	2,  // 4: executor.v1.ExecutionResult.error_category:type_name -> executor.v1.ErrorCategory
+	14, // 5: executor.v1.ExecuteRequest.params:type_name -> executor.v1.ExecuteRequest.ParamsEntry
+	3,  // 6: executor.v1.ExecuteResponse.execution_state:type_name -> executor.v1.ExecutionState
+	3,  // 7: executor.v1.InterruptResponse.execution_state:type_name -> executor.v1.ExecutionState
+	3,  // 8: executor.v1.QueryResponse.execution_state:type_name -> executor.v1.ExecutionState
+	15, // 9: executor.v1.PrepareRequest.params:type_name -> executor.v1.PrepareRequest.ParamsEntry
+	16, // 10: executor.v1.PrepareResponse.params:type_name -> executor.v1.PrepareResponse.ParamsEntry
+	5,  // 11: executor.v1.ExecutorService.Execute:input_type -> executor.v1.ExecuteRequest
+	7,  // 12: executor.v1.ExecutorService.Interrupt:input_type -> executor.v1.InterruptRequest
+	9,  // 13: executor.v1.ExecutorService.Query:input_type -> executor.v1.QueryRequest
+	11, // 14: executor.v1.ExecutorService.Prepare:input_type -> executor.v1.PrepareRequest
+	6,  // 15: executor.v1.ExecutorService.Execute:output_type -> executor.v1.ExecuteResponse
+	8,  // 16: executor.v1.ExecutorService.Interrupt:output_type -> executor.v1.InterruptResponse
+	10, // 17: executor.v1.ExecutorService.Query:output_type -> executor.v1.QueryResponse
+	12, // 18: executor.v1.ExecutorService.Prepare:output_type -> executor.v1.PrepareResponse
+	15, // [15:19] is the sub-list for method output_type
+	11, // [11:15] is the sub-list for method input_type
+	11, // [11:11] is the sub-list for extension type_name
+	11, // [11:11] is the sub-list for extension extendee
+	0,  // [0:11] is the sub-list for field type_name
 }
 
 func init() { file_executor_v1_executor_proto_init() }
@@ -744,8 +1029,8 @@ func file_executor_v1_executor_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_executor_v1_executor_proto_rawDesc), len(file_executor_v1_executor_proto_rawDesc)),
-			NumEnums:      1,
-			NumMessages:   13,
+			NumEnums:      3,
+			NumMessages:   14,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -22,7 +22,7 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
-// 异步 Kafka 消息定义参考这个
+// 异步 Kafka 消息定义参考这个：每条消息为一个 protojson 编码的 ReportRequest，topic 为 report_topic，key 为执行 ID
 type ReportRequest struct {
 	state          protoimpl.MessageState `protogen:"open.v1"`
 	ExecutionState *v1.ExecutionState     `protobuf:"bytes,1,opt,name=execution_state,json=executionState,proto3" json:"execution_state,omitempty"`
@@ -148,7 +148,9 @@ func (x *BatchReportRequest) GetReports() []*ReportRequest {
 }
 
 type BatchReportResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// 每个上报的处理结果，与请求中的 reports 按顺序一一对应
+	Results       []*ReportResult `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -183,6 +185,249 @@ func (*BatchReportResponse) Descriptor() ([]byte, []int) {
 	return file_reporter_v1_reporter_proto_rawDescGZIP(), []int{3}
 }
 
+func (x *BatchReportResponse) GetResults() []*ReportResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+// 单个上报的处理结果，执行节点据此只重发处理失败的上报
+type ReportResult struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// 执行实例ID
+	Eid int64 `protobuf:"varint,1,opt,name=eid,proto3" json:"eid,omitempty"`
+	// 是否处理成功
+	Success bool `protobuf:"varint,2,opt,name=success,proto3" json:"success,omitempty"`
+	// 处理失败时是否值得重发，执行记录不存在、状态迁移非法等确定性失败重发也不会成功
+	Retryable bool `protobuf:"varint,3,opt,name=retryable,proto3" json:"retryable,omitempty"`
+	// 失败原因
+	Error         string `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReportResult) Reset() {
+	*x = ReportResult{}
+	mi := &file_reporter_v1_reporter_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReportResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReportResult) ProtoMessage() {}
+
+func (x *ReportResult) ProtoReflect() protoreflect.Message {
+	mi := &file_reporter_v1_reporter_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReportResult.ProtoReflect.Descriptor instead.
+func (*ReportResult) Descriptor() ([]byte, []int) {
+	return file_reporter_v1_reporter_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ReportResult) GetEid() int64 {
+	if x != nil {
+		return x.Eid
+	}
+	return 0
+}
+
+func (x *ReportResult) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *ReportResult) GetRetryable() bool {
+	if x != nil {
+		return x.Retryable
+	}
+	return false
+}
+
+func (x *ReportResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+// 任务日志块，执行节点按大小和时间将日志行打包上报
+// 通过 Kafka 上报时每条消息为一个 protojson 编码的 LogChunk，topic 为 report_log_topic，key 为执行 ID
+type LogChunk struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// 执行实例ID
+	Eid int64 `protobuf:"varint,1,opt,name=eid,proto3" json:"eid,omitempty"`
+	// 块序号，同一执行节点内单调递增
+	Sequence int64 `protobuf:"varint,2,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	// 执行节点的 nodeID
+	ExecutorNodeId string `protobuf:"bytes,3,opt,name=executor_node_id,json=executorNodeId,proto3" json:"executor_node_id,omitempty"`
+	// 日志内容，多行以换行符分隔
+	Content string `protobuf:"bytes,4,opt,name=content,proto3" json:"content,omitempty"`
+	// 块内第一行日志的产生时间（毫秒时间戳）
+	Timestamp     int64 `protobuf:"varint,5,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LogChunk) Reset() {
+	*x = LogChunk{}
+	mi := &file_reporter_v1_reporter_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogChunk) ProtoMessage() {}
+
+func (x *LogChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_reporter_v1_reporter_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogChunk.ProtoReflect.Descriptor instead.
+func (*LogChunk) Descriptor() ([]byte, []int) {
+	return file_reporter_v1_reporter_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *LogChunk) GetEid() int64 {
+	if x != nil {
+		return x.Eid
+	}
+	return 0
+}
+
+func (x *LogChunk) GetSequence() int64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *LogChunk) GetExecutorNodeId() string {
+	if x != nil {
+		return x.ExecutorNodeId
+	}
+	return ""
+}
+
+func (x *LogChunk) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *LogChunk) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+type ReportLogsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Chunks        []*LogChunk            `protobuf:"bytes,1,rep,name=chunks,proto3" json:"chunks,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReportLogsRequest) Reset() {
+	*x = ReportLogsRequest{}
+	mi := &file_reporter_v1_reporter_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReportLogsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReportLogsRequest) ProtoMessage() {}
+
+func (x *ReportLogsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_reporter_v1_reporter_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReportLogsRequest.ProtoReflect.Descriptor instead.
+func (*ReportLogsRequest) Descriptor() ([]byte, []int) {
+	return file_reporter_v1_reporter_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ReportLogsRequest) GetChunks() []*LogChunk {
+	if x != nil {
+		return x.Chunks
+	}
+	return nil
+}
+
+type ReportLogsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReportLogsResponse) Reset() {
+	*x = ReportLogsResponse{}
+	mi := &file_reporter_v1_reporter_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReportLogsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReportLogsResponse) ProtoMessage() {}
+
+func (x *ReportLogsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_reporter_v1_reporter_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReportLogsResponse.ProtoReflect.Descriptor instead.
+func (*ReportLogsResponse) Descriptor() ([]byte, []int) {
+	return file_reporter_v1_reporter_proto_rawDescGZIP(), []int{7}
+}
+
 var File_reporter_v1_reporter_proto protoreflect.FileDescriptor
 
 const file_reporter_v1_reporter_proto_rawDesc = "" +
@@ -192,11 +437,28 @@ const file_reporter_v1_reporter_proto_rawDesc = "" +
 	"\x0fexecution_state\x18\x01 \x01(\v2\x1b.executor.v1.ExecutionStateR\x0eexecutionState\"\x10\n" +
 	"\x0eReportResponse\"J\n" +
 	"\x12BatchReportRequest\x124\n" +
-	"\areports\x18\x01 \x03(\v2\x1a.reporter.v1.ReportRequestR\areports\"\x15\n" +
-	"\x13BatchReportResponse2\xa6\x01\n" +
+	"\areports\x18\x01 \x03(\v2\x1a.reporter.v1.ReportRequestR\areports\"J\n" +
+	"\x13BatchReportResponse\x123\n" +
+	"\aresults\x18\x01 \x03(\v2\x19.reporter.v1.ReportResultR\aresults\"n\n" +
+	"\fReportResult\x12\x10\n" +
+	"\x03eid\x18\x01 \x01(\x03R\x03eid\x12\x18\n" +
+	"\asuccess\x18\x02 \x01(\bR\asuccess\x12\x1c\n" +
+	"\tretryable\x18\x03 \x01(\bR\tretryable\x12\x14\n" +
+	"\x05error\x18\x04 \x01(\tR\x05error\"\x9a\x01\n" +
+	"\bLogChunk\x12\x10\n" +
+	"\x03eid\x18\x01 \x01(\x03R\x03eid\x12\x1a\n" +
+	"\bsequence\x18\x02 \x01(\x03R\bsequence\x12(\n" +
+	"\x10executor_node_id\x18\x03 \x01(\tR\x0eexecutorNodeId\x12\x18\n" +
+	"\acontent\x18\x04 \x01(\tR\acontent\x12\x1c\n" +
+	"\ttimestamp\x18\x05 \x01(\x03R\ttimestamp\"B\n" +
+	"\x11ReportLogsRequest\x12-\n" +
+	"\x06chunks\x18\x01 \x03(\v2\x15.reporter.v1.LogChunkR\x06chunks\"\x14\n" +
+	"\x12ReportLogsResponse2\xf5\x01\n" +
 	"\x0fReporterService\x12A\n" +
 	"\x06Report\x12\x1a.reporter.v1.ReportRequest\x1a\x1b.reporter.v1.ReportResponse\x12P\n" +
-	"\vBatchReport\x12\x1f.reporter.v1.BatchReportRequest\x1a .reporter.v1.BatchReportResponseB\xb4\x01\n" +
+	"\vBatchReport\x12\x1f.reporter.v1.BatchReportRequest\x1a .reporter.v1.BatchReportResponse\x12M\n" +
+	"\n" +
+	"ReportLogs\x12\x1e.reporter.v1.ReportLogsRequest\x1a\x1f.reporter.v1.ReportLogsResponseB\xb4\x01\n" +
 	"\x0fcom.reporter.v1B\rReporterProtoP\x01ZEgithub.com/Duke1616/ework-runner/api/proto/gen/reporter/v1;reporterv1\xa2\x02\x03RXX\xaa\x02\vReporter.V1\xca\x02\vReporter\\V1\xe2\x02\x17Reporter\\V1\\GPBMetadata\xea\x02\fReporter::V1b\x06proto3"
 
 var (
@@ -211,26 +473,34 @@ func file_reporter_v1_reporter_proto_rawDescGZIP() []byte {
 	return file_reporter_v1_reporter_proto_rawDescData
 }
 
-var file_reporter_v1_reporter_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_reporter_v1_reporter_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
 var file_reporter_v1_reporter_proto_goTypes = []any{
 	(*ReportRequest)(nil),       // 0: reporter.v1.ReportRequest
 	(*ReportResponse)(nil),      // 1: reporter.v1.ReportResponse
 	(*BatchReportRequest)(nil),  // 2: reporter.v1.BatchReportRequest
 	(*BatchReportResponse)(nil), // 3: reporter.v1.BatchReportResponse
-	(*v1.ExecutionState)(nil),   // 4: executor.v1.ExecutionState
+	(*ReportResult)(nil),        // 4: reporter.v1.ReportResult
+	(*LogChunk)(nil),            // 5: reporter.v1.LogChunk
+	(*ReportLogsRequest)(nil),   // 6: reporter.v1.ReportLogsRequest
+	(*ReportLogsResponse)(nil),  // 7: reporter.v1.ReportLogsResponse
+	(*v1.ExecutionState)(nil),   // 8: executor.v1.ExecutionState
 }
 var file_reporter_v1_reporter_proto_depIdxs = []int32{
-	4, // 0: reporter.v1.ReportRequest.execution_state:type_name -> executor.v1.ExecutionState
+	8, // 0: reporter.v1.ReportRequest.execution_state:type_name -> executor.v1.ExecutionState
 	0, // 1: reporter.v1.BatchReportRequest.reports:type_name -> reporter.v1.ReportRequest
-	0, // 2: reporter.v1.ReporterService.Report:input_type -> reporter.v1.ReportRequest
-	2, // 3: reporter.v1.ReporterService.BatchReport:input_type -> reporter.v1.BatchReportRequest
-	1, // 4: reporter.v1.ReporterService.Report:output_type -> reporter.v1.ReportResponse
-	3, // 5: reporter.v1.ReporterService.BatchReport:output_type -> reporter.v1.BatchReportResponse
-	4, // [4:6] is the sub-list for method output_type
-	2, // [2:4] is the sub-list for method input_type
-	2, // [2:2] is the sub-list for extension type_name
-	2, // [2:2] is the sub-list for extension extendee
-	0, // [0:2] is the sub-list for field type_name
+	4, // 2: reporter.v1.BatchReportResponse.results:type_name -> reporter.v1.ReportResult
+	5, // 3: reporter.v1.ReportLogsRequest.chunks:type_name -> reporter.v1.LogChunk
+	0, // 4: reporter.v1.ReporterService.Report:input_type -> reporter.v1.ReportRequest
+	2, // 5: reporter.v1.ReporterService.BatchReport:input_type -> reporter.v1.BatchReportRequest
+	6, // 6: reporter.v1.ReporterService.ReportLogs:input_type -> reporter.v1.ReportLogsRequest
+	1, // 7: reporter.v1.ReporterService.Report:output_type -> reporter.v1.ReportResponse
+	3, // 8: reporter.v1.ReporterService.BatchReport:output_type -> reporter.v1.BatchReportResponse
+	7, // 9: reporter.v1.ReporterService.ReportLogs:output_type -> reporter.v1.ReportLogsResponse
+	7, // [7:10] is the sub-list for method output_type
+	4, // [4:7] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
 }
 
 func init() { file_reporter_v1_reporter_proto_init() }
@@ -244,7 +514,7 @@ func file_reporter_v1_reporter_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_reporter_v1_reporter_proto_rawDesc), len(file_reporter_v1_reporter_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   4,
+			NumMessages:   8,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -21,6 +21,7 @@ const _ = grpc.SupportPackageIsVersion9
 const (
 	ReporterService_Report_FullMethodName      = "/reporter.v1.ReporterService/Report"
 	ReporterService_BatchReport_FullMethodName = "/reporter.v1.ReporterService/BatchReport"
+	ReporterService_ReportLogs_FullMethodName  = "/reporter.v1.ReporterService/ReportLogs"
 )
 
 // ReporterServiceClient is the client API for ReporterService service.
@@ -33,6 +34,8 @@ type ReporterServiceClient interface {
 	Report(ctx context.Context, in *ReportRequest, opts ...grpc.CallOption) (*ReportResponse, error)
 	// 批量上报进度
 	BatchReport(ctx context.Context, in *BatchReportRequest, opts ...grpc.CallOption) (*BatchReportResponse, error)
+	// 上报任务日志
+	ReportLogs(ctx context.Context, in *ReportLogsRequest, opts ...grpc.CallOption) (*ReportLogsResponse, error)
 }
 
 type reporterServiceClient struct {
@@ -63,6 +66,16 @@ func (c *reporterServiceClient) BatchReport(ctx context.Context, in *BatchReport
 	return out, nil
 }
 
+func (c *reporterServiceClient) ReportLogs(ctx context.Context, in *ReportLogsRequest, opts ...grpc.CallOption) (*ReportLogsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReportLogsResponse)
+	err := c.cc.Invoke(ctx, ReporterService_ReportLogs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ReporterServiceServer is the server API for ReporterService service.
 // All implementations must embed UnimplementedReporterServiceServer
 // for forward compatibility.
@@ -73,6 +86,8 @@ type ReporterServiceServer interface {
 	Report(context.Context, *ReportRequest) (*ReportResponse, error)
 	// 批量上报进度
 	BatchReport(context.Context, *BatchReportRequest) (*BatchReportResponse, error)
+	// 上报任务日志
+	ReportLogs(context.Context, *ReportLogsRequest) (*ReportLogsResponse, error)
 	mustEmbedUnimplementedReporterServiceServer()
 }
 
@@ -89,6 +104,9 @@ func (UnimplementedReporterServiceServer) Report(context.Context, *ReportRequest
 func (UnimplementedReporterServiceServer) BatchReport(context.Context, *BatchReportRequest) (*BatchReportResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method BatchReport not implemented")
 }
+func (UnimplementedReporterServiceServer) ReportLogs(context.Context, *ReportLogsRequest) (*ReportLogsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ReportLogs not implemented")
+}
 func (UnimplementedReporterServiceServer) mustEmbedUnimplementedReporterServiceServer() {}
 func (UnimplementedReporterServiceServer) testEmbeddedByValue()                         {}
 
@@ -146,6 +164,24 @@ func _ReporterService_BatchReport_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ReporterService_ReportLogs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReportLogsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReporterServiceServer).ReportLogs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ReporterService_ReportLogs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReporterServiceServer).ReportLogs(ctx, req.(*ReportLogsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // ReporterService_ServiceDesc is the grpc.ServiceDesc for ReporterService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -161,6 +197,10 @@ var ReporterService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "BatchReport",
 			Handler:    _ReporterService_BatchReport_Handler,
 		},
+		{
+			MethodName: "ReportLogs",
+			Handler:    _ReporterService_ReportLogs_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "reporter/v1/reporter.proto",
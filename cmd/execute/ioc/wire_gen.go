@@ -15,8 +15,10 @@ import (
 	"github.com/Duke1616/ework-runner/pkg/grpc/registry/etcd"
 	"github.com/Duke1616/ework-runner/sdk/executor"
 	"github.com/google/wire"
+	"github.com/gotomicro/ego/server/egovernor"
 	"github.com/spf13/viper"
 	"go.etcd.io/etcd/client/v3"
+	"time"
 )
 
 // Injectors from wire.go:
@@ -27,8 +29,10 @@ func InitExecuteApp() *ExecuteApp {
 	registry := InitRegistry(client)
 	executor := InitExecutor(config, registry)
 	server := InitExecutorServer(executor)
+	component := ioc.InitExecutorGovernor()
 	executeApp := &ExecuteApp{
-		Server: server,
+		Server:   server,
+		Governor: component,
 	}
 	return executeApp
 }
@@ -41,7 +45,7 @@ var (
 	ExecutorSet = wire.NewSet(
 		InitConfig,
 		InitExecutor,
-		InitExecutorServer,
+		InitExecutorServer, ioc.InitExecutorGovernor,
 	)
 )
 
@@ -65,9 +69,66 @@ func InitConfig() grpc.Config {
 	return cfg
 }
 
+// DrainTimeout 优雅退出时等待运行中任务结束的最长时间
+func DrainTimeout() time.Duration {
+	if timeout := viper.GetDuration("grpc.server.executor.drain_timeout"); timeout > 0 {
+		return timeout
+	}
+	return executor.DefaultDrainTimeout
+}
+
+// executorOptions 从配置中读取并发控制、排空、状态存储等 Executor 选项
+func executorOptions() []executor.Option {
+	type Config struct {
+		MaxConcurrency     int            `mapstructure:"max_concurrency"`
+		QueueSize          int            `mapstructure:"queue_size"`
+		HandlerConcurrency map[string]int `mapstructure:"handler_concurrency"`
+		// Report 上报通道：grpc（默认）或 kafka
+		Report struct {
+			Transport string `mapstructure:"transport"`
+		} `mapstructure:"report"`
+		StateStore struct {
+			Path string        `mapstructure:"path"`
+			TTL  time.Duration `mapstructure:"ttl"`
+		} `mapstructure:"state_store"`
+	}
+	var cfg Config
+	if err := viper.UnmarshalKey("grpc.server.executor", &cfg); err != nil {
+		panic(err)
+	}
+
+	opts := []executor.Option{executor.WithDrainTimeout(DrainTimeout()), executor.WithMaxConcurrency(cfg.MaxConcurrency), executor.WithQueueSize(cfg.QueueSize)}
+	for name, n := range cfg.HandlerConcurrency {
+		opts = append(opts, executor.WithHandlerConcurrency(name, n))
+	}
+
+	if cfg.Report.Transport == "kafka" {
+		producer, err := ioc.InitMQ().Producer(ioc.ReportTopic)
+		if err != nil {
+			panic(err)
+		}
+		logProducer, err := ioc.InitMQ().Producer(ioc.ReportLogTopic)
+		if err != nil {
+			panic(err)
+		}
+		opts = append(opts, executor.WithReportTransport(executor.NewMQReportTransport(producer, logProducer)))
+	}
+
+	if cfg.StateStore.Path != "" {
+		store, err := executor.NewBoltStateStore(cfg.StateStore.Path, cfg.StateStore.TTL)
+		if err != nil {
+			panic(err)
+		}
+		opts = append(opts, executor.WithStateStore(store), executor.WithReportStore(store))
+	} else {
+		opts = append(opts, executor.WithStateStore(executor.NewMemoryStateStore(cfg.StateStore.TTL)))
+	}
+	return opts
+}
+
 // InitExecutor 初始化 SDK Executor 实例
 func InitExecutor(cfg grpc.Config, reg registry.Registry) *executor.Executor {
-	exec, err := executor.NewExecutor(cfg, reg)
+	exec, err := executor.NewExecutor(cfg, reg, executorOptions()...)
 	if err != nil {
 		panic(err)
 	}
@@ -89,5 +150,11 @@ func InitExecutorServer(exec *executor.Executor) *grpc.Server {
 }
 
 type ExecuteApp struct {
-	Server *grpc.Server
+	Server   *grpc.Server
+	Governor *egovernor.Component
+}
+
+// InitTracer 初始化链路追踪，返回退出前导出剩余 span 的函数
+func InitTracer() func() {
+	return ioc.InitTracer("ework-executor")
 }
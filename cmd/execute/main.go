@@ -1,6 +1,8 @@
 package main
 
 import (
+	"time"
+
 	"github.com/Duke1616/ework-runner/cmd/execute/ioc"
 	"github.com/gotomicro/ego"
 	"github.com/gotomicro/ego/core/elog"
@@ -9,11 +11,18 @@ import (
 	"github.com/spf13/viper"
 )
 
+// stopTimeoutMargin 排空之外留给注销服务、停止 gRPC Server 的时间
+const stopTimeoutMargin = 10 * time.Second
+
 func main() {
 	initViper()
 
 	// 创建 ego 应用实例
-	egoApp := ego.New()
+	// NOTE: 停止超时需要覆盖执行节点的排空时间，否则运行中的任务来不及交接
+	egoApp := ego.New(ego.WithStopTimeout(ioc.DrainTimeout() + stopTimeoutMargin))
+	// 覆盖 ego 默认初始化的链路追踪，退出前导出剩余的 span
+	shutdownTracer := ioc.InitTracer()
+	defer shutdownTracer()
 
 	// 初始化 Executor 应用
 	app := ioc.InitExecuteApp()
@@ -23,6 +32,9 @@ func main() {
 		func() server.Server {
 			return app.Server
 		}(),
+		func() server.Server {
+			return app.Governor
+		}(),
 	).Run(); err != nil {
 		elog.Panic("startup", elog.FieldErr(err))
 	}
@@ -6,6 +6,8 @@ import (
 	"github.com/Duke1616/ework-runner/internal/grpc"
 	"github.com/Duke1616/ework-runner/internal/repository"
 	"github.com/Duke1616/ework-runner/internal/repository/dao"
+	"github.com/Duke1616/ework-runner/internal/service/cluster"
+	"github.com/Duke1616/ework-runner/internal/service/deadletter"
 	taskSvc "github.com/Duke1616/ework-runner/internal/service/task"
 	"github.com/Duke1616/ework-runner/internal/web/task"
 	"github.com/Duke1616/ework-runner/ioc"
@@ -44,24 +46,60 @@ var (
 	taskExecutionSet = wire.NewSet(
 		dao.NewGORMTaskExecutionDAO,
 		repository.NewTaskExecutionRepository,
-		taskSvc.NewExecutionService,
+		ioc.InitExecutionService,
+		task.NewExecutionHandler,
+	)
+
+	executionLogSet = wire.NewSet(
+		dao.NewGORMExecutionLogDAO,
+		repository.NewExecutionLogRepository,
+		taskSvc.NewLogService,
+	)
+
+	deadLetterSet = wire.NewSet(
+		dao.NewGORMDeadLetterDAO,
+		repository.NewDeadLetterRepository,
+		deadletter.NewService,
+		task.NewDeadLetterHandler,
+	)
+
+	hookSet = wire.NewSet(
+		dao.NewGORMHookDeliveryDAO,
+		repository.NewHookDeliveryRepository,
+		ioc.InitHookService,
+		ioc.InitHookRelay,
 	)
 
 	schedulerSet = wire.NewSet(
 		ioc.InitNodeID,
 		ioc.InitScheduler,
+		ioc.InitSchedulerGovernor,
 		ioc.InitMySQLTaskAcquirer,
 		ioc.InitExecutorNodePicker,
 	)
 
+	clusterSet = wire.NewSet(
+		ioc.InitSchedulerNodeDAO,
+		repository.NewSchedulerNodeRepository,
+		cluster.NewService,
+		task.NewClusterHandler,
+	)
+
 	compensatorSet = wire.NewSet(
 		ioc.InitRetryCompensator,
 		ioc.InitRescheduleCompensator,
 		ioc.InitInterruptCompensator,
+		ioc.InitReconcileCompensator,
+		ioc.InitPrepareCompensator,
+		ioc.InitLongRunningCompensator,
+		ioc.InitLeaderCompensator,
 	)
 
 	producerSet = wire.NewSet(
 		ioc.InitCompleteProducer,
+		dao.NewGORMOutboxDAO,
+		repository.NewOutboxRepository,
+		ioc.InitOutboxRelay,
 	)
 
 	grpcSet = wire.NewSet(
@@ -70,6 +108,8 @@ var (
 
 	consumerSet = wire.NewSet(
 		ioc.InitCompleteEventConsumer,
+		ioc.InitReportEventConsumer,
+		ioc.InitReportLogEventConsumer,
 	)
 )
 
@@ -80,7 +120,11 @@ func InitSchedulerApp() *ioc.SchedulerApp {
 
 		taskSet,
 		taskExecutionSet,
+		executionLogSet,
+		deadLetterSet,
+		hookSet,
 		schedulerSet,
+		clusterSet,
 		compensatorSet,
 		consumerSet,
 		producerSet,
@@ -98,3 +142,8 @@ func InitSchedulerApp() *ioc.SchedulerApp {
 
 	return new(ioc.SchedulerApp)
 }
+
+// InitTracer 初始化链路追踪，返回退出前导出剩余 span 的函数
+func InitTracer() func() {
+	return ioc.InitTracer("ework-scheduler")
+}
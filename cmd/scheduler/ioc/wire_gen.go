@@ -10,6 +10,8 @@ import (
 	"github.com/Duke1616/ework-runner/internal/grpc"
 	"github.com/Duke1616/ework-runner/internal/repository"
 	"github.com/Duke1616/ework-runner/internal/repository/dao"
+	"github.com/Duke1616/ework-runner/internal/service/cluster"
+	"github.com/Duke1616/ework-runner/internal/service/deadletter"
 	"github.com/Duke1616/ework-runner/internal/service/task"
 	task2 "github.com/Duke1616/ework-runner/internal/web/task"
 	"github.com/Duke1616/ework-runner/ioc"
@@ -32,31 +34,58 @@ func InitSchedulerApp() *ioc.SchedulerApp {
 	taskRepository := repository.NewTaskRepository(taskDAO)
 	service := task.NewService(taskRepository)
 	handler := task2.NewHandler(service)
-	component := ioc.InitGinWebServer(v, checkPolicyMiddlewareBuilder, provider, handler)
-	registry := ioc.InitRegistry(client)
 	string2 := ioc.InitNodeID()
 	taskExecutionDAO := dao.NewGORMTaskExecutionDAO(db)
 	taskExecutionRepository := repository.NewTaskExecutionRepository(taskExecutionDAO, taskRepository)
+	outboxDAO := dao.NewGORMOutboxDAO(db)
+	outboxRepository := repository.NewOutboxRepository(outboxDAO)
 	taskAcquirer := ioc.InitMySQLTaskAcquirer(taskRepository)
 	mq := ioc.InitMQ()
 	completeProducer := ioc.InitCompleteProducer(mq)
-	executionService := task.NewExecutionService(string2, taskExecutionRepository, service, taskAcquirer, completeProducer, registry)
-	reporterServer := grpc.NewReporterServer(executionService)
+	registry := ioc.InitRegistry(client)
+	hookDeliveryDAO := dao.NewGORMHookDeliveryDAO(db)
+	hookDeliveryRepository := repository.NewHookDeliveryRepository(hookDeliveryDAO)
+	hookService := ioc.InitHookService(taskRepository, hookDeliveryRepository, mq)
+	executionService := ioc.InitExecutionService(string2, taskExecutionRepository, outboxRepository, service, taskAcquirer, completeProducer, registry, hookService)
+	executionLogDAO := dao.NewGORMExecutionLogDAO(db)
+	executionLogRepository := repository.NewExecutionLogRepository(executionLogDAO)
+	logService := task.NewLogService(executionLogRepository)
+	executionHandler := task2.NewExecutionHandler(executionService, logService, hookService)
+	deadLetterDAO := dao.NewGORMDeadLetterDAO(db)
+	deadLetterRepository := repository.NewDeadLetterRepository(deadLetterDAO)
+	deadletterService := deadletter.NewService(deadLetterRepository, executionService, service, hookService)
+	deadLetterHandler := task2.NewDeadLetterHandler(deadletterService)
+	schedulerNodeDAO := ioc.InitSchedulerNodeDAO(client)
+	schedulerNodeRepository := repository.NewSchedulerNodeRepository(schedulerNodeDAO)
+	clusterService := cluster.NewService(schedulerNodeRepository, taskRepository, executionService, taskAcquirer, registry)
+	clusterHandler := task2.NewClusterHandler(clusterService)
+	component := ioc.InitGinWebServer(v, checkPolicyMiddlewareBuilder, provider, handler, executionHandler, deadLetterHandler, clusterHandler)
+	reporterServer := grpc.NewReporterServer(executionService, logService)
 	server := ioc.InitSchedulerNodeGRPCServer(registry, reporterServer)
 	clients := ioc.InitExecutorServiceGRPCClients(registry)
 	invoker := ioc.InitInvoker(clients)
 	runner := ioc.InitRunner(string2, service, executionService, taskAcquirer, invoker, completeProducer)
 	executorNodePicker := ioc.InitExecutorNodePicker(registry)
-	scheduler := ioc.InitScheduler(string2, runner, service, executionService, taskAcquirer, executorNodePicker)
+	scheduler := ioc.InitScheduler(string2, runner, service, executionService, taskAcquirer, executorNodePicker, clusterService)
+	egovernorComponent := ioc.InitSchedulerGovernor()
 	retryCompensator := ioc.InitRetryCompensator(runner, executionService)
 	rescheduleCompensator := ioc.InitRescheduleCompensator(runner, executionService)
-	interruptCompensator := ioc.InitInterruptCompensator(clients, executionService)
-	completeConsumer := ioc.InitCompleteEventConsumer(mq, service, executionService, taskAcquirer)
-	v2 := ioc.InitTasks(retryCompensator, rescheduleCompensator, interruptCompensator, completeConsumer)
+	interruptCompensator := ioc.InitInterruptCompensator(clients, registry, executionService)
+	reconcileCompensator := ioc.InitReconcileCompensator(clients, executionService)
+	prepareCompensator := ioc.InitPrepareCompensator(runner, executionService)
+	longRunningCompensator := ioc.InitLongRunningCompensator(executionService, hookService)
+	leaderCompensator := ioc.InitLeaderCompensator(client, string2, retryCompensator, rescheduleCompensator, interruptCompensator, reconcileCompensator, prepareCompensator, longRunningCompensator)
+	completeConsumer := ioc.InitCompleteEventConsumer(mq, service, executionService, taskAcquirer, deadletterService, hookService)
+	reportConsumer := ioc.InitReportEventConsumer(mq, executionService)
+	relay := ioc.InitOutboxRelay(outboxRepository, completeProducer, mq)
+	reportLogConsumer := ioc.InitReportLogEventConsumer(mq, logService)
+	hookRelay := ioc.InitHookRelay(hookService)
+	v2 := ioc.InitTasks(leaderCompensator, completeConsumer, reportConsumer, relay, reportLogConsumer, hookRelay)
 	schedulerApp := &ioc.SchedulerApp{
 		Web:       component,
 		Server:    server,
 		Scheduler: scheduler,
+		Governor:  egovernorComponent,
 		Tasks:     v2,
 	}
 	return schedulerApp
@@ -71,15 +100,28 @@ var (
 
 	taskSet = wire.NewSet(dao.NewGORMTaskDAO, repository.NewTaskRepository, task.NewService, task2.NewHandler)
 
-	taskExecutionSet = wire.NewSet(dao.NewGORMTaskExecutionDAO, repository.NewTaskExecutionRepository, task.NewExecutionService)
+	taskExecutionSet = wire.NewSet(dao.NewGORMTaskExecutionDAO, repository.NewTaskExecutionRepository, ioc.InitExecutionService, task2.NewExecutionHandler)
+
+	executionLogSet = wire.NewSet(dao.NewGORMExecutionLogDAO, repository.NewExecutionLogRepository, task.NewLogService)
+
+	deadLetterSet = wire.NewSet(dao.NewGORMDeadLetterDAO, repository.NewDeadLetterRepository, deadletter.NewService, task2.NewDeadLetterHandler)
 
-	schedulerSet = wire.NewSet(ioc.InitNodeID, ioc.InitScheduler, ioc.InitMySQLTaskAcquirer, ioc.InitExecutorNodePicker)
+	hookSet = wire.NewSet(dao.NewGORMHookDeliveryDAO, repository.NewHookDeliveryRepository, ioc.InitHookService, ioc.InitHookRelay)
 
-	compensatorSet = wire.NewSet(ioc.InitRetryCompensator, ioc.InitRescheduleCompensator, ioc.InitInterruptCompensator)
+	schedulerSet = wire.NewSet(ioc.InitNodeID, ioc.InitScheduler, ioc.InitSchedulerGovernor, ioc.InitMySQLTaskAcquirer, ioc.InitExecutorNodePicker)
 
-	producerSet = wire.NewSet(ioc.InitCompleteProducer)
+	clusterSet = wire.NewSet(ioc.InitSchedulerNodeDAO, repository.NewSchedulerNodeRepository, cluster.NewService, task2.NewClusterHandler)
+
+	compensatorSet = wire.NewSet(ioc.InitRetryCompensator, ioc.InitRescheduleCompensator, ioc.InitInterruptCompensator, ioc.InitReconcileCompensator, ioc.InitPrepareCompensator, ioc.InitLongRunningCompensator, ioc.InitLeaderCompensator)
+
+	producerSet = wire.NewSet(ioc.InitCompleteProducer, dao.NewGORMOutboxDAO, repository.NewOutboxRepository, ioc.InitOutboxRelay)
 
 	grpcSet = wire.NewSet(ioc.InitExecutorServiceGRPCClients)
 
-	consumerSet = wire.NewSet(ioc.InitCompleteEventConsumer)
+	consumerSet = wire.NewSet(ioc.InitCompleteEventConsumer, ioc.InitReportEventConsumer, ioc.InitReportLogEventConsumer)
 )
+
+// InitTracer 初始化链路追踪，返回退出前导出剩余 span 的函数
+func InitTracer() func() {
+	return ioc.InitTracer("ework-scheduler")
+}
@@ -16,6 +16,9 @@ func main() {
 
 	// 创建 ego 应用实例
 	egoApp := ego.New()
+	// 覆盖 ego 默认初始化的链路追踪，退出前导出剩余的 span
+	shutdownTracer := ioc.InitTracer()
+	defer shutdownTracer()
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
@@ -33,6 +36,9 @@ func main() {
 		func() server.Server {
 			return app.Scheduler
 		}(),
+		func() server.Server {
+			return app.Governor
+		}(),
 	).Cron().
 		Run(); err != nil {
 		elog.Panic("startup", elog.FieldErr(err))
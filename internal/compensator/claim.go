@@ -0,0 +1,37 @@
+package compensator
+
+import (
+	"context"
+	"time"
+
+	"github.com/Duke1616/ework-runner/internal/domain"
+	"github.com/Duke1616/ework-runner/internal/metrics"
+	"github.com/Duke1616/ework-runner/internal/service/task"
+	"github.com/gotomicro/ego/core/elog"
+)
+
+// defaultDispatchLease 未配置时的默认分发租约
+// 租约需要覆盖调用执行节点到执行节点上报 RUNNING 的时间，分发失败时租约过期后重新分发
+const defaultDispatchLease = time.Minute
+
+// claimDispatch 分发前认领执行记录，查询和认领之间执行记录可能已经被其他补偿器认领，认领失败时跳过
+func claimDispatch(ctx context.Context, execSvc task.ExecutionService, execution domain.TaskExecution,
+	lease time.Duration, compensator string, logger *elog.Component) bool {
+	ok, err := execSvc.ClaimDispatch(ctx, execution, lease)
+	if err != nil {
+		metrics.CompensatorHandledTotal.Inc(compensator, metrics.ResultFailed)
+		logger.Error("认领执行记录失败",
+			elog.Int64("executionId", execution.ID),
+			elog.String("taskName", execution.Task.Name),
+			elog.FieldErr(err))
+		return false
+	}
+	if !ok {
+		metrics.CompensatorHandledTotal.Inc(compensator, metrics.ResultConflict)
+		logger.Info("执行记录已被其他补偿器认领或状态已经变化，跳过",
+			elog.Int64("executionId", execution.ID),
+			elog.String("taskName", execution.Task.Name))
+		return false
+	}
+	return true
+}
@@ -8,36 +8,64 @@ import (
 	executorv1 "github.com/Duke1616/ework-runner/api/proto/gen/executor/v1"
 	"github.com/Duke1616/ework-runner/internal/domain"
 	"github.com/Duke1616/ework-runner/internal/errs"
+	"github.com/Duke1616/ework-runner/internal/metrics"
 	"github.com/Duke1616/ework-runner/internal/service/task"
 	"github.com/Duke1616/ework-runner/pkg/grpc"
+	"github.com/Duke1616/ework-runner/pkg/grpc/balancer"
+	"github.com/Duke1616/ework-runner/pkg/grpc/registry"
 	"github.com/gotomicro/ego/core/elog"
 )
 
+const (
+	// defaultMaxInterruptFailures 未配置时连续中断失败的默认上限
+	defaultMaxInterruptFailures = 3
+	// interruptAbandonedCategory 放弃中断时记录的错误分类
+	interruptAbandonedCategory = "INTERRUPT_ABANDONED"
+)
+
 // InterruptConfig 中断补偿器配置
 type InterruptConfig struct {
 	BatchSize   int           // 批次大小
 	MinDuration time.Duration // 最小等待时间，防止空转
+	MaxFailures int           // 连续中断失败达到该次数后放弃中断，默认 3
+	// 放弃中断后执行记录迁移到的状态：FAILED_RESCHEDULED（默认，重调度到其他执行节点）或 FAILED_RETRYABLE（按任务的重试配置重试）
+	AbandonStatus domain.TaskExecutionStatus
 }
 
 // InterruptCompensator 中断补偿器
+// 执行节点已经下线或者连续多次中断失败时，不再等待执行节点确认，按 AbandonStatus 结束本次执行，
+// 否则执行记录会一直停留在超时的 RUNNING 状态
 type InterruptCompensator struct {
 	execSvc     task.ExecutionService
 	config      InterruptConfig
 	logger      *elog.Component
 	grpcClients *grpc.Clients[executorv1.ExecutorServiceClient] // gRPC客户端池
+	registry    registry.Registry                               // 判断执行节点是否在线
+
+	// failures 执行记录连续中断失败的次数，只在补偿器所在的主节点内存中计数
+	failures map[int64]int
 }
 
 // NewInterruptCompensator 创建中断补偿器
 func NewInterruptCompensator(
 	grpcClients *grpc.Clients[executorv1.ExecutorServiceClient],
+	registry registry.Registry,
 	execSvc task.ExecutionService,
 	config InterruptConfig,
 ) *InterruptCompensator {
+	if config.MaxFailures <= 0 {
+		config.MaxFailures = defaultMaxInterruptFailures
+	}
+	if !config.AbandonStatus.IsFailedRetryable() {
+		config.AbandonStatus = domain.TaskExecutionStatusFailedRescheduled
+	}
 	return &InterruptCompensator{
 		grpcClients: grpcClients,
+		registry:    registry,
 		execSvc:     execSvc,
 		config:      config,
 		logger:      elog.DefaultLogger.With(elog.FieldComponentName("compensator.interrupt")),
+		failures:    make(map[int64]int),
 	}
 }
 
@@ -80,6 +108,7 @@ func (t *InterruptCompensator) interruptTimeoutTasks(ctx context.Context) error
 	if err != nil {
 		return fmt.Errorf("查找可中断任务失败: %w", err)
 	}
+	metrics.CompensatorBatchSize.Observe(float64(len(executions)), "interrupt")
 
 	if len(executions) == 0 {
 		t.logger.Info("没有找到可中断的任务")
@@ -88,9 +117,22 @@ func (t *InterruptCompensator) interruptTimeoutTasks(ctx context.Context) error
 
 	t.logger.Info("找到可中断任务", elog.Int("count", len(executions)))
 
+	// 只保留本轮仍然超时的执行记录的失败次数
+	failures := make(map[int64]int, len(executions))
+	for i := range executions {
+		if n, ok := t.failures[executions[i].ID]; ok {
+			failures[executions[i].ID] = n
+		}
+	}
+	t.failures = failures
+
 	// 处理每个超时的执行
 	for i := range executions {
+		if err = checkLeader(ctx); err != nil {
+			return err
+		}
 		err = t.interruptTaskExecution(ctx, executions[i])
+		metrics.CompensatorHandledTotal.Inc("interrupt", metrics.Result(err))
 		if err != nil {
 			t.logger.Error("中断超时任务失败",
 				elog.Int64("executionId", executions[i].ID),
@@ -107,10 +149,55 @@ func (t *InterruptCompensator) interruptTimeoutTasks(ctx context.Context) error
 
 func (t *InterruptCompensator) interruptTaskExecution(ctx context.Context, execution domain.TaskExecution) error {
 	if execution.Task.GrpcConfig == nil {
-		return fmt.Errorf("未找到GPRC配置，无法执行中断任务")
+		return t.abandon(ctx, execution, "未找到GPRC配置，无法执行中断任务")
+	}
+
+	online, err := t.isExecutorOnline(ctx, execution)
+	if err != nil {
+		return err
+	}
+	if !online {
+		return t.abandon(ctx, execution, fmt.Sprintf("执行节点 %s 已下线", execution.ExecutorNodeID))
+	}
+
+	err = t.interrupt(ctx, execution)
+	if err == nil {
+		delete(t.failures, execution.ID)
+		return nil
+	}
+	t.failures[execution.ID]++
+	if t.failures[execution.ID] < t.config.MaxFailures {
+		return err
 	}
+	return t.abandon(ctx, execution, fmt.Sprintf("连续 %d 次中断失败：%s", t.failures[execution.ID], err))
+}
+
+// isExecutorOnline 执行节点是否仍然注册在注册中心，不知道执行节点时视为在线
+func (t *InterruptCompensator) isExecutorOnline(ctx context.Context, execution domain.TaskExecution) (bool, error) {
+	if execution.ExecutorNodeID == "" {
+		return true, nil
+	}
+	instances, err := t.registry.ListServices(ctx, execution.Task.GrpcConfig.ServiceName)
+	if err != nil {
+		return false, fmt.Errorf("查询执行节点失败：%w", err)
+	}
+	for _, ins := range instances {
+		if ins.ID == execution.ExecutorNodeID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// interrupt 向实际执行该任务的节点发送中断请求，并按返回的状态更新执行记录
+func (t *InterruptCompensator) interrupt(ctx context.Context, execution domain.TaskExecution) error {
 	client := t.grpcClients.Get(execution.Task.GrpcConfig.ServiceName)
-	resp, err := client.Interrupt(ctx, &executorv1.InterruptRequest{
+	callCtx := ctx
+	if execution.ExecutorNodeID != "" {
+		// 只有正在执行该任务的节点能够中断它
+		callCtx = balancer.WithSpecificNodeID(ctx, execution.ExecutorNodeID)
+	}
+	resp, err := client.Interrupt(callCtx, &executorv1.InterruptRequest{
 		Eid: execution.ID,
 	})
 	if err != nil {
@@ -122,3 +209,31 @@ func (t *InterruptCompensator) interruptTaskExecution(ctx context.Context, execu
 	}
 	return t.execSvc.UpdateState(ctx, domain.ExecutionStateFromProto(resp.GetExecutionState()))
 }
+
+// abandon 放弃中断，按配置把执行记录迁移到重调度或可重试失败，并记录放弃的原因
+// 当前尝试随之结束，执行节点只是网络分区而没有宕机时，它恢复后迟到的上报不会再改变执行记录的状态
+func (t *InterruptCompensator) abandon(ctx context.Context, execution domain.TaskExecution, reason string) error {
+	t.logger.Warn("放弃中断超时任务",
+		elog.Int64("executionId", execution.ID),
+		elog.String("taskName", execution.Task.Name),
+		elog.String("executorNodeId", execution.ExecutorNodeID),
+		elog.String("status", t.config.AbandonStatus.String()),
+		elog.String("reason", reason))
+
+	err := t.execSvc.UpdateState(ctx, domain.ExecutionState{
+		ID:             execution.ID,
+		TaskID:         execution.Task.ID,
+		TaskName:       execution.Task.Name,
+		Status:         t.config.AbandonStatus,
+		ExecutorNodeID: execution.ExecutorNodeID,
+		Result: domain.ExecutionResult{
+			ErrorMessage:  reason,
+			ErrorCategory: interruptAbandonedCategory,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("放弃中断后更新执行记录失败：%w", err)
+	}
+	delete(t.failures, execution.ID)
+	return nil
+}
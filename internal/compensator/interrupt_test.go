@@ -0,0 +1,149 @@
+//go:build unit
+
+package compensator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Duke1616/ework-runner/internal/domain"
+	"github.com/Duke1616/ework-runner/internal/service/task"
+	"github.com/Duke1616/ework-runner/internal/test"
+	"github.com/Duke1616/ework-runner/pkg/grpc/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 执行节点已经下线或无法中断时不再等待执行节点确认，按配置的状态结束本次执行并记录原因
+func TestInterruptCompensator_Abandon(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		grpcConfig    *domain.GrpcConfig
+		abandonStatus domain.TaskExecutionStatus
+		wantStatus    domain.TaskExecutionStatus
+		wantReason    string
+	}{
+		{
+			name:       "执行节点已下线，默认重调度",
+			grpcConfig: &domain.GrpcConfig{ServiceName: "executor"},
+			wantStatus: domain.TaskExecutionStatusFailedRescheduled,
+			wantReason: "执行节点 node-a 已下线",
+		},
+		{
+			name:          "执行节点已下线，按配置重试",
+			grpcConfig:    &domain.GrpcConfig{ServiceName: "executor"},
+			abandonStatus: domain.TaskExecutionStatusFailedRetryable,
+			wantStatus:    domain.TaskExecutionStatusFailedRetryable,
+			wantReason:    "执行节点 node-a 已下线",
+		},
+		{
+			name:       "没有gRPC配置",
+			wantStatus: domain.TaskExecutionStatusFailedRescheduled,
+			wantReason: "未找到GPRC配置，无法执行中断任务",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			execSvc := &stateRecorder{}
+			reg := &staticRegistry{instances: []registry.ServiceInstance{{Name: "executor", ID: "node-b"}}}
+			c := NewInterruptCompensator(nil, reg, execSvc, InterruptConfig{AbandonStatus: tc.abandonStatus})
+
+			err := c.interruptTaskExecution(context.Background(), domain.TaskExecution{
+				ID:             1,
+				Status:         domain.TaskExecutionStatusRunning,
+				ExecutorNodeID: "node-a",
+				Task:           domain.Task{ID: 10, Name: "sync-user", GrpcConfig: tc.grpcConfig},
+			})
+			require.NoError(t, err)
+			require.Len(t, execSvc.states, 1)
+			state := execSvc.states[0]
+			assert.Equal(t, tc.wantStatus, state.Status)
+			assert.Equal(t, "node-a", state.ExecutorNodeID)
+			assert.Equal(t, tc.wantReason, state.Result.ErrorMessage)
+			assert.Equal(t, interruptAbandonedCategory, state.Result.ErrorCategory)
+		})
+	}
+}
+
+// 放弃中断后失联的执行节点恢复，迟到的上报不会覆盖放弃后的状态，也不会结束新尝试
+func TestInterruptCompensator_LateReportAfterAbandon(t *testing.T) {
+	t.Parallel()
+
+	repo := test.NewMemExecutionRepo(domain.TaskExecution{
+		ID:             1,
+		Status:         domain.TaskExecutionStatusRunning,
+		ExecutorNodeID: "node-a",
+		Attempts:       1,
+		Task: domain.Task{
+			ID:          10,
+			Name:        "sync-user",
+			GrpcConfig:  &domain.GrpcConfig{ServiceName: "executor"},
+			RetryConfig: &domain.RetryConfig{MaxRetries: 3, InitialInterval: 1000, MaxInterval: 1000},
+		},
+	})
+	repo.AddAttempts(domain.ExecutionAttempt{ExecutionID: 1, Attempt: 1, Kind: domain.AttemptKindRun, ExecutorNodeID: "node-a"})
+	producer := test.NewChanProducer()
+	execSvc := task.NewExecutionService("scheduler-1", repo, repo, nil, nil, producer, nil, nil, 0)
+	// node-a 与注册中心之间网络分区，被当作已经下线
+	reg := &staticRegistry{instances: []registry.ServiceInstance{{Name: "executor", ID: "node-b"}}}
+	c := NewInterruptCompensator(nil, reg, execSvc, InterruptConfig{AbandonStatus: domain.TaskExecutionStatusFailedRetryable})
+	ctx := context.Background()
+
+	require.NoError(t, c.interruptTaskExecution(ctx, repo.Get(1)))
+	abandoned := repo.Get(1)
+	assert.Equal(t, domain.TaskExecutionStatusFailedRetryable, abandoned.Status)
+	assert.Greater(t, repo.Attempts(1)[0].EndTime, int64(0))
+
+	late := domain.ExecutionState{
+		ID:             1,
+		Status:         domain.TaskExecutionStatusSuccess,
+		ExecutorNodeID: "node-a",
+		Attempt:        1,
+	}
+	// 1. 重试开始之前到达
+	require.NoError(t, execSvc.UpdateState(ctx, late))
+	assert.Equal(t, abandoned, repo.Get(1))
+
+	// 2. 重试在 node-b 上开始之后到达
+	attempt, err := repo.StartAttempt(ctx, 1, domain.AttemptKindRetry, "node-b", task.DefaultMaxAttempts)
+	require.NoError(t, err)
+	require.NoError(t, execSvc.UpdateState(ctx, late))
+	assert.Equal(t, domain.TaskExecutionStatusFailedRetryable, repo.Get(1).Status)
+	assert.Empty(t, producer.Events)
+
+	// 新尝试的上报正常处理
+	require.NoError(t, execSvc.UpdateState(ctx, domain.ExecutionState{
+		ID:             1,
+		Status:         domain.TaskExecutionStatusSuccess,
+		ExecutorNodeID: "node-b",
+		Attempt:        attempt,
+	}))
+	assert.Equal(t, domain.TaskExecutionStatusSuccess, repo.Get(1).Status)
+	assert.Equal(t, domain.TaskExecutionStatusSuccess, producer.Wait(t).ExecStatus)
+}
+
+// stateRecorder 记录补偿器写回的执行状态
+type stateRecorder struct {
+	task.ExecutionService
+	states []domain.ExecutionState
+}
+
+func (s *stateRecorder) UpdateState(_ context.Context, state domain.ExecutionState) error {
+	s.states = append(s.states, state)
+	return nil
+}
+
+// staticRegistry 返回固定的服务实例
+type staticRegistry struct {
+	registry.Registry
+	instances []registry.ServiceInstance
+}
+
+func (r *staticRegistry) ListServices(_ context.Context, _ string) ([]registry.ServiceInstance, error) {
+	return r.instances, nil
+}
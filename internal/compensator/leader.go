@@ -0,0 +1,215 @@
+package compensator
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Duke1616/ework-runner/internal/errs"
+	"github.com/Duke1616/ework-runner/internal/metrics"
+	"github.com/gotomicro/ego/core/elog"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/concurrency"
+)
+
+const (
+	defaultLeaderTTL           = 15
+	defaultLeaderRetryInterval = 5 * time.Second
+	resignTimeout              = 3 * time.Second
+)
+
+// Compensator 补偿器，ctx 取消后 Start 返回
+type Compensator interface {
+	Start(ctx context.Context)
+}
+
+// LeaderConfig 补偿器选主配置
+type LeaderConfig struct {
+	Prefix        string        `yaml:"prefix"`        // 选主使用的 etcd key 前缀
+	TTL           int           `yaml:"ttl"`           // 租约秒数，主节点失联超过该时长后其他节点才能当选
+	RetryInterval time.Duration `yaml:"retryInterval"` // 竞选失败或失去主节点身份后，重新竞选前的等待时间
+}
+
+// leadership 当选后持有的主节点身份
+type leadership interface {
+	// Done 租约丢失后关闭
+	Done() <-chan struct{}
+	// Check 确认仍然是主节点，已经不是主节点时返回 errs.ErrNotCompensatorLeader
+	Check(ctx context.Context) error
+	// Resign 主动让出主节点
+	Resign(ctx context.Context) error
+	// Close 释放选主会话
+	Close() error
+}
+
+// LeaderCompensator 通过 etcd 选主，只在当选的调度节点上运行补偿器
+// 重试、重调度、中断、对账、PREPARE 补偿器都是扫描全表后逐条处理，多个调度节点同时运行会重复处理同一条执行记录
+type LeaderCompensator struct {
+	campaign     func(ctx context.Context) (leadership, error)
+	nodeID       string
+	compensators []Compensator
+	config       LeaderConfig
+	logger       *elog.Component
+}
+
+// NewLeaderCompensator 创建选主补偿器
+func NewLeaderCompensator(
+	client *clientv3.Client,
+	nodeID string,
+	config LeaderConfig,
+	compensators ...Compensator,
+) *LeaderCompensator {
+	l := newLeaderCompensator(nil, nodeID, config, compensators...)
+	l.campaign = func(ctx context.Context) (leadership, error) {
+		return campaignEtcd(ctx, client, nodeID, l.config)
+	}
+	return l
+}
+
+func newLeaderCompensator(
+	campaign func(ctx context.Context) (leadership, error),
+	nodeID string,
+	config LeaderConfig,
+	compensators ...Compensator,
+) *LeaderCompensator {
+	if config.TTL <= 0 {
+		config.TTL = defaultLeaderTTL
+	}
+	if config.RetryInterval <= 0 {
+		config.RetryInterval = defaultLeaderRetryInterval
+	}
+	return &LeaderCompensator{
+		campaign:     campaign,
+		nodeID:       nodeID,
+		compensators: compensators,
+		config:       config,
+		logger:       elog.DefaultLogger.With(elog.FieldComponentName("compensator.leader")),
+	}
+}
+
+// Start 持续竞选，当选后运行补偿器，失去主节点身份后停止补偿器并重新竞选
+func (l *LeaderCompensator) Start(ctx context.Context) {
+	l.logger.Info("补偿器开始竞选主节点", elog.String("nodeId", l.nodeID))
+
+	for {
+		err := l.lead(ctx)
+		if ctx.Err() != nil {
+			l.logger.Info("补偿器选主停止", elog.String("nodeId", l.nodeID))
+			return
+		}
+		if err != nil {
+			l.logger.Error("补偿器竞选主节点失败", elog.String("nodeId", l.nodeID), elog.FieldErr(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(l.config.RetryInterval):
+		}
+	}
+}
+
+// lead 阻塞直到当选，当选后运行补偿器，直到 ctx 取消或租约丢失
+func (l *LeaderCompensator) lead(ctx context.Context) error {
+	leader, err := l.campaign(ctx)
+	if err != nil {
+		return err
+	}
+	defer leader.Close()
+
+	l.logger.Info("当选补偿器主节点", elog.String("nodeId", l.nodeID))
+	metrics.CompensatorLeader.Set(1)
+	defer metrics.CompensatorLeader.Set(0)
+
+	// 补偿器在写入前通过 ctx 中的主节点身份确认仍然是主节点，
+	// 租约已经过期但本节点尚未感知时，旧主节点的写入会被拒绝，不会与新的主节点重叠
+	leaderCtx, cancel := context.WithCancel(withLeadership(ctx, leader))
+	var wg sync.WaitGroup
+	for _, c := range l.compensators {
+		wg.Add(1)
+		go func(c Compensator) {
+			defer wg.Done()
+			c.Start(leaderCtx)
+		}(c)
+	}
+
+	select {
+	case <-ctx.Done():
+	case <-leader.Done():
+		l.logger.Warn("选主租约丢失，停止补偿器", elog.String("nodeId", l.nodeID))
+	}
+	// 先停止补偿器，等所有补偿器退出后再让出，让出之后当选的主节点不会与本节点同时处理
+	cancel()
+	wg.Wait()
+
+	// 主动让出，其他节点无需等待租约过期即可当选
+	resignCtx, resignCancel := context.WithTimeout(context.Background(), resignTimeout)
+	defer resignCancel()
+	if err = leader.Resign(resignCtx); err != nil {
+		l.logger.Warn("让出主节点失败", elog.String("nodeId", l.nodeID), elog.FieldErr(err))
+	}
+	return nil
+}
+
+type leadershipKey struct{}
+
+func withLeadership(ctx context.Context, leader leadership) context.Context {
+	return context.WithValue(ctx, leadershipKey{}, leader)
+}
+
+// checkLeader 补偿器写入前确认仍然是主节点，不经过选主直接运行的补偿器不做检查
+func checkLeader(ctx context.Context) error {
+	leader, ok := ctx.Value(leadershipKey{}).(leadership)
+	if !ok {
+		return nil
+	}
+	return leader.Check(ctx)
+}
+
+// etcdLeadership 基于 etcd 选主的主节点身份，以当选时写入的 key 的创建版本号作为防护令牌
+type etcdLeadership struct {
+	client   *clientv3.Client
+	session  *concurrency.Session
+	election *concurrency.Election
+}
+
+// campaignEtcd 创建选主会话并阻塞直到当选
+func campaignEtcd(ctx context.Context, client *clientv3.Client, nodeID string, config LeaderConfig) (leadership, error) {
+	session, err := concurrency.NewSession(client, concurrency.WithTTL(config.TTL))
+	if err != nil {
+		return nil, fmt.Errorf("创建选主会话失败: %w", err)
+	}
+	election := concurrency.NewElection(session, config.Prefix)
+	if err = election.Campaign(ctx, nodeID); err != nil {
+		_ = session.Close()
+		return nil, fmt.Errorf("竞选失败: %w", err)
+	}
+	return &etcdLeadership{client: client, session: session, election: election}, nil
+}
+
+func (l *etcdLeadership) Done() <-chan struct{} {
+	return l.session.Done()
+}
+
+// Check 当选时写入的 key 仍然存在且没有被重新创建时才是主节点，租约过期后 key 随之删除
+func (l *etcdLeadership) Check(ctx context.Context) error {
+	resp, err := l.client.Txn(ctx).
+		If(clientv3.Compare(clientv3.CreateRevision(l.election.Key()), "=", l.election.Rev())).
+		Commit()
+	if err != nil {
+		return fmt.Errorf("确认主节点身份失败: %w", err)
+	}
+	if !resp.Succeeded {
+		return errs.ErrNotCompensatorLeader
+	}
+	return nil
+}
+
+func (l *etcdLeadership) Resign(ctx context.Context) error {
+	return l.election.Resign(ctx)
+}
+
+func (l *etcdLeadership) Close() error {
+	return l.session.Close()
+}
@@ -0,0 +1,182 @@
+//go:build unit
+
+package compensator
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Duke1616/ework-runner/internal/errs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 旧主节点的租约在服务端过期、新主节点当选后，旧主节点在感知到租约丢失前的写入被拒绝；
+// 感知到之后先停止补偿器再让出
+func TestLeaderCompensator_Handover(t *testing.T) {
+	t.Parallel()
+
+	election := &fakeElection{}
+	a, b := newWriteCompensator(), newWriteCompensator()
+	config := LeaderConfig{RetryInterval: 10 * time.Millisecond}
+	leaderA := newLeaderCompensator(election.campaignFor(a), "scheduler-1", config, a)
+	leaderB := newLeaderCompensator(election.campaignFor(b), "scheduler-2", config, b)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	for _, l := range []*LeaderCompensator{leaderA, leaderB} {
+		wg.Add(1)
+		go func(l *LeaderCompensator) {
+			defer wg.Done()
+			l.Start(ctx)
+		}(l)
+		// 保证 scheduler-1 先当选
+		require.Eventually(t, func() bool {
+			return election.term() == 1
+		}, time.Second, time.Millisecond)
+	}
+
+	// 1. 只有主节点运行补偿器
+	require.Eventually(t, a.running.Load, time.Second, time.Millisecond)
+	assert.NoError(t, a.write(t))
+	assert.False(t, b.running.Load())
+
+	// 2. scheduler-1 的租约在服务端过期，scheduler-2 当选，此时 scheduler-1 还没有感知到
+	first := election.current()
+	election.expire()
+	require.Eventually(t, b.running.Load, time.Second, time.Millisecond)
+	assert.True(t, a.running.Load())
+	assert.ErrorIs(t, a.write(t), errs.ErrNotCompensatorLeader)
+	assert.NoError(t, b.write(t))
+
+	// 3. scheduler-1 感知到租约丢失，补偿器全部退出后才让出
+	close(first.done)
+	require.Eventually(t, func() bool {
+		return !a.running.Load()
+	}, time.Second, time.Millisecond)
+	require.Eventually(t, first.resigned.Load, time.Second, time.Millisecond)
+	assert.False(t, first.resignedRunning.Load())
+
+	cancel()
+	wg.Wait()
+	assert.False(t, b.running.Load())
+}
+
+// writeCompensator 收到写入请求后确认主节点身份，并把结果返回给测试
+type writeCompensator struct {
+	running atomic.Bool
+	step    chan chan error
+}
+
+func newWriteCompensator() *writeCompensator {
+	return &writeCompensator{step: make(chan chan error)}
+}
+
+func (c *writeCompensator) Start(ctx context.Context) {
+	c.running.Store(true)
+	defer c.running.Store(false)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case reply := <-c.step:
+			reply <- checkLeader(ctx)
+		}
+	}
+}
+
+func (c *writeCompensator) write(t *testing.T) error {
+	t.Helper()
+	reply := make(chan error, 1)
+	select {
+	case c.step <- reply:
+	case <-time.After(time.Second):
+		require.FailNow(t, "补偿器没有运行")
+	}
+	return <-reply
+}
+
+// fakeElection 基于内存的选主，主节点 key 被删除（让出或者租约过期）后其他节点才能当选
+type fakeElection struct {
+	mu     sync.Mutex
+	holder *fakeLeadership
+	terms  int
+}
+
+func (e *fakeElection) campaignFor(c *writeCompensator) func(ctx context.Context) (leadership, error) {
+	return func(ctx context.Context) (leadership, error) {
+		for {
+			e.mu.Lock()
+			if e.holder == nil {
+				e.terms++
+				e.holder = &fakeLeadership{election: e, compensator: c, done: make(chan struct{})}
+				l := e.holder
+				e.mu.Unlock()
+				return l, nil
+			}
+			e.mu.Unlock()
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(time.Millisecond):
+			}
+		}
+	}
+}
+
+func (e *fakeElection) term() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.terms
+}
+
+func (e *fakeElection) current() *fakeLeadership {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.holder
+}
+
+// expire 租约在服务端过期，主节点 key 被删除
+func (e *fakeElection) expire() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.holder = nil
+}
+
+type fakeLeadership struct {
+	election    *fakeElection
+	compensator *writeCompensator
+	done        chan struct{}
+
+	resigned        atomic.Bool
+	resignedRunning atomic.Bool
+}
+
+func (l *fakeLeadership) Done() <-chan struct{} {
+	return l.done
+}
+
+func (l *fakeLeadership) Check(_ context.Context) error {
+	if l.election.current() != l {
+		return errs.ErrNotCompensatorLeader
+	}
+	return nil
+}
+
+func (l *fakeLeadership) Resign(_ context.Context) error {
+	l.resignedRunning.Store(l.compensator.running.Load())
+	l.resigned.Store(true)
+	l.election.mu.Lock()
+	defer l.election.mu.Unlock()
+	if l.election.holder == l {
+		l.election.holder = nil
+	}
+	return nil
+}
+
+func (l *fakeLeadership) Close() error {
+	return nil
+}
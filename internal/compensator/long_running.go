@@ -0,0 +1,98 @@
+package compensator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Duke1616/ework-runner/internal/metrics"
+	"github.com/Duke1616/ework-runner/internal/service/hook"
+	"github.com/Duke1616/ework-runner/internal/service/task"
+	"github.com/gotomicro/ego/core/elog"
+)
+
+// LongRunningConfig 长时间运行补偿器配置
+type LongRunningConfig struct {
+	BatchSize   int           // 批次大小
+	MinDuration time.Duration // 最小等待时间，防止空转
+}
+
+// LongRunningCompensator 长时间运行补偿器
+// 定期扫描所有运行中的执行记录，运行时间超过订阅配置阈值的发布长时间运行事件，
+// 不依赖执行节点的进度上报，执行节点长时间没有上报时同样可以发现
+type LongRunningCompensator struct {
+	execSvc task.ExecutionService
+	hookSvc hook.Service
+	config  LongRunningConfig
+	logger  *elog.Component
+}
+
+// NewLongRunningCompensator 创建长时间运行补偿器
+func NewLongRunningCompensator(
+	execSvc task.ExecutionService,
+	hookSvc hook.Service,
+	config LongRunningConfig,
+) *LongRunningCompensator {
+	return &LongRunningCompensator{
+		execSvc: execSvc,
+		hookSvc: hookSvc,
+		config:  config,
+		logger:  elog.DefaultLogger.With(elog.FieldComponentName("compensator.longRunning")),
+	}
+}
+
+// Start 启动补偿器
+func (l *LongRunningCompensator) Start(ctx context.Context) {
+	l.logger.Info("长时间运行补偿器启动")
+
+	for {
+		select {
+		case <-ctx.Done():
+			l.logger.Info("长时间运行补偿器停止")
+			return
+		default:
+			startTime := time.Now()
+
+			err := l.check(ctx)
+			if err != nil {
+				l.logger.Error("检查长时间运行任务失败", elog.FieldErr(err))
+			}
+
+			// 防空转：确保最小等待时间
+			elapsed := time.Since(startTime)
+			if elapsed < l.config.MinDuration {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(l.config.MinDuration - elapsed):
+				}
+			}
+		}
+	}
+}
+
+// check 按ID分页扫描一轮所有运行中的执行记录，同一次尝试的事件由投递记录去重，重复检查不会重复投递
+func (l *LongRunningCompensator) check(ctx context.Context) error {
+	var afterID int64
+	for {
+		executions, err := l.execSvc.FindRunningExecutions(ctx, afterID, l.config.BatchSize)
+		if err != nil {
+			return fmt.Errorf("查找运行中任务失败: %w", err)
+		}
+		metrics.CompensatorBatchSize.Observe(float64(len(executions)), "longRunning")
+		if len(executions) == 0 {
+			return nil
+		}
+
+		if err = checkLeader(ctx); err != nil {
+			return err
+		}
+		if err = l.hookSvc.CheckLongRunning(ctx, executions); err != nil {
+			l.logger.Error("检查长时间运行任务失败", elog.FieldErr(err))
+		}
+		if len(executions) < l.config.BatchSize {
+			return nil
+		}
+		afterID = executions[len(executions)-1].ID
+	}
+}
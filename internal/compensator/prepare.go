@@ -0,0 +1,188 @@
+package compensator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Duke1616/ework-runner/internal/domain"
+	"github.com/Duke1616/ework-runner/internal/metrics"
+	"github.com/Duke1616/ework-runner/internal/service/runner"
+	"github.com/Duke1616/ework-runner/internal/service/task"
+	"github.com/gotomicro/ego/core/elog"
+)
+
+const (
+	// defaultPrepareTimeoutWindow 未配置时的默认 PREPARE 超时窗口
+	defaultPrepareTimeoutWindow = 5 * time.Minute
+	// defaultMaxRedispatches 未配置时 REDISPATCH 策略默认最多重新分发的次数
+	defaultMaxRedispatches = 3
+	// prepareTimeoutCategory 处理停留在 PREPARE 状态的执行记录时记录的错误分类
+	prepareTimeoutCategory = "PREPARE_TIMEOUT"
+)
+
+// PreparePolicy 停留在 PREPARE 状态的执行记录的处理策略
+type PreparePolicy string
+
+const (
+	// PreparePolicyRetryable 按可重试失败处理，由重试补偿器按任务的重试配置重新分发，重试次数用尽时失败并释放任务
+	PreparePolicyRetryable PreparePolicy = "RETRYABLE"
+	// PreparePolicyRedispatch 立即重新分发，不计入重试次数，重新分发次数用尽后按 FAIL 处理
+	PreparePolicyRedispatch PreparePolicy = "REDISPATCH"
+	// PreparePolicyFail 直接失败，由完成事件释放任务
+	PreparePolicyFail PreparePolicy = "FAIL"
+)
+
+// PrepareConfig PREPARE 补偿器配置
+type PrepareConfig struct {
+	BatchSize              int           `yaml:"batchSize"`              // 批量处理大小
+	MinDuration            time.Duration `yaml:"minDuration"`            // 最小等待时间，防止空转
+	PrepareTimeoutWindowMs int64         `yaml:"prepareTimeoutWindowMs"` // PREPARE状态超时窗口，超过该时长没有任何更新的执行记录视为没有执行节点接手
+	Policy                 PreparePolicy `yaml:"policy"`                 // 处理策略，默认 RETRYABLE
+	DispatchLease          time.Duration `yaml:"dispatchLease"`          // 分发租约，租约内其他补偿器不会重复处理同一条执行记录
+	MaxRedispatches        int64         `yaml:"maxRedispatches"`        // REDISPATCH 策略下最多重新分发的次数，默认 3
+}
+
+// PrepareCompensator PREPARE 补偿器
+// 执行记录在分发前创建为 PREPARE 状态，调度节点在分发过程中宕机时执行记录会一直停留在 PREPARE 状态，
+// 任务也一直处于 PREEMPTED 状态无法再被调度。PREPARE 补偿器按策略重新分发或者结束这些执行记录
+type PrepareCompensator struct {
+	runner  runner.Runner
+	execSvc task.ExecutionService
+	config  PrepareConfig
+	logger  *elog.Component
+}
+
+// NewPrepareCompensator 创建 PREPARE 补偿器
+func NewPrepareCompensator(
+	runner runner.Runner,
+	execSvc task.ExecutionService,
+	config PrepareConfig,
+) *PrepareCompensator {
+	if config.PrepareTimeoutWindowMs <= 0 {
+		config.PrepareTimeoutWindowMs = defaultPrepareTimeoutWindow.Milliseconds()
+	}
+	switch config.Policy {
+	case PreparePolicyRedispatch, PreparePolicyFail:
+	default:
+		config.Policy = PreparePolicyRetryable
+	}
+	if config.DispatchLease <= 0 {
+		config.DispatchLease = defaultDispatchLease
+	}
+	if config.MaxRedispatches <= 0 {
+		config.MaxRedispatches = defaultMaxRedispatches
+	}
+	return &PrepareCompensator{
+		runner:  runner,
+		execSvc: execSvc,
+		config:  config,
+		logger:  elog.DefaultLogger.With(elog.FieldComponentName("compensator.prepare")),
+	}
+}
+
+// Start 启动补偿器
+func (p *PrepareCompensator) Start(ctx context.Context) {
+	p.logger.Info("PREPARE补偿器启动", elog.String("policy", string(p.config.Policy)))
+
+	for {
+		select {
+		case <-ctx.Done():
+			p.logger.Info("PREPARE补偿器停止")
+			return
+		default:
+			startTime := time.Now()
+
+			err := p.recover(ctx)
+			if err != nil {
+				p.logger.Error("处理PREPARE超时任务失败", elog.FieldErr(err))
+			}
+
+			// 防空转：确保最小等待时间
+			elapsed := time.Since(startTime)
+			if elapsed < p.config.MinDuration {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(p.config.MinDuration - elapsed):
+				}
+			}
+		}
+	}
+}
+
+// recover 执行一轮补偿
+func (p *PrepareCompensator) recover(ctx context.Context) error {
+	window := time.Duration(p.config.PrepareTimeoutWindowMs) * time.Millisecond
+	executions, err := p.execSvc.FindStalePrepareExecutions(ctx, window, p.config.BatchSize)
+	if err != nil {
+		return fmt.Errorf("查找PREPARE超时任务失败: %w", err)
+	}
+	metrics.CompensatorBatchSize.Observe(float64(len(executions)), "prepare")
+
+	if len(executions) == 0 {
+		p.logger.Info("没有找到PREPARE超时的任务")
+		return nil
+	}
+
+	p.logger.Info("找到PREPARE超时任务", elog.Int("count", len(executions)))
+
+	for i := range executions {
+		if err = checkLeader(ctx); err != nil {
+			return err
+		}
+		if !claimDispatch(ctx, p.execSvc, executions[i], p.config.DispatchLease, "prepare", p.logger) {
+			continue
+		}
+		err = p.handle(ctx, executions[i])
+		metrics.CompensatorHandledTotal.Inc("prepare", metrics.Result(err))
+		if err != nil {
+			p.logger.Error("处理PREPARE超时任务失败",
+				elog.Int64("executionId", executions[i].ID),
+				elog.String("taskName", executions[i].Task.Name),
+				elog.String("policy", string(p.config.Policy)),
+				elog.FieldErr(err))
+		}
+	}
+	return nil
+}
+
+// handle 按策略处理一条停留在 PREPARE 状态的执行记录
+func (p *PrepareCompensator) handle(ctx context.Context, execution domain.TaskExecution) error {
+	p.logger.Warn("执行记录超时未被执行节点接手",
+		elog.Int64("executionId", execution.ID),
+		elog.String("taskName", execution.Task.Name),
+		elog.Int64("utime", execution.UTime),
+		elog.String("policy", string(p.config.Policy)))
+
+	policy := p.config.Policy
+	msg := fmt.Sprintf("超过 %dms 没有执行节点接手", p.config.PrepareTimeoutWindowMs)
+	if policy == PreparePolicyRedispatch {
+		// 执行记录不会迁移回 PREPARE，仍然停留在 PREPARE 的执行记录除首次分发外的尝试都是重新分发
+		redispatches := max(execution.Attempts-1, 0)
+		if redispatches < p.config.MaxRedispatches {
+			return p.runner.Reschedule(ctx, execution)
+		}
+		p.logger.Warn("重新分发次数用尽，按失败处理",
+			elog.Int64("executionId", execution.ID),
+			elog.String("taskName", execution.Task.Name),
+			elog.Int64("redispatches", redispatches))
+		policy = PreparePolicyFail
+		msg = fmt.Sprintf("%s，已重新分发 %d 次", msg, redispatches)
+	}
+
+	status := domain.TaskExecutionStatusFailedRetryable
+	if policy == PreparePolicyFail {
+		status = domain.TaskExecutionStatusFailed
+	}
+	return p.execSvc.UpdateState(ctx, domain.ExecutionState{
+		ID:       execution.ID,
+		TaskID:   execution.Task.ID,
+		TaskName: execution.Task.Name,
+		Status:   status,
+		Result: domain.ExecutionResult{
+			ErrorMessage:  msg,
+			ErrorCategory: prepareTimeoutCategory,
+		},
+	})
+}
@@ -0,0 +1,105 @@
+//go:build unit
+
+package compensator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Duke1616/ework-runner/internal/domain"
+	"github.com/Duke1616/ework-runner/internal/service/runner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 停留在 PREPARE 状态的执行记录按策略重新分发、写回可重试失败或者直接失败，重新分发次数用尽后直接失败
+func TestPrepareCompensator_Handle(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		policy   PreparePolicy
+		attempts int64
+
+		wantReschedule bool
+		wantStatus     domain.TaskExecutionStatus
+		wantMessage    string
+	}{
+		{
+			name:        "默认按可重试失败处理",
+			attempts:    1,
+			wantStatus:  domain.TaskExecutionStatusFailedRetryable,
+			wantMessage: "超过 60000ms 没有执行节点接手",
+		},
+		{
+			name:        "直接失败",
+			policy:      PreparePolicyFail,
+			attempts:    1,
+			wantStatus:  domain.TaskExecutionStatusFailed,
+			wantMessage: "超过 60000ms 没有执行节点接手",
+		},
+		{
+			name:           "重新分发",
+			policy:         PreparePolicyRedispatch,
+			attempts:       1,
+			wantReschedule: true,
+		},
+		{
+			name:           "分发前宕机没有记录尝试时重新分发",
+			policy:         PreparePolicyRedispatch,
+			wantReschedule: true,
+		},
+		{
+			name:        "重新分发次数用尽后直接失败",
+			policy:      PreparePolicyRedispatch,
+			attempts:    3,
+			wantStatus:  domain.TaskExecutionStatusFailed,
+			wantMessage: "超过 60000ms 没有执行节点接手，已重新分发 2 次",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			execSvc := &stateRecorder{}
+			r := &rescheduleRecorder{}
+			c := NewPrepareCompensator(r, execSvc, PrepareConfig{
+				Policy:                 tc.policy,
+				PrepareTimeoutWindowMs: 60000,
+				MaxRedispatches:        2,
+			})
+
+			err := c.handle(context.Background(), domain.TaskExecution{
+				ID:       1,
+				Status:   domain.TaskExecutionStatusPrepare,
+				Attempts: tc.attempts,
+				Task:     domain.Task{ID: 10, Name: "sync-user"},
+			})
+			require.NoError(t, err)
+			if tc.wantReschedule {
+				assert.Equal(t, []int64{1}, r.executionIDs)
+				assert.Empty(t, execSvc.states)
+				return
+			}
+			assert.Empty(t, r.executionIDs)
+			require.Len(t, execSvc.states, 1)
+			state := execSvc.states[0]
+			assert.Equal(t, int64(1), state.ID)
+			assert.Equal(t, tc.wantStatus, state.Status)
+			assert.Equal(t, tc.wantMessage, state.Result.ErrorMessage)
+			assert.Equal(t, prepareTimeoutCategory, state.Result.ErrorCategory)
+		})
+	}
+}
+
+// rescheduleRecorder 记录重新分发的执行记录
+type rescheduleRecorder struct {
+	runner.Runner
+	executionIDs []int64
+}
+
+func (r *rescheduleRecorder) Reschedule(_ context.Context, execution domain.TaskExecution) error {
+	r.executionIDs = append(r.executionIDs, execution.ID)
+	return nil
+}
@@ -0,0 +1,151 @@
+package compensator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	executorv1 "github.com/Duke1616/ework-runner/api/proto/gen/executor/v1"
+	"github.com/Duke1616/ework-runner/internal/domain"
+	"github.com/Duke1616/ework-runner/internal/metrics"
+	"github.com/Duke1616/ework-runner/internal/service/task"
+	"github.com/Duke1616/ework-runner/pkg/grpc"
+	"github.com/Duke1616/ework-runner/pkg/grpc/balancer"
+	"github.com/gotomicro/ego/core/elog"
+)
+
+// defaultStaleThreshold 未配置时的默认对账阈值
+const defaultStaleThreshold = 5 * time.Minute
+
+// ReconcileConfig 对账补偿器配置
+type ReconcileConfig struct {
+	BatchSize      int           // 批次大小
+	MinDuration    time.Duration // 最小等待时间，防止空转
+	StaleThreshold time.Duration // 超过该时长没有收到任何上报的运行中任务，主动向执行节点查询
+}
+
+// ReconcileCompensator 对账补偿器
+// 执行节点的最终上报可能丢失，此时执行记录会一直停留在 RUNNING 直到超时中断。
+// 对账补偿器定期向执行该任务的节点查询（轮询模式）最新状态，并按上报流程更新执行记录
+type ReconcileCompensator struct {
+	execSvc     task.ExecutionService
+	config      ReconcileConfig
+	logger      *elog.Component
+	grpcClients *grpc.Clients[executorv1.ExecutorServiceClient] // gRPC客户端池
+}
+
+// NewReconcileCompensator 创建对账补偿器
+func NewReconcileCompensator(
+	grpcClients *grpc.Clients[executorv1.ExecutorServiceClient],
+	execSvc task.ExecutionService,
+	config ReconcileConfig,
+) *ReconcileCompensator {
+	if config.StaleThreshold <= 0 {
+		config.StaleThreshold = defaultStaleThreshold
+	}
+	return &ReconcileCompensator{
+		grpcClients: grpcClients,
+		execSvc:     execSvc,
+		config:      config,
+		logger:      elog.DefaultLogger.With(elog.FieldComponentName("compensator.reconcile")),
+	}
+}
+
+// Start 启动补偿器
+func (r *ReconcileCompensator) Start(ctx context.Context) {
+	r.logger.Info("对账补偿器启动")
+
+	for {
+		select {
+		case <-ctx.Done():
+			r.logger.Info("对账补偿器停止")
+			return
+		default:
+			startTime := time.Now()
+
+			err := r.reconcile(ctx)
+			if err != nil {
+				r.logger.Error("对账失败", elog.FieldErr(err))
+			}
+
+			// 防空转：确保最小等待时间
+			elapsed := time.Since(startTime)
+			if elapsed < r.config.MinDuration {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(r.config.MinDuration - elapsed):
+				}
+			}
+		}
+	}
+}
+
+// reconcile 对账长时间没有上报的运行中任务
+func (r *ReconcileCompensator) reconcile(ctx context.Context) error {
+	executions, err := r.execSvc.FindStaleRunningExecutions(ctx, r.config.StaleThreshold, r.config.BatchSize)
+	if err != nil {
+		return fmt.Errorf("查找待对账任务失败: %w", err)
+	}
+	metrics.CompensatorBatchSize.Observe(float64(len(executions)), "reconcile")
+
+	if len(executions) == 0 {
+		r.logger.Info("没有找到待对账的任务")
+		return nil
+	}
+
+	r.logger.Info("找到待对账任务", elog.Int("count", len(executions)))
+
+	for i := range executions {
+		if err = checkLeader(ctx); err != nil {
+			return err
+		}
+		err = r.reconcileExecution(ctx, executions[i])
+		metrics.CompensatorHandledTotal.Inc("reconcile", metrics.Result(err))
+		if err != nil {
+			r.logger.Error("对账任务失败",
+				elog.Int64("executionId", executions[i].ID),
+				elog.String("taskName", executions[i].Task.Name),
+				elog.String("executorNodeId", executions[i].ExecutorNodeID),
+				elog.FieldErr(err))
+		}
+	}
+	return nil
+}
+
+func (r *ReconcileCompensator) reconcileExecution(ctx context.Context, execution domain.TaskExecution) error {
+	if execution.Task.GrpcConfig == nil || execution.ExecutorNodeID == "" {
+		// 非远程执行或不知道执行节点，无法查询，交给中断补偿器在超时后处理
+		return nil
+	}
+
+	// 必须向实际执行该任务的节点查询
+	client := r.grpcClients.Get(execution.Task.GrpcConfig.ServiceName)
+	resp, err := client.Query(balancer.WithSpecificNodeID(ctx, execution.ExecutorNodeID), &executorv1.QueryRequest{
+		Eid: execution.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("发送查询请求失败：%w", err)
+	}
+
+	state := domain.ExecutionStateFromProto(resp.GetExecutionState())
+	if state.Status == domain.TaskExecutionStatusUnknown {
+		// 执行节点已经没有该任务的记录（如重启后丢失），任务不可能还在运行，转为重调度
+		r.logger.Warn("执行节点没有该任务的记录，转为重调度",
+			elog.Int64("executionId", execution.ID),
+			elog.String("executorNodeId", execution.ExecutorNodeID))
+		state = domain.ExecutionState{
+			ID:             execution.ID,
+			TaskID:         execution.Task.ID,
+			TaskName:       execution.Task.Name,
+			Status:         domain.TaskExecutionStatusFailedRescheduled,
+			ExecutorNodeID: execution.ExecutorNodeID,
+		}
+	}
+	if state.ExecutorNodeID == "" {
+		state.ExecutorNodeID = execution.ExecutorNodeID
+	}
+	// 查询得到的是执行节点当前的最新状态，不参与上报序号比较，保证未变化的运行中状态也能刷新更新时间
+	state.Sequence = 0
+	return r.execSvc.UpdateState(ctx, state)
+}
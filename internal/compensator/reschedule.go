@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/Duke1616/ework-runner/internal/metrics"
 	"github.com/Duke1616/ework-runner/internal/service/runner"
 	"github.com/Duke1616/ework-runner/internal/service/task"
 	"github.com/gotomicro/ego/core/elog"
@@ -12,8 +13,9 @@ import (
 
 // RescheduleConfig 重调度补偿器配置
 type RescheduleConfig struct {
-	BatchSize   int           // 批次大小
-	MinDuration time.Duration // 最小等待时间，防止空转
+	BatchSize     int           // 批次大小
+	MinDuration   time.Duration // 最小等待时间，防止空转
+	DispatchLease time.Duration // 分发租约，租约内其他补偿器不会重复重调度同一条执行记录
 }
 
 // RescheduleCompensator 重调度补偿器
@@ -30,6 +32,9 @@ func NewRescheduleCompensator(
 	execSvc task.ExecutionService,
 	config RescheduleConfig,
 ) *RescheduleCompensator {
+	if config.DispatchLease <= 0 {
+		config.DispatchLease = defaultDispatchLease
+	}
 	return &RescheduleCompensator{
 		runner:  runner,
 		execSvc: execSvc,
@@ -78,6 +83,7 @@ func (r *RescheduleCompensator) reschedule(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("查找可重调度任务失败: %w", err)
 	}
+	metrics.CompensatorBatchSize.Observe(float64(len(executions)), "reschedule")
 
 	if len(executions) == 0 {
 		r.logger.Info("没有找到可重调度的任务")
@@ -88,7 +94,14 @@ func (r *RescheduleCompensator) reschedule(ctx context.Context) error {
 
 	// 处理每个可重调度的执行
 	for i := range executions {
+		if err = checkLeader(ctx); err != nil {
+			return err
+		}
+		if !claimDispatch(ctx, r.execSvc, executions[i], r.config.DispatchLease, "reschedule", r.logger) {
+			continue
+		}
 		err = r.runner.Reschedule(ctx, executions[i])
+		metrics.CompensatorHandledTotal.Inc("reschedule", metrics.Result(err))
 		if err != nil {
 			r.logger.Error("重调度失败",
 				elog.Int64("executionId", executions[i].ID),
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/Duke1616/ework-runner/internal/domain"
+	"github.com/Duke1616/ework-runner/internal/metrics"
 	"github.com/Duke1616/ework-runner/internal/service/runner"
 	"github.com/Duke1616/ework-runner/internal/service/task"
 	"github.com/gotomicro/ego/core/elog"
@@ -12,10 +14,10 @@ import (
 
 // RetryConfig 重试补偿器配置
 type RetryConfig struct {
-	MaxRetryCount          int64         `yaml:"maxRetryCount"`          // 最大重试次数
-	PrepareTimeoutWindowMs int64         `yaml:"prepareTimeoutWindowMs"` // PREPARE状态超时窗口
-	BatchSize              int           `yaml:"batchSize"`              // 批量处理大小
-	MinDuration            time.Duration `yaml:"minDuration"`            // 最小等待时间，防止空转
+	MaxRetryCount int64         `yaml:"maxRetryCount"` // 集群级最大重试次数，与任务 RetryConfig.MaxRetries 同时生效，0 表示不限制
+	BatchSize     int           `yaml:"batchSize"`     // 批量处理大小
+	MinDuration   time.Duration `yaml:"minDuration"`   // 最小等待时间，防止空转
+	DispatchLease time.Duration `yaml:"dispatchLease"` // 分发租约，租约内其他补偿器不会重复重试同一条执行记录
 }
 
 // RetryCompensator 重试补偿器
@@ -32,6 +34,9 @@ func NewRetryCompensator(
 	execSvc task.ExecutionService,
 	config RetryConfig,
 ) *RetryCompensator {
+	if config.DispatchLease <= 0 {
+		config.DispatchLease = defaultDispatchLease
+	}
 	return &RetryCompensator{
 		runner:  runner,
 		execSvc: execSvc,
@@ -80,6 +85,7 @@ func (r *RetryCompensator) retry(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("查找可重试任务失败: %w", err)
 	}
+	metrics.CompensatorBatchSize.Observe(float64(len(executions)), "retry")
 
 	if len(executions) == 0 {
 		r.logger.Info("没有找到可重试的任务")
@@ -90,7 +96,19 @@ func (r *RetryCompensator) retry(ctx context.Context) error {
 
 	// 处理每个可重试的执行
 	for i := range executions {
+		if err = checkLeader(ctx); err != nil {
+			return err
+		}
+		// 先认领再处理，避免多个补偿器重复重试或重复结束同一条执行记录
+		if !claimDispatch(ctx, r.execSvc, executions[i], r.config.DispatchLease, "retry", r.logger) {
+			continue
+		}
+		if r.config.MaxRetryCount > 0 && executions[i].RetryCount >= r.config.MaxRetryCount {
+			r.giveUp(ctx, executions[i])
+			continue
+		}
 		err = r.runner.Retry(ctx, executions[i])
+		metrics.CompensatorHandledTotal.Inc("retry", metrics.Result(err))
 		if err != nil {
 			r.logger.Error("重试任务失败",
 				elog.Int64("executionId", executions[i].ID),
@@ -102,3 +120,25 @@ func (r *RetryCompensator) retry(ctx context.Context) error {
 	}
 	return nil
 }
+
+// giveUp 超过集群级最大重试次数，不再重试，按不可重试失败结束执行
+func (r *RetryCompensator) giveUp(ctx context.Context, execution domain.TaskExecution) {
+	r.logger.Warn("超过集群最大重试次数，不再重试",
+		elog.Int64("executionId", execution.ID),
+		elog.String("taskName", execution.Task.Name),
+		elog.Int64("retryCount", execution.RetryCount),
+		elog.Int64("maxRetryCount", r.config.MaxRetryCount))
+
+	err := r.execSvc.UpdateState(ctx, domain.ExecutionState{
+		ID:       execution.ID,
+		TaskID:   execution.Task.ID,
+		TaskName: execution.Task.Name,
+		Status:   domain.TaskExecutionStatusFailed,
+		Result:   execution.Result,
+	})
+	if err != nil {
+		r.logger.Error("结束超过最大重试次数的执行失败",
+			elog.Int64("executionId", execution.ID),
+			elog.FieldErr(err))
+	}
+}
@@ -0,0 +1,110 @@
+//go:build unit
+
+package compensator
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Duke1616/ework-runner/internal/domain"
+	"github.com/Duke1616/ework-runner/internal/service/runner"
+	"github.com/Duke1616/ework-runner/internal/service/task"
+	"github.com/Duke1616/ework-runner/internal/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 两个调度节点的重试补偿器同时查询到同一条执行记录，只有认领成功的一方发起重试
+func TestRetryCompensator_ClaimRace(t *testing.T) {
+	t.Parallel()
+
+	repo := &racingRepo{MemExecutionRepo: test.NewMemExecutionRepo(domain.TaskExecution{
+		ID:            1,
+		Status:        domain.TaskExecutionStatusFailedRetryable,
+		NextRetryTime: time.Now().UnixMilli(),
+		Task: domain.Task{
+			ID:          10,
+			Name:        "sync-user",
+			RetryConfig: &domain.RetryConfig{MaxRetries: 3, InitialInterval: 1000, MaxInterval: 1000},
+		},
+	})}
+	// 两个补偿器都查询完成后才开始处理，保证查询到同一条执行记录
+	repo.found.Add(2)
+	inv := &blockingInvoker{release: make(chan struct{})}
+
+	compensators := make([]*RetryCompensator, 0, 2)
+	for _, nodeID := range []string{"scheduler-1", "scheduler-2"} {
+		execSvc := task.NewExecutionService(nodeID, repo, nil, nil, nil, nil, nil, nil, 0)
+		r := runner.NewNormalTaskRunner(nodeID, nil, execSvc, nil, inv, nil)
+		compensators = append(compensators, NewRetryCompensator(r, execSvc, RetryConfig{BatchSize: 10}))
+	}
+
+	var wg sync.WaitGroup
+	for _, c := range compensators {
+		wg.Add(1)
+		go func(c *RetryCompensator) {
+			defer wg.Done()
+			assert.NoError(t, c.retry(context.Background()))
+		}(c)
+	}
+	wg.Wait()
+
+	execution := repo.Get(1)
+	assert.Equal(t, int64(1), execution.RetryCount)
+	assert.Greater(t, execution.DispatchingUntil, time.Now().UnixMilli())
+	require.Eventually(t, func() bool {
+		return inv.calls.Load() == 1
+	}, time.Second, 5*time.Millisecond)
+	// 租约内不会再被查询到
+	executions, err := repo.MemExecutionRepo.FindRetryableExecutions(context.Background(), 10)
+	require.NoError(t, err)
+	assert.Empty(t, executions)
+
+	// 调用执行节点失败后立即写回可重试失败，清除租约并等待下次重试时间
+	close(inv.release)
+	require.Eventually(t, func() bool {
+		return repo.Get(1).Result.ErrorCategory == runner.DispatchFailedCategory
+	}, time.Second, 5*time.Millisecond)
+	execution = repo.Get(1)
+	assert.Equal(t, domain.TaskExecutionStatusFailedRetryable, execution.Status)
+	assert.Zero(t, execution.DispatchingUntil)
+	assert.Greater(t, execution.NextRetryTime, time.Now().UnixMilli())
+	assert.Equal(t, int64(1), inv.calls.Load())
+}
+
+// racingRepo 两个补偿器都查询完成后才返回查询结果
+type racingRepo struct {
+	*test.MemExecutionRepo
+	found sync.WaitGroup
+}
+
+func (r *racingRepo) FindRetryableExecutions(ctx context.Context, limit int) ([]domain.TaskExecution, error) {
+	executions, err := r.MemExecutionRepo.FindRetryableExecutions(ctx, limit)
+	r.found.Done()
+	r.found.Wait()
+	return executions, err
+}
+
+// blockingInvoker 记录调用次数，release 关闭后返回调用失败
+type blockingInvoker struct {
+	calls   atomic.Int64
+	release chan struct{}
+}
+
+func (i *blockingInvoker) Name() string {
+	return "blocking"
+}
+
+func (i *blockingInvoker) Run(_ context.Context, _ domain.TaskExecution) (domain.ExecutionState, error) {
+	i.calls.Add(1)
+	<-i.release
+	return domain.ExecutionState{}, errors.New("执行节点不可用")
+}
+
+func (i *blockingInvoker) Prepare(_ context.Context, _ domain.TaskExecution) (map[string]string, error) {
+	return nil, nil
+}
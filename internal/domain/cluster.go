@@ -0,0 +1,54 @@
+package domain
+
+import "time"
+
+// SchedulerNode 调度节点的心跳信息，调度节点运行期间定期写入注册中心
+type SchedulerNode struct {
+	NodeID           string
+	StartTime        int64         // 启动时间（毫秒时间戳）
+	LastRenewTime    int64         // 最近一次成功续约抢占任务的时间
+	LastScheduleTime int64         // 调度循环最近一次完成一轮调度的时间
+	ScheduleInterval time.Duration // 没有可调度任务时的调度间隔
+	BatchTimeout     time.Duration // 单次获取可调度任务的超时时间
+	RenewInterval    time.Duration // 续约间隔
+	UTime            int64         // 心跳写入时间
+}
+
+// IsScheduleLoopHealthy 调度循环是否还在正常推进
+// 一轮调度最多耗费一次获取超时加一次调度间隔，心跳又最多滞后一个续约间隔，超过两倍仍未推进视为卡住
+func (n SchedulerNode) IsScheduleLoopHealthy(now int64) bool {
+	if n.LastScheduleTime == 0 {
+		return false
+	}
+	threshold := 2*(n.ScheduleInterval+n.BatchTimeout) + n.RenewInterval
+	return now-n.LastScheduleTime <= threshold.Milliseconds()
+}
+
+// IsRenewHealthy 续约是否还在正常推进，连续两个续约间隔没有成功续约视为异常
+func (n SchedulerNode) IsRenewHealthy(now int64) bool {
+	if n.LastRenewTime == 0 {
+		return false
+	}
+	return now-n.LastRenewTime <= (2 * n.RenewInterval).Milliseconds()
+}
+
+// SchedulerNodeState 调度节点及其抢占的任务
+// 注册中心中已经没有心跳的节点仍然持有 PREEMPTED 任务时，Online 为 false，这些任务需要等待超时或强制释放
+type SchedulerNodeState struct {
+	Node           SchedulerNode
+	Online         bool
+	PreemptedTasks []Task
+}
+
+// ExecutorNode 注册中心中的执行节点
+type ExecutorNode struct {
+	ServiceName string
+	NodeID      string
+	Address     string
+}
+
+// ClusterState 调度集群的实时状态
+type ClusterState struct {
+	SchedulerNodes []SchedulerNodeState
+	ExecutorNodes  map[string][]ExecutorNode // 按服务名分组
+}
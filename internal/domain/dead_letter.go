@@ -0,0 +1,28 @@
+package domain
+
+// DeadLetterStatus 死信状态
+type DeadLetterStatus string
+
+const (
+	DeadLetterStatusPending DeadLetterStatus = "PENDING" // 待处理
+	DeadLetterStatusRetried DeadLetterStatus = "RETRIED" // 已从死信重新发起重试
+)
+
+func (s DeadLetterStatus) String() string {
+	return string(s)
+}
+
+// DeadLetter 最终失败的执行记录，重试用尽或不可重试失败的执行进入死信，保存失败时执行记录的完整快照
+// 同一执行记录的同一次失败（按尝试次数区分）只有一条死信，重复写入不会改变已有死信；
+// 从死信重试后再次失败时尝试次数已经增加，会写入一条新的死信
+type DeadLetter struct {
+	ID          int64
+	ExecutionID int64
+	Attempt     int64 // 进入死信时执行记录的尝试次数
+	TaskID      int64
+	TaskName    string
+	Execution   TaskExecution // 进入死信时的执行记录快照
+	Status      DeadLetterStatus
+	CTime       int64
+	UTime       int64
+}
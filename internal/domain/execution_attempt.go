@@ -0,0 +1,31 @@
+package domain
+
+// AttemptKind 尝试的发起方式
+type AttemptKind string
+
+const (
+	AttemptKindRun        AttemptKind = "RUN"        // 首次执行
+	AttemptKindRetry      AttemptKind = "RETRY"      // 失败后重试
+	AttemptKindReschedule AttemptKind = "RESCHEDULE" // 重调度
+)
+
+func (k AttemptKind) String() string {
+	return string(k)
+}
+
+// ExecutionAttempt 执行记录的一次尝试，首次执行、每次重试和重调度各对应一条
+// 执行记录只保留最近一次尝试的执行节点和时间，历史尝试通过它查看
+type ExecutionAttempt struct {
+	ID             int64
+	ExecutionID    int64
+	Attempt        int64               // 尝试序号，从 1 开始
+	Kind           AttemptKind         // 发起方式
+	ExecutorNodeID string              // 执行节点
+	Status         TaskExecutionStatus // 本次尝试的状态
+	StartTime      int64               // 开始时间
+	EndTime        int64               // 结束时间
+	ErrorMessage   string              // 失败时的错误信息
+	ErrorCategory  string              // 失败时的错误分类
+	CTime          int64
+	UTime          int64
+}
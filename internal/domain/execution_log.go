@@ -0,0 +1,28 @@
+package domain
+
+import reporterv1 "github.com/Duke1616/ework-runner/api/proto/gen/reporter/v1"
+
+// ExecutionLog 执行节点上报的一段任务日志
+type ExecutionLog struct {
+	ID             int64
+	ExecutionID    int64
+	Sequence       int64  // 日志块在执行节点内的序号
+	ExecutorNodeID string // 产生日志的执行节点
+	Content        string
+	Timestamp      int64 // 日志块中第一行的产生时间（毫秒时间戳）
+	CTime          int64
+}
+
+// ExecutionLogTruncatedMarker 单次执行的日志超出存储上限后追加的截断提示
+const ExecutionLogTruncatedMarker = "\n...[日志超出存储上限，后续内容已丢弃]...\n"
+
+// ExecutionLogFromProto 将执行节点上报的日志块转换为执行日志
+func ExecutionLogFromProto(chunk *reporterv1.LogChunk) ExecutionLog {
+	return ExecutionLog{
+		ExecutionID:    chunk.GetEid(),
+		Sequence:       chunk.GetSequence(),
+		ExecutorNodeID: chunk.GetExecutorNodeId(),
+		Content:        chunk.GetContent(),
+		Timestamp:      chunk.GetTimestamp(),
+	}
+}
@@ -0,0 +1,137 @@
+package domain
+
+import "time"
+
+// HookEventType 执行生命周期事件类型
+type HookEventType string
+
+const (
+	HookEventStart       HookEventType = "START"        // 执行节点开始执行（每次尝试各一次）
+	HookEventSuccess     HookEventType = "SUCCESS"      // 执行成功
+	HookEventFailure     HookEventType = "FAILURE"      // 执行最终失败（重试用尽或不可重试）
+	HookEventTimeout     HookEventType = "TIMEOUT"      // 超过最大执行时间被中断或执行节点上报超时
+	HookEventLongRunning HookEventType = "LONG_RUNNING" // 运行时间超过最大执行时间的一定比例
+	HookEventDeadLetter  HookEventType = "DEAD_LETTER"  // 执行进入死信，按任务的告警配置投递，不受订阅配置影响
+)
+
+func (t HookEventType) String() string {
+	return string(t)
+}
+
+// HookChannel 事件投递通道
+type HookChannel string
+
+const (
+	HookChannelWebhook  HookChannel = "WEBHOOK"  // HTTP 回调，携带 HMAC 签名
+	HookChannelKafka    HookChannel = "KAFKA"    // 消息队列 topic
+	HookChannelCallback HookChannel = "CALLBACK" // 进程内注册的回调
+	HookChannelEmail    HookChannel = "EMAIL"    // 邮件，目前只用于死信告警
+)
+
+func (c HookChannel) String() string {
+	return string(c)
+}
+
+// DefaultLongRunningPercent 未配置时，运行超过最大执行时间的 80% 视为长时间运行
+const DefaultLongRunningPercent = 80
+
+// HookConfig 任务的生命周期事件订阅配置
+type HookConfig struct {
+	Events             []HookEventType `json:"events"`             // 订阅的事件，为空时订阅全部
+	Webhooks           []WebhookTarget `json:"webhooks"`           // HTTP 回调地址
+	Topics             []string        `json:"topics"`             // 消息队列 topic
+	Callbacks          []string        `json:"callbacks"`          // 进程内注册的回调名称
+	LongRunningPercent int32           `json:"longRunningPercent"` // 运行时间超过 MaxExecutionSeconds 的百分比，默认 80
+}
+
+// WebhookTarget HTTP 回调地址，Secret 不为空时对请求体做 HMAC-SHA256 签名
+type WebhookTarget struct {
+	URL    string `json:"url"`
+	Secret string `json:"secret"`
+}
+
+// HookTarget 一个具体的投递目标
+type HookTarget struct {
+	Channel HookChannel
+	Target  string // 回调地址、topic 或回调名称
+	Secret  string // 仅 WEBHOOK 使用
+}
+
+// Subscribed 是否订阅了指定事件
+func (h *HookConfig) Subscribed(typ HookEventType) bool {
+	if h == nil {
+		return false
+	}
+	if len(h.Events) == 0 {
+		return true
+	}
+	for _, e := range h.Events {
+		if e == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// Targets 展开所有投递目标
+func (h *HookConfig) Targets() []HookTarget {
+	if h == nil {
+		return nil
+	}
+	targets := make([]HookTarget, 0, len(h.Webhooks)+len(h.Topics)+len(h.Callbacks))
+	for _, w := range h.Webhooks {
+		targets = append(targets, HookTarget{Channel: HookChannelWebhook, Target: w.URL, Secret: w.Secret})
+	}
+	for _, topic := range h.Topics {
+		targets = append(targets, HookTarget{Channel: HookChannelKafka, Target: topic})
+	}
+	for _, name := range h.Callbacks {
+		targets = append(targets, HookTarget{Channel: HookChannelCallback, Target: name})
+	}
+	return targets
+}
+
+// LongRunningThreshold 运行多久后视为长时间运行，maxExecutionSeconds 不大于 0 时返回 0 表示不检测
+func (h *HookConfig) LongRunningThreshold(maxExecutionSeconds int64) time.Duration {
+	if h == nil || maxExecutionSeconds <= 0 {
+		return 0
+	}
+	percent := h.LongRunningPercent
+	if percent <= 0 || percent > 100 {
+		percent = DefaultLongRunningPercent
+	}
+	return time.Duration(maxExecutionSeconds) * time.Second * time.Duration(percent) / 100
+}
+
+// HookDeliveryStatus 投递状态
+type HookDeliveryStatus string
+
+const (
+	HookDeliveryStatusPending HookDeliveryStatus = "PENDING" // 投递中
+	HookDeliveryStatusSuccess HookDeliveryStatus = "SUCCESS" // 投递成功
+	HookDeliveryStatusFailed  HookDeliveryStatus = "FAILED"  // 重试用尽仍然失败
+)
+
+func (s HookDeliveryStatus) String() string {
+	return string(s)
+}
+
+// HookDelivery 生命周期事件的投递记录，同一执行记录的同一次尝试、同一事件对同一目标只投递一次
+type HookDelivery struct {
+	ID          int64
+	ExecutionID int64
+	Attempt     int64 // 按尝试触发的事件（START、TIMEOUT、LONG_RUNNING）为尝试序号，执行最终结果为 0
+	TaskID      int64
+	Event       HookEventType
+	Channel     HookChannel
+	TaskName    string
+	Target      string
+	Payload     []byte // JSON 编码的事件或告警，重试投递时原样发送
+	Status      HookDeliveryStatus
+	// 投递中的记录下次投递的时间，投递失败后按重试策略推迟，由投递中继到期后重新投递
+	NextRetryTime int64
+	Tries         int32  // 已投递次数
+	LastError     string // 最近一次投递失败的错误
+	CTime         int64
+	UTime         int64
+}
@@ -0,0 +1,13 @@
+package domain
+
+// OutboxMessage 发件箱中待发送的完成事件，与执行记录的终止状态在同一个事务中写入，发送成功后删除
+type OutboxMessage struct {
+	ID          int64
+	ExecutionID int64
+	Payload     []byte // 序列化后的完成事件
+	Tries       int32  // 已发送失败的次数
+	NextTime    int64  // 下次可以发送的时间，中继发送前以 CAS 方式推迟它来认领消息
+	LastError   string // 最近一次发送失败的错误
+	CTime       int64
+	UTime       int64
+}
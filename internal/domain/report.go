@@ -1,5 +1,10 @@
 package domain
 
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
 // Report 进度上报结构
 type Report struct {
 	ExecutionState ExecutionState `json:"executionState"`
@@ -16,6 +21,68 @@ type ExecutionState struct {
 	RescheduleParams  map[string]string `json:"rescheduleParams"`
 	// 执行节点的 nodeID，用于记录是哪个节点处理了任务
 	ExecutorNodeID string `json:"executorNodeId"`
+	// 执行节点拒绝执行的原因（BUSY、DRAINING），为空表示未拒绝
+	RejectReason string `json:"rejectReason,omitempty"`
+	// 上报序号，同一执行节点内单调递增，0 表示不参与序号比较
+	Sequence int64 `json:"sequence,omitempty"`
+	// 尝试序号，执行节点原样回传调度时下发的序号，0 表示未知（旧版本执行节点），按当前尝试处理
+	Attempt int64 `json:"attempt,omitempty"`
+	// 执行结果，任务结束时由执行节点填充
+	Result ExecutionResult `json:"result"`
+}
+
+const (
+	// MaxResultPayloadSize 结果数据的大小上限
+	MaxResultPayloadSize = 64 * 1024
+	// maxResultErrorMessageSize 错误信息的大小上限
+	maxResultErrorMessageSize = 1024
+	// maxResultErrorDetailSize 错误详情的大小上限
+	maxResultErrorDetailSize = 4 * 1024
+)
+
+// ExecutionResult 执行结果
+type ExecutionResult struct {
+	Payload      []byte `json:"payload,omitempty"`      // 结果数据，通常为 JSON
+	ErrorMessage string `json:"errorMessage,omitempty"` // 错误信息
+	ErrorCode    string `json:"errorCode,omitempty"`    // 错误码
+	// 错误分类，如 TIMEOUT、INTERRUPTED、HANDLER_NOT_FOUND、SCRIPT_EXIT、INVALID_PARAMS
+	ErrorCategory string `json:"errorCategory,omitempty"`
+	ErrorDetail   string `json:"errorDetail,omitempty"` // 执行节点侧的错误详情，如 panic 堆栈、脚本输出末尾
+	ExitCode      int32  `json:"exitCode,omitempty"`    // 脚本退出码
+}
+
+// IsEmpty 是否没有任何结果
+func (r ExecutionResult) IsEmpty() bool {
+	return len(r.Payload) == 0 && r.ErrorMessage == "" && r.ErrorCode == "" && r.ErrorCategory == ""
+}
+
+// Bounded 限制结果大小：超限的结果数据被丢弃并记录到错误信息中，超长的错误信息被截断
+func (r ExecutionResult) Bounded() ExecutionResult {
+	if len(r.Payload) > MaxResultPayloadSize {
+		r.ErrorMessage = fmt.Sprintf("执行结果超过大小上限被丢弃: %d > %d; %s",
+			len(r.Payload), MaxResultPayloadSize, r.ErrorMessage)
+		r.Payload = nil
+	}
+	r.ErrorMessage = truncateUTF8(r.ErrorMessage, maxResultErrorMessageSize)
+	r.ErrorDetail = truncateUTF8(r.ErrorDetail, maxResultErrorDetailSize)
+	return r
+}
+
+// truncateUTF8 按字节截断字符串，保证不截断多字节字符
+func truncateUTF8(s string, size int) string {
+	if len(s) <= size {
+		return s
+	}
+	s = s[:size]
+	for len(s) > 0 && !utf8.ValidString(s) {
+		s = s[:len(s)-1]
+	}
+	return s
+}
+
+// IsRejected 执行节点是否拒绝了本次执行，此时应排除该节点立即转移到其他执行节点
+func (s ExecutionState) IsRejected() bool {
+	return s.RejectReason != ""
 }
 
 type BatchReport struct {
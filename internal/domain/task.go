@@ -50,6 +50,8 @@ type Task struct {
 	GrpcConfig          *GrpcConfig
 	HTTPConfig          *HTTPConfig
 	RetryConfig         *RetryConfig
+	AlertConfig         *AlertConfig      // 执行最终失败时的告警配置
+	HookConfig          *HookConfig       // 执行生命周期事件订阅
 	MaxExecutionSeconds int64             // 最大执行秒数，默认24小时
 	ScheduleNodeID      string            // 调度节点ID
 	ScheduleParams      map[string]string // 调度参数（如分页偏移量、处理进度等）
@@ -62,20 +64,88 @@ type Task struct {
 
 // RetryConfig 重试配置
 type RetryConfig struct {
+	// 重试策略：fixed、exponential、exponential_jitter、schedule，为空时使用 exponential
+	Type            string
 	MaxRetries      int32
-	InitialInterval int64 // 毫秒
-	MaxInterval     int64 // 毫秒
+	InitialInterval int64   // 毫秒，fixed 策略下为固定的重试间隔
+	MaxInterval     int64   // 毫秒
+	Schedule        []int64 // 毫秒，schedule 策略下第 n 次重试使用第 n 个间隔，列表长度即最大重试次数，忽略 MaxRetries
+}
+
+// Enabled 是否允许重试，重试策略中最大重试次数为 0 表示不限次数，因此这里单独判断
+func (r *RetryConfig) Enabled() bool {
+	if r == nil {
+		return false
+	}
+	if r.Type == retry.TypeSchedule {
+		return len(r.Schedule) > 0
+	}
+	return r.MaxRetries > 0
 }
 
 func (r *RetryConfig) ToRetryComponentConfig() retry.Config {
-	return retry.Config{
-		Type: "exponential",
-		ExponentialBackoff: &retry.ExponentialBackoffConfig{
-			InitialInterval: time.Duration(r.InitialInterval) * time.Millisecond,
-			MaxInterval:     time.Duration(r.MaxInterval) * time.Millisecond,
-			MaxRetries:      r.MaxRetries,
-		},
+	switch r.Type {
+	case retry.TypeFixed:
+		return retry.Config{
+			Type: retry.TypeFixed,
+			FixedInterval: &retry.FixedIntervalConfig{
+				Interval:   time.Duration(r.InitialInterval) * time.Millisecond,
+				MaxRetries: r.MaxRetries,
+			},
+		}
+	case retry.TypeSchedule:
+		intervals := make([]time.Duration, 0, len(r.Schedule))
+		for _, interval := range r.Schedule {
+			intervals = append(intervals, time.Duration(interval)*time.Millisecond)
+		}
+		return retry.Config{
+			Type:     retry.TypeSchedule,
+			Schedule: &retry.ScheduleConfig{Intervals: intervals},
+		}
+	default:
+		typ := r.Type
+		if typ == "" {
+			typ = retry.TypeExponential
+		}
+		return retry.Config{
+			Type: typ,
+			ExponentialBackoff: &retry.ExponentialBackoffConfig{
+				InitialInterval: time.Duration(r.InitialInterval) * time.Millisecond,
+				MaxInterval:     time.Duration(r.MaxInterval) * time.Millisecond,
+				MaxRetries:      r.MaxRetries,
+			},
+		}
+	}
+}
+
+// AlertConfig 告警配置，执行重试用尽或不可重试失败进入死信后，按配置的通道发送告警
+type AlertConfig struct {
+	Webhooks []string `json:"webhooks"` // 以 POST JSON 方式回调的地址
+	Emails   []string `json:"emails"`   // 收件人邮箱
+	Topic    string   `json:"topic"`    // 消息队列 topic
+}
+
+// IsEmpty 是否没有配置任何告警通道
+func (a *AlertConfig) IsEmpty() bool {
+	return a == nil || (len(a.Webhooks) == 0 && len(a.Emails) == 0 && a.Topic == "")
+}
+
+// Targets 展开所有告警目标，告警与生命周期事件共用同一套投递通道，每个收件人单独投递
+func (a *AlertConfig) Targets() []HookTarget {
+	if a.IsEmpty() {
+		return nil
+	}
+	targets := make([]HookTarget, 0, len(a.Webhooks)+len(a.Emails)+1)
+	for _, url := range a.Webhooks {
+		targets = append(targets, HookTarget{Channel: HookChannelWebhook, Target: url})
+	}
+	for _, email := range a.Emails {
+		targets = append(targets, HookTarget{Channel: HookChannelEmail, Target: email})
+	}
+	if a.Topic != "" {
+		targets = append(targets, HookTarget{Channel: HookChannelKafka, Target: a.Topic})
 	}
+	return targets
 }
 
 // GrpcConfig gRPC配置
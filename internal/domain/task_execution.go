@@ -1,7 +1,9 @@
 package domain
 
 import (
+	"slices"
 	"strconv"
+	"time"
 
 	executorv1 "github.com/Duke1616/ework-runner/api/proto/gen/executor/v1"
 )
@@ -29,7 +31,8 @@ func (t TaskExecutionStatus) IsValid() bool {
 		TaskExecutionStatusRunning,
 		TaskExecutionStatusSuccess,
 		TaskExecutionStatusFailed,
-		TaskExecutionStatusFailedRetryable:
+		TaskExecutionStatusFailedRetryable,
+		TaskExecutionStatusFailedRescheduled:
 		return true
 	default:
 		return false
@@ -81,20 +84,107 @@ func (t TaskExecutionStatus) IsTerminalStatus() bool {
 	return t.IsSuccess() || t.IsFailed()
 }
 
+// TransitionKind 状态迁移的触发方式
+type TransitionKind uint8
+
+const (
+	// TransitionReport 当前尝试的执行节点上报、调度节点分发以及补偿器触发的迁移
+	TransitionReport TransitionKind = 1 << iota
+	// TransitionRedispatch 重试、重调度开始的新尝试写入结果，写入前执行记录仍然处于上一次尝试的失败状态
+	TransitionRedispatch
+	// TransitionRequeue 人工把失败的执行记录重新放回重试队列
+	TransitionRequeue
+)
+
+// taskExecutionTransitions 执行状态迁移表，所有状态迁移都以此为准，key 为当前状态，value 为允许迁移到的目标状态及其触发方式
+//   - PREPARE、RUNNING：同步执行时执行节点可能直接返回最终结果，所以可以直接结束；RUNNING 到 RUNNING 表示更新进度
+//   - FAILED_RETRYABLE、FAILED_RESCHEDULED：上一次尝试已经结束，只有重试、重调度通过 StartAttempt 开始新尝试之后，
+//     新尝试的结果（包括分发失败的写回）才能迁移；调度节点放弃重试时直接迁移到 FAILED
+//   - 终止状态不接受上报，FAILED 只能人工重新放回重试队列
+var taskExecutionTransitions = map[TaskExecutionStatus]map[TaskExecutionStatus]TransitionKind{
+	TaskExecutionStatusPrepare: {
+		TaskExecutionStatusRunning:           TransitionReport,
+		TaskExecutionStatusSuccess:           TransitionReport,
+		TaskExecutionStatusFailed:            TransitionReport,
+		TaskExecutionStatusFailedRetryable:   TransitionReport,
+		TaskExecutionStatusFailedRescheduled: TransitionReport,
+	},
+	TaskExecutionStatusRunning: {
+		TaskExecutionStatusRunning:           TransitionReport,
+		TaskExecutionStatusSuccess:           TransitionReport,
+		TaskExecutionStatusFailed:            TransitionReport,
+		TaskExecutionStatusFailedRetryable:   TransitionReport,
+		TaskExecutionStatusFailedRescheduled: TransitionReport,
+	},
+	TaskExecutionStatusFailedRetryable: {
+		TaskExecutionStatusRunning:           TransitionRedispatch,
+		TaskExecutionStatusSuccess:           TransitionRedispatch,
+		TaskExecutionStatusFailed:            TransitionReport | TransitionRedispatch,
+		TaskExecutionStatusFailedRetryable:   TransitionRedispatch,
+		TaskExecutionStatusFailedRescheduled: TransitionRedispatch,
+	},
+	TaskExecutionStatusFailedRescheduled: {
+		TaskExecutionStatusRunning:           TransitionRedispatch,
+		TaskExecutionStatusSuccess:           TransitionRedispatch,
+		TaskExecutionStatusFailed:            TransitionReport | TransitionRedispatch,
+		TaskExecutionStatusFailedRetryable:   TransitionRedispatch,
+		TaskExecutionStatusFailedRescheduled: TransitionRedispatch,
+	},
+	TaskExecutionStatusFailed: {
+		TaskExecutionStatusFailedRetryable: TransitionRequeue,
+	},
+}
+
+// CanTransitTo 当前状态是否允许由当前尝试的上报、分发迁移到目标状态
+func (t TaskExecutionStatus) CanTransitTo(to TaskExecutionStatus) bool {
+	return t.canTransitBy(to, TransitionReport)
+}
+
+// CanRedispatchTo 当前状态是否允许由重试、重调度开始的新尝试迁移到目标状态
+func (t TaskExecutionStatus) CanRedispatchTo(to TaskExecutionStatus) bool {
+	return t.canTransitBy(to, TransitionRedispatch)
+}
+
+// CanUpdateTo 迁移表中是否存在除人工放回队列以外的迁移，持久层据此拦截迁移表之外的迁移，触发方式由调用方区分
+func (t TaskExecutionStatus) CanUpdateTo(to TaskExecutionStatus) bool {
+	return t.canTransitBy(to, TransitionReport|TransitionRedispatch)
+}
+
+// CanRequeueTo 当前状态是否允许人工重新放回队列，迁移到目标状态
+func (t TaskExecutionStatus) CanRequeueTo(to TaskExecutionStatus) bool {
+	return t.canTransitBy(to, TransitionRequeue)
+}
+
+func (t TaskExecutionStatus) canTransitBy(to TaskExecutionStatus, kind TransitionKind) bool {
+	return taskExecutionTransitions[t][to]&kind != 0
+}
+
 // TaskExecution 任务执行记录
 type TaskExecution struct {
 	ID              int64
 	Deadline        int64               // 任务执行截止时间（毫秒时间戳）
 	ExecutorNodeID  string              // 执行节点的 nodeID，用于记录是哪个节点处理了任务
+	FailedNodeIDs   []string            // 执行失败过的节点 nodeID 列表，重试时需要排除
+	ReportNodeID    string              // 最近一次被接受的上报来自的执行节点
+	ReportSeq       int64               // 最近一次被接受的上报序号
+	Result          ExecutionResult     // 执行结果
 	StartTime       int64               // 开始时间
 	EndTime         int64               // 结束时间
-	RetryCount      int64               // 已重试次数
+	RetryCount      int64               // 已发起的重试次数
+	Attempts        int64               // 已发起的尝试次数，首次执行、重试和重调度都计入
 	NextRetryTime   int64               // 下次重试时间
 	RunningProgress int32               // 进度 0-100，RUNNING 状态才有意义
 	Status          TaskExecutionStatus // 执行状态
 	CTime           int64               // 创建时间
 	UTime           int64               // 更新时间
 	Task            Task                // 创建时刻从Task冗余的信息
+
+	// 完成事件是否已经被消费者处理，进入终止状态时重置，消费者据此丢弃重复的完成事件
+	CompletionHandled bool
+	// 创建执行记录时的链路上下文，重试、重调度、上报和完成事件据此归入同一条链路
+	TraceContext map[string]string
+	// 重试、重调度的分发租约截止时间（毫秒时间戳），租约内补偿器不会再次拉取该执行记录
+	DispatchingUntil int64
 }
 
 func (te *TaskExecution) MergeTaskScheduleParams(scheduleParams map[string]string) {
@@ -111,6 +201,52 @@ func (te *TaskExecution) MergeTaskScheduleParams(scheduleParams map[string]strin
 	}
 }
 
+// AddFailedNodeID 记录执行失败的节点，重复的节点只记录一次
+func (te *TaskExecution) AddFailedNodeID(nodeID string) {
+	if nodeID == "" || slices.Contains(te.FailedNodeIDs, nodeID) {
+		return
+	}
+	te.FailedNodeIDs = append(te.FailedNodeIDs, nodeID)
+}
+
+// IsStaleReport 上报状态是否为重复或乱序到达的旧状态
+// 序号只在同一执行节点内可比较，来自其他节点或不带序号的上报不视为过期
+func (te *TaskExecution) IsStaleReport(state ExecutionState) bool {
+	return state.Sequence > 0 &&
+		state.ExecutorNodeID == te.ReportNodeID &&
+		state.Sequence <= te.ReportSeq
+}
+
+// IsSupersededReport 上报是否来自已经被新尝试取代的旧尝试
+// 重试、重调度开始新尝试后，旧尝试的执行节点（可能是其他节点）迟到的上报不能再改变执行记录的状态；
+// 不带尝试序号的上报无法判断，不视为被取代
+func (te *TaskExecution) IsSupersededReport(state ExecutionState) bool {
+	return state.Attempt > 0 && state.Attempt < te.Attempts
+}
+
+// IsDuplicateReport 上报状态是否为已经生效的终止状态，重复上报的终止状态不需要再处理
+func (te *TaskExecution) IsDuplicateReport(state ExecutionState) bool {
+	return te.Status.IsTerminalStatus() && te.Status == state.Status
+}
+
+// IsTimedOut 上报的结束状态是否由超时导致：执行节点以 TIMEOUT 分类上报，或者超过截止时间后被中断
+func (te *TaskExecution) IsTimedOut(state ExecutionState, now time.Time) bool {
+	if state.Status.IsRunning() || state.Status.IsSuccess() {
+		return false
+	}
+	return state.Result.ErrorCategory == executorv1.ErrorCategory_TIMEOUT.String() ||
+		(te.Deadline > 0 && now.UnixMilli() >= te.Deadline)
+}
+
+// ExcludedNodeIDs 重试时需要排除的节点，包括所有失败过的节点以及最近一次执行的节点
+func (te *TaskExecution) ExcludedNodeIDs() []string {
+	nodeIDs := slices.Clone(te.FailedNodeIDs)
+	if te.ExecutorNodeID != "" && !slices.Contains(nodeIDs, te.ExecutorNodeID) {
+		nodeIDs = append(nodeIDs, te.ExecutorNodeID)
+	}
+	return nodeIDs
+}
+
 // GRPCParams 获取gRPC执行参数（业务参数 + 调度参数）
 // 调度参数优先级更高，会覆盖同名的业务参数
 func (te *TaskExecution) GRPCParams() map[string]string {
@@ -149,5 +285,30 @@ func ExecutionStateFromProto(protoState *executorv1.ExecutionState) ExecutionSta
 		RequestReschedule: protoState.GetRequestReschedule(),
 		RescheduleParams:  protoState.GetRescheduledParams(),
 		ExecutorNodeID:    protoState.GetExecutorNodeId(),
+		RejectReason:      rejectReasonFromProto(protoState.GetRejectReason()),
+		Sequence:          protoState.GetSequence(),
+		Attempt:           protoState.GetAttempt(),
+		Result: ExecutionResult{
+			Payload:       protoState.GetResult().GetPayload(),
+			ErrorMessage:  protoState.GetResult().GetErrorMessage(),
+			ErrorCode:     protoState.GetResult().GetErrorCode(),
+			ErrorCategory: errorCategoryFromProto(protoState.GetResult().GetErrorCategory()),
+			ErrorDetail:   protoState.GetResult().GetErrorDetail(),
+			ExitCode:      protoState.GetResult().GetExitCode(),
+		},
+	}
+}
+
+func rejectReasonFromProto(reason executorv1.RejectReason) string {
+	if reason == executorv1.RejectReason_NOT_REJECTED {
+		return ""
+	}
+	return reason.String()
+}
+
+func errorCategoryFromProto(category executorv1.ErrorCategory) string {
+	if category == executorv1.ErrorCategory_UNCATEGORIZED {
+		return ""
 	}
+	return category.String()
 }
@@ -0,0 +1,159 @@
+//go:build unit
+
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaskExecutionStatus_CanTransitTo(t *testing.T) {
+	t.Parallel()
+
+	var (
+		prepare     = TaskExecutionStatusPrepare
+		running     = TaskExecutionStatusRunning
+		success     = TaskExecutionStatusSuccess
+		failed      = TaskExecutionStatusFailed
+		retryable   = TaskExecutionStatusFailedRetryable
+		rescheduled = TaskExecutionStatusFailedRescheduled
+	)
+	testCases := []struct {
+		from TaskExecutionStatus
+		to   TaskExecutionStatus
+		// 当前尝试的上报、分发允许的迁移
+		want bool
+		// 重试、重调度开始的新尝试允许的迁移
+		wantRedispatch bool
+		// 人工放回重试队列允许的迁移
+		wantRequeue bool
+	}{
+		{from: prepare, to: prepare, want: false},
+		{from: prepare, to: running, want: true},
+		{from: prepare, to: success, want: true},
+		{from: prepare, to: failed, want: true},
+		{from: prepare, to: retryable, want: true},
+		{from: prepare, to: rescheduled, want: true},
+
+		{from: running, to: prepare, want: false},
+		{from: running, to: running, want: true},
+		{from: running, to: success, want: true},
+		{from: running, to: failed, want: true},
+		{from: running, to: retryable, want: true},
+		{from: running, to: rescheduled, want: true},
+
+		{from: success, to: prepare, want: false},
+		{from: success, to: running, want: false},
+		{from: success, to: success, want: false},
+		{from: success, to: failed, want: false},
+		{from: success, to: retryable, want: false},
+		{from: success, to: rescheduled, want: false},
+
+		{from: failed, to: prepare, want: false},
+		{from: failed, to: running, want: false},
+		{from: failed, to: success, want: false},
+		{from: failed, to: failed, want: false},
+		{from: failed, to: retryable, want: false, wantRequeue: true},
+		{from: failed, to: rescheduled, want: false},
+
+		{from: retryable, to: prepare, want: false},
+		{from: retryable, to: running, want: false, wantRedispatch: true},
+		{from: retryable, to: success, want: false, wantRedispatch: true},
+		{from: retryable, to: failed, want: true, wantRedispatch: true},
+		{from: retryable, to: retryable, want: false, wantRedispatch: true},
+		{from: retryable, to: rescheduled, want: false, wantRedispatch: true},
+
+		{from: rescheduled, to: prepare, want: false},
+		{from: rescheduled, to: running, want: false, wantRedispatch: true},
+		{from: rescheduled, to: success, want: false, wantRedispatch: true},
+		{from: rescheduled, to: failed, want: true, wantRedispatch: true},
+		{from: rescheduled, to: retryable, want: false, wantRedispatch: true},
+		{from: rescheduled, to: rescheduled, want: false, wantRedispatch: true},
+
+		{from: TaskExecutionStatusUnknown, to: running, want: false},
+		{from: running, to: TaskExecutionStatusUnknown, want: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.from.String()+"->"+tc.to.String(), func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.want, tc.from.CanTransitTo(tc.to))
+			assert.Equal(t, tc.wantRedispatch, tc.from.CanRedispatchTo(tc.to))
+			assert.Equal(t, tc.want || tc.wantRedispatch, tc.from.CanUpdateTo(tc.to))
+			assert.Equal(t, tc.wantRequeue, tc.from.CanRequeueTo(tc.to))
+		})
+	}
+}
+
+func TestTaskExecution_IsDuplicateReport(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		current TaskExecutionStatus
+		report  TaskExecutionStatus
+		want    bool
+	}{
+		{name: "重复上报成功", current: TaskExecutionStatusSuccess, report: TaskExecutionStatusSuccess, want: true},
+		{name: "重复上报失败", current: TaskExecutionStatusFailed, report: TaskExecutionStatusFailed, want: true},
+		{name: "成功后上报失败", current: TaskExecutionStatusSuccess, report: TaskExecutionStatusFailed, want: false},
+		{name: "运行中更新进度", current: TaskExecutionStatusRunning, report: TaskExecutionStatusRunning, want: false},
+		{name: "重试后再次可重试失败", current: TaskExecutionStatusFailedRetryable, report: TaskExecutionStatusFailedRetryable, want: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			execution := TaskExecution{Status: tc.current}
+			assert.Equal(t, tc.want, execution.IsDuplicateReport(ExecutionState{Status: tc.report}))
+		})
+	}
+}
+
+func TestTaskExecution_IsSupersededReport(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		report ExecutionState
+		want   bool
+	}{
+		{
+			name:   "当前尝试的上报",
+			report: ExecutionState{ExecutorNodeID: "node-b", Attempt: 2, Status: TaskExecutionStatusRunning},
+			want:   false,
+		},
+		{
+			name:   "旧尝试的执行节点在新尝试开始后上报成功",
+			report: ExecutionState{ExecutorNodeID: "node-a", Attempt: 1, Sequence: 5, Status: TaskExecutionStatusSuccess},
+			want:   true,
+		},
+		{
+			name:   "同一节点旧尝试迟到的上报",
+			report: ExecutionState{ExecutorNodeID: "node-b", Attempt: 1, Status: TaskExecutionStatusFailed},
+			want:   true,
+		},
+		{
+			name:   "不带尝试序号的上报",
+			report: ExecutionState{ExecutorNodeID: "node-a", Status: TaskExecutionStatusSuccess},
+			want:   false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			// 第 1 次尝试在 node-a 上执行，重调度后第 2 次尝试在 node-b 上执行
+			execution := TaskExecution{
+				Status:         TaskExecutionStatusRunning,
+				ExecutorNodeID: "node-b",
+				Attempts:       2,
+				ReportNodeID:   "node-b",
+				ReportSeq:      1,
+			}
+			assert.False(t, execution.IsStaleReport(tc.report))
+			assert.Equal(t, tc.want, execution.IsSupersededReport(tc.report))
+		})
+	}
+}
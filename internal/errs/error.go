@@ -9,6 +9,7 @@ var (
 	ErrTaskUpdateNextTimeFailed       = errors.New("任务更新下次执行时间失败")
 	ErrTaskUpdateScheduleParamsFailed = errors.New("任务更新调度参数失败")
 	ErrTaskUpdateStatusFailed         = errors.New("任务更新状态失败")
+	ErrTaskNotPreempted               = errors.New("任务没有被调度节点抢占")
 
 	ErrExecutionNotFound            = errors.New("执行记录不存在")
 	ErrInvalidTaskExecutionStatus   = errors.New("执行记录状态非法")
@@ -18,6 +19,7 @@ var (
 	ErrInvalidTaskScheduleNodeID  = errors.New("无效的调度节点ID")
 	ErrInvalidTaskExecutionMethod = errors.New("任务执行方式非法")
 	ErrInvalidTaskShardingRule    = errors.New("分片规则非法")
+	ErrInvalidTaskRetryConfig     = errors.New("重试配置非法")
 	ErrTaskShardingRuleNotFound   = errors.New("分片规则未找到")
 
 	ErrSetExecutionStateRunningFailed        = errors.New("设置运行状态失败")
@@ -27,8 +29,14 @@ var (
 	ErrUpdateExecutionRetryResultFailed      = errors.New("更新任务执行记录的重试结果失败")
 
 	ErrExecutionMaxRetriesExceeded   = errors.New("超过最大重试次数")
+	ErrExecutionMaxAttemptsExceeded  = errors.New("超过最大尝试次数")
 	ErrExecutionStateHandlerNotFound = errors.New("执行状态处理器未找到")
 
+	ErrDeadLetterNotFound       = errors.New("死信不存在")
+	ErrDeadLetterAlreadyRetried = errors.New("死信已经重试过")
+
+	ErrNotCompensatorLeader = errors.New("已经不是补偿器主节点")
+
 	ErrInitPlanFailed = errors.New("plan和实际创建的任务不符")
 	ErrExceedLimit    = errors.New("抢资源超出限制")
 )
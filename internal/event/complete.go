@@ -2,6 +2,13 @@ package event
 
 import "github.com/Duke1616/ework-runner/internal/domain"
 
+const (
+	// HeaderError 转入死信 topic 的消息头，记录最后一次处理失败的原因
+	HeaderError = "x-ework-error"
+	// HeaderOriginTopic 转入死信 topic 的消息头，记录消息原本所在的 topic
+	HeaderOriginTopic = "x-ework-origin-topic"
+)
+
 type Event struct {
 	TaskID         int64                      `json:"taskId"`
 	ExecID         int64                      `json:"execId"`
@@ -9,4 +16,8 @@ type Event struct {
 	ScheduleNodeID string                     `json:"scheduleNodeId"`
 	ExecStatus     domain.TaskExecutionStatus `json:"execStatus"`
 	Name           string                     `json:"name"`
+	Result         domain.ExecutionResult     `json:"result"`
+
+	// 产生完成事件时的链路上下文，发送时同时写入消息头
+	TraceContext map[string]string `json:"traceContext,omitempty"`
 }
@@ -3,71 +3,166 @@ package complete
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"time"
 
 	"github.com/Duke1616/ework-runner/internal/domain"
+	"github.com/Duke1616/ework-runner/internal/errs"
 	"github.com/Duke1616/ework-runner/internal/event"
 	"github.com/Duke1616/ework-runner/internal/service/acquirer"
+	"github.com/Duke1616/ework-runner/internal/service/deadletter"
+	"github.com/Duke1616/ework-runner/internal/service/hook"
 	"github.com/Duke1616/ework-runner/internal/service/task"
+	"github.com/Duke1616/ework-runner/pkg/tracex"
 	"github.com/ecodeclub/mq-api"
+	"github.com/gotomicro/ego/core/elog"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
-const (
-	number100 = 100
-	number0   = 0
-)
+// maxReleaseAttempts 释放任务遇到版本号冲突时最多尝试的次数
+const maxReleaseAttempts = 3
 
 type Consumer struct {
 	// 更新
 	execSvc task.ExecutionService
 	taskSvc task.Service
 	acquire acquirer.TaskAcquirer
+	// 最终失败的执行写入死信并告警
+	dlqSvc deadletter.Service
+	// 发布执行成功、失败事件
+	hookSvc hook.Service
+	// 处理失败的消息转入死信 topic，不阻塞同一分区后续完成事件的消费
+	dlqProducer mq.Producer
+	logger      *elog.Component
 }
 
 func NewConsumer(execSvc task.ExecutionService,
 	taskSvc task.Service,
 	acquirer acquirer.TaskAcquirer,
+	dlqSvc deadletter.Service,
+	hookSvc hook.Service,
+	dlqProducer mq.Producer,
 ) *Consumer {
 	return &Consumer{
-		taskSvc: taskSvc,
-		execSvc: execSvc,
-		acquire: acquirer,
+		taskSvc:     taskSvc,
+		execSvc:     execSvc,
+		acquire:     acquirer,
+		dlqSvc:      dlqSvc,
+		hookSvc:     hookSvc,
+		dlqProducer: dlqProducer,
+		logger:      elog.DefaultLogger.With(elog.FieldComponentName("event.complete")),
 	}
 }
 
-func (c *Consumer) Consume(ctx context.Context, message *mq.Message) error {
+func (c *Consumer) Consume(ctx context.Context, message *mq.Message) (err error) {
+	// 从消息头恢复产生完成事件时的链路上下文
+	ctx, span := tracex.Start(tracex.Extract(ctx, message.Header), "complete.Consumer",
+		trace.WithSpanKind(trace.SpanKindConsumer))
+	defer func() {
+		tracex.End(span, err)
+	}()
+
 	var evt event.Event
-	err := json.Unmarshal(message.Value, &evt)
+	err = json.Unmarshal(message.Value, &evt)
 	if err != nil {
-		return fmt.Errorf("序列化失败 %w", err)
+		// 无法解析的消息重试也不会成功，直接转入死信 topic
+		return c.sendToDLQ(ctx, message, fmt.Errorf("序列化失败 %w", err))
 	}
+	span.SetAttributes(
+		attribute.Int64("ework.execution_id", evt.ExecID),
+		attribute.Int64("ework.task_id", evt.TaskID),
+		attribute.String("ework.status", evt.ExecStatus.String()))
 
-	return c.handleTask(ctx, evt)
+	// 版本号冲突在 handleTask 内部重新读取后重试，其他失败直接转入死信 topic
+	if err = c.handle(ctx, evt); err != nil {
+		return c.sendToDLQ(ctx, message, err)
+	}
+	return nil
 }
 
-func (c *Consumer) handleTask(ctx context.Context, evt event.Event) error {
-	var err error
-	if evt.ExecStatus.IsSuccess() {
-		err = c.execSvc.UpdateScheduleResult(ctx, evt.ExecID, domain.TaskExecutionStatusSuccess,
-			number100, time.Now().UnixMilli(), nil, "")
-	} else {
-		err = c.execSvc.UpdateScheduleResult(ctx, evt.ExecID, domain.TaskExecutionStatusFailed,
-			number0, time.Now().UnixMilli(), nil, "")
-	}
+// handle 处理完成事件，任务的后续调度完成后才标记完成事件已经被处理
+// 完成事件由直接发送和发件箱中继共同保证送达，可能被重复投递；处理中途失败或者调度节点重启时没有标记，
+// 再次投递的完成事件会重新处理，handleTask 以版本号和调度节点为条件释放任务，重复处理是幂等的
+func (c *Consumer) handle(ctx context.Context, evt event.Event) error {
+	execution, err := c.execSvc.FindByID(ctx, evt.ExecID)
 	if err != nil {
 		return err
 	}
+	if execution.CompletionHandled {
+		c.logger.Info("完成事件已经处理过，忽略",
+			elog.Int64("executionID", evt.ExecID),
+			elog.Int64("taskID", evt.TaskID))
+		return nil
+	}
+	if err = c.handleTask(ctx, evt, execution); err != nil {
+		return err
+	}
+	return c.execSvc.MarkCompletionHandled(ctx, evt.ExecID)
+}
+
+// sendToDLQ 把无法处理的消息原样转入死信 topic，失败原因放在消息头中，避免阻塞后续消息的消费
+func (c *Consumer) sendToDLQ(ctx context.Context, message *mq.Message, cause error) error {
+	c.logger.Error("完成事件无法处理，转入死信 topic",
+		elog.String("message", string(message.Value)),
+		elog.FieldErr(cause))
+	header := mq.Header{}
+	for k, v := range message.Header {
+		header[k] = v
+	}
+	header[event.HeaderError] = cause.Error()
+	header[event.HeaderOriginTopic] = message.Topic
+	_, err := c.dlqProducer.Produce(ctx, &mq.Message{
+		Key:    message.Key,
+		Value:  message.Value,
+		Header: header,
+	})
+	if err != nil {
+		return fmt.Errorf("转入死信 topic 失败: %w, 处理失败原因: %w", err, cause)
+	}
+	return nil
+}
+
+func (c *Consumer) handleTask(ctx context.Context, evt event.Event, execution domain.TaskExecution) error {
+	// 终止状态已经随完成事件一起写入，这里只处理任务的后续调度
+	c.publishHook(ctx, evt, execution)
+	if !evt.ExecStatus.IsSuccess() {
+		// 死信写入失败不影响任务后续调度，只记录日志
+		if _, err := c.dlqSvc.Record(ctx, evt.ExecID); err != nil {
+			c.logger.Error("写入死信失败",
+				elog.Int64("executionID", evt.ExecID),
+				elog.Int64("taskID", evt.TaskID),
+				elog.FieldErr(err))
+		}
+	}
+	// 更新下次执行时间遇到版本号冲突时由任务服务重新读取后重试
 	t, err := c.taskSvc.UpdateNextTime(ctx, evt.TaskID)
 	if err != nil {
 		return err
 	}
 
-	// 只有状态还是 PREEMPTED 的任务才需要释放
-	// 一次性任务已经变为 INACTIVE，不需要释放
-	if t.Status == domain.TaskStatusPreempted {
-		return c.acquire.Release(ctx, evt.TaskID, evt.ScheduleNodeID)
+	for i := 0; ; i++ {
+		// 只有还被本次执行的调度节点抢占的任务才需要释放
+		// 一次性任务已经变为 INACTIVE，重复投递的完成事件到达时任务已经被释放或者被重新抢占，都不需要释放
+		if t.Status != domain.TaskStatusPreempted || t.ScheduleNodeID != evt.ScheduleNodeID {
+			return nil
+		}
+		// 以读取到的版本号为条件释放，期间任务被续约时释放失败，重新读取后重试
+		err = c.acquire.Release(ctx, evt.TaskID, t.Version, evt.ScheduleNodeID)
+		if !errors.Is(err, errs.ErrTaskReleaseFailed) || i+1 >= maxReleaseAttempts {
+			return err
+		}
+		if t, err = c.taskSvc.GetByID(ctx, evt.TaskID); err != nil {
+			return err
+		}
 	}
+}
 
-	return nil
+// publishHook 发布执行的最终结果，事件重复消费时由投递记录去重
+func (c *Consumer) publishHook(ctx context.Context, evt event.Event, execution domain.TaskExecution) {
+	typ := domain.HookEventFailure
+	if evt.ExecStatus.IsSuccess() {
+		typ = domain.HookEventSuccess
+	}
+	c.hookSvc.Publish(ctx, typ, execution)
 }
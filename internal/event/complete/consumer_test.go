@@ -0,0 +1,268 @@
+//go:build unit
+
+package complete
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/Duke1616/ework-runner/internal/domain"
+	"github.com/Duke1616/ework-runner/internal/errs"
+	"github.com/Duke1616/ework-runner/internal/event"
+	"github.com/Duke1616/ework-runner/internal/repository"
+	"github.com/Duke1616/ework-runner/internal/service/acquirer"
+	"github.com/Duke1616/ework-runner/internal/service/deadletter"
+	"github.com/Duke1616/ework-runner/internal/service/hook"
+	"github.com/Duke1616/ework-runner/internal/service/task"
+	"github.com/ecodeclub/mq-api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsumer_Consume(t *testing.T) {
+	t.Parallel()
+
+	evt, err := json.Marshal(event.Event{
+		TaskID:         10,
+		ExecID:         1,
+		ScheduleNodeID: "scheduler-1",
+		ExecStatus:     domain.TaskExecutionStatusSuccess,
+	})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name             string
+		value            []byte
+		handled          bool
+		conflicts        int
+		releaseConflicts int
+		scheduleNodeID   string
+
+		wantUpdates  int
+		wantHandled  bool
+		wantReleased bool
+		wantDLQ      bool
+	}{
+		{
+			name:         "处理完成事件",
+			value:        evt,
+			wantUpdates:  1,
+			wantHandled:  true,
+			wantReleased: true,
+		},
+		{
+			name:         "版本号冲突时重新读取后重试",
+			value:        evt,
+			conflicts:    2,
+			wantUpdates:  3,
+			wantHandled:  true,
+			wantReleased: true,
+		},
+		{
+			name:             "释放任务时版本号冲突，重新读取后重试",
+			value:            evt,
+			releaseConflicts: 2,
+			wantUpdates:      1,
+			wantHandled:      true,
+			wantReleased:     true,
+		},
+		{
+			name:           "任务已经被其他调度节点重新抢占，不需要释放",
+			value:          evt,
+			scheduleNodeID: "scheduler-2",
+			wantUpdates:    1,
+			wantHandled:    true,
+		},
+		{
+			name:        "重复投递的完成事件已经处理过，不再处理",
+			value:       evt,
+			handled:     true,
+			wantHandled: true,
+		},
+		{
+			name:      "更新下次执行时间版本号冲突次数用尽后转入死信 topic，不标记为已经处理",
+			value:     evt,
+			conflicts: 100,
+			// 任务服务内部最多尝试 3 次，消费者不再阻塞重试
+			wantUpdates: 3,
+			wantDLQ:     true,
+		},
+		{
+			name:             "释放任务版本号冲突次数用尽后转入死信 topic",
+			value:            evt,
+			releaseConflicts: 100,
+			wantUpdates:      1,
+			wantDLQ:          true,
+		},
+		{
+			name:    "无法解析的消息直接转入死信 topic",
+			value:   []byte("{"),
+			wantDLQ: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			execSvc := &fakeExecutionService{
+				execution: domain.TaskExecution{ID: 1, Status: domain.TaskExecutionStatusSuccess},
+				handled:   tc.handled,
+			}
+			scheduleNodeID := tc.scheduleNodeID
+			if scheduleNodeID == "" {
+				scheduleNodeID = "scheduler-1"
+			}
+			taskRepo := &fakeTaskRepo{conflicts: tc.conflicts, scheduleNodeID: scheduleNodeID}
+			acq := &fakeAcquirer{failures: tc.releaseConflicts, err: errs.ErrTaskReleaseFailed}
+			producer := &fakeProducer{}
+			consumer := NewConsumer(execSvc, task.NewService(taskRepo), acq,
+				&fakeDeadLetterService{}, &fakeHookService{}, producer)
+
+			err := consumer.Consume(context.Background(), &mq.Message{Topic: "complete_topic", Value: tc.value})
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.wantUpdates, taskRepo.updates)
+			assert.Equal(t, tc.wantHandled, execSvc.handled)
+			if tc.wantReleased {
+				// 以更新下次执行时间后的版本号释放
+				assert.Equal(t, []int64{int64(tc.wantUpdates)}, acq.versions)
+			} else {
+				assert.Empty(t, acq.versions)
+			}
+			if !tc.wantDLQ {
+				assert.Empty(t, producer.messages)
+				return
+			}
+			require.Len(t, producer.messages, 1)
+			assert.Equal(t, tc.value, producer.messages[0].Value)
+			assert.Equal(t, "complete_topic", producer.messages[0].Header[event.HeaderOriginTopic])
+			assert.NotEmpty(t, producer.messages[0].Header[event.HeaderError])
+		})
+	}
+}
+
+// 释放任务失败的完成事件转入死信 topic，没有被标记为已经处理，再次投递时仍然释放任务
+func TestConsumer_ConsumeAbandoned(t *testing.T) {
+	t.Parallel()
+
+	evt, err := json.Marshal(event.Event{
+		TaskID:         10,
+		ExecID:         1,
+		ScheduleNodeID: "scheduler-1",
+		ExecStatus:     domain.TaskExecutionStatusSuccess,
+	})
+	require.NoError(t, err)
+	message := &mq.Message{Topic: "complete_topic", Value: evt}
+
+	execSvc := &fakeExecutionService{execution: domain.TaskExecution{ID: 1, Status: domain.TaskExecutionStatusSuccess}}
+	taskRepo := &fakeTaskRepo{scheduleNodeID: "scheduler-1"}
+	acq := &fakeAcquirer{failures: 1, err: errors.New("数据库不可用")}
+	producer := &fakeProducer{}
+	consumer := NewConsumer(execSvc, task.NewService(taskRepo), acq,
+		&fakeDeadLetterService{}, &fakeHookService{}, producer)
+
+	require.NoError(t, consumer.Consume(context.Background(), message))
+	assert.Len(t, producer.messages, 1)
+	assert.False(t, execSvc.handled)
+	assert.Empty(t, acq.versions)
+
+	// 直接发送和发件箱中继的另一份完成事件投递后释放任务
+	require.NoError(t, consumer.Consume(context.Background(), message))
+	assert.True(t, execSvc.handled)
+	assert.Equal(t, []int64{2}, acq.versions)
+}
+
+type fakeExecutionService struct {
+	task.ExecutionService
+	execution domain.TaskExecution
+	handled   bool
+}
+
+func (s *fakeExecutionService) FindByID(_ context.Context, _ int64) (domain.TaskExecution, error) {
+	execution := s.execution
+	execution.CompletionHandled = s.handled
+	return execution, nil
+}
+
+func (s *fakeExecutionService) MarkCompletionHandled(_ context.Context, _ int64) error {
+	s.handled = true
+	return nil
+}
+
+// fakeTaskRepo 前 conflicts 次更新下次执行时间时返回版本号冲突，任务被 scheduleNodeID 抢占
+type fakeTaskRepo struct {
+	repository.TaskRepository
+	conflicts      int
+	updates        int
+	scheduleNodeID string
+}
+
+func (r *fakeTaskRepo) GetByID(_ context.Context, id int64) (domain.Task, error) {
+	return domain.Task{
+		ID:             id,
+		CronExpr:       "0 * * * * *",
+		Status:         domain.TaskStatusPreempted,
+		ScheduleNodeID: r.scheduleNodeID,
+		Version:        int64(r.updates),
+	}, nil
+}
+
+func (r *fakeTaskRepo) UpdateNextTime(_ context.Context, id, version, nextTime int64) (domain.Task, error) {
+	r.updates++
+	if r.conflicts > 0 {
+		r.conflicts--
+		return domain.Task{}, errs.ErrTaskUpdateNextTimeFailed
+	}
+	return domain.Task{
+		ID:             id,
+		Status:         domain.TaskStatusPreempted,
+		ScheduleNodeID: r.scheduleNodeID,
+		Version:        version + 1,
+		NextTime:       nextTime,
+	}, nil
+}
+
+// fakeAcquirer 前 failures 次释放任务时返回 err
+type fakeAcquirer struct {
+	acquirer.TaskAcquirer
+	failures int
+	err      error
+	versions []int64
+}
+
+func (a *fakeAcquirer) Release(_ context.Context, _, version int64, _ string) error {
+	if a.failures > 0 {
+		a.failures--
+		return a.err
+	}
+	a.versions = append(a.versions, version)
+	return nil
+}
+
+type fakeDeadLetterService struct {
+	deadletter.Service
+}
+
+type fakeHookService struct {
+	hook.Service
+}
+
+func (s *fakeHookService) Publish(_ context.Context, _ domain.HookEventType, _ domain.TaskExecution) {
+}
+
+type fakeProducer struct {
+	mq.Producer
+	mu       sync.Mutex
+	messages []*mq.Message
+}
+
+func (p *fakeProducer) Produce(_ context.Context, m *mq.Message) (*mq.ProducerResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.messages = append(p.messages, m)
+	return &mq.ProducerResult{}, nil
+}
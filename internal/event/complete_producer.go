@@ -28,6 +28,8 @@ func (c *completeProducer) Produce(ctx context.Context, evt Event) error {
 	}
 	_, err = c.producer.Produce(ctx, &mq.Message{
 		Value: val,
+		// 链路上下文写入消息头，消费者不需要解析消息体即可恢复链路
+		Header: evt.TraceContext,
 	})
 	return err
 }
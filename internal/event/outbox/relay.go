@@ -0,0 +1,162 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Duke1616/ework-runner/internal/domain"
+	"github.com/Duke1616/ework-runner/internal/event"
+	"github.com/Duke1616/ework-runner/internal/repository"
+	"github.com/Duke1616/ework-runner/pkg/retry/strategy"
+	"github.com/ecodeclub/mq-api"
+	"github.com/gotomicro/ego/core/elog"
+)
+
+// Config 发件箱中继配置
+type Config struct {
+	BatchSize   int           `yaml:"batchSize"`   // 批量处理大小
+	MinDuration time.Duration `yaml:"minDuration"` // 最小等待时间，防止空转
+	Lease       time.Duration `yaml:"lease"`       // 认领消息后独占的时间，超过后其他节点可以重新认领
+}
+
+// Relay 发件箱中继，补发没有被直接发送成功的完成事件
+// 多个调度节点可以同时运行，通过 CAS 推迟下次发送时间来认领消息，认领后节点宕机的消息在租约到期后被重新认领
+type Relay struct {
+	repo     repository.OutboxRepository
+	producer event.CompleteProducer
+	// 无法解析的消息原样转入完成事件的死信 topic，不再重试
+	dlqProducer mq.Producer
+	retry       strategy.Strategy // 发送失败后的退避策略，不应限制重试次数
+	config      Config
+	logger      *elog.Component
+}
+
+// NewRelay 创建发件箱中继
+func NewRelay(
+	repo repository.OutboxRepository,
+	producer event.CompleteProducer,
+	dlqProducer mq.Producer,
+	retry strategy.Strategy,
+	config Config,
+) *Relay {
+	return &Relay{
+		repo:        repo,
+		producer:    producer,
+		dlqProducer: dlqProducer,
+		retry:       retry,
+		config:      config,
+		logger:      elog.DefaultLogger.With(elog.FieldComponentName("event.outbox")),
+	}
+}
+
+// Start 启动中继
+func (r *Relay) Start(ctx context.Context) {
+	r.logger.Info("发件箱中继启动")
+
+	for {
+		select {
+		case <-ctx.Done():
+			r.logger.Info("发件箱中继停止")
+			return
+		default:
+			startTime := time.Now()
+
+			err := r.relay(ctx)
+			if err != nil {
+				r.logger.Error("补发完成事件失败", elog.FieldErr(err))
+			}
+
+			// 防空转：确保最小等待时间
+			elapsed := time.Since(startTime)
+			if elapsed < r.config.MinDuration {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(r.config.MinDuration - elapsed):
+				}
+			}
+		}
+	}
+}
+
+// relay 执行一轮补发
+func (r *Relay) relay(ctx context.Context) error {
+	now := time.Now()
+	messages, err := r.repo.FindDue(ctx, now.UnixMilli(), r.config.BatchSize)
+	if err != nil {
+		return fmt.Errorf("查找待发送的完成事件失败: %w", err)
+	}
+	if len(messages) == 0 {
+		return nil
+	}
+
+	r.logger.Info("找到待发送的完成事件", elog.Int("count", len(messages)))
+	for i := range messages {
+		claimed, err1 := r.repo.Claim(ctx, messages[i].ID, messages[i].NextTime, now.Add(r.config.Lease).UnixMilli())
+		if err1 != nil {
+			r.logger.Error("认领完成事件失败",
+				elog.Int64("messageID", messages[i].ID),
+				elog.FieldErr(err1))
+			continue
+		}
+		if !claimed {
+			// 已经被其他节点认领
+			continue
+		}
+		r.send(ctx, messages[i])
+	}
+	return nil
+}
+
+// send 发送单条消息，成功后删除，失败时按退避策略推迟下次发送
+func (r *Relay) send(ctx context.Context, msg domain.OutboxMessage) {
+	var evt event.Event
+	err := json.Unmarshal(msg.Payload, &evt)
+	if err != nil {
+		// 无法解析的消息重试也不会成功
+		err = r.sendToDLQ(ctx, msg, fmt.Errorf("序列化失败 %w", err))
+	} else {
+		err = r.producer.Produce(ctx, evt)
+	}
+	if err != nil {
+		tries := msg.Tries + 1
+		duration, _ := r.retry.NextWithRetries(tries)
+		r.logger.Warn("补发完成事件失败，稍后重试",
+			elog.Int64("messageID", msg.ID),
+			elog.Int64("executionID", msg.ExecutionID),
+			elog.Int("tries", int(tries)),
+			elog.FieldErr(err))
+		if err = r.repo.MarkFailed(ctx, msg.ID, tries, time.Now().Add(duration).UnixMilli(), err.Error()); err != nil {
+			r.logger.Error("记录完成事件发送失败出错",
+				elog.Int64("messageID", msg.ID),
+				elog.FieldErr(err))
+		}
+		return
+	}
+
+	if err = r.repo.Delete(ctx, msg.ID); err != nil {
+		// 租约到期后会再发送一次，由消费者按执行记录去重
+		r.logger.Error("删除已发送的完成事件失败",
+			elog.Int64("messageID", msg.ID),
+			elog.FieldErr(err))
+	}
+}
+
+// sendToDLQ 把无法解析的消息原样转入死信 topic，失败原因放在消息头中
+func (r *Relay) sendToDLQ(ctx context.Context, msg domain.OutboxMessage, cause error) error {
+	r.logger.Error("完成事件无法解析，转入死信 topic",
+		elog.Int64("messageID", msg.ID),
+		elog.Int64("executionID", msg.ExecutionID),
+		elog.String("message", string(msg.Payload)),
+		elog.FieldErr(cause))
+	_, err := r.dlqProducer.Produce(ctx, &mq.Message{
+		Value:  msg.Payload,
+		Header: mq.Header{event.HeaderError: cause.Error()},
+	})
+	if err != nil {
+		return fmt.Errorf("转入死信 topic 失败: %w, 解析失败原因: %w", err, cause)
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+//go:build unit
+
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Duke1616/ework-runner/internal/domain"
+	"github.com/Duke1616/ework-runner/internal/event"
+	"github.com/Duke1616/ework-runner/internal/repository"
+	"github.com/Duke1616/ework-runner/pkg/retry/strategy"
+	"github.com/ecodeclub/mq-api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelay_Relay(t *testing.T) {
+	t.Parallel()
+
+	payload, err := json.Marshal(event.Event{ExecID: 1, TaskID: 10, ExecStatus: domain.TaskExecutionStatusSuccess})
+	require.NoError(t, err)
+	due := time.Now().Add(-time.Second).UnixMilli()
+
+	testCases := []struct {
+		name       string
+		payload    []byte
+		produceErr error
+		claimed    bool
+		after      func(t *testing.T, repo *memOutboxRepo, producer *recordProducer, dlq *dlqProducer)
+	}{
+		{
+			name:    "发送成功后删除",
+			claimed: true,
+			after: func(t *testing.T, repo *memOutboxRepo, producer *recordProducer, _ *dlqProducer) {
+				assert.Empty(t, repo.messages)
+				require.Len(t, producer.events, 1)
+				assert.Equal(t, int64(1), producer.events[0].ExecID)
+			},
+		},
+		{
+			name:       "发送失败后按退避策略推迟",
+			produceErr: errors.New("broker 不可用"),
+			claimed:    true,
+			after: func(t *testing.T, repo *memOutboxRepo, _ *recordProducer, _ *dlqProducer) {
+				msg := repo.messages[1]
+				assert.Equal(t, int32(1), msg.Tries)
+				assert.Equal(t, "broker 不可用", msg.LastError)
+				assert.Greater(t, msg.NextTime, time.Now().Add(30*time.Second).UnixMilli())
+			},
+		},
+		{
+			name:    "无法解析的消息转入死信 topic 后删除，不再重试",
+			payload: []byte("{"),
+			claimed: true,
+			after: func(t *testing.T, repo *memOutboxRepo, producer *recordProducer, dlq *dlqProducer) {
+				assert.Empty(t, repo.messages)
+				assert.Empty(t, producer.events)
+				require.Len(t, dlq.messages, 1)
+				assert.Equal(t, []byte("{"), dlq.messages[0].Value)
+				assert.NotEmpty(t, dlq.messages[0].Header[event.HeaderError])
+			},
+		},
+		{
+			name:    "已被其他节点认领时跳过",
+			claimed: false,
+			after: func(t *testing.T, repo *memOutboxRepo, producer *recordProducer, _ *dlqProducer) {
+				assert.Len(t, repo.messages, 1)
+				assert.Empty(t, producer.events)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			msgPayload := payload
+			if tc.payload != nil {
+				msgPayload = tc.payload
+			}
+			repo := &memOutboxRepo{
+				messages: map[int64]domain.OutboxMessage{
+					1: {ID: 1, ExecutionID: 1, Payload: msgPayload, NextTime: due},
+				},
+				lost: !tc.claimed,
+			}
+			producer := &recordProducer{err: tc.produceErr}
+			dlq := &dlqProducer{}
+			relay := NewRelay(repo, producer, dlq,
+				strategy.NewFixedIntervalRetryStrategy(time.Minute, 0),
+				Config{BatchSize: 10, Lease: time.Minute})
+
+			require.NoError(t, relay.relay(context.Background()))
+			tc.after(t, repo, producer, dlq)
+		})
+	}
+}
+
+// memOutboxRepo 基于内存的发件箱，lost 为 true 时模拟消息已被其他节点认领
+type memOutboxRepo struct {
+	repository.OutboxRepository
+
+	mu       sync.Mutex
+	messages map[int64]domain.OutboxMessage
+	lost     bool
+}
+
+func (r *memOutboxRepo) FindDue(_ context.Context, now int64, limit int) ([]domain.OutboxMessage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var messages []domain.OutboxMessage
+	for _, msg := range r.messages {
+		if msg.NextTime <= now && len(messages) < limit {
+			messages = append(messages, msg)
+		}
+	}
+	return messages, nil
+}
+
+func (r *memOutboxRepo) Claim(_ context.Context, id, nextTime, newNextTime int64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	msg := r.messages[id]
+	if r.lost || msg.NextTime != nextTime {
+		return false, nil
+	}
+	msg.NextTime = newNextTime
+	r.messages[id] = msg
+	return true, nil
+}
+
+func (r *memOutboxRepo) Delete(_ context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.messages, id)
+	return nil
+}
+
+func (r *memOutboxRepo) MarkFailed(_ context.Context, id int64, tries int32, nextTime int64, lastError string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	msg := r.messages[id]
+	msg.Tries = tries
+	msg.NextTime = nextTime
+	msg.LastError = lastError
+	r.messages[id] = msg
+	return nil
+}
+
+// recordProducer 记录发送的完成事件，err 不为空时发送失败
+type recordProducer struct {
+	err    error
+	events []event.Event
+}
+
+func (p *recordProducer) Produce(_ context.Context, evt event.Event) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.events = append(p.events, evt)
+	return nil
+}
+
+// dlqProducer 记录转入死信 topic 的消息
+type dlqProducer struct {
+	mq.Producer
+	messages []*mq.Message
+}
+
+func (p *dlqProducer) Produce(_ context.Context, m *mq.Message) (*mq.ProducerResult, error) {
+	p.messages = append(p.messages, m)
+	return &mq.ProducerResult{}, nil
+}
@@ -0,0 +1,99 @@
+package report
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	reporterv1 "github.com/Duke1616/ework-runner/api/proto/gen/reporter/v1"
+	"github.com/Duke1616/ework-runner/internal/domain"
+	"github.com/Duke1616/ework-runner/internal/event"
+	"github.com/Duke1616/ework-runner/internal/service/task"
+	"github.com/Duke1616/ework-runner/pkg/retry/strategy"
+	"github.com/ecodeclub/mq-api"
+	"github.com/gotomicro/ego/core/elog"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// Consumer 消费执行节点通过消息队列上报的执行状态，与 gRPC ReporterService 的处理逻辑一致
+// 执行节点发送成功即视为送达，不会重发，可重试的处理失败在这里退避重试，重试次数用尽后转入死信 topic
+type Consumer struct {
+	execSvc task.ExecutionService
+	// 处理失败后的重试策略，应当只重试少量次数，避免阻塞同一分区后续上报的消费
+	retry       strategy.Strategy
+	dlqProducer mq.Producer
+	logger      *elog.Component
+}
+
+func NewConsumer(execSvc task.ExecutionService, retry strategy.Strategy, dlqProducer mq.Producer) *Consumer {
+	return &Consumer{
+		execSvc:     execSvc,
+		retry:       retry,
+		dlqProducer: dlqProducer,
+		logger:      elog.DefaultLogger.With(elog.FieldComponentName("event.report")),
+	}
+}
+
+func (c *Consumer) Consume(ctx context.Context, message *mq.Message) error {
+	var req reporterv1.ReportRequest
+	err := protojson.Unmarshal(message.Value, &req)
+	if err != nil {
+		// 无法解析的消息重试也不会成功，直接转入死信 topic
+		return c.sendToDLQ(ctx, message, fmt.Errorf("序列化失败 %w", err))
+	}
+	if req.GetExecutionState() == nil {
+		return nil
+	}
+
+	state := domain.ExecutionStateFromProto(req.GetExecutionState())
+	for retries := int32(1); ; retries++ {
+		err = c.execSvc.HandleReports(ctx, []*domain.Report{{ExecutionState: state}})[0]
+		if err == nil {
+			return nil
+		}
+		if !task.IsRetryableReportErr(err) {
+			// 确定性失败，与 gRPC 上报一致直接丢弃
+			c.logger.Warn("上报无法处理，丢弃",
+				elog.Int64("executionID", state.ID),
+				elog.FieldErr(err))
+			return nil
+		}
+		duration, shouldRetry := c.retry.NextWithRetries(retries)
+		if !shouldRetry {
+			break
+		}
+		c.logger.Warn("处理上报失败，稍后重试",
+			elog.Int64("executionID", state.ID),
+			elog.Int("retries", int(retries)),
+			elog.FieldErr(err))
+		select {
+		case <-ctx.Done():
+			// 调度节点停止时同样转入死信 topic，不丢失上报
+			return c.sendToDLQ(context.WithoutCancel(ctx), message, err)
+		case <-time.After(duration):
+		}
+	}
+	return c.sendToDLQ(ctx, message, err)
+}
+
+// sendToDLQ 把无法处理的消息原样转入死信 topic，失败原因放在消息头中
+func (c *Consumer) sendToDLQ(ctx context.Context, message *mq.Message, cause error) error {
+	c.logger.Error("上报无法处理，转入死信 topic",
+		elog.String("message", string(message.Value)),
+		elog.FieldErr(cause))
+	header := mq.Header{}
+	for k, v := range message.Header {
+		header[k] = v
+	}
+	header[event.HeaderError] = cause.Error()
+	header[event.HeaderOriginTopic] = message.Topic
+	_, err := c.dlqProducer.Produce(ctx, &mq.Message{
+		Key:    message.Key,
+		Value:  message.Value,
+		Header: header,
+	})
+	if err != nil {
+		return fmt.Errorf("转入死信 topic 失败: %w, 处理失败原因: %w", err, cause)
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+//go:build unit
+
+package report
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	executorv1 "github.com/Duke1616/ework-runner/api/proto/gen/executor/v1"
+	reporterv1 "github.com/Duke1616/ework-runner/api/proto/gen/reporter/v1"
+	"github.com/Duke1616/ework-runner/internal/domain"
+	"github.com/Duke1616/ework-runner/internal/errs"
+	"github.com/Duke1616/ework-runner/internal/event"
+	"github.com/Duke1616/ework-runner/internal/service/task"
+	"github.com/Duke1616/ework-runner/pkg/retry/strategy"
+	"github.com/ecodeclub/mq-api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+func TestConsumer_Consume(t *testing.T) {
+	t.Parallel()
+
+	report, err := protojson.Marshal(&reporterv1.ReportRequest{
+		ExecutionState: &executorv1.ExecutionState{
+			Id:     1,
+			Status: executorv1.ExecutionStatus_SUCCESS,
+		},
+	})
+	require.NoError(t, err)
+	dbErr := errors.New("数据库不可用")
+
+	testCases := []struct {
+		name  string
+		value []byte
+		errs  []error
+
+		wantCalls int
+		wantDLQ   bool
+	}{
+		{
+			name:      "处理成功",
+			value:     report,
+			wantCalls: 1,
+		},
+		{
+			name:      "可重试失败退避重试后处理成功",
+			value:     report,
+			errs:      []error{dbErr, dbErr},
+			wantCalls: 3,
+		},
+		{
+			name:      "可重试失败重试次数用尽后转入死信 topic",
+			value:     report,
+			errs:      []error{dbErr, dbErr, dbErr},
+			wantCalls: 3,
+			wantDLQ:   true,
+		},
+		{
+			name:      "执行记录不存在直接丢弃",
+			value:     report,
+			errs:      []error{errs.ErrExecutionNotFound},
+			wantCalls: 1,
+		},
+		{
+			name:      "状态迁移非法直接丢弃",
+			value:     report,
+			errs:      []error{errs.ErrInvalidTaskExecutionStatus},
+			wantCalls: 1,
+		},
+		{
+			name:    "无法解析的消息直接转入死信 topic",
+			value:   []byte("{"),
+			wantDLQ: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			execSvc := &fakeExecutionService{errs: tc.errs}
+			producer := &fakeProducer{}
+			consumer := NewConsumer(execSvc, strategy.NewFixedIntervalRetryStrategy(time.Millisecond, 2), producer)
+
+			err := consumer.Consume(context.Background(), &mq.Message{Topic: "report_topic", Value: tc.value})
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.wantCalls, len(execSvc.states))
+			for _, state := range execSvc.states {
+				assert.Equal(t, int64(1), state.ID)
+				assert.Equal(t, domain.TaskExecutionStatusSuccess, state.Status)
+			}
+			if !tc.wantDLQ {
+				assert.Empty(t, producer.messages)
+				return
+			}
+			require.Len(t, producer.messages, 1)
+			assert.Equal(t, tc.value, producer.messages[0].Value)
+			assert.Equal(t, "report_topic", producer.messages[0].Header[event.HeaderOriginTopic])
+			assert.NotEmpty(t, producer.messages[0].Header[event.HeaderError])
+		})
+	}
+}
+
+// fakeExecutionService 依次返回 errs 作为处理结果，用完后处理成功
+type fakeExecutionService struct {
+	task.ExecutionService
+	errs   []error
+	states []domain.ExecutionState
+}
+
+func (s *fakeExecutionService) HandleReports(_ context.Context, reports []*domain.Report) []error {
+	s.states = append(s.states, reports[0].ExecutionState)
+	var err error
+	if len(s.errs) > 0 {
+		err, s.errs = s.errs[0], s.errs[1:]
+	}
+	return []error{err}
+}
+
+type fakeProducer struct {
+	mq.Producer
+	mu       sync.Mutex
+	messages []*mq.Message
+}
+
+func (p *fakeProducer) Produce(_ context.Context, m *mq.Message) (*mq.ProducerResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.messages = append(p.messages, m)
+	return &mq.ProducerResult{}, nil
+}
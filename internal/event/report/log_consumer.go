@@ -0,0 +1,36 @@
+package report
+
+import (
+	"context"
+	"fmt"
+
+	reporterv1 "github.com/Duke1616/ework-runner/api/proto/gen/reporter/v1"
+	"github.com/Duke1616/ework-runner/internal/domain"
+	"github.com/Duke1616/ework-runner/internal/service/task"
+	"github.com/ecodeclub/mq-api"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// LogConsumer 消费执行节点通过消息队列上报的任务日志，与 gRPC ReporterService.ReportLogs 的处理逻辑一致
+type LogConsumer struct {
+	logSvc task.LogService
+}
+
+func NewLogConsumer(logSvc task.LogService) *LogConsumer {
+	return &LogConsumer{
+		logSvc: logSvc,
+	}
+}
+
+func (c *LogConsumer) Consume(ctx context.Context, message *mq.Message) error {
+	var chunk reporterv1.LogChunk
+	err := protojson.Unmarshal(message.Value, &chunk)
+	if err != nil {
+		return fmt.Errorf("序列化失败 %w", err)
+	}
+	if chunk.GetEid() == 0 {
+		return nil
+	}
+
+	return c.logSvc.Append(ctx, []domain.ExecutionLog{domain.ExecutionLogFromProto(&chunk)})
+}
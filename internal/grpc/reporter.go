@@ -6,6 +6,7 @@ import (
 
 	reporterv1 "github.com/Duke1616/ework-runner/api/proto/gen/reporter/v1"
 	"github.com/Duke1616/ework-runner/internal/domain"
+	"github.com/Duke1616/ework-runner/internal/metrics"
 	"github.com/Duke1616/ework-runner/internal/service/task"
 	"github.com/ecodeclub/ekit/slice"
 	"github.com/gotomicro/ego/core/elog"
@@ -17,15 +18,18 @@ import (
 type ReporterServer struct {
 	reporterv1.UnimplementedReporterServiceServer
 	execSvc task.ExecutionService
+	logSvc  task.LogService
 	logger  *elog.Component
 }
 
 // NewReporterServer 创建 ReporterServer 实例
 func NewReporterServer(
 	execSvc task.ExecutionService,
+	logSvc task.LogService,
 ) *ReporterServer {
 	return &ReporterServer{
 		execSvc: execSvc,
+		logSvc:  logSvc,
 		logger:  elog.DefaultLogger.With(elog.FieldComponentName("scheduler.grpc.ReporterServer")),
 	}
 }
@@ -45,7 +49,9 @@ func (s *ReporterServer) Report(ctx context.Context, req *reporterv1.ReportReque
 		elog.String("requestReschedule", fmt.Sprintf("%v", state.RequestReschedule)))
 
 	// 调用业务处理方法
-	err := s.handleReports(ctx, s.toDomainReports([]*reporterv1.ReportRequest{req}))
+	err := s.handleReports(ctx, s.toDomainReports([]*reporterv1.ReportRequest{req}))[0]
+	metrics.ReportTotal.Inc("Report", metrics.Result(err))
+	metrics.ReportItems.Inc("Report")
 	if err != nil {
 		s.logger.Error("处理执行状态上报失败",
 			elog.Int64("executionId", state.Id),
@@ -68,8 +74,8 @@ func (s *ReporterServer) toDomainReports(reqs []*reporterv1.ReportRequest) []*do
 	})
 }
 
-// handleReports 处理报告
-func (s *ReporterServer) handleReports(ctx context.Context, reports []*domain.Report) error {
+// handleReports 处理报告，返回与 reports 一一对应的处理结果
+func (s *ReporterServer) handleReports(ctx context.Context, reports []*domain.Report) []error {
 	s.logger.Debug("处理执行状态上报", elog.Int("count", len(reports)))
 	return s.execSvc.HandleReports(ctx, reports)
 }
@@ -83,14 +89,58 @@ func (s *ReporterServer) BatchReport(ctx context.Context, req *reporterv1.BatchR
 
 	s.logger.Info("收到批量执行状态上报请求", elog.Int("count", len(req.Reports)))
 
-	err := s.handleReports(ctx, s.toDomainReports(req.GetReports()))
-	if err != nil {
-		s.logger.Error("处理批量执行状态上报失败",
+	// 逐个返回处理结果，执行节点只重发处理失败的上报，单个上报失败不会导致整批重发
+	reportErrs := s.handleReports(ctx, s.toDomainReports(req.GetReports()))
+	results := make([]*reporterv1.ReportResult, 0, len(reportErrs))
+	var err error
+	failed := 0
+	for i, err1 := range reportErrs {
+		results = append(results, toReportResult(req.GetReports()[i].GetExecutionState().GetId(), err1))
+		if err1 != nil {
+			err = err1
+			failed++
+		}
+	}
+	metrics.ReportTotal.Inc("BatchReport", metrics.Result(err))
+	metrics.ReportItems.Add(float64(len(req.Reports)), "BatchReport")
+	if failed > 0 {
+		s.logger.Error("批量执行状态上报部分处理失败",
 			elog.Int("count", len(req.Reports)),
+			elog.Int("failed", failed),
 			elog.FieldErr(err))
-		return nil, status.Error(codes.Internal, "处理失败")
+	} else {
+		s.logger.Debug("批量执行状态上报处理成功", elog.Int("count", len(req.Reports)))
 	}
+	return &reporterv1.BatchReportResponse{Results: results}, nil
+}
 
-	s.logger.Debug("批量执行状态上报处理成功", elog.Int("count", len(req.Reports)))
-	return &reporterv1.BatchReportResponse{}, nil
+// toReportResult 转换单个上报的处理结果，确定性失败告知执行节点不再重发
+func toReportResult(eid int64, err error) *reporterv1.ReportResult {
+	if err == nil {
+		return &reporterv1.ReportResult{Eid: eid, Success: true}
+	}
+	return &reporterv1.ReportResult{
+		Eid:       eid,
+		Retryable: task.IsRetryableReportErr(err),
+		Error:     err.Error(),
+	}
+}
+
+// ReportLogs 批量上报任务日志
+func (s *ReporterServer) ReportLogs(ctx context.Context, req *reporterv1.ReportLogsRequest) (*reporterv1.ReportLogsResponse, error) {
+	if len(req.GetChunks()) == 0 {
+		return &reporterv1.ReportLogsResponse{}, nil
+	}
+
+	logs := slice.Map(req.GetChunks(), func(_ int, src *reporterv1.LogChunk) domain.ExecutionLog {
+		return domain.ExecutionLogFromProto(src)
+	})
+	err := s.logSvc.Append(ctx, logs)
+	metrics.ReportTotal.Inc("ReportLogs", metrics.Result(err))
+	metrics.ReportItems.Add(float64(len(logs)), "ReportLogs")
+	if err != nil {
+		s.logger.Error("保存任务日志失败", elog.Int("count", len(logs)), elog.FieldErr(err))
+		return nil, status.Error(codes.Internal, "处理失败")
+	}
+	return &reporterv1.ReportLogsResponse{}, nil
 }
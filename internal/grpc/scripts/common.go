@@ -2,7 +2,11 @@
 package scripts
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -14,6 +18,9 @@ import (
 
 const TEMPDIR = "/app"
 
+// maxOutputTailSize 脚本失败时随错误详情上报的输出末尾大小
+const maxOutputTailSize = 2 * 1024
+
 // ---------------------------
 // 通用抽象定义
 // ---------------------------
@@ -56,7 +63,7 @@ func (e *ScriptExecutor) Run(ctx *executor.Context) error {
 	vars := ctx.Param("variables")
 
 	if code == "" {
-		return fmt.Errorf("[%s] code parameter is required", e.language)
+		return executor.NewInvalidParamsError(fmt.Errorf("[%s] code parameter is required", e.language))
 	}
 
 	// 2. 准备执行环境
@@ -69,7 +76,7 @@ func (e *ScriptExecutor) Run(ctx *executor.Context) error {
 	// 处理变量 (部分语言需要转为文件,部分直接传参)
 	varsResource, err := e.varsProcessor(vars)
 	if err != nil {
-		return fmt.Errorf("process vars failed: %w", err)
+		return executor.NewInvalidParamsError(fmt.Errorf("process vars failed: %w", err))
 	}
 
 	// 3. 构建命令
@@ -82,10 +89,27 @@ func (e *ScriptExecutor) Run(ctx *executor.Context) error {
 
 	// 4. 执行命令
 	logger.Info("开始执行脚本", elog.String("language", e.language))
-	output, err := cmd.CombinedOutput()
-	logger.Info("脚本输出", elog.String("output", string(output)))
+	// 输出实时写入任务日志上报给调度节点，同时保留一份用于提取执行结果
+	var output bytes.Buffer
+	cmd.Stdout = io.MultiWriter(&output, ctx.TaskLogger())
+	cmd.Stderr = cmd.Stdout
+	err = cmd.Run()
+	logger.Info("脚本输出", elog.String("output", output.String()))
+
+	// 约定脚本最后一行非空输出为 JSON 时作为执行结果
+	if result := lastJSONLine(output.Bytes()); result != nil {
+		if err1 := ctx.SetResult(result); err1 != nil {
+			logger.Warn("设置执行结果失败", elog.FieldErr(err1))
+		}
+	}
 
 	if err != nil {
+		// 非零退出时携带退出码和输出的末尾部分，便于不登录执行节点直接排查
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return executor.NewScriptExitError(exitErr.ExitCode(), outputTail(output.Bytes()),
+				fmt.Errorf("execution failed: %w", err))
+		}
 		return fmt.Errorf("execution failed: %w", err)
 	}
 	return nil
@@ -169,3 +193,21 @@ func saveFile(path string, content []byte) {
 		fmt.Printf("save file %s failed: %v\n", path, err)
 	}
 }
+
+// lastJSONLine 获取输出中最后一行非空内容，不是合法 JSON 时返回 nil
+func lastJSONLine(output []byte) []byte {
+	lines := bytes.Split(bytes.TrimSpace(output), []byte("\n"))
+	last := bytes.TrimSpace(lines[len(lines)-1])
+	if len(last) == 0 || !json.Valid(last) {
+		return nil
+	}
+	return last
+}
+
+// outputTail 取脚本输出的末尾部分
+func outputTail(output []byte) string {
+	if len(output) > maxOutputTailSize {
+		output = output[len(output)-maxOutputTailSize:]
+	}
+	return string(bytes.ToValidUTF8(output, nil))
+}
@@ -0,0 +1,134 @@
+// Package metrics 调度节点的 Prometheus 指标，注册到默认 Registry，由 ego governor 的 /metrics 暴露
+package metrics
+
+import (
+	"time"
+
+	"github.com/gotomicro/ego/core/emetric"
+)
+
+const (
+	namespace = "ework"
+	subsystem = "scheduler"
+)
+
+const (
+	ResultSuccess  = "success"
+	ResultFailed   = "failed"
+	ResultConflict = "conflict"
+)
+
+var (
+	// ScheduleLag 任务实际被调度的时间与计划执行时间（NextTime）的差值
+	ScheduleLag = emetric.HistogramVecOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "schedule_lag_seconds",
+		Help:      "任务实际调度时间与计划执行时间的差值",
+		Buckets:   []float64{0.1, 0.5, 1, 2, 5, 10, 30, 60, 300},
+	}.Build()
+
+	// ScheduleBatchSize 调度循环每轮拉取到的可调度任务数量
+	ScheduleBatchSize = emetric.HistogramVecOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "schedule_batch_size",
+		Help:      "调度循环每轮拉取到的可调度任务数量",
+		Buckets:   []float64{0, 1, 5, 10, 20, 50, 100, 200},
+	}.Build()
+
+	// AcquireTotal 任务抢占次数，result 为 success、conflict（已被其他节点抢占）或 failed
+	AcquireTotal = emetric.CounterVecOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "acquire_total",
+		Help:      "任务抢占次数",
+		Labels:    []string{"result"},
+	}.Build()
+
+	// DispatchTotal 发起执行的次数，kind 为 RUN、RETRY 或 RESCHEDULE
+	DispatchTotal = emetric.CounterVecOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "dispatch_total",
+		Help:      "发起首次执行、重试和重调度的次数",
+		Labels:    []string{"kind", "result"},
+	}.Build()
+
+	// InvokeDuration 调用执行节点的耗时，invoker 为 grpc、http 或 local
+	InvokeDuration = emetric.HistogramVecOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "invoke_duration_seconds",
+		Help:      "调用执行节点的耗时",
+		Buckets:   []float64{0.005, 0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30},
+		Labels:    []string{"invoker", "result"},
+	}.Build()
+
+	// ExecutionStatusTotal 执行记录状态迁移成功的次数，按实际写入的状态计数，重试次数用尽的可重试失败记为 FAILED
+	ExecutionStatusTotal = emetric.CounterVecOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "execution_status_total",
+		Help:      "执行记录状态迁移成功的次数",
+		Labels:    []string{"status"},
+	}.Build()
+
+	// ReportTotal 执行节点上报的 RPC 次数，method 为 gRPC 方法名
+	ReportTotal = emetric.CounterVecOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "report_total",
+		Help:      "执行节点上报的 RPC 次数",
+		Labels:    []string{"method", "result"},
+	}.Build()
+
+	// ReportItems 执行节点上报的执行状态和日志条数
+	ReportItems = emetric.CounterVecOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "report_items_total",
+		Help:      "执行节点上报的执行状态和日志条数",
+		Labels:    []string{"method"},
+	}.Build()
+
+	// CompensatorBatchSize 补偿器每轮找到的执行记录数量
+	CompensatorBatchSize = emetric.HistogramVecOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "compensator_batch_size",
+		Help:      "补偿器每轮找到的执行记录数量",
+		Buckets:   []float64{0, 1, 5, 10, 20, 50, 100, 200},
+		Labels:    []string{"compensator"},
+	}.Build()
+
+	// CompensatorHandledTotal 补偿器处理执行记录的次数，即重试、重调度、中断、对账的次数
+	CompensatorHandledTotal = emetric.CounterVecOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "compensator_handled_total",
+		Help:      "补偿器处理执行记录的次数",
+		Labels:    []string{"compensator", "result"},
+	}.Build()
+
+	// CompensatorLeader 当前调度节点是否为运行补偿器的主节点，1 表示是
+	CompensatorLeader = emetric.GaugeVecOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "compensator_leader",
+		Help:      "当前调度节点是否为运行补偿器的主节点",
+	}.Build()
+)
+
+// Result 按 err 返回 success 或 failed
+func Result(err error) string {
+	if err != nil {
+		return ResultFailed
+	}
+	return ResultSuccess
+}
+
+// SinceMilli 毫秒时间戳到现在经过的秒数
+func SinceMilli(ms int64) float64 {
+	return time.Since(time.UnixMilli(ms)).Seconds()
+}
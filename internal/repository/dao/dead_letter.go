@@ -0,0 +1,112 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"github.com/Duke1616/ework-runner/internal/domain"
+	"github.com/Duke1616/ework-runner/pkg/sqlx"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	DeadLetterStatusPending = "PENDING"
+	DeadLetterStatusRetried = "RETRIED"
+)
+
+// DeadLetter 死信表DAO对象
+type DeadLetter struct {
+	ID          int64                                 `gorm:"type:bigint;primaryKey;autoIncrement;"`
+	ExecutionID int64                                 `gorm:"type:bigint;not null;uniqueIndex:uniq_idx_execution_attempt,priority:1;comment:'任务执行ID'"`
+	Attempt     int64                                 `gorm:"type:bigint;not null;default:0;uniqueIndex:uniq_idx_execution_attempt,priority:2;comment:'进入死信时的尝试次数'"`
+	TaskID      int64                                 `gorm:"type:bigint;not null;index:idx_task_id;comment:'任务ID'"`
+	TaskName    string                                `gorm:"type:varchar(255);not null;comment:'任务名称'"`
+	Snapshot    sqlx.JSONColumn[domain.TaskExecution] `gorm:"type:json;comment:'进入死信时的执行记录快照'"`
+	Status      string                                `gorm:"type:ENUM('PENDING', 'RETRIED');not null;default:'PENDING';index:idx_status_ctime,priority:1;comment:'死信状态: PENDING-待处理, RETRIED-已重试'"`
+	Ctime       int64                                 `gorm:"index:idx_status_ctime,priority:2;comment:'创建时间'"`
+	Utime       int64                                 `gorm:"comment:'更新时间'"`
+}
+
+// TableName 指定表名
+func (DeadLetter) TableName() string {
+	return "dead_letters"
+}
+
+type DeadLetterDAO interface {
+	// Create 写入死信，同一执行记录的同一次尝试已有死信时不做修改并返回已有死信和 false
+	Create(ctx context.Context, letter DeadLetter) (DeadLetter, bool, error)
+	// GetByID 根据ID获取死信
+	GetByID(ctx context.Context, id int64) (DeadLetter, error)
+	// List 按创建时间倒序分页查询死信，status 为空时查询全部
+	List(ctx context.Context, status string, offset, limit int) ([]DeadLetter, error)
+	// Count 统计死信数量，status 为空时统计全部
+	Count(ctx context.Context, status string) (int64, error)
+	// CASStatus 以 CAS 方式更新死信状态，当前状态不是 from 时返回 false
+	CASStatus(ctx context.Context, id int64, from, to string) (bool, error)
+}
+
+type GORMDeadLetterDAO struct {
+	db *gorm.DB
+}
+
+func NewGORMDeadLetterDAO(db *gorm.DB) DeadLetterDAO {
+	return &GORMDeadLetterDAO{db: db}
+}
+
+func (g *GORMDeadLetterDAO) Create(ctx context.Context, letter DeadLetter) (DeadLetter, bool, error) {
+	now := time.Now().UnixMilli()
+	letter.Status = DeadLetterStatusPending
+	letter.Ctime = now
+	letter.Utime = now
+	result := g.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&letter)
+	if result.Error != nil {
+		return DeadLetter{}, false, result.Error
+	}
+	if result.RowsAffected > 0 {
+		return letter, true, nil
+	}
+	// 已有死信时查询返回，重试、已处理等状态保持不变
+	var saved DeadLetter
+	err := g.db.WithContext(ctx).
+		Where("execution_id = ? AND attempt = ?", letter.ExecutionID, letter.Attempt).
+		First(&saved).Error
+	return saved, false, err
+}
+
+func (g *GORMDeadLetterDAO) GetByID(ctx context.Context, id int64) (DeadLetter, error) {
+	var letter DeadLetter
+	err := g.db.WithContext(ctx).Where("id = ?", id).First(&letter).Error
+	return letter, err
+}
+
+func (g *GORMDeadLetterDAO) List(ctx context.Context, status string, offset, limit int) ([]DeadLetter, error) {
+	var letters []DeadLetter
+	db := g.db.WithContext(ctx)
+	if status != "" {
+		db = db.Where("status = ?", status)
+	}
+	err := db.Order("ctime DESC").Offset(offset).Limit(limit).Find(&letters).Error
+	return letters, err
+}
+
+func (g *GORMDeadLetterDAO) Count(ctx context.Context, status string) (int64, error) {
+	var count int64
+	db := g.db.WithContext(ctx).Model(&DeadLetter{})
+	if status != "" {
+		db = db.Where("status = ?", status)
+	}
+	err := db.Count(&count).Error
+	return count, err
+}
+
+func (g *GORMDeadLetterDAO) CASStatus(ctx context.Context, id int64, from, to string) (bool, error) {
+	result := g.db.WithContext(ctx).
+		Model(&DeadLetter{}).
+		Where("id = ? AND status = ?", id, from).
+		Updates(map[string]any{
+			"status": to,
+			"utime":  time.Now().UnixMilli(),
+		})
+	return result.RowsAffected > 0, result.Error
+}
@@ -0,0 +1,22 @@
+package dao
+
+// ExecutionAttempt 任务执行尝试表DAO对象，执行记录的子表
+type ExecutionAttempt struct {
+	ID             int64  `gorm:"type:bigint;primaryKey;autoIncrement;"`
+	ExecutionID    int64  `gorm:"type:bigint;not null;uniqueIndex:uniq_idx_execution_attempt,priority:1;comment:'任务执行ID'"`
+	Attempt        int64  `gorm:"type:bigint;not null;uniqueIndex:uniq_idx_execution_attempt,priority:2;comment:'尝试序号，从1开始'"`
+	Kind           string `gorm:"type:ENUM('RUN', 'RETRY', 'RESCHEDULE');not null;comment:'发起方式: RUN-首次执行, RETRY-重试, RESCHEDULE-重调度'"`
+	ExecutorNodeID string `gorm:"type:varchar(255);not null;default:'';comment:'执行节点ID'"`
+	Status         string `gorm:"type:varchar(32);not null;comment:'本次尝试的状态'"`
+	Stime          int64  `gorm:"type:bigint;comment:'开始时间'"`
+	Etime          int64  `gorm:"type:bigint;comment:'结束时间'"`
+	ErrorMessage   string `gorm:"type:varchar(1024);not null;default:'';comment:'失败时的错误信息'"`
+	ErrorCategory  string `gorm:"type:varchar(32);not null;default:'';comment:'失败时的错误分类'"`
+	Ctime          int64  `gorm:"comment:'创建时间'"`
+	Utime          int64  `gorm:"comment:'更新时间'"`
+}
+
+// TableName 指定表名
+func (ExecutionAttempt) TableName() string {
+	return "execution_attempts"
+}
@@ -0,0 +1,86 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ExecutionLog 任务执行日志表DAO对象
+type ExecutionLog struct {
+	ID             int64  `gorm:"type:bigint;primaryKey;autoIncrement;"`
+	ExecutionID    int64  `gorm:"type:bigint;not null;uniqueIndex:uniq_idx_execution_node_seq,priority:1;comment:'任务执行ID'"`
+	ExecutorNodeID string `gorm:"type:varchar(255);not null;uniqueIndex:uniq_idx_execution_node_seq,priority:2;comment:'产生日志的执行节点ID'"`
+	Sequence       int64  `gorm:"type:bigint;not null;uniqueIndex:uniq_idx_execution_node_seq,priority:3;comment:'日志块在执行节点内的序号，用于去重'"`
+	Content        string `gorm:"type:mediumtext;comment:'日志内容'"`
+	Size           int64  `gorm:"type:bigint;not null;default:0;comment:'日志内容字节数'"`
+	Timestamp      int64  `gorm:"type:bigint;not null;default:0;comment:'日志块中第一行的产生时间'"`
+	Ctime          int64  `gorm:"comment:'创建时间'"`
+}
+
+// TableName 指定表名
+func (ExecutionLog) TableName() string {
+	return "execution_logs"
+}
+
+type ExecutionLogDAO interface {
+	// BatchCreate 批量写入日志块，重复上报的日志块会被忽略
+	BatchCreate(ctx context.Context, logs []ExecutionLog) error
+	// TotalSize 获取指定执行已存储的日志总字节数
+	TotalSize(ctx context.Context, executionID int64) (int64, error)
+	// FindByExecutionID 按写入顺序查询指定执行 ID 大于 afterID 的日志块
+	FindByExecutionID(ctx context.Context, executionID, afterID int64, limit int) ([]ExecutionLog, error)
+	// FindCreatedBefore 按写入顺序查询指定执行 ID 大于 afterID 并且创建时间不晚于 ctime 的日志块
+	FindCreatedBefore(ctx context.Context, executionID, afterID, ctime int64, limit int) ([]ExecutionLog, error)
+}
+
+type GORMExecutionLogDAO struct {
+	db *gorm.DB
+}
+
+func NewGORMExecutionLogDAO(db *gorm.DB) ExecutionLogDAO {
+	return &GORMExecutionLogDAO{db: db}
+}
+
+func (g *GORMExecutionLogDAO) BatchCreate(ctx context.Context, logs []ExecutionLog) error {
+	if len(logs) == 0 {
+		return nil
+	}
+	now := time.Now().UnixMilli()
+	for i := range logs {
+		logs[i].Ctime = now
+		logs[i].Size = int64(len(logs[i].Content))
+	}
+	return g.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&logs).Error
+}
+
+func (g *GORMExecutionLogDAO) TotalSize(ctx context.Context, executionID int64) (int64, error) {
+	var size int64
+	err := g.db.WithContext(ctx).Model(&ExecutionLog{}).
+		Where("execution_id = ?", executionID).
+		Select("COALESCE(SUM(size), 0)").
+		Scan(&size).Error
+	return size, err
+}
+
+func (g *GORMExecutionLogDAO) FindByExecutionID(ctx context.Context, executionID, afterID int64, limit int) ([]ExecutionLog, error) {
+	var logs []ExecutionLog
+	err := g.db.WithContext(ctx).
+		Where("execution_id = ? AND id > ?", executionID, afterID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&logs).Error
+	return logs, err
+}
+
+func (g *GORMExecutionLogDAO) FindCreatedBefore(ctx context.Context, executionID, afterID, ctime int64, limit int) ([]ExecutionLog, error) {
+	var logs []ExecutionLog
+	err := g.db.WithContext(ctx).
+		Where("execution_id = ? AND id > ? AND ctime <= ?", executionID, afterID, ctime).
+		Order("id ASC").
+		Limit(limit).
+		Find(&logs).Error
+	return logs, err
+}
@@ -0,0 +1,111 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const HookDeliveryStatusPending = "PENDING"
+
+// HookDelivery 生命周期事件投递记录表DAO对象
+type HookDelivery struct {
+	ID          int64  `gorm:"type:bigint;primaryKey;autoIncrement;"`
+	ExecutionID int64  `gorm:"type:bigint;not null;uniqueIndex:uniq_idx_execution_attempt_event_target,priority:1;comment:'任务执行ID'"`
+	Attempt     int64  `gorm:"type:bigint;not null;default:0;uniqueIndex:uniq_idx_execution_attempt_event_target,priority:2;comment:'尝试序号，执行最终结果的事件为 0'"`
+	Event       string `gorm:"type:varchar(32);not null;uniqueIndex:uniq_idx_execution_attempt_event_target,priority:3;comment:'事件类型'"`
+	Channel     string `gorm:"type:varchar(32);not null;uniqueIndex:uniq_idx_execution_attempt_event_target,priority:4;comment:'投递通道: WEBHOOK、KAFKA、CALLBACK、EMAIL'"`
+	Target      string `gorm:"type:varchar(512);not null;uniqueIndex:uniq_idx_execution_attempt_event_target,priority:5;comment:'回调地址、topic 或回调名称'"`
+	TaskID      int64  `gorm:"type:bigint;not null;comment:'任务ID'"`
+	TaskName    string `gorm:"type:varchar(255);not null;default:'';comment:'任务名称'"`
+	Payload     []byte `gorm:"type:blob;comment:'JSON 编码的事件或告警，重试投递时原样发送'"`
+	Status      string `gorm:"type:ENUM('PENDING', 'SUCCESS', 'FAILED');not null;default:'PENDING';index:idx_status_next_retry_time,priority:1;comment:'投递状态: PENDING-投递中, SUCCESS-成功, FAILED-重试用尽仍然失败'"`
+	// 投递中的记录下次投递的时间，投递前以 CAS 方式推迟该时间来认领，认领后节点宕机的记录在租约到期后被重新认领
+	NextRetryTime int64  `gorm:"type:bigint;not null;default:0;index:idx_status_next_retry_time,priority:2;comment:'下次投递时间'"`
+	Tries         int32  `gorm:"type:int;not null;default:0;comment:'已投递次数'"`
+	LastError     string `gorm:"type:text;comment:'最近一次投递失败的错误'"`
+	Ctime         int64  `gorm:"comment:'创建时间'"`
+	Utime         int64  `gorm:"comment:'更新时间'"`
+}
+
+// TableName 指定表名
+func (HookDelivery) TableName() string {
+	return "hook_deliveries"
+}
+
+type HookDeliveryDAO interface {
+	// Create 创建投递记录，同一执行记录的同一次尝试、同一事件对同一目标已有记录时返回 false
+	Create(ctx context.Context, delivery HookDelivery) (HookDelivery, bool, error)
+	// UpdateResult 记录一次投递的结果，status 为 PENDING 时 nextRetryTime 为下次投递的时间
+	UpdateResult(ctx context.Context, id int64, status string, tries int32, lastError string, nextRetryTime int64) error
+	// FindDue 查找到达下次投递时间的投递中记录
+	FindDue(ctx context.Context, now int64, limit int) ([]HookDelivery, error)
+	// Claim 以 CAS 方式把下次投递时间从 nextRetryTime 推迟到 newNextRetryTime 来认领投递记录，已被其他节点认领时返回 false
+	Claim(ctx context.Context, id, nextRetryTime, newNextRetryTime int64) (bool, error)
+	// FindByExecutionID 查询执行记录的所有投递记录
+	FindByExecutionID(ctx context.Context, executionID int64) ([]HookDelivery, error)
+}
+
+type GORMHookDeliveryDAO struct {
+	db *gorm.DB
+}
+
+func NewGORMHookDeliveryDAO(db *gorm.DB) HookDeliveryDAO {
+	return &GORMHookDeliveryDAO{db: db}
+}
+
+func (g *GORMHookDeliveryDAO) Create(ctx context.Context, delivery HookDelivery) (HookDelivery, bool, error) {
+	now := time.Now().UnixMilli()
+	delivery.Ctime = now
+	delivery.Utime = now
+	result := g.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&delivery)
+	if result.Error != nil {
+		return HookDelivery{}, false, result.Error
+	}
+	return delivery, result.RowsAffected > 0, nil
+}
+
+func (g *GORMHookDeliveryDAO) UpdateResult(ctx context.Context, id int64, status string, tries int32, lastError string, nextRetryTime int64) error {
+	return g.db.WithContext(ctx).
+		Model(&HookDelivery{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"status":          status,
+			"tries":           tries,
+			"last_error":      lastError,
+			"next_retry_time": nextRetryTime,
+			"utime":           time.Now().UnixMilli(),
+		}).Error
+}
+
+func (g *GORMHookDeliveryDAO) FindDue(ctx context.Context, now int64, limit int) ([]HookDelivery, error) {
+	var deliveries []HookDelivery
+	err := g.db.WithContext(ctx).
+		Where("status = ? AND next_retry_time <= ?", HookDeliveryStatusPending, now).
+		Order("next_retry_time ASC").
+		Limit(limit).
+		Find(&deliveries).Error
+	return deliveries, err
+}
+
+func (g *GORMHookDeliveryDAO) Claim(ctx context.Context, id, nextRetryTime, newNextRetryTime int64) (bool, error) {
+	result := g.db.WithContext(ctx).
+		Model(&HookDelivery{}).
+		Where("id = ? AND status = ? AND next_retry_time = ?", id, HookDeliveryStatusPending, nextRetryTime).
+		Updates(map[string]any{
+			"next_retry_time": newNextRetryTime,
+			"utime":           time.Now().UnixMilli(),
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (g *GORMHookDeliveryDAO) FindByExecutionID(ctx context.Context, executionID int64) ([]HookDelivery, error) {
+	var deliveries []HookDelivery
+	err := g.db.WithContext(ctx).
+		Where("execution_id = ?", executionID).
+		Order("id ASC").
+		Find(&deliveries).Error
+	return deliveries, err
+}
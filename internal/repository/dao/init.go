@@ -6,5 +6,10 @@ func InitTables(db *gorm.DB) error {
 	return db.AutoMigrate(
 		&Task{},
 		&TaskExecution{},
+		&ExecutionLog{},
+		&ExecutionAttempt{},
+		&DeadLetter{},
+		&HookDelivery{},
+		&CompletionOutbox{},
 	)
 }
@@ -0,0 +1,81 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CompletionOutbox 完成事件发件箱表DAO对象
+type CompletionOutbox struct {
+	ID          int64  `gorm:"type:bigint;primaryKey;autoIncrement;"`
+	ExecutionID int64  `gorm:"type:bigint;not null;index:idx_execution_id;comment:'任务执行ID'"`
+	Payload     []byte `gorm:"type:blob;not null;comment:'序列化后的完成事件'"`
+	Tries       int32  `gorm:"type:int;not null;default:0;comment:'已发送失败的次数'"`
+	NextTime    int64  `gorm:"type:bigint;not null;index:idx_next_time;comment:'下次可以发送的时间'"`
+	LastError   string `gorm:"type:text;comment:'最近一次发送失败的错误'"`
+	Ctime       int64  `gorm:"comment:'创建时间'"`
+	Utime       int64  `gorm:"comment:'更新时间'"`
+}
+
+// TableName 指定表名
+func (CompletionOutbox) TableName() string {
+	return "completion_outbox"
+}
+
+type OutboxDAO interface {
+	// FindDue 查找到达发送时间的消息
+	FindDue(ctx context.Context, now int64, limit int) ([]CompletionOutbox, error)
+	// Claim 以 CAS 方式把下次发送时间从 nextTime 推迟到 newNextTime 来认领消息，已被其他节点认领时返回 false
+	Claim(ctx context.Context, id, nextTime, newNextTime int64) (bool, error)
+	// Delete 删除发送成功的消息
+	Delete(ctx context.Context, id int64) error
+	// MarkFailed 记录一次发送失败以及下次发送时间
+	MarkFailed(ctx context.Context, id int64, tries int32, nextTime int64, lastError string) error
+}
+
+type GORMOutboxDAO struct {
+	db *gorm.DB
+}
+
+func NewGORMOutboxDAO(db *gorm.DB) OutboxDAO {
+	return &GORMOutboxDAO{db: db}
+}
+
+func (g *GORMOutboxDAO) FindDue(ctx context.Context, now int64, limit int) ([]CompletionOutbox, error) {
+	var messages []CompletionOutbox
+	err := g.db.WithContext(ctx).
+		Where("next_time <= ?", now).
+		Order("next_time ASC").
+		Limit(limit).
+		Find(&messages).Error
+	return messages, err
+}
+
+func (g *GORMOutboxDAO) Claim(ctx context.Context, id, nextTime, newNextTime int64) (bool, error) {
+	result := g.db.WithContext(ctx).
+		Model(&CompletionOutbox{}).
+		Where("id = ? AND next_time = ?", id, nextTime).
+		Updates(map[string]any{
+			"next_time": newNextTime,
+			"utime":     time.Now().UnixMilli(),
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (g *GORMOutboxDAO) Delete(ctx context.Context, id int64) error {
+	return g.db.WithContext(ctx).Where("id = ?", id).Delete(&CompletionOutbox{}).Error
+}
+
+func (g *GORMOutboxDAO) MarkFailed(ctx context.Context, id int64, tries int32, nextTime int64, lastError string) error {
+	return g.db.WithContext(ctx).
+		Model(&CompletionOutbox{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"tries":      tries,
+			"next_time":  nextTime,
+			"last_error": lastError,
+			"utime":      time.Now().UnixMilli(),
+		}).Error
+}
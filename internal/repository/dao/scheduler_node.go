@@ -0,0 +1,124 @@
+package dao
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/concurrency"
+)
+
+// SchedulerNode 调度节点心跳，以 JSON 形式保存在 etcd 中
+type SchedulerNode struct {
+	NodeID           string `json:"nodeId"`
+	StartTime        int64  `json:"startTime"`
+	LastRenewTime    int64  `json:"lastRenewTime"`
+	LastScheduleTime int64  `json:"lastScheduleTime"`
+	ScheduleInterval int64  `json:"scheduleInterval"` // 毫秒
+	BatchTimeout     int64  `json:"batchTimeout"`     // 毫秒
+	RenewInterval    int64  `json:"renewInterval"`    // 毫秒
+	Utime            int64  `json:"utime"`
+}
+
+type SchedulerNodeDAO interface {
+	// Save 写入调度节点心跳，心跳绑定在当前进程的租约上，进程退出或失联后自动删除
+	Save(ctx context.Context, node SchedulerNode) error
+	// Delete 删除调度节点心跳
+	Delete(ctx context.Context, nodeID string) error
+	// List 列出所有在线的调度节点
+	List(ctx context.Context) ([]SchedulerNode, error)
+}
+
+// leaseSession 心跳绑定的租约会话，由 *concurrency.Session 实现
+type leaseSession interface {
+	Lease() clientv3.LeaseID
+	Done() <-chan struct{}
+}
+
+type EtcdSchedulerNodeDAO struct {
+	kv         clientv3.KV
+	prefix     string
+	newSession func() (leaseSession, error)
+
+	mu   sync.Mutex
+	sess leaseSession
+}
+
+// NewEtcdSchedulerNodeDAO 创建基于 etcd 的调度节点心跳存储，ttl 为租约的秒数
+func NewEtcdSchedulerNodeDAO(client *clientv3.Client, prefix string, ttl int) (SchedulerNodeDAO, error) {
+	return newEtcdSchedulerNodeDAO(client, prefix, func() (leaseSession, error) {
+		return concurrency.NewSession(client, concurrency.WithTTL(ttl))
+	})
+}
+
+func newEtcdSchedulerNodeDAO(kv clientv3.KV, prefix string, newSession func() (leaseSession, error)) (*EtcdSchedulerNodeDAO, error) {
+	sess, err := newSession()
+	if err != nil {
+		return nil, err
+	}
+	return &EtcdSchedulerNodeDAO{
+		kv:         kv,
+		prefix:     prefix,
+		newSession: newSession,
+		sess:       sess,
+	}, nil
+}
+
+func (d *EtcdSchedulerNodeDAO) Save(ctx context.Context, node SchedulerNode) error {
+	val, err := json.Marshal(node)
+	if err != nil {
+		return err
+	}
+	lease, err := d.lease()
+	if err != nil {
+		return fmt.Errorf("创建调度节点心跳租约失败: %w", err)
+	}
+	_, err = d.kv.Put(ctx, d.key(node.NodeID), string(val), clientv3.WithLease(lease))
+	if err != nil {
+		return fmt.Errorf("写入调度节点心跳失败: %w", err)
+	}
+	return nil
+}
+
+// lease 返回心跳绑定的租约，与 etcd 失联导致租约过期、会话结束后重新创建会话，下一次心跳重新写入
+func (d *EtcdSchedulerNodeDAO) lease() (clientv3.LeaseID, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	select {
+	case <-d.sess.Done():
+		sess, err := d.newSession()
+		if err != nil {
+			return 0, err
+		}
+		d.sess = sess
+	default:
+	}
+	return d.sess.Lease(), nil
+}
+
+func (d *EtcdSchedulerNodeDAO) Delete(ctx context.Context, nodeID string) error {
+	_, err := d.kv.Delete(ctx, d.key(nodeID))
+	return err
+}
+
+func (d *EtcdSchedulerNodeDAO) List(ctx context.Context) ([]SchedulerNode, error) {
+	resp, err := d.kv.Get(ctx, d.prefix+"/", clientv3.WithPrefix())
+	if err != nil {
+		return nil, fmt.Errorf("查询调度节点失败: %w", err)
+	}
+	nodes := make([]SchedulerNode, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		var node SchedulerNode
+		if err = json.Unmarshal(kv.Value, &node); err != nil {
+			return nil, fmt.Errorf("解析调度节点心跳失败: %w", err)
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
+func (d *EtcdSchedulerNodeDAO) key(nodeID string) string {
+	return fmt.Sprintf("%s/%s", d.prefix, nodeID)
+}
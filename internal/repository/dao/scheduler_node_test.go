@@ -0,0 +1,103 @@
+//go:build unit
+
+package dao
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"google.golang.org/grpc"
+)
+
+// 租约过期、会话结束后重新创建会话，心跳绑定到新的租约上
+func TestEtcdSchedulerNodeDAO_RecreateSession(t *testing.T) {
+	t.Parallel()
+
+	remote := &fakeKVClient{values: make(map[string]*pb.PutRequest)}
+	var (
+		sessions []*fakeSession
+		fail     bool
+	)
+	d, err := newEtcdSchedulerNodeDAO(clientv3.NewKVFromKVClient(remote, nil), "scheduler/nodes",
+		func() (leaseSession, error) {
+			if fail {
+				return nil, errors.New("etcd 不可用")
+			}
+			sess := &fakeSession{lease: clientv3.LeaseID(len(sessions) + 1), done: make(chan struct{})}
+			sessions = append(sessions, sess)
+			return sess, nil
+		})
+	require.NoError(t, err)
+	ctx := context.Background()
+	node := SchedulerNode{NodeID: "scheduler-1", StartTime: 1}
+
+	require.NoError(t, d.Save(ctx, node))
+	assert.Equal(t, int64(1), remote.lease("scheduler/nodes/scheduler-1"))
+
+	// 会话结束后重新创建会话失败时心跳写入失败，不会写到已经过期的租约上
+	close(sessions[0].done)
+	fail = true
+	assert.Error(t, d.Save(ctx, node))
+	assert.Len(t, sessions, 1)
+
+	fail = false
+	require.NoError(t, d.Save(ctx, node))
+	require.NoError(t, d.Save(ctx, node))
+	assert.Len(t, sessions, 2)
+	assert.Equal(t, int64(2), remote.lease("scheduler/nodes/scheduler-1"))
+
+	nodes, err := d.List(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []SchedulerNode{node}, nodes)
+}
+
+type fakeSession struct {
+	lease clientv3.LeaseID
+	done  chan struct{}
+}
+
+func (s *fakeSession) Lease() clientv3.LeaseID {
+	return s.lease
+}
+
+func (s *fakeSession) Done() <-chan struct{} {
+	return s.done
+}
+
+// fakeKVClient 基于内存的 etcd KV 服务，记录每个 key 最近一次写入时绑定的租约
+type fakeKVClient struct {
+	pb.KVClient
+
+	mu     sync.Mutex
+	values map[string]*pb.PutRequest
+}
+
+func (c *fakeKVClient) Put(_ context.Context, in *pb.PutRequest, _ ...grpc.CallOption) (*pb.PutResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[string(in.Key)] = in
+	return &pb.PutResponse{Header: &pb.ResponseHeader{}}, nil
+}
+
+func (c *fakeKVClient) Range(_ context.Context, _ *pb.RangeRequest, _ ...grpc.CallOption) (*pb.RangeResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	resp := &pb.RangeResponse{Header: &pb.ResponseHeader{}}
+	for _, v := range c.values {
+		resp.Kvs = append(resp.Kvs, &mvccpb.KeyValue{Key: v.Key, Value: v.Value, Lease: v.Lease})
+	}
+	return resp, nil
+}
+
+func (c *fakeKVClient) lease(key string) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.values[key].Lease
+}
@@ -28,6 +28,8 @@ type Task struct {
 	GrpcConfig          sqlx.JSONColumn[domain.GrpcConfig]  `gorm:"type:json;comment:'gRPC配置：{\"serviceName\": \"user-service\"}'"`
 	HTTPConfig          sqlx.JSONColumn[domain.HTTPConfig]  `gorm:"type:json;comment:'HTTP配置：{\"endpoint\": \"https://host:port/api\"}'"`
 	RetryConfig         sqlx.JSONColumn[domain.RetryConfig] `gorm:"type:json;comment:'重试配置'"`
+	AlertConfig         sqlx.JSONColumn[domain.AlertConfig] `gorm:"type:json;comment:'最终失败时的告警配置'"`
+	HookConfig          sqlx.JSONColumn[domain.HookConfig]  `gorm:"type:json;comment:'执行生命周期事件订阅'"`
 	ScheduleParams      sqlx.JSONColumn[map[string]string]  `gorm:"type:json;comment:'每次执行要用到的基础调度参数'"`
 	MaxExecutionSeconds int64                               `gorm:"type:bigint;not null;default:86400;comment:'最大执行秒数，默认24小时'"`
 	ScheduleNodeID      sql.NullString                      `gorm:"type:varchar(255);index:idx_schedule_node_id_status,priority:1;comment:'当前抢占的调度节点ID'"`
@@ -57,14 +59,18 @@ type TaskDAO interface {
 	Acquire(ctx context.Context, id, version int64, scheduleNodeID string) (*Task, error)
 	// Renew 续约所有被抢占的任务任务
 	Renew(ctx context.Context, scheduleNodeID string) error
-	// Release 释放任务，更新状态为ACTIVE
-	Release(ctx context.Context, id int64, scheduleNodeID string) (*Task, error)
+	// Release 释放任务，更新状态为ACTIVE（CAS操作），版本号或者抢占的调度节点变化时返回 errs.ErrTaskReleaseFailed
+	Release(ctx context.Context, id, version int64, scheduleNodeID string) (*Task, error)
 	// UpdateNextTime 更新下一次执行时间
 	UpdateNextTime(ctx context.Context, id, version, nextTime int64) (*Task, error)
 	// UpdateScheduleParams 更新调度参数（CAS操作）
 	UpdateScheduleParams(ctx context.Context, id, version int64, scheduleParams map[string]string) (*Task, error)
 	// UpdateStatus 更新任务状态
 	UpdateStatus(ctx context.Context, id int64, status string) (*Task, error)
+	// FindPreempted 查询处于 PREEMPTED 状态的任务，按调度节点分组排列
+	FindPreempted(ctx context.Context, limit int) ([]*Task, error)
+	// FindGrpcServiceNames 查询任务配置的所有 gRPC 执行节点服务名
+	FindGrpcServiceNames(ctx context.Context) ([]string, error)
 }
 
 type GORMTaskDAO struct {
@@ -84,6 +90,32 @@ func (g *GORMTaskDAO) FindByPlanID(ctx context.Context, planID int64) ([]*Task,
 	return tasks, nil
 }
 
+func (g *GORMTaskDAO) FindPreempted(ctx context.Context, limit int) ([]*Task, error) {
+	var tasks []*Task
+	err := g.db.WithContext(ctx).
+		Where("status = ?", StatusPreempted).
+		Order("schedule_node_id ASC, id ASC").
+		Limit(limit).
+		Find(&tasks).Error
+	if err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+func (g *GORMTaskDAO) FindGrpcServiceNames(ctx context.Context) ([]string, error) {
+	var names []string
+	err := g.db.WithContext(ctx).
+		Model(&Task{}).
+		Where("grpc_config IS NOT NULL").
+		Distinct().
+		Pluck("JSON_UNQUOTE(JSON_EXTRACT(grpc_config, '$.serviceName'))", &names).Error
+	if err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
 func (g *GORMTaskDAO) Create(ctx context.Context, task Task) (*Task, error) {
 	now := time.Now().UnixMilli()
 	task.Utime, task.Ctime = now, now
@@ -170,11 +202,11 @@ func (g *GORMTaskDAO) Renew(ctx context.Context, scheduleNodeID string) error {
 	return nil
 }
 
-func (g *GORMTaskDAO) Release(ctx context.Context, id int64, scheduleNodeID string) (*Task, error) {
+func (g *GORMTaskDAO) Release(ctx context.Context, id, version int64, scheduleNodeID string) (*Task, error) {
 	var releasedTask *Task
 	err := g.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		result := tx.Model(&Task{}).
-			Where("id = ? AND status = ? AND schedule_node_id = ?", id, StatusPreempted, scheduleNodeID).
+			Where("id = ? AND version = ? AND status = ? AND schedule_node_id = ?", id, version, StatusPreempted, scheduleNodeID).
 			Updates(map[string]any{
 				"status":           StatusActive,
 				"schedule_node_id": gorm.Expr("NULL"),
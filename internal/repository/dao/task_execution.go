@@ -3,6 +3,7 @@ package dao
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
@@ -17,10 +18,21 @@ const (
 	TaskExecutionStatusRunning           = "RUNNING"
 	TaskExecutionStatusFailedRetryable   = "FAILED_RETRYABLE"
 	TaskExecutionStatusFailedRescheduled = "FAILED_RESCHEDULED"
+	TaskExecutionStatusFailed            = "FAILED"
+	TaskExecutionStatusSuccess           = "SUCCESS"
 
 	milliseconds = 1000
 )
 
+// checkTransit 按 domain 中的迁移表校验状态迁移，from 为调用方读取到的状态，触发方式由调用方按尝试区分
+// 所有状态迁移都以 status = from 作为条件更新，读取之后状态被并发修改时更新失败，不会覆盖已经迁移的状态
+func checkTransit(from, to string) error {
+	if !domain.TaskExecutionStatus(from).CanUpdateTo(domain.TaskExecutionStatus(to)) {
+		return fmt.Errorf("%w: 不允许从 %s 迁移到 %s", errs.ErrInvalidTaskExecutionStatus, from, to)
+	}
+	return nil
+}
+
 // TaskExecution 任务执行记录表DAO对象
 type TaskExecution struct {
 	ID int64 `gorm:"type:bigint;primaryKey;autoIncrement;"`
@@ -38,16 +50,28 @@ type TaskExecution struct {
 	TaskScheduleParams      sqlx.JSONColumn[map[string]string]  `gorm:"type:json;comment:'创建时Task的调度参数快照'"`
 
 	// 下面这些是 TaskExecution 的自身信息
-	ExecutorNodeID  sql.NullString `gorm:"type:varchar(255);comment:'执行节点的 nodeID，用于记录是哪个节点处理了任务'"`
-	Deadline        int64          `gorm:"type:bigint;not null;comment:'任务执行截止时间（毫秒时间戳）'"`
-	Stime           int64          `gorm:"type:bigint;comment:'开始时间'"`
-	Etime           int64          `gorm:"type:bigint;comment:'结束时间'"`
-	RetryCount      int64          `gorm:"type:bigint;not null;default:0;comment:'已重试次数'"`
-	NextRetryTime   int64          `gorm:"type:bigint;comment:'下次重试时间'"`
-	RunningProgress int32          `gorm:"type:int;default:0;comment:'执行进度0-100，RUNNING状态下有效'"`
-	Status          string         `gorm:"type:ENUM('PREPARE', 'RUNNING', 'FAILED_RETRYABLE', 'FAILED_RESCHEDULED', 'FAILED', 'SUCCESS');not null;default:'PREPARE';comment:'执行状态: PREPARE-初始化(没有执行节点在执行）, RUNNING-执行中（有执行节点在执行）, FAILED_RETRYABLE-可重试失败, FAILED_RESCHEDULED-重调度失败， FAILED-失败, SUCCESS-成功'"`
-	Ctime           int64          `gorm:"comment:'创建时间'"`
-	Utime           int64          `gorm:"comment:'更新时间'"`
+	ExecutorNodeID  sql.NullString                          `gorm:"type:varchar(255);comment:'执行节点的 nodeID，用于记录是哪个节点处理了任务'"`
+	FailedNodeIDs   sqlx.JSONColumn[[]string]               `gorm:"type:json;comment:'执行失败过的节点 nodeID 列表，重试时需要排除'"`
+	Deadline        int64                                   `gorm:"type:bigint;not null;comment:'任务执行截止时间（毫秒时间戳）'"`
+	Stime           int64                                   `gorm:"type:bigint;comment:'开始时间'"`
+	Etime           int64                                   `gorm:"type:bigint;comment:'结束时间'"`
+	RetryCount      int64                                   `gorm:"type:bigint;not null;default:0;comment:'已发起的重试次数'"`
+	Attempts        int64                                   `gorm:"type:bigint;not null;default:0;comment:'已发起的尝试次数，首次执行、重试和重调度都计入'"`
+	NextRetryTime   int64                                   `gorm:"type:bigint;comment:'下次重试时间'"`
+	RunningProgress int32                                   `gorm:"type:int;default:0;comment:'执行进度0-100，RUNNING状态下有效'"`
+	Result          sqlx.JSONColumn[domain.ExecutionResult] `gorm:"type:json;comment:'执行结果：结果数据、错误信息、错误码'"`
+	ReportNodeID    string                                  `gorm:"type:varchar(255);not null;default:'';comment:'最近一次被接受的上报来自的执行节点'"`
+	ReportSeq       int64                                   `gorm:"type:bigint;not null;default:0;comment:'最近一次被接受的上报序号，同一执行节点的序号不大于它的上报会被丢弃'"`
+	Status          string                                  `gorm:"type:ENUM('PREPARE', 'RUNNING', 'FAILED_RETRYABLE', 'FAILED_RESCHEDULED', 'FAILED', 'SUCCESS');not null;default:'PREPARE';comment:'执行状态: PREPARE-初始化(没有执行节点在执行）, RUNNING-执行中（有执行节点在执行）, FAILED_RETRYABLE-可重试失败, FAILED_RESCHEDULED-重调度失败， FAILED-失败, SUCCESS-成功'"`
+	Ctime           int64                                   `gorm:"comment:'创建时间'"`
+	Utime           int64                                   `gorm:"comment:'更新时间'"`
+
+	// 完成事件是否已经被消费者处理，进入终止状态时重置
+	CompletionHandled bool `gorm:"type:tinyint(1);not null;default:0;comment:'完成事件是否已经被消费者处理，进入终止状态时重置'"`
+	// 创建执行记录时的链路上下文（W3C Trace Context）
+	TraceContext sqlx.JSONColumn[map[string]string] `gorm:"type:json;comment:'创建执行记录时的链路上下文'"`
+	// 补偿器认领执行记录后设置，租约内其他补偿器不会重复分发；分发结果写回（迁移到失败状态）时清零
+	DispatchingUntil int64 `gorm:"type:bigint;not null;default:0;comment:'重试、重调度的分发租约截止时间（毫秒时间戳）'"`
 }
 
 // TableName 指定表名
@@ -62,27 +86,53 @@ type TaskExecutionDAO interface {
 	BatchCreate(ctx context.Context, executions []TaskExecution) ([]TaskExecution, error)
 	// GetByID 根据ID获取执行记录
 	GetByID(ctx context.Context, id int64) (TaskExecution, error)
-	// UpdateStatus 更新执行状态
-	UpdateStatus(ctx context.Context, id int64, status string) error
+	// UpdateStatus 以读取到的状态 from 为条件更新执行状态
+	UpdateStatus(ctx context.Context, id int64, from, status string) error
 	// FindRetryableExecutions 查找所有可以重试的执行记录
 	// limit: 查询结果数量限制
 	FindRetryableExecutions(ctx context.Context, limit int) ([]TaskExecution, error)
-	// UpdateRetryResult 更新重试结果
-	UpdateRetryResult(ctx context.Context, id, retryCount, nextRetryTime int64, status string, progress int32, endTime int64, scheduleParams map[string]string, executorNodeID string) error
-	// SetRunningState 设置任务为运行状态并更新进度
-	SetRunningState(ctx context.Context, id int64, progress int32, executorNodeID string) error
+	// UpdateRetryResult 以读取到的状态 from 为条件更新重试结果，执行结果与状态在同一个条件更新中写入
+	UpdateRetryResult(ctx context.Context, id, retryCount, nextRetryTime int64, from, status string, progress int32, endTime int64, result domain.ExecutionResult, scheduleParams map[string]string, executorNodeID string, failedNodeIDs []string) error
+	// SetRunningState 以读取到的状态 from 为条件设置任务为运行状态并更新进度
+	SetRunningState(ctx context.Context, id int64, from string, progress int32, executorNodeID string) error
 	// UpdateProgress 更新任务执行进度、开始时间（仅在RUNNING状态下有效）
 	UpdateProgress(ctx context.Context, id int64, progress int32) error
-	// UpdateScheduleResult 更新调度结果
-	UpdateScheduleResult(ctx context.Context, id int64, status string, progress int32, endTime int64, scheduleParams map[string]string, executorNodeID string) error
+	// UpdateScheduleResult 以读取到的状态 from 为条件更新调度结果，执行结果与状态在同一个条件更新中写入
+	UpdateScheduleResult(ctx context.Context, id int64, from, status string, progress int32, endTime int64, result domain.ExecutionResult, scheduleParams map[string]string, executorNodeID string) error
 	// FindReschedulableExecutions 查找所有可以重调度的执行记录
 	FindReschedulableExecutions(ctx context.Context, limit int) ([]TaskExecution, error)
+	// ClaimDispatch 以 CAS 方式认领处于 status 状态且没有分发租约的执行记录，租约截止到 leaseUntil（毫秒时间戳），
+	// 已被其他补偿器认领或状态已经变化时返回 false
+	ClaimDispatch(ctx context.Context, id int64, status string, leaseUntil int64) (bool, error)
 	// FindExecutionByPlanID 查找对应planExecID下的所有执行计划
 	FindExecutionByPlanID(ctx context.Context, planExecID int64) (map[int64]TaskExecution, error)
 	FindByTaskID(ctx context.Context, taskID int64) ([]TaskExecution, error)
 	FindExecutionByTaskIDAndPlanExecID(ctx context.Context, taskID int64, planExecID int64) (TaskExecution, error)
 	// FindTimeoutExecutions 查找超时的执行记录
 	FindTimeoutExecutions(ctx context.Context, limit int) ([]TaskExecution, error)
+	// AcceptReport 以 CAS 方式记录上报序号，同一执行节点的序号不大于已记录序号时返回 false
+	AcceptReport(ctx context.Context, id int64, nodeID string, seq int64) (bool, error)
+	// FindStaleRunningExecutions 查找在 before（毫秒时间戳）之后没有任何更新、且尚未超时的运行中执行记录
+	FindStaleRunningExecutions(ctx context.Context, before int64, limit int) ([]TaskExecution, error)
+	// FindStalePrepareExecutions 查找在 before（毫秒时间戳）之后没有任何更新、且没有被补偿器认领的 PREPARE 执行记录
+	FindStalePrepareExecutions(ctx context.Context, before int64, limit int) ([]TaskExecution, error)
+	// FindRunningExecutions 按ID升序分页查找ID大于 afterID 的运行中执行记录
+	FindRunningExecutions(ctx context.Context, afterID int64, limit int) ([]TaskExecution, error)
+	// FindActiveByTaskID 查找任务尚未结束的执行记录
+	FindActiveByTaskID(ctx context.Context, taskID int64) ([]TaskExecution, error)
+	// StartAttempt 开始一次新的尝试：累加尝试次数（重试时同时累加重试次数）并写入尝试记录，返回尝试序号
+	// 以尝试次数小于 maxAttempts 为条件累加，达到上限时返回 errs.ErrExecutionMaxAttemptsExceeded
+	StartAttempt(ctx context.Context, id int64, kind string, executorNodeID string, maxAttempts int64) (int64, error)
+	// UpdateAttempt 更新尝试记录的执行节点、状态、结束时间和错误信息，零值字段不更新，已经结束的尝试不再更新
+	UpdateAttempt(ctx context.Context, attempt ExecutionAttempt) error
+	// FindAttempts 按尝试序号查询执行记录的所有尝试
+	FindAttempts(ctx context.Context, id int64) ([]ExecutionAttempt, error)
+	// Complete 在同一个事务中以读取到的状态 from 为条件将执行记录迁移到终止状态、写入执行结果并把完成事件写入发件箱
+	Complete(ctx context.Context, id int64, from, status string, progress int32, endTime int64, result domain.ExecutionResult, msg CompletionOutbox) (CompletionOutbox, error)
+	// MarkCompletionHandled 标记完成事件已经被处理
+	MarkCompletionHandled(ctx context.Context, id int64) error
+	// RequeueFailed 以读取到的状态 from 为条件将失败的执行记录重新放回重试队列，清零重试次数和失败节点，立即可被重试补偿器拉取
+	RequeueFailed(ctx context.Context, id int64, from string) error
 }
 
 type GORMTaskExecutionDAO struct {
@@ -168,10 +218,13 @@ func (g *GORMTaskExecutionDAO) GetByID(ctx context.Context, id int64) (TaskExecu
 	return execution, err
 }
 
-func (g *GORMTaskExecutionDAO) UpdateStatus(ctx context.Context, id int64, status string) error {
+func (g *GORMTaskExecutionDAO) UpdateStatus(ctx context.Context, id int64, from, status string) error {
+	if err := checkTransit(from, status); err != nil {
+		return fmt.Errorf("%w: %w", errs.ErrUpdateExecutionStatusFailed, err)
+	}
 	result := g.db.WithContext(ctx).
 		Model(&TaskExecution{}).
-		Where("id = ?", id).
+		Where("id = ? AND status = ?", id, from).
 		Updates(map[string]any{
 			"status": status,
 			"utime":  time.Now().UnixMilli(),
@@ -180,7 +233,8 @@ func (g *GORMTaskExecutionDAO) UpdateStatus(ctx context.Context, id int64, statu
 		return fmt.Errorf("%w: 数据库操作失败: %w", errs.ErrUpdateExecutionStatusFailed, result.Error)
 	}
 	if result.RowsAffected == 0 {
-		return fmt.Errorf("%w：ID=%d", errs.ErrUpdateExecutionStatusFailed, id)
+		return fmt.Errorf("%w: %w: 执行记录不存在或状态已经不是 %s，ID=%d",
+			errs.ErrUpdateExecutionStatusFailed, errs.ErrInvalidTaskExecutionStatus, from, id)
 	}
 	return nil
 }
@@ -196,23 +250,31 @@ func (g *GORMTaskExecutionDAO) FindRetryableExecutions(ctx context.Context, limi
 		Where(`status=? AND next_retry_time <= ?`, TaskExecutionStatusFailedRetryable, now).
 		// 确保到了可以执行的时间
 		Where(" next_retry_time <= ?", now).
+		// 排除已被补偿器认领、正在分发的记录
+		Where("dispatching_until <= ?", now).
 		Limit(limit).
 		Find(&executions).Error
 	return executions, err
 }
 
-func (g *GORMTaskExecutionDAO) UpdateRetryResult(ctx context.Context, id, retryCount, nextRetryTime int64, status string, progress int32, endTime int64, scheduleParams map[string]string, executorNodeID string) error {
+func (g *GORMTaskExecutionDAO) UpdateRetryResult(ctx context.Context, id, retryCount, nextRetryTime int64, from, status string, progress int32, endTime int64, executionResult domain.ExecutionResult, scheduleParams map[string]string, executorNodeID string, failedNodeIDs []string) error {
+	if err := checkTransit(from, status); err != nil {
+		return fmt.Errorf("%w: %w", errs.ErrUpdateExecutionRetryResultFailed, err)
+	}
 	result := g.db.WithContext(ctx).
 		Model(&TaskExecution{}).
-		Where("id = ?", id).
+		Where("id = ? AND status = ?", id, from).
 		Updates(map[string]any{
 			"retry_count":          retryCount,
 			"next_retry_time":      nextRetryTime,
 			"status":               status,
 			"running_progress":     progress,
 			"etime":                endTime,
+			"result":               sqlx.JSONColumn[domain.ExecutionResult]{Val: executionResult, Valid: true},
 			"task_schedule_params": scheduleParams,
 			"executor_node_id":     sql.NullString{String: executorNodeID, Valid: executorNodeID != ""},
+			"failed_node_ids":      sqlx.JSONColumn[[]string]{Val: failedNodeIDs, Valid: failedNodeIDs != nil},
+			"dispatching_until":    0,
 			"utime":                time.Now().UnixMilli(),
 		})
 
@@ -220,12 +282,21 @@ func (g *GORMTaskExecutionDAO) UpdateRetryResult(ctx context.Context, id, retryC
 		return fmt.Errorf("%w: 数据库操作失败: %w", errs.ErrUpdateExecutionRetryResultFailed, result.Error)
 	}
 	if result.RowsAffected == 0 {
-		return fmt.Errorf("%w: ID=%d", errs.ErrUpdateExecutionRetryResultFailed, id)
+		return fmt.Errorf("%w: %w: 执行记录不存在或状态已经不是 %s，ID=%d",
+			errs.ErrUpdateExecutionRetryResultFailed, errs.ErrInvalidTaskExecutionStatus, from, id)
 	}
 	return nil
 }
 
-func (g *GORMTaskExecutionDAO) SetRunningState(ctx context.Context, id int64, progress int32, executorNodeID string) error {
+func (g *GORMTaskExecutionDAO) SetRunningState(ctx context.Context, id int64, from string, progress int32, executorNodeID string) error {
+	// 已经处于 RUNNING 状态时只更新进度（UpdateProgress），不重新设置开始时间和截止时间
+	if from == TaskExecutionStatusRunning {
+		return fmt.Errorf("%w: %w: 任务已经处于RUNNING状态, ID=%d",
+			errs.ErrSetExecutionStateRunningFailed, errs.ErrInvalidTaskExecutionStatus, id)
+	}
+	if err := checkTransit(from, TaskExecutionStatusRunning); err != nil {
+		return fmt.Errorf("%w: %w", errs.ErrSetExecutionStateRunningFailed, err)
+	}
 	now := time.Now().UnixMilli()
 
 	// 首先查询任务执行记录
@@ -240,8 +311,7 @@ func (g *GORMTaskExecutionDAO) SetRunningState(ctx context.Context, id int64, pr
 
 	result := g.db.WithContext(ctx).
 		Model(&TaskExecution{}).
-		Where("id = ? AND (status = ? OR status = ? OR status = ?) ",
-			id, TaskExecutionStatusPrepare, TaskExecutionStatusFailedRetryable, TaskExecutionStatusFailedRescheduled).
+		Where("id = ? AND status = ?", id, from).
 		Updates(map[string]any{
 			"status":           TaskExecutionStatusRunning,
 			"running_progress": progress,
@@ -255,7 +325,8 @@ func (g *GORMTaskExecutionDAO) SetRunningState(ctx context.Context, id int64, pr
 		return fmt.Errorf("%w: 数据库操作失败: %w", errs.ErrSetExecutionStateRunningFailed, result.Error)
 	}
 	if result.RowsAffected == 0 {
-		return fmt.Errorf("%w: 任务不在PREPARE/FAILED_RETRYABLE状态或不存在, ID=%d", errs.ErrSetExecutionStateRunningFailed, id)
+		return fmt.Errorf("%w: %w: 执行记录不存在或状态已经不是 %s, ID=%d",
+			errs.ErrSetExecutionStateRunningFailed, errs.ErrInvalidTaskExecutionStatus, from, id)
 	}
 	return nil
 }
@@ -273,43 +344,122 @@ func (g *GORMTaskExecutionDAO) UpdateProgress(ctx context.Context, id int64, pro
 		return fmt.Errorf("%w: 数据库操作失败: %w", errs.ErrUpdateExecutionRunningProgressFailed, result.Error)
 	}
 	if result.RowsAffected == 0 {
-		return fmt.Errorf("%w: 任务不在RUNNING状态或不存在，ID=%d", errs.ErrUpdateExecutionRunningProgressFailed, id)
+		return fmt.Errorf("%w: %w: 任务不在RUNNING状态或不存在，ID=%d",
+			errs.ErrUpdateExecutionRunningProgressFailed, errs.ErrInvalidTaskExecutionStatus, id)
 	}
 	return nil
 }
 
-func (g *GORMTaskExecutionDAO) UpdateScheduleResult(ctx context.Context, id int64, status string, progress int32, endTime int64, scheduleParams map[string]string, executorNodeID string) error {
+func (g *GORMTaskExecutionDAO) UpdateScheduleResult(ctx context.Context, id int64, from, status string, progress int32, endTime int64, executionResult domain.ExecutionResult, scheduleParams map[string]string, executorNodeID string) error {
+	if err := checkTransit(from, status); err != nil {
+		return fmt.Errorf("%w: %w", errs.ErrUpdateExecutionStatusAndEndTimeFailed, err)
+	}
 	result := g.db.WithContext(ctx).
 		Model(&TaskExecution{}).
-		Where("id = ?", id).
+		Where("id = ? AND status = ?", id, from).
 		Updates(map[string]any{
 			"status":               status,
 			"running_progress":     progress,
 			"etime":                endTime,
+			"result":               sqlx.JSONColumn[domain.ExecutionResult]{Val: executionResult, Valid: true},
 			"task_schedule_params": sqlx.JSONColumn[map[string]string]{Val: scheduleParams, Valid: scheduleParams != nil},
 			"executor_node_id":     sql.NullString{String: executorNodeID, Valid: executorNodeID != ""},
+			"dispatching_until":    0,
 			"utime":                time.Now().UnixMilli(),
 		})
 	if result.Error != nil {
 		return fmt.Errorf("%w: 数据库操作失败: %w", errs.ErrUpdateExecutionStatusAndEndTimeFailed, result.Error)
 	}
 	if result.RowsAffected == 0 {
-		return fmt.Errorf("%w: ID=%d", errs.ErrUpdateExecutionStatusAndEndTimeFailed, id)
+		return fmt.Errorf("%w: %w: 执行记录不存在或状态已经不是 %s，ID=%d",
+			errs.ErrUpdateExecutionStatusAndEndTimeFailed, errs.ErrInvalidTaskExecutionStatus, from, id)
 	}
 	return nil
 }
 
 func (g *GORMTaskExecutionDAO) FindReschedulableExecutions(ctx context.Context, limit int) ([]TaskExecution, error) {
 	var executions []TaskExecution
-	// 查找可重调度的执行记录
+	// 查找可重调度的执行记录，排除已被补偿器认领、正在分发的记录
 	err := g.db.WithContext(ctx).
-		Where("status = ?", TaskExecutionStatusFailedRescheduled).
+		Where("status = ? AND dispatching_until <= ?", TaskExecutionStatusFailedRescheduled, time.Now().UnixMilli()).
 		Order("utime ASC").
 		Limit(limit).
 		Find(&executions).Error
 	return executions, err
 }
 
+func (g *GORMTaskExecutionDAO) ClaimDispatch(ctx context.Context, id int64, status string, leaseUntil int64) (bool, error) {
+	result := g.db.WithContext(ctx).
+		Model(&TaskExecution{}).
+		Where("id = ? AND status = ? AND dispatching_until <= ?", id, status, time.Now().UnixMilli()).
+		Update("dispatching_until", leaseUntil)
+	if result.Error != nil {
+		return false, fmt.Errorf("认领执行记录失败: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (g *GORMTaskExecutionDAO) AcceptReport(ctx context.Context, id int64, nodeID string, seq int64) (bool, error) {
+	result := g.db.WithContext(ctx).
+		Model(&TaskExecution{}).
+		Where("id = ? AND (report_node_id <> ? OR report_seq < ?)", id, nodeID, seq).
+		Updates(map[string]any{
+			"report_node_id": nodeID,
+			"report_seq":     seq,
+		})
+	if result.Error != nil {
+		return false, fmt.Errorf("记录上报序号失败: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (g *GORMTaskExecutionDAO) FindStaleRunningExecutions(ctx context.Context, before int64, limit int) ([]TaskExecution, error) {
+	var executions []TaskExecution
+	now := time.Now().UnixMilli()
+
+	err := g.db.WithContext(ctx).
+		Where("status = ? AND utime <= ? AND deadline > ?", TaskExecutionStatusRunning, before, now).
+		Order("utime ASC").
+		Limit(limit).
+		Find(&executions).Error
+
+	return executions, err
+}
+
+func (g *GORMTaskExecutionDAO) FindRunningExecutions(ctx context.Context, afterID int64, limit int) ([]TaskExecution, error) {
+	var executions []TaskExecution
+	err := g.db.WithContext(ctx).
+		Where("status = ? AND id > ?", TaskExecutionStatusRunning, afterID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&executions).Error
+	return executions, err
+}
+
+func (g *GORMTaskExecutionDAO) FindActiveByTaskID(ctx context.Context, taskID int64) ([]TaskExecution, error) {
+	var executions []TaskExecution
+	err := g.db.WithContext(ctx).
+		Where("task_id = ? AND status NOT IN ?", taskID, []string{TaskExecutionStatusSuccess, TaskExecutionStatusFailed}).
+		Find(&executions).Error
+	if err != nil {
+		return nil, fmt.Errorf("查询任务 %d 尚未结束的执行记录失败: %w", taskID, err)
+	}
+	return executions, nil
+}
+
+func (g *GORMTaskExecutionDAO) FindStalePrepareExecutions(ctx context.Context, before int64, limit int) ([]TaskExecution, error) {
+	var executions []TaskExecution
+	now := time.Now().UnixMilli()
+
+	err := g.db.WithContext(ctx).
+		Where("status = ? AND utime <= ? AND dispatching_until <= ?", TaskExecutionStatusPrepare, before, now).
+		Order("utime ASC").
+		Limit(limit).
+		Find(&executions).Error
+
+	return executions, err
+}
+
 func (g *GORMTaskExecutionDAO) FindTimeoutExecutions(ctx context.Context, limit int) ([]TaskExecution, error) {
 	var executions []TaskExecution
 	now := time.Now().UnixMilli()
@@ -322,3 +472,150 @@ func (g *GORMTaskExecutionDAO) FindTimeoutExecutions(ctx context.Context, limit
 
 	return executions, err
 }
+
+func (g *GORMTaskExecutionDAO) StartAttempt(ctx context.Context, id int64, kind string, executorNodeID string, maxAttempts int64) (int64, error) {
+	now := time.Now().UnixMilli()
+	var attempt int64
+	err := g.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		updates := map[string]any{
+			"attempts": gorm.Expr("attempts + 1"),
+			"utime":    now,
+		}
+		if kind == string(domain.AttemptKindRetry) {
+			updates["retry_count"] = gorm.Expr("retry_count + 1")
+		}
+		result := tx.Model(&TaskExecution{}).Where("id = ? AND attempts < ?", id, maxAttempts).Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		var execution TaskExecution
+		if err := tx.Select("attempts").Where("id = ?", id).First(&execution).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("%w: ID=%d", errs.ErrExecutionNotFound, id)
+			}
+			return err
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("%w: ID=%d, attempts=%d", errs.ErrExecutionMaxAttemptsExceeded, id, execution.Attempts)
+		}
+		attempt = execution.Attempts
+		return tx.Create(&ExecutionAttempt{
+			ExecutionID:    id,
+			Attempt:        attempt,
+			Kind:           kind,
+			ExecutorNodeID: executorNodeID,
+			Status:         TaskExecutionStatusPrepare,
+			Stime:          now,
+			Ctime:          now,
+			Utime:          now,
+		}).Error
+	})
+	if err != nil {
+		return 0, fmt.Errorf("开始新的尝试失败: %w", err)
+	}
+	return attempt, nil
+}
+
+func (g *GORMTaskExecutionDAO) UpdateAttempt(ctx context.Context, attempt ExecutionAttempt) error {
+	updates := map[string]any{
+		"status": attempt.Status,
+		"utime":  time.Now().UnixMilli(),
+	}
+	if attempt.ExecutorNodeID != "" {
+		updates["executor_node_id"] = attempt.ExecutorNodeID
+	}
+	if attempt.Etime > 0 {
+		updates["etime"] = attempt.Etime
+	}
+	if attempt.ErrorMessage != "" {
+		updates["error_message"] = attempt.ErrorMessage
+	}
+	if attempt.ErrorCategory != "" {
+		updates["error_category"] = attempt.ErrorCategory
+	}
+	err := g.db.WithContext(ctx).
+		Model(&ExecutionAttempt{}).
+		Where("execution_id = ? AND attempt = ? AND etime = 0", attempt.ExecutionID, attempt.Attempt).
+		Updates(updates).Error
+	if err != nil {
+		return fmt.Errorf("更新尝试记录失败: %w", err)
+	}
+	return nil
+}
+
+func (g *GORMTaskExecutionDAO) FindAttempts(ctx context.Context, id int64) ([]ExecutionAttempt, error) {
+	var attempts []ExecutionAttempt
+	err := g.db.WithContext(ctx).
+		Where("execution_id = ?", id).
+		Order("attempt ASC").
+		Find(&attempts).Error
+	return attempts, err
+}
+
+func (g *GORMTaskExecutionDAO) RequeueFailed(ctx context.Context, id int64, from string) error {
+	if !domain.TaskExecutionStatus(from).CanRequeueTo(domain.TaskExecutionStatusFailedRetryable) {
+		return fmt.Errorf("%w: %s 状态的执行记录不能重新放回重试队列，ID=%d", errs.ErrInvalidTaskExecutionStatus, from, id)
+	}
+	now := time.Now().UnixMilli()
+	result := g.db.WithContext(ctx).
+		Model(&TaskExecution{}).
+		Where("id = ? AND status = ?", id, from).
+		Updates(map[string]any{
+			"status":            TaskExecutionStatusFailedRetryable,
+			"retry_count":       0,
+			"next_retry_time":   now,
+			"failed_node_ids":   sqlx.JSONColumn[[]string]{},
+			"running_progress":  0,
+			"dispatching_until": 0,
+			"utime":             now,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("%w: 数据库操作失败: %w", errs.ErrUpdateExecutionStatusFailed, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: 执行记录不存在或状态已经不是 %s，ID=%d", errs.ErrInvalidTaskExecutionStatus, from, id)
+	}
+	return nil
+}
+
+func (g *GORMTaskExecutionDAO) Complete(ctx context.Context, id int64, from, status string, progress int32, endTime int64, executionResult domain.ExecutionResult, msg CompletionOutbox) (CompletionOutbox, error) {
+	if err := checkTransit(from, status); err != nil {
+		return CompletionOutbox{}, err
+	}
+	now := time.Now().UnixMilli()
+	err := g.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&TaskExecution{}).
+			Where("id = ? AND status = ?", id, from).
+			Updates(map[string]any{
+				"status":             status,
+				"running_progress":   progress,
+				"etime":              endTime,
+				"result":             sqlx.JSONColumn[domain.ExecutionResult]{Val: executionResult, Valid: true},
+				"completion_handled": false,
+				"utime":              now,
+			})
+		if result.Error != nil {
+			return fmt.Errorf("%w: 数据库操作失败: %w", errs.ErrUpdateExecutionStatusAndEndTimeFailed, result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("%w: 执行记录不存在或状态已经不是 %s，ID=%d", errs.ErrInvalidTaskExecutionStatus, from, id)
+		}
+
+		msg.ExecutionID = id
+		msg.Ctime = now
+		msg.Utime = now
+		return tx.Create(&msg).Error
+	})
+	return msg, err
+}
+
+func (g *GORMTaskExecutionDAO) MarkCompletionHandled(ctx context.Context, id int64) error {
+	return g.db.WithContext(ctx).
+		Model(&TaskExecution{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"completion_handled": true,
+			"utime":              time.Now().UnixMilli(),
+		}).Error
+}
@@ -0,0 +1,97 @@
+//go:build unit
+
+package dao
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestGORMTaskExecutionDAO_ClaimDispatch(t *testing.T) {
+	t.Parallel()
+
+	// 以状态和分发租约作为条件做 CAS，租约未过期或状态已经变化时影响行数为 0
+	claimSQL := regexp.QuoteMeta("UPDATE `task_executions` SET `dispatching_until`=? " +
+		"WHERE id = ? AND status = ? AND dispatching_until <= ?")
+
+	testCases := []struct {
+		name    string
+		mock    func(mock sqlmock.Sqlmock)
+		want    bool
+		wantErr bool
+	}{
+		{
+			name: "认领成功",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(claimSQL).
+					WithArgs(int64(2000), int64(1), TaskExecutionStatusFailedRetryable, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			want: true,
+		},
+		{
+			name: "已被其他补偿器认领",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(claimSQL).
+					WithArgs(int64(2000), int64(1), TaskExecutionStatusFailedRetryable, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+			want: false,
+		},
+		{
+			name: "数据库错误",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(claimSQL).WillReturnError(errors.New("数据库不可用"))
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock := newMockDB(t)
+			tc.mock(mock)
+
+			ok, err := NewGORMTaskExecutionDAO(db).ClaimDispatch(context.Background(), 1, TaskExecutionStatusFailedRetryable, 2000)
+			if tc.wantErr {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tc.want, ok)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func newMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	t.Helper()
+	sqlDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = sqlDB.Close()
+	})
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      sqlDB,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	return db, mock
+}
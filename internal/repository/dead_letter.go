@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Duke1616/ework-runner/internal/domain"
+	"github.com/Duke1616/ework-runner/internal/repository/dao"
+	"github.com/Duke1616/ework-runner/pkg/sqlx"
+	"github.com/ecodeclub/ekit/slice"
+)
+
+type DeadLetterRepository interface {
+	// Create 写入死信，同一执行记录的同一次尝试已有死信时不做修改并返回已有死信和 false
+	Create(ctx context.Context, letter domain.DeadLetter) (domain.DeadLetter, bool, error)
+	// GetByID 根据ID获取死信
+	GetByID(ctx context.Context, id int64) (domain.DeadLetter, error)
+	// List 按创建时间倒序分页查询死信，同时返回总数，status 为空时查询全部
+	List(ctx context.Context, status domain.DeadLetterStatus, offset, limit int) ([]domain.DeadLetter, int64, error)
+	// CASStatus 以 CAS 方式更新死信状态，当前状态不是 from 时返回 false
+	CASStatus(ctx context.Context, id int64, from, to domain.DeadLetterStatus) (bool, error)
+}
+
+type deadLetterRepository struct {
+	dao dao.DeadLetterDAO
+}
+
+func NewDeadLetterRepository(letterDAO dao.DeadLetterDAO) DeadLetterRepository {
+	return &deadLetterRepository{dao: letterDAO}
+}
+
+func (r *deadLetterRepository) Create(ctx context.Context, letter domain.DeadLetter) (domain.DeadLetter, bool, error) {
+	saved, created, err := r.dao.Create(ctx, r.toEntity(letter))
+	if err != nil {
+		return domain.DeadLetter{}, false, err
+	}
+	return r.toDomain(saved), created, nil
+}
+
+func (r *deadLetterRepository) GetByID(ctx context.Context, id int64) (domain.DeadLetter, error) {
+	letter, err := r.dao.GetByID(ctx, id)
+	if err != nil {
+		return domain.DeadLetter{}, err
+	}
+	return r.toDomain(letter), nil
+}
+
+func (r *deadLetterRepository) List(ctx context.Context, status domain.DeadLetterStatus, offset, limit int) ([]domain.DeadLetter, int64, error) {
+	letters, err := r.dao.List(ctx, status.String(), offset, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := r.dao.Count(ctx, status.String())
+	if err != nil {
+		return nil, 0, err
+	}
+	return slice.Map(letters, func(_ int, src dao.DeadLetter) domain.DeadLetter {
+		return r.toDomain(src)
+	}), total, nil
+}
+
+func (r *deadLetterRepository) CASStatus(ctx context.Context, id int64, from, to domain.DeadLetterStatus) (bool, error) {
+	return r.dao.CASStatus(ctx, id, from.String(), to.String())
+}
+
+func (r *deadLetterRepository) toEntity(letter domain.DeadLetter) dao.DeadLetter {
+	return dao.DeadLetter{
+		ID:          letter.ID,
+		ExecutionID: letter.ExecutionID,
+		Attempt:     letter.Attempt,
+		TaskID:      letter.TaskID,
+		TaskName:    letter.TaskName,
+		Snapshot:    sqlx.JSONColumn[domain.TaskExecution]{Val: letter.Execution, Valid: true},
+		Status:      letter.Status.String(),
+		Ctime:       letter.CTime,
+		Utime:       letter.UTime,
+	}
+}
+
+func (r *deadLetterRepository) toDomain(letter dao.DeadLetter) domain.DeadLetter {
+	return domain.DeadLetter{
+		ID:          letter.ID,
+		ExecutionID: letter.ExecutionID,
+		Attempt:     letter.Attempt,
+		TaskID:      letter.TaskID,
+		TaskName:    letter.TaskName,
+		Execution:   letter.Snapshot.Val,
+		Status:      domain.DeadLetterStatus(letter.Status),
+		CTime:       letter.Ctime,
+		UTime:       letter.Utime,
+	}
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Duke1616/ework-runner/internal/domain"
+	"github.com/Duke1616/ework-runner/internal/repository/dao"
+	"github.com/ecodeclub/ekit/slice"
+)
+
+type ExecutionLogRepository interface {
+	// BatchCreate 批量写入日志块
+	BatchCreate(ctx context.Context, logs []domain.ExecutionLog) error
+	// TotalSize 获取指定执行已存储的日志总字节数
+	TotalSize(ctx context.Context, executionID int64) (int64, error)
+	// FindByExecutionID 按写入顺序查询指定执行 ID 大于 afterID 的日志块
+	FindByExecutionID(ctx context.Context, executionID, afterID int64, limit int) ([]domain.ExecutionLog, error)
+	// FindCreatedBefore 按写入顺序查询指定执行 ID 大于 afterID 并且创建时间不晚于 ctime 的日志块
+	FindCreatedBefore(ctx context.Context, executionID, afterID, ctime int64, limit int) ([]domain.ExecutionLog, error)
+}
+
+type executionLogRepository struct {
+	dao dao.ExecutionLogDAO
+}
+
+func NewExecutionLogRepository(logDAO dao.ExecutionLogDAO) ExecutionLogRepository {
+	return &executionLogRepository{dao: logDAO}
+}
+
+func (r *executionLogRepository) BatchCreate(ctx context.Context, logs []domain.ExecutionLog) error {
+	return r.dao.BatchCreate(ctx, slice.Map(logs, func(_ int, src domain.ExecutionLog) dao.ExecutionLog {
+		return r.toEntity(src)
+	}))
+}
+
+func (r *executionLogRepository) TotalSize(ctx context.Context, executionID int64) (int64, error) {
+	return r.dao.TotalSize(ctx, executionID)
+}
+
+func (r *executionLogRepository) FindByExecutionID(ctx context.Context, executionID, afterID int64, limit int) ([]domain.ExecutionLog, error) {
+	logs, err := r.dao.FindByExecutionID(ctx, executionID, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map(logs, func(_ int, src dao.ExecutionLog) domain.ExecutionLog {
+		return r.toDomain(src)
+	}), nil
+}
+
+func (r *executionLogRepository) FindCreatedBefore(ctx context.Context, executionID, afterID, ctime int64, limit int) ([]domain.ExecutionLog, error) {
+	logs, err := r.dao.FindCreatedBefore(ctx, executionID, afterID, ctime, limit)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map(logs, func(_ int, src dao.ExecutionLog) domain.ExecutionLog {
+		return r.toDomain(src)
+	}), nil
+}
+
+func (r *executionLogRepository) toEntity(log domain.ExecutionLog) dao.ExecutionLog {
+	return dao.ExecutionLog{
+		ID:             log.ID,
+		ExecutionID:    log.ExecutionID,
+		ExecutorNodeID: log.ExecutorNodeID,
+		Sequence:       log.Sequence,
+		Content:        log.Content,
+		Timestamp:      log.Timestamp,
+		Ctime:          log.CTime,
+	}
+}
+
+func (r *executionLogRepository) toDomain(log dao.ExecutionLog) domain.ExecutionLog {
+	return domain.ExecutionLog{
+		ID:             log.ID,
+		ExecutionID:    log.ExecutionID,
+		ExecutorNodeID: log.ExecutorNodeID,
+		Sequence:       log.Sequence,
+		Content:        log.Content,
+		Timestamp:      log.Timestamp,
+		CTime:          log.Ctime,
+	}
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Duke1616/ework-runner/internal/domain"
+	"github.com/Duke1616/ework-runner/internal/repository/dao"
+	"github.com/ecodeclub/ekit/slice"
+)
+
+type HookDeliveryRepository interface {
+	// Create 创建投递记录，同一执行记录的同一次尝试、同一事件对同一目标已有记录时返回 false
+	Create(ctx context.Context, delivery domain.HookDelivery) (domain.HookDelivery, bool, error)
+	// UpdateResult 记录一次投递的结果，status 为 PENDING 时 nextRetryTime 为下次投递的时间
+	UpdateResult(ctx context.Context, id int64, status domain.HookDeliveryStatus, tries int32, lastError string, nextRetryTime int64) error
+	// FindDue 查找到达下次投递时间的投递中记录
+	FindDue(ctx context.Context, now int64, limit int) ([]domain.HookDelivery, error)
+	// Claim 以 CAS 方式把下次投递时间从 nextRetryTime 推迟到 newNextRetryTime 来认领投递记录，已被其他节点认领时返回 false
+	Claim(ctx context.Context, id, nextRetryTime, newNextRetryTime int64) (bool, error)
+	// FindByExecutionID 查询执行记录的所有投递记录
+	FindByExecutionID(ctx context.Context, executionID int64) ([]domain.HookDelivery, error)
+}
+
+type hookDeliveryRepository struct {
+	dao dao.HookDeliveryDAO
+}
+
+func NewHookDeliveryRepository(deliveryDAO dao.HookDeliveryDAO) HookDeliveryRepository {
+	return &hookDeliveryRepository{dao: deliveryDAO}
+}
+
+func (r *hookDeliveryRepository) Create(ctx context.Context, delivery domain.HookDelivery) (domain.HookDelivery, bool, error) {
+	created, ok, err := r.dao.Create(ctx, r.toEntity(delivery))
+	if err != nil {
+		return domain.HookDelivery{}, false, err
+	}
+	return r.toDomain(created), ok, nil
+}
+
+func (r *hookDeliveryRepository) UpdateResult(ctx context.Context, id int64, status domain.HookDeliveryStatus, tries int32, lastError string, nextRetryTime int64) error {
+	return r.dao.UpdateResult(ctx, id, status.String(), tries, lastError, nextRetryTime)
+}
+
+func (r *hookDeliveryRepository) FindDue(ctx context.Context, now int64, limit int) ([]domain.HookDelivery, error) {
+	deliveries, err := r.dao.FindDue(ctx, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map(deliveries, func(_ int, src dao.HookDelivery) domain.HookDelivery {
+		return r.toDomain(src)
+	}), nil
+}
+
+func (r *hookDeliveryRepository) Claim(ctx context.Context, id, nextRetryTime, newNextRetryTime int64) (bool, error) {
+	return r.dao.Claim(ctx, id, nextRetryTime, newNextRetryTime)
+}
+
+func (r *hookDeliveryRepository) FindByExecutionID(ctx context.Context, executionID int64) ([]domain.HookDelivery, error) {
+	deliveries, err := r.dao.FindByExecutionID(ctx, executionID)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map(deliveries, func(_ int, src dao.HookDelivery) domain.HookDelivery {
+		return r.toDomain(src)
+	}), nil
+}
+
+func (r *hookDeliveryRepository) toEntity(delivery domain.HookDelivery) dao.HookDelivery {
+	return dao.HookDelivery{
+		ID:            delivery.ID,
+		ExecutionID:   delivery.ExecutionID,
+		Attempt:       delivery.Attempt,
+		Event:         delivery.Event.String(),
+		Channel:       delivery.Channel.String(),
+		Target:        delivery.Target,
+		TaskID:        delivery.TaskID,
+		TaskName:      delivery.TaskName,
+		Payload:       delivery.Payload,
+		Status:        delivery.Status.String(),
+		NextRetryTime: delivery.NextRetryTime,
+		Tries:         delivery.Tries,
+		LastError:     delivery.LastError,
+		Ctime:         delivery.CTime,
+		Utime:         delivery.UTime,
+	}
+}
+
+func (r *hookDeliveryRepository) toDomain(delivery dao.HookDelivery) domain.HookDelivery {
+	return domain.HookDelivery{
+		ID:            delivery.ID,
+		ExecutionID:   delivery.ExecutionID,
+		Attempt:       delivery.Attempt,
+		Event:         domain.HookEventType(delivery.Event),
+		Channel:       domain.HookChannel(delivery.Channel),
+		Target:        delivery.Target,
+		TaskID:        delivery.TaskID,
+		TaskName:      delivery.TaskName,
+		Payload:       delivery.Payload,
+		Status:        domain.HookDeliveryStatus(delivery.Status),
+		NextRetryTime: delivery.NextRetryTime,
+		Tries:         delivery.Tries,
+		LastError:     delivery.LastError,
+		CTime:         delivery.Ctime,
+		UTime:         delivery.Utime,
+	}
+}
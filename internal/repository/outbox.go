@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Duke1616/ework-runner/internal/domain"
+	"github.com/Duke1616/ework-runner/internal/repository/dao"
+	"github.com/ecodeclub/ekit/slice"
+)
+
+// OutboxRepository 完成事件发件箱，消息随执行记录的终止状态一起写入（见 TaskExecutionRepository.Complete）
+type OutboxRepository interface {
+	// FindDue 查找到达发送时间的消息
+	FindDue(ctx context.Context, now int64, limit int) ([]domain.OutboxMessage, error)
+	// Claim 以 CAS 方式把下次发送时间从 nextTime 推迟到 newNextTime 来认领消息，已被其他节点认领时返回 false
+	Claim(ctx context.Context, id, nextTime, newNextTime int64) (bool, error)
+	// Delete 删除发送成功的消息
+	Delete(ctx context.Context, id int64) error
+	// MarkFailed 记录一次发送失败以及下次发送时间
+	MarkFailed(ctx context.Context, id int64, tries int32, nextTime int64, lastError string) error
+}
+
+type outboxRepository struct {
+	dao dao.OutboxDAO
+}
+
+func NewOutboxRepository(outboxDAO dao.OutboxDAO) OutboxRepository {
+	return &outboxRepository{dao: outboxDAO}
+}
+
+func (r *outboxRepository) FindDue(ctx context.Context, now int64, limit int) ([]domain.OutboxMessage, error) {
+	messages, err := r.dao.FindDue(ctx, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map(messages, func(_ int, src dao.CompletionOutbox) domain.OutboxMessage {
+		return toOutboxDomain(src)
+	}), nil
+}
+
+func (r *outboxRepository) Claim(ctx context.Context, id, nextTime, newNextTime int64) (bool, error) {
+	return r.dao.Claim(ctx, id, nextTime, newNextTime)
+}
+
+func (r *outboxRepository) Delete(ctx context.Context, id int64) error {
+	return r.dao.Delete(ctx, id)
+}
+
+func (r *outboxRepository) MarkFailed(ctx context.Context, id int64, tries int32, nextTime int64, lastError string) error {
+	return r.dao.MarkFailed(ctx, id, tries, nextTime, lastError)
+}
+
+func toOutboxEntity(msg domain.OutboxMessage) dao.CompletionOutbox {
+	return dao.CompletionOutbox{
+		ID:          msg.ID,
+		ExecutionID: msg.ExecutionID,
+		Payload:     msg.Payload,
+		Tries:       msg.Tries,
+		NextTime:    msg.NextTime,
+		LastError:   msg.LastError,
+		Ctime:       msg.CTime,
+		Utime:       msg.UTime,
+	}
+}
+
+func toOutboxDomain(msg dao.CompletionOutbox) domain.OutboxMessage {
+	return domain.OutboxMessage{
+		ID:          msg.ID,
+		ExecutionID: msg.ExecutionID,
+		Payload:     msg.Payload,
+		Tries:       msg.Tries,
+		NextTime:    msg.NextTime,
+		LastError:   msg.LastError,
+		CTime:       msg.Ctime,
+		UTime:       msg.Utime,
+	}
+}
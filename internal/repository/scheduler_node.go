@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Duke1616/ework-runner/internal/domain"
+	"github.com/Duke1616/ework-runner/internal/repository/dao"
+	"github.com/ecodeclub/ekit/slice"
+)
+
+// SchedulerNodeRepository 调度节点心跳
+type SchedulerNodeRepository interface {
+	// Save 写入调度节点心跳
+	Save(ctx context.Context, node domain.SchedulerNode) error
+	// Delete 调度节点退出时删除心跳
+	Delete(ctx context.Context, nodeID string) error
+	// List 列出所有在线的调度节点
+	List(ctx context.Context) ([]domain.SchedulerNode, error)
+}
+
+type schedulerNodeRepository struct {
+	dao dao.SchedulerNodeDAO
+}
+
+func NewSchedulerNodeRepository(nodeDAO dao.SchedulerNodeDAO) SchedulerNodeRepository {
+	return &schedulerNodeRepository{dao: nodeDAO}
+}
+
+func (r *schedulerNodeRepository) Save(ctx context.Context, node domain.SchedulerNode) error {
+	return r.dao.Save(ctx, dao.SchedulerNode{
+		NodeID:           node.NodeID,
+		StartTime:        node.StartTime,
+		LastRenewTime:    node.LastRenewTime,
+		LastScheduleTime: node.LastScheduleTime,
+		ScheduleInterval: node.ScheduleInterval.Milliseconds(),
+		BatchTimeout:     node.BatchTimeout.Milliseconds(),
+		RenewInterval:    node.RenewInterval.Milliseconds(),
+		Utime:            time.Now().UnixMilli(),
+	})
+}
+
+func (r *schedulerNodeRepository) Delete(ctx context.Context, nodeID string) error {
+	return r.dao.Delete(ctx, nodeID)
+}
+
+func (r *schedulerNodeRepository) List(ctx context.Context) ([]domain.SchedulerNode, error) {
+	nodes, err := r.dao.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map(nodes, func(_ int, src dao.SchedulerNode) domain.SchedulerNode {
+		return domain.SchedulerNode{
+			NodeID:           src.NodeID,
+			StartTime:        src.StartTime,
+			LastRenewTime:    src.LastRenewTime,
+			LastScheduleTime: src.LastScheduleTime,
+			ScheduleInterval: time.Duration(src.ScheduleInterval) * time.Millisecond,
+			BatchTimeout:     time.Duration(src.BatchTimeout) * time.Millisecond,
+			RenewInterval:    time.Duration(src.RenewInterval) * time.Millisecond,
+			UTime:            src.Utime,
+		}
+	}), nil
+}
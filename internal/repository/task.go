@@ -19,8 +19,8 @@ type TaskRepository interface {
 	SchedulableTasks(ctx context.Context, preemptedTimeoutMs int64, limit int) ([]domain.Task, error)
 	// Acquire 抢占任务
 	Acquire(ctx context.Context, id, version int64, scheduleNodeID string) (domain.Task, error)
-	// Release 释放任务
-	Release(ctx context.Context, id int64, scheduleNodeID string) (domain.Task, error)
+	// Release 以版本号为条件释放任务
+	Release(ctx context.Context, id, version int64, scheduleNodeID string) (domain.Task, error)
 	// Renew 续约所有抢占到的任务
 	Renew(ctx context.Context, scheduleNodeID string) error
 	// UpdateNextTime 更新任务的下次执行时间
@@ -31,6 +31,10 @@ type TaskRepository interface {
 	FindByPlanID(ctx context.Context, planID int64) ([]domain.Task, error)
 	// UpdateStatus 更新任务状态
 	UpdateStatus(ctx context.Context, id int64, status domain.TaskStatus) (domain.Task, error)
+	// FindPreempted 查询处于 PREEMPTED 状态的任务
+	FindPreempted(ctx context.Context, limit int) ([]domain.Task, error)
+	// FindGrpcServiceNames 查询任务配置的所有 gRPC 执行节点服务名
+	FindGrpcServiceNames(ctx context.Context) ([]string, error)
 }
 
 type taskRepository struct {
@@ -51,6 +55,20 @@ func (r *taskRepository) FindByPlanID(ctx context.Context, planID int64) ([]doma
 	}), nil
 }
 
+func (r *taskRepository) FindPreempted(ctx context.Context, limit int) ([]domain.Task, error) {
+	tasks, err := r.dao.FindPreempted(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map(tasks, func(_ int, src *dao.Task) domain.Task {
+		return r.toDomain(src)
+	}), nil
+}
+
+func (r *taskRepository) FindGrpcServiceNames(ctx context.Context) ([]string, error) {
+	return r.dao.FindGrpcServiceNames(ctx)
+}
+
 func (r *taskRepository) Create(ctx context.Context, task domain.Task) (domain.Task, error) {
 	created, err := r.dao.Create(ctx, r.toEntity(task))
 	if err != nil {
@@ -85,8 +103,8 @@ func (r *taskRepository) Acquire(ctx context.Context, id, version int64, schedul
 	return r.toDomain(task), nil
 }
 
-func (r *taskRepository) Release(ctx context.Context, id int64, scheduleNodeID string) (domain.Task, error) {
-	task, err := r.dao.Release(ctx, id, scheduleNodeID)
+func (r *taskRepository) Release(ctx context.Context, id, version int64, scheduleNodeID string) (domain.Task, error) {
+	task, err := r.dao.Release(ctx, id, version, scheduleNodeID)
 	if err != nil {
 		return domain.Task{}, err
 	}
@@ -144,6 +162,16 @@ func (r *taskRepository) toEntity(task domain.Task) dao.Task {
 		retryConfig = sqlx.JSONColumn[domain.RetryConfig]{Val: *task.RetryConfig, Valid: true}
 	}
 
+	var alertConfig sqlx.JSONColumn[domain.AlertConfig]
+	if task.AlertConfig != nil {
+		alertConfig = sqlx.JSONColumn[domain.AlertConfig]{Val: *task.AlertConfig, Valid: true}
+	}
+
+	var hookConfig sqlx.JSONColumn[domain.HookConfig]
+	if task.HookConfig != nil {
+		hookConfig = sqlx.JSONColumn[domain.HookConfig]{Val: *task.HookConfig, Valid: true}
+	}
+
 	var scheduleParams sqlx.JSONColumn[map[string]string]
 	if task.ScheduleParams != nil {
 		scheduleParams = sqlx.JSONColumn[map[string]string]{Val: task.ScheduleParams, Valid: true}
@@ -157,6 +185,8 @@ func (r *taskRepository) toEntity(task domain.Task) dao.Task {
 		GrpcConfig:          grpcConfig,
 		HTTPConfig:          httpConfig,
 		RetryConfig:         retryConfig,
+		AlertConfig:         alertConfig,
+		HookConfig:          hookConfig,
 		ScheduleParams:      scheduleParams,
 		MaxExecutionSeconds: task.MaxExecutionSeconds,
 		ScheduleNodeID:      scheduleNodeID,
@@ -190,6 +220,16 @@ func (r *taskRepository) toDomain(daoTask *dao.Task) domain.Task {
 		retryConfig = &daoTask.RetryConfig.Val
 	}
 
+	var alertConfig *domain.AlertConfig
+	if daoTask.AlertConfig.Valid {
+		alertConfig = &daoTask.AlertConfig.Val
+	}
+
+	var hookConfig *domain.HookConfig
+	if daoTask.HookConfig.Valid {
+		hookConfig = &daoTask.HookConfig.Val
+	}
+
 	var scheduleParams map[string]string
 	if daoTask.ScheduleParams.Valid {
 		scheduleParams = daoTask.ScheduleParams.Val
@@ -203,6 +243,8 @@ func (r *taskRepository) toDomain(daoTask *dao.Task) domain.Task {
 		GrpcConfig:          grpcConfig,
 		HTTPConfig:          httpConfig,
 		RetryConfig:         retryConfig,
+		AlertConfig:         alertConfig,
+		HookConfig:          hookConfig,
 		MaxExecutionSeconds: daoTask.MaxExecutionSeconds,
 		ScheduleParams:      scheduleParams,
 		ScheduleNodeID:      scheduleNodeID,
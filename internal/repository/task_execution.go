@@ -15,29 +15,54 @@ import (
 type TaskExecutionRepository interface {
 	// Create 创建任务执行实例
 	Create(ctx context.Context, execution domain.TaskExecution) (domain.TaskExecution, error)
-	// UpdateStatus 更新执行状态
-	UpdateStatus(ctx context.Context, id int64, status domain.TaskExecutionStatus) error
+	// UpdateStatus 以读取到的状态 from 为条件更新执行状态
+	UpdateStatus(ctx context.Context, id int64, from, status domain.TaskExecutionStatus) error
 	// GetByID 根据ID获取执行实例
 	GetByID(ctx context.Context, id int64) (domain.TaskExecution, error)
 	// FindRetryableExecutions 查找所有可以重试的执行记录
 	// limit: 查询结果数量限制
 	FindRetryableExecutions(ctx context.Context, limit int) ([]domain.TaskExecution, error)
-	// UpdateRetryResult 更新重试结果
-	UpdateRetryResult(ctx context.Context, id, retryCount, nextRetryTime int64, status domain.TaskExecutionStatus, progress int32, endTime int64, scheduleParams map[string]string, executorNodeID string) error
-	// SetRunningState 设置任务为运行状态并更新进度
-	SetRunningState(ctx context.Context, id int64, progress int32, executorNodeID string) error
+	// UpdateRetryResult 以读取到的状态 from 为条件更新重试结果，执行结果与状态在同一个条件更新中写入
+	UpdateRetryResult(ctx context.Context, id, retryCount, nextRetryTime int64, from, status domain.TaskExecutionStatus, progress int32, endTime int64, result domain.ExecutionResult, scheduleParams map[string]string, executorNodeID string, failedNodeIDs []string) error
+	// SetRunningState 以读取到的状态 from 为条件设置任务为运行状态并更新进度
+	SetRunningState(ctx context.Context, id int64, from domain.TaskExecutionStatus, progress int32, executorNodeID string) error
 	// UpdateRunningProgress 更新任务执行进度（仅在RUNNING状态下有效）
 	UpdateRunningProgress(ctx context.Context, id int64, progress int32) error
-	// UpdateScheduleResult 更新调度结果
-	UpdateScheduleResult(ctx context.Context, id int64, status domain.TaskExecutionStatus, progress int32, endTime int64, scheduleParams map[string]string, executorNodeID string) error
+	// UpdateScheduleResult 以读取到的状态 from 为条件更新调度结果，执行结果与状态在同一个条件更新中写入
+	UpdateScheduleResult(ctx context.Context, id int64, from, status domain.TaskExecutionStatus, progress int32, endTime int64, result domain.ExecutionResult, scheduleParams map[string]string, executorNodeID string) error
 	// FindReschedulableExecutions 查找所有可以重调度的执行记录
 	FindReschedulableExecutions(ctx context.Context, limit int) ([]domain.TaskExecution, error)
+	// ClaimDispatch 以 CAS 方式认领处于 status 状态的执行记录并设置分发租约，已被认领或状态已经变化时返回 false
+	ClaimDispatch(ctx context.Context, id int64, status domain.TaskExecutionStatus, leaseUntil int64) (bool, error)
 
 	FindExecutionsByPlanExecID(ctx context.Context, planExecID int64) (map[int64]domain.TaskExecution, error)
 	FindByTaskID(ctx context.Context, taskID int64) ([]domain.TaskExecution, error)
 	FindExecutionByTaskIDAndPlanExecID(ctx context.Context, taskID int64, planExecID int64) (domain.TaskExecution, error)
 	// FindTimeoutExecutions 查找超时的执行记录
 	FindTimeoutExecutions(ctx context.Context, limit int) ([]domain.TaskExecution, error)
+	// AcceptReport 以 CAS 方式记录上报序号，同一执行节点的序号不大于已记录序号时返回 false
+	AcceptReport(ctx context.Context, id int64, nodeID string, seq int64) (bool, error)
+	// FindStaleRunningExecutions 查找在 before（毫秒时间戳）之后没有任何更新、且尚未超时的运行中执行记录
+	FindStaleRunningExecutions(ctx context.Context, before int64, limit int) ([]domain.TaskExecution, error)
+	// FindStalePrepareExecutions 查找在 before（毫秒时间戳）之后没有任何更新、且没有被补偿器认领的 PREPARE 执行记录
+	FindStalePrepareExecutions(ctx context.Context, before int64, limit int) ([]domain.TaskExecution, error)
+	// FindRunningExecutions 按ID升序分页查找ID大于 afterID 的运行中执行记录
+	FindRunningExecutions(ctx context.Context, afterID int64, limit int) ([]domain.TaskExecution, error)
+	// FindActiveByTaskID 查找任务尚未结束的执行记录
+	FindActiveByTaskID(ctx context.Context, taskID int64) ([]domain.TaskExecution, error)
+	// StartAttempt 开始一次新的尝试，返回尝试序号；重试时同时累加重试次数
+	// 尝试次数已经达到 maxAttempts 时返回 errs.ErrExecutionMaxAttemptsExceeded
+	StartAttempt(ctx context.Context, id int64, kind domain.AttemptKind, executorNodeID string, maxAttempts int64) (int64, error)
+	// UpdateAttempt 更新尝试记录
+	UpdateAttempt(ctx context.Context, attempt domain.ExecutionAttempt) error
+	// FindAttempts 查询执行记录的所有尝试
+	FindAttempts(ctx context.Context, id int64) ([]domain.ExecutionAttempt, error)
+	// Complete 在同一个事务中以读取到的状态 from 为条件将执行记录迁移到终止状态、写入执行结果并把完成事件写入发件箱
+	Complete(ctx context.Context, id int64, from, status domain.TaskExecutionStatus, progress int32, endTime int64, result domain.ExecutionResult, msg domain.OutboxMessage) (domain.OutboxMessage, error)
+	// MarkCompletionHandled 标记完成事件已经被处理
+	MarkCompletionHandled(ctx context.Context, id int64) error
+	// RequeueFailed 以读取到的状态 from 为条件将不可重试失败的执行记录重新放回重试队列
+	RequeueFailed(ctx context.Context, id int64, from domain.TaskExecutionStatus) error
 }
 
 type taskExecutionRepository struct {
@@ -106,8 +131,8 @@ func (r *taskExecutionRepository) Create(ctx context.Context, execution domain.T
 	return r.toDomain(created), nil
 }
 
-func (r *taskExecutionRepository) UpdateStatus(ctx context.Context, id int64, status domain.TaskExecutionStatus) error {
-	return r.dao.UpdateStatus(ctx, id, status.String())
+func (r *taskExecutionRepository) UpdateStatus(ctx context.Context, id int64, from, status domain.TaskExecutionStatus) error {
+	return r.dao.UpdateStatus(ctx, id, from.String(), status.String())
 }
 
 func (r *taskExecutionRepository) GetByID(ctx context.Context, id int64) (domain.TaskExecution, error) {
@@ -128,20 +153,20 @@ func (r *taskExecutionRepository) FindRetryableExecutions(ctx context.Context, l
 	}), nil
 }
 
-func (r *taskExecutionRepository) UpdateRetryResult(ctx context.Context, id, retryCount, nextRetryTime int64, status domain.TaskExecutionStatus, progress int32, endTime int64, scheduleParams map[string]string, executorNodeID string) error {
-	return r.dao.UpdateRetryResult(ctx, id, retryCount, nextRetryTime, status.String(), progress, endTime, scheduleParams, executorNodeID)
+func (r *taskExecutionRepository) UpdateRetryResult(ctx context.Context, id, retryCount, nextRetryTime int64, from, status domain.TaskExecutionStatus, progress int32, endTime int64, result domain.ExecutionResult, scheduleParams map[string]string, executorNodeID string, failedNodeIDs []string) error {
+	return r.dao.UpdateRetryResult(ctx, id, retryCount, nextRetryTime, from.String(), status.String(), progress, endTime, result, scheduleParams, executorNodeID, failedNodeIDs)
 }
 
-func (r *taskExecutionRepository) SetRunningState(ctx context.Context, id int64, progress int32, executorNodeID string) error {
-	return r.dao.SetRunningState(ctx, id, progress, executorNodeID)
+func (r *taskExecutionRepository) SetRunningState(ctx context.Context, id int64, from domain.TaskExecutionStatus, progress int32, executorNodeID string) error {
+	return r.dao.SetRunningState(ctx, id, from.String(), progress, executorNodeID)
 }
 
 func (r *taskExecutionRepository) UpdateRunningProgress(ctx context.Context, id int64, progress int32) error {
 	return r.dao.UpdateProgress(ctx, id, progress)
 }
 
-func (r *taskExecutionRepository) UpdateScheduleResult(ctx context.Context, id int64, status domain.TaskExecutionStatus, progress int32, endTime int64, scheduleParams map[string]string, executorNodeID string) error {
-	return r.dao.UpdateScheduleResult(ctx, id, status.String(), progress, endTime, scheduleParams, executorNodeID)
+func (r *taskExecutionRepository) UpdateScheduleResult(ctx context.Context, id int64, from, status domain.TaskExecutionStatus, progress int32, endTime int64, result domain.ExecutionResult, scheduleParams map[string]string, executorNodeID string) error {
+	return r.dao.UpdateScheduleResult(ctx, id, from.String(), status.String(), progress, endTime, result, scheduleParams, executorNodeID)
 }
 
 func (r *taskExecutionRepository) FindReschedulableExecutions(ctx context.Context, limit int) ([]domain.TaskExecution, error) {
@@ -154,6 +179,10 @@ func (r *taskExecutionRepository) FindReschedulableExecutions(ctx context.Contex
 	}), nil
 }
 
+func (r *taskExecutionRepository) ClaimDispatch(ctx context.Context, id int64, status domain.TaskExecutionStatus, leaseUntil int64) (bool, error) {
+	return r.dao.ClaimDispatch(ctx, id, status.String(), leaseUntil)
+}
+
 func (r *taskExecutionRepository) FindTimeoutExecutions(ctx context.Context, limit int) ([]domain.TaskExecution, error) {
 	daoExecutions, err := r.dao.FindTimeoutExecutions(ctx, limit)
 	if err != nil {
@@ -164,6 +193,50 @@ func (r *taskExecutionRepository) FindTimeoutExecutions(ctx context.Context, lim
 	}), nil
 }
 
+func (r *taskExecutionRepository) AcceptReport(ctx context.Context, id int64, nodeID string, seq int64) (bool, error) {
+	return r.dao.AcceptReport(ctx, id, nodeID, seq)
+}
+
+func (r *taskExecutionRepository) FindStalePrepareExecutions(ctx context.Context, before int64, limit int) ([]domain.TaskExecution, error) {
+	daoExecutions, err := r.dao.FindStalePrepareExecutions(ctx, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map(daoExecutions, func(_ int, src dao.TaskExecution) domain.TaskExecution {
+		return r.toDomain(src)
+	}), nil
+}
+
+func (r *taskExecutionRepository) FindStaleRunningExecutions(ctx context.Context, before int64, limit int) ([]domain.TaskExecution, error) {
+	daoExecutions, err := r.dao.FindStaleRunningExecutions(ctx, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map(daoExecutions, func(_ int, src dao.TaskExecution) domain.TaskExecution {
+		return r.toDomain(src)
+	}), nil
+}
+
+func (r *taskExecutionRepository) FindRunningExecutions(ctx context.Context, afterID int64, limit int) ([]domain.TaskExecution, error) {
+	daoExecutions, err := r.dao.FindRunningExecutions(ctx, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map(daoExecutions, func(_ int, src dao.TaskExecution) domain.TaskExecution {
+		return r.toDomain(src)
+	}), nil
+}
+
+func (r *taskExecutionRepository) FindActiveByTaskID(ctx context.Context, taskID int64) ([]domain.TaskExecution, error) {
+	daoExecutions, err := r.dao.FindActiveByTaskID(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map(daoExecutions, func(_ int, src dao.TaskExecution) domain.TaskExecution {
+		return r.toDomain(src)
+	}), nil
+}
+
 // toEntity 将领域模型转换为DAO模型
 func (r *taskExecutionRepository) toEntity(execution domain.TaskExecution) dao.TaskExecution {
 	var grpcConfig sqlx.JSONColumn[domain.GrpcConfig]
@@ -191,6 +264,16 @@ func (r *taskExecutionRepository) toEntity(execution domain.TaskExecution) dao.T
 		executorNodeID = sql.NullString{String: execution.ExecutorNodeID, Valid: true}
 	}
 
+	var failedNodeIDs sqlx.JSONColumn[[]string]
+	if execution.FailedNodeIDs != nil {
+		failedNodeIDs = sqlx.JSONColumn[[]string]{Val: execution.FailedNodeIDs, Valid: true}
+	}
+
+	var traceContext sqlx.JSONColumn[map[string]string]
+	if execution.TraceContext != nil {
+		traceContext = sqlx.JSONColumn[map[string]string]{Val: execution.TraceContext, Valid: true}
+	}
+
 	return dao.TaskExecution{
 		ID: execution.ID,
 		// 从Task展开的冗余字段
@@ -208,14 +291,19 @@ func (r *taskExecutionRepository) toEntity(execution domain.TaskExecution) dao.T
 		// TaskExecution自身字段
 		Deadline:        execution.Deadline,
 		ExecutorNodeID:  executorNodeID,
+		FailedNodeIDs:   failedNodeIDs,
 		Stime:           execution.StartTime,
 		Etime:           execution.EndTime,
 		RetryCount:      execution.RetryCount,
+		Attempts:        execution.Attempts,
 		NextRetryTime:   execution.NextRetryTime,
 		RunningProgress: execution.RunningProgress,
 		Status:          execution.Status.String(),
 		Ctime:           execution.CTime,
 		Utime:           execution.UTime,
+
+		TraceContext:     traceContext,
+		DispatchingUntil: execution.DispatchingUntil,
 	}
 }
 
@@ -246,6 +334,11 @@ func (r *taskExecutionRepository) toDomain(daoExecution dao.TaskExecution) domai
 		executorNodeID = daoExecution.ExecutorNodeID.String
 	}
 
+	var failedNodeIDs []string
+	if daoExecution.FailedNodeIDs.Valid {
+		failedNodeIDs = daoExecution.FailedNodeIDs.Val
+	}
+
 	return domain.TaskExecution{
 		ID: daoExecution.ID,
 		Task: domain.Task{
@@ -264,13 +357,77 @@ func (r *taskExecutionRepository) toDomain(daoExecution dao.TaskExecution) domai
 
 		Deadline:        daoExecution.Deadline,
 		ExecutorNodeID:  executorNodeID,
+		FailedNodeIDs:   failedNodeIDs,
+		ReportNodeID:    daoExecution.ReportNodeID,
+		ReportSeq:       daoExecution.ReportSeq,
+		Result:          daoExecution.Result.Val,
 		StartTime:       daoExecution.Stime,
 		EndTime:         daoExecution.Etime,
 		RetryCount:      daoExecution.RetryCount,
+		Attempts:        daoExecution.Attempts,
 		NextRetryTime:   daoExecution.NextRetryTime,
 		RunningProgress: daoExecution.RunningProgress,
 		Status:          domain.TaskExecutionStatus(daoExecution.Status),
 		CTime:           daoExecution.Ctime,
 		UTime:           daoExecution.Utime,
+
+		CompletionHandled: daoExecution.CompletionHandled,
+		TraceContext:      daoExecution.TraceContext.Val,
+		DispatchingUntil:  daoExecution.DispatchingUntil,
 	}
 }
+
+func (r *taskExecutionRepository) StartAttempt(ctx context.Context, id int64, kind domain.AttemptKind, executorNodeID string, maxAttempts int64) (int64, error) {
+	return r.dao.StartAttempt(ctx, id, kind.String(), executorNodeID, maxAttempts)
+}
+
+func (r *taskExecutionRepository) UpdateAttempt(ctx context.Context, attempt domain.ExecutionAttempt) error {
+	return r.dao.UpdateAttempt(ctx, dao.ExecutionAttempt{
+		ExecutionID:    attempt.ExecutionID,
+		Attempt:        attempt.Attempt,
+		ExecutorNodeID: attempt.ExecutorNodeID,
+		Status:         attempt.Status.String(),
+		Etime:          attempt.EndTime,
+		ErrorMessage:   attempt.ErrorMessage,
+		ErrorCategory:  attempt.ErrorCategory,
+	})
+}
+
+func (r *taskExecutionRepository) Complete(ctx context.Context, id int64, from, status domain.TaskExecutionStatus, progress int32, endTime int64, result domain.ExecutionResult, msg domain.OutboxMessage) (domain.OutboxMessage, error) {
+	created, err := r.dao.Complete(ctx, id, from.String(), status.String(), progress, endTime, result, toOutboxEntity(msg))
+	if err != nil {
+		return domain.OutboxMessage{}, err
+	}
+	return toOutboxDomain(created), nil
+}
+
+func (r *taskExecutionRepository) MarkCompletionHandled(ctx context.Context, id int64) error {
+	return r.dao.MarkCompletionHandled(ctx, id)
+}
+
+func (r *taskExecutionRepository) RequeueFailed(ctx context.Context, id int64, from domain.TaskExecutionStatus) error {
+	return r.dao.RequeueFailed(ctx, id, from.String())
+}
+
+func (r *taskExecutionRepository) FindAttempts(ctx context.Context, id int64) ([]domain.ExecutionAttempt, error) {
+	attempts, err := r.dao.FindAttempts(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map(attempts, func(_ int, src dao.ExecutionAttempt) domain.ExecutionAttempt {
+		return domain.ExecutionAttempt{
+			ID:             src.ID,
+			ExecutionID:    src.ExecutionID,
+			Attempt:        src.Attempt,
+			Kind:           domain.AttemptKind(src.Kind),
+			ExecutorNodeID: src.ExecutorNodeID,
+			Status:         domain.TaskExecutionStatus(src.Status),
+			StartTime:      src.Stime,
+			EndTime:        src.Etime,
+			ErrorMessage:   src.ErrorMessage,
+			ErrorCategory:  src.ErrorCategory,
+			CTime:          src.Ctime,
+			UTime:          src.Utime,
+		}
+	}), nil
+}
@@ -13,8 +13,8 @@ var _ TaskAcquirer = &MySQLTaskAcquirer{}
 type TaskAcquirer interface {
 	// Acquire 抢占指定任务
 	Acquire(ctx context.Context, taskID, version int64, scheduleNodeID string) (domain.Task, error)
-	// Release 释放指定任务
-	Release(ctx context.Context, taskID int64, scheduleNodeID string) error
+	// Release 释放指定任务，任务的版本号和抢占的调度节点都没有变化时才能释放
+	Release(ctx context.Context, taskID, version int64, scheduleNodeID string) error
 	// Renew 续约所有抢占到的任务
 	Renew(ctx context.Context, scheduleNodeID string) error
 }
@@ -41,8 +41,8 @@ func (t *MySQLTaskAcquirer) Acquire(ctx context.Context, taskID, version int64,
 }
 
 // Release 释放指定任务
-func (t *MySQLTaskAcquirer) Release(ctx context.Context, taskID int64, scheduleNodeID string) error {
-	_, err := t.taskRepo.Release(ctx, taskID, scheduleNodeID)
+func (t *MySQLTaskAcquirer) Release(ctx context.Context, taskID, version int64, scheduleNodeID string) error {
+	_, err := t.taskRepo.Release(ctx, taskID, version, scheduleNodeID)
 	return err
 }
 
@@ -0,0 +1,211 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Duke1616/ework-runner/internal/domain"
+	"github.com/Duke1616/ework-runner/internal/errs"
+	"github.com/Duke1616/ework-runner/internal/repository"
+	"github.com/Duke1616/ework-runner/internal/service/acquirer"
+	"github.com/Duke1616/ework-runner/internal/service/task"
+	"github.com/Duke1616/ework-runner/pkg/grpc/registry"
+	"github.com/ecodeclub/ekit/slice"
+)
+
+const (
+	// maxPreemptedTasks 查询集群状态时最多返回的 PREEMPTED 任务数量
+	maxPreemptedTasks = 1000
+	// ForceReleasedCategory 任务被强制释放时结束执行记录所记录的错误分类
+	ForceReleasedCategory = "FORCE_RELEASED"
+)
+
+// Service 调度集群状态服务
+type Service interface {
+	// Heartbeat 写入调度节点心跳
+	Heartbeat(ctx context.Context, node domain.SchedulerNode) error
+	// Unregister 调度节点退出时删除心跳
+	Unregister(ctx context.Context, nodeID string) error
+	// State 查询调度节点、节点抢占的任务以及执行节点，executorServices 为空时查询所有任务配置的执行节点服务
+	State(ctx context.Context, executorServices []string) (domain.ClusterState, error)
+	// ForceRelease 强制释放卡在 PREEMPTED 状态的任务，不需要等待抢占超时
+	// 释放前先按 FAILED 结束任务尚未结束的执行记录，避免任务被重新抢占后与旧的执行同时运行
+	ForceRelease(ctx context.Context, taskID int64) error
+}
+
+type service struct {
+	nodeRepo repository.SchedulerNodeRepository
+	taskRepo repository.TaskRepository
+	execSvc  task.ExecutionService
+	acquirer acquirer.TaskAcquirer
+	registry registry.Registry
+}
+
+// NewService 创建调度集群状态服务
+func NewService(
+	nodeRepo repository.SchedulerNodeRepository,
+	taskRepo repository.TaskRepository,
+	execSvc task.ExecutionService,
+	acquirer acquirer.TaskAcquirer,
+	registry registry.Registry,
+) Service {
+	return &service{
+		nodeRepo: nodeRepo,
+		taskRepo: taskRepo,
+		execSvc:  execSvc,
+		acquirer: acquirer,
+		registry: registry,
+	}
+}
+
+func (s *service) Heartbeat(ctx context.Context, node domain.SchedulerNode) error {
+	return s.nodeRepo.Save(ctx, node)
+}
+
+func (s *service) Unregister(ctx context.Context, nodeID string) error {
+	return s.nodeRepo.Delete(ctx, nodeID)
+}
+
+func (s *service) State(ctx context.Context, executorServices []string) (domain.ClusterState, error) {
+	schedulerNodes, err := s.schedulerNodes(ctx)
+	if err != nil {
+		return domain.ClusterState{}, err
+	}
+	executorNodes, err := s.executorNodes(ctx, executorServices)
+	if err != nil {
+		return domain.ClusterState{}, err
+	}
+	return domain.ClusterState{
+		SchedulerNodes: schedulerNodes,
+		ExecutorNodes:  executorNodes,
+	}, nil
+}
+
+// schedulerNodes 按调度节点汇总抢占的任务，没有心跳但仍持有任务的节点标记为离线
+func (s *service) schedulerNodes(ctx context.Context) ([]domain.SchedulerNodeState, error) {
+	nodes, err := s.nodeRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := s.taskRepo.FindPreempted(ctx, maxPreemptedTasks)
+	if err != nil {
+		return nil, err
+	}
+
+	states := slice.Map(nodes, func(_ int, src domain.SchedulerNode) domain.SchedulerNodeState {
+		return domain.SchedulerNodeState{Node: src, Online: true}
+	})
+	index := make(map[string]int, len(states))
+	for i := range states {
+		index[states[i].Node.NodeID] = i
+	}
+	for _, task := range tasks {
+		i, ok := index[task.ScheduleNodeID]
+		if !ok {
+			states = append(states, domain.SchedulerNodeState{
+				Node: domain.SchedulerNode{NodeID: task.ScheduleNodeID},
+			})
+			i = len(states) - 1
+			index[task.ScheduleNodeID] = i
+		}
+		states[i].PreemptedTasks = append(states[i].PreemptedTasks, task)
+		// 续约会同时更新任务的更新时间，离线节点以任务最近一次被续约的时间作为最后续约时间
+		if !states[i].Online && task.UTime > states[i].Node.LastRenewTime {
+			states[i].Node.LastRenewTime = task.UTime
+		}
+	}
+	return states, nil
+}
+
+func (s *service) executorNodes(ctx context.Context, services []string) (map[string][]domain.ExecutorNode, error) {
+	if len(services) == 0 {
+		var err error
+		if services, err = s.taskRepo.FindGrpcServiceNames(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	nodes := make(map[string][]domain.ExecutorNode, len(services))
+	for _, name := range services {
+		if name == "" {
+			continue
+		}
+		instances, err := s.registry.ListServices(ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("查询执行节点 %s 失败: %w", name, err)
+		}
+		nodes[name] = slice.Map(instances, func(_ int, src registry.ServiceInstance) domain.ExecutorNode {
+			return domain.ExecutorNode{
+				ServiceName: name,
+				NodeID:      src.ID,
+				Address:     src.Address,
+			}
+		})
+	}
+	return nodes, nil
+}
+
+func (s *service) ForceRelease(ctx context.Context, taskID int64) error {
+	t, err := s.taskRepo.GetByID(ctx, taskID)
+	if err != nil {
+		return err
+	}
+	if t.Status != domain.TaskStatusPreempted {
+		return fmt.Errorf("%w: 当前状态 %s", errs.ErrTaskNotPreempted, t.Status)
+	}
+
+	failed, err := s.failActiveExecutions(ctx, t)
+	if err != nil {
+		return err
+	}
+	if failed > 0 {
+		// 执行记录结束后完成事件的消费者会更新下次执行时间并释放任务，版本号随之变化，重新读取
+		if t, err = s.taskRepo.GetByID(ctx, taskID); err != nil {
+			return err
+		}
+		if t.Status != domain.TaskStatusPreempted {
+			return nil
+		}
+	}
+	// 以任务当前的版本号和调度节点作为条件释放，期间任务被续约或者重新抢占时释放失败
+	err = s.acquirer.Release(ctx, t.ID, t.Version, t.ScheduleNodeID)
+	if errors.Is(err, errs.ErrTaskReleaseFailed) && failed > 0 {
+		// 与完成事件的消费者并发释放，已经被消费者释放时视为成功
+		if t, err = s.taskRepo.GetByID(ctx, taskID); err != nil {
+			return err
+		}
+		if t.Status != domain.TaskStatusPreempted {
+			return nil
+		}
+		return errs.ErrTaskReleaseFailed
+	}
+	return err
+}
+
+// failActiveExecutions 按 FAILED 结束任务尚未结束的执行记录，返回结束的执行记录数量
+// 执行节点之后的上报会因为执行记录已经结束而被拒绝
+func (s *service) failActiveExecutions(ctx context.Context, t domain.Task) (int, error) {
+	executions, err := s.execSvc.FindActiveByTaskID(ctx, t.ID)
+	if err != nil {
+		return 0, err
+	}
+	for _, execution := range executions {
+		err = s.execSvc.UpdateState(ctx, domain.ExecutionState{
+			ID:             execution.ID,
+			TaskID:         t.ID,
+			TaskName:       t.Name,
+			Status:         domain.TaskExecutionStatusFailed,
+			ExecutorNodeID: execution.ExecutorNodeID,
+			Result: domain.ExecutionResult{
+				ErrorMessage:  fmt.Sprintf("任务被强制释放，调度节点 %s", t.ScheduleNodeID),
+				ErrorCategory: ForceReleasedCategory,
+			},
+		})
+		// 查询之后执行记录已经自行结束时同样由完成事件的消费者处理后续调度
+		if err != nil && !errors.Is(err, errs.ErrInvalidTaskExecutionStatus) {
+			return 0, fmt.Errorf("结束执行记录 %d 失败: %w", execution.ID, err)
+		}
+	}
+	return len(executions), nil
+}
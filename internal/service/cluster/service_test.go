@@ -0,0 +1,148 @@
+//go:build unit
+
+package cluster
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Duke1616/ework-runner/internal/domain"
+	"github.com/Duke1616/ework-runner/internal/errs"
+	"github.com/Duke1616/ework-runner/internal/repository"
+	"github.com/Duke1616/ework-runner/internal/service/acquirer"
+	"github.com/Duke1616/ework-runner/internal/service/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_ForceRelease(t *testing.T) {
+	t.Parallel()
+
+	preempted := domain.Task{ID: 10, Name: "sync-user", Status: domain.TaskStatusPreempted, Version: 3, ScheduleNodeID: "scheduler-1"}
+	// 完成事件的消费者更新下次执行时间后的任务
+	updated := preempted
+	updated.Version = 4
+	released := updated
+	released.Status = domain.TaskStatusActive
+
+	testCases := []struct {
+		name       string
+		tasks      []domain.Task
+		executions []domain.TaskExecution
+		updateErr  error
+
+		wantErr     error
+		wantFailed  []int64
+		wantRelease []int64
+	}{
+		{
+			name:        "没有尚未结束的执行记录时直接释放",
+			tasks:       []domain.Task{preempted},
+			wantRelease: []int64{3},
+		},
+		{
+			name:        "先结束运行中的执行记录，再按重新读取的版本号释放",
+			tasks:       []domain.Task{preempted, updated},
+			executions:  []domain.TaskExecution{{ID: 1, Status: domain.TaskExecutionStatusRunning, ExecutorNodeID: "node-a"}},
+			wantFailed:  []int64{1},
+			wantRelease: []int64{4},
+		},
+		{
+			name:       "完成事件的消费者已经释放任务",
+			tasks:      []domain.Task{preempted, released},
+			executions: []domain.TaskExecution{{ID: 1, Status: domain.TaskExecutionStatusRunning}},
+			wantFailed: []int64{1},
+		},
+		{
+			name:       "执行记录已经自行结束",
+			tasks:      []domain.Task{preempted, updated},
+			executions: []domain.TaskExecution{{ID: 1, Status: domain.TaskExecutionStatusRunning}},
+			updateErr:  errs.ErrInvalidTaskExecutionStatus,
+			// 执行记录自行结束，同样重新读取版本号后释放
+			wantRelease: []int64{4},
+		},
+		{
+			name:       "结束执行记录失败时不释放",
+			tasks:      []domain.Task{preempted},
+			executions: []domain.TaskExecution{{ID: 1, Status: domain.TaskExecutionStatusRunning}},
+			updateErr:  errors.New("数据库不可用"),
+			wantErr:    errors.New("数据库不可用"),
+		},
+		{
+			name:    "任务没有被抢占",
+			tasks:   []domain.Task{released},
+			wantErr: errs.ErrTaskNotPreempted,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			execSvc := &fakeExecutionService{executions: tc.executions, err: tc.updateErr}
+			acq := &fakeAcquirer{}
+			svc := NewService(nil, &fakeTaskRepo{tasks: tc.tasks}, execSvc, acq, nil)
+
+			err := svc.ForceRelease(context.Background(), 10)
+			switch {
+			case tc.wantErr == nil:
+				require.NoError(t, err)
+			case errors.Is(tc.wantErr, errs.ErrTaskNotPreempted):
+				assert.ErrorIs(t, err, tc.wantErr)
+			default:
+				assert.ErrorContains(t, err, tc.wantErr.Error())
+			}
+			assert.Equal(t, tc.wantFailed, execSvc.failed)
+			assert.Equal(t, tc.wantRelease, acq.versions)
+		})
+	}
+}
+
+// fakeTaskRepo 按顺序返回每次读取到的任务，读完后一直返回最后一个
+type fakeTaskRepo struct {
+	repository.TaskRepository
+	tasks []domain.Task
+}
+
+func (r *fakeTaskRepo) GetByID(_ context.Context, _ int64) (domain.Task, error) {
+	t := r.tasks[0]
+	if len(r.tasks) > 1 {
+		r.tasks = r.tasks[1:]
+	}
+	return t, nil
+}
+
+type fakeExecutionService struct {
+	task.ExecutionService
+	executions []domain.TaskExecution
+	err        error
+	failed     []int64
+}
+
+func (s *fakeExecutionService) FindActiveByTaskID(_ context.Context, _ int64) ([]domain.TaskExecution, error) {
+	return s.executions, nil
+}
+
+func (s *fakeExecutionService) UpdateState(_ context.Context, state domain.ExecutionState) error {
+	if s.err != nil {
+		return s.err
+	}
+	if state.Status.IsFailed() && state.Result.ErrorCategory == ForceReleasedCategory {
+		s.failed = append(s.failed, state.ID)
+	}
+	return nil
+}
+
+type fakeAcquirer struct {
+	acquirer.TaskAcquirer
+	versions []int64
+}
+
+func (a *fakeAcquirer) Release(_ context.Context, _, version int64, scheduleNodeID string) error {
+	if scheduleNodeID != "scheduler-1" {
+		return errs.ErrTaskReleaseFailed
+	}
+	a.versions = append(a.versions, version)
+	return nil
+}
@@ -0,0 +1,139 @@
+package deadletter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Duke1616/ework-runner/internal/domain"
+	"github.com/Duke1616/ework-runner/internal/errs"
+	"github.com/Duke1616/ework-runner/internal/repository"
+	"github.com/Duke1616/ework-runner/internal/service/hook"
+	"github.com/Duke1616/ework-runner/internal/service/task"
+	"github.com/gotomicro/ego/core/elog"
+)
+
+// Service 死信服务：记录最终失败的执行、按任务的告警配置发送告警，并支持从死信重新发起重试
+type Service interface {
+	// Record 将最终失败的执行记录写入死信并按任务的告警配置发送告警
+	// 同一执行记录的同一次失败重复写入时返回已有死信，不修改死信状态，也不会重复告警
+	Record(ctx context.Context, executionID int64) (domain.DeadLetter, error)
+	// List 按创建时间倒序分页查询死信，同时返回总数，status 为空时查询全部
+	List(ctx context.Context, status domain.DeadLetterStatus, offset, limit int) ([]domain.DeadLetter, int64, error)
+	// Retry 将死信对应的执行记录重新放回重试队列，由重试补偿器重新发起，只有待处理的死信可以重试
+	Retry(ctx context.Context, id int64) error
+}
+
+type service struct {
+	repo    repository.DeadLetterRepository
+	execSvc task.ExecutionService
+	taskSvc task.Service
+	hookSvc hook.Service // 告警与生命周期事件共用投递通道，投递结果记录在投递记录中，失败按重试策略重试
+	logger  *elog.Component
+}
+
+func NewService(
+	repo repository.DeadLetterRepository,
+	execSvc task.ExecutionService,
+	taskSvc task.Service,
+	hookSvc hook.Service,
+) Service {
+	return &service{
+		repo:    repo,
+		execSvc: execSvc,
+		taskSvc: taskSvc,
+		hookSvc: hookSvc,
+		logger:  elog.DefaultLogger.With(elog.FieldComponentName("service.deadletter")),
+	}
+}
+
+func (s *service) Record(ctx context.Context, executionID int64) (domain.DeadLetter, error) {
+	execution, err := s.execSvc.FindByID(ctx, executionID)
+	if err != nil {
+		return domain.DeadLetter{}, fmt.Errorf("%w: ID=%d, %w", errs.ErrExecutionNotFound, executionID, err)
+	}
+
+	letter, created, err := s.repo.Create(ctx, domain.DeadLetter{
+		ExecutionID: execution.ID,
+		Attempt:     execution.Attempts,
+		TaskID:      execution.Task.ID,
+		TaskName:    execution.Task.Name,
+		Execution:   execution,
+		Status:      domain.DeadLetterStatusPending,
+	})
+	if err != nil {
+		return domain.DeadLetter{}, fmt.Errorf("写入死信失败: %w", err)
+	}
+	if !created {
+		// 重复写入同一条死信，已经告警过
+		return letter, nil
+	}
+
+	s.logger.Warn("执行最终失败，已写入死信",
+		elog.Int64("deadLetterID", letter.ID),
+		elog.Int64("executionID", execution.ID),
+		elog.Int64("taskID", execution.Task.ID),
+		elog.String("taskName", execution.Task.Name),
+		elog.String("errorMessage", execution.Result.ErrorMessage))
+	return letter, s.alert(ctx, letter)
+}
+
+// alert 按任务当前的告警配置写入投递记录，投递异步进行，投递结果和重试记录在投递记录中
+func (s *service) alert(ctx context.Context, letter domain.DeadLetter) error {
+	t, err := s.taskSvc.GetByID(ctx, letter.TaskID)
+	if err != nil {
+		return fmt.Errorf("查询任务告警配置失败: %w", err)
+	}
+	if t.AlertConfig.IsEmpty() {
+		return nil
+	}
+
+	payload, err := json.Marshal(newAlert(letter))
+	if err != nil {
+		return fmt.Errorf("序列化告警失败: %w", err)
+	}
+	err = s.hookSvc.Deliver(ctx, domain.HookDelivery{
+		ExecutionID: letter.ExecutionID,
+		Attempt:     letter.Attempt,
+		TaskID:      letter.TaskID,
+		TaskName:    letter.TaskName,
+		Event:       domain.HookEventDeadLetter,
+		Payload:     payload,
+	}, t.AlertConfig.Targets())
+	if err != nil {
+		return fmt.Errorf("发送告警失败: %w", err)
+	}
+	return nil
+}
+
+func (s *service) List(ctx context.Context, status domain.DeadLetterStatus, offset, limit int) ([]domain.DeadLetter, int64, error) {
+	return s.repo.List(ctx, status, offset, limit)
+}
+
+func (s *service) Retry(ctx context.Context, id int64) error {
+	letter, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("%w: ID=%d, %w", errs.ErrDeadLetterNotFound, id, err)
+	}
+	if letter.Status != domain.DeadLetterStatusPending {
+		return fmt.Errorf("%w: ID=%d, status=%s", errs.ErrDeadLetterAlreadyRetried, id, letter.Status)
+	}
+
+	// 执行记录以 CAS 方式从 FAILED 放回重试队列，并发重试同一条死信时只有一次成功
+	if err = s.execSvc.RequeueFailed(ctx, letter.ExecutionID); err != nil {
+		return err
+	}
+	ok, err := s.repo.CASStatus(ctx, id, domain.DeadLetterStatusPending, domain.DeadLetterStatusRetried)
+	if err != nil || !ok {
+		// 执行记录已经放回重试队列，死信状态更新失败只影响展示
+		s.logger.Error("更新死信状态失败",
+			elog.Int64("deadLetterID", id),
+			elog.Any("updated", ok),
+			elog.FieldErr(err))
+	}
+	s.logger.Info("从死信重新发起重试",
+		elog.Int64("deadLetterID", id),
+		elog.Int64("executionID", letter.ExecutionID),
+		elog.String("taskName", letter.TaskName))
+	return nil
+}
@@ -0,0 +1,176 @@
+//go:build unit
+
+package deadletter
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/Duke1616/ework-runner/internal/domain"
+	"github.com/Duke1616/ework-runner/internal/errs"
+	"github.com/Duke1616/ework-runner/internal/repository"
+	"github.com/Duke1616/ework-runner/internal/service/hook"
+	"github.com/Duke1616/ework-runner/internal/service/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_RecordAndRetry(t *testing.T) {
+	t.Parallel()
+
+	execution := domain.TaskExecution{
+		ID:             1,
+		Status:         domain.TaskExecutionStatusFailed,
+		ExecutorNodeID: "node-a",
+		RetryCount:     3,
+		Attempts:       4,
+		Result:         domain.ExecutionResult{ErrorMessage: "下游服务不可用", ErrorCategory: "HANDLER_ERROR"},
+		Task:           domain.Task{ID: 10, Name: "nightly-report"},
+	}
+	execSvc := &fakeExecutionService{execution: execution}
+	taskSvc := &fakeTaskService{task: domain.Task{
+		ID:   10,
+		Name: "nightly-report",
+		AlertConfig: &domain.AlertConfig{
+			Webhooks: []string{"http://alert.example.com"},
+			Emails:   []string{"ops@example.com"},
+		},
+	}}
+	repo := &memDeadLetterRepo{}
+	hookSvc := &fakeHookService{}
+	svc := NewService(repo, execSvc, taskSvc, hookSvc)
+	ctx := context.Background()
+
+	// 1. 写入死信并按任务配置投递告警
+	letter, err := svc.Record(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, domain.DeadLetterStatusPending, letter.Status)
+	assert.Equal(t, int64(4), letter.Attempt)
+	assert.Equal(t, execution, letter.Execution)
+
+	require.Len(t, hookSvc.deliveries, 1)
+	delivery := hookSvc.deliveries[0]
+	assert.Equal(t, domain.HookEventDeadLetter, delivery.Event)
+	assert.Equal(t, int64(4), delivery.Attempt)
+	assert.Equal(t, []domain.HookTarget{
+		{Channel: domain.HookChannelWebhook, Target: "http://alert.example.com"},
+		{Channel: domain.HookChannelEmail, Target: "ops@example.com"},
+	}, hookSvc.targets[0])
+	var alert Alert
+	require.NoError(t, json.Unmarshal(delivery.Payload, &alert))
+	assert.Equal(t, letter.ID, alert.DeadLetterID)
+	assert.Equal(t, "nightly-report", alert.TaskName)
+	assert.Equal(t, "下游服务不可用", alert.ErrorMessage)
+	assert.Equal(t, int64(4), alert.Attempts)
+
+	// 2. 从死信重试：执行记录放回重试队列，死信标记为已重试，不允许再次重试
+	require.NoError(t, svc.Retry(ctx, letter.ID))
+	assert.Equal(t, []int64{1}, execSvc.requeued)
+	assert.ErrorIs(t, svc.Retry(ctx, letter.ID), errs.ErrDeadLetterAlreadyRetried)
+
+	// 3. 同一次失败重复写入：不新增死信，不重置已重试的状态，不重复告警
+	_, err = svc.Record(ctx, 1)
+	require.NoError(t, err)
+	letters, total, err := svc.List(ctx, "", 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, domain.DeadLetterStatusRetried, letters[0].Status)
+	assert.Len(t, hookSvc.deliveries, 1)
+
+	// 4. 重试后再次失败是新的一次失败，写入新的死信并告警
+	execSvc.execution.Attempts = 5
+	letter, err = svc.Record(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, domain.DeadLetterStatusPending, letter.Status)
+	_, total, err = svc.List(ctx, "", 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Len(t, hookSvc.deliveries, 2)
+}
+
+type fakeExecutionService struct {
+	task.ExecutionService
+	execution domain.TaskExecution
+	requeued  []int64
+}
+
+func (s *fakeExecutionService) FindByID(_ context.Context, _ int64) (domain.TaskExecution, error) {
+	return s.execution, nil
+}
+
+func (s *fakeExecutionService) RequeueFailed(_ context.Context, id int64) error {
+	s.requeued = append(s.requeued, id)
+	return nil
+}
+
+type fakeTaskService struct {
+	task.Service
+	task domain.Task
+}
+
+func (s *fakeTaskService) GetByID(_ context.Context, _ int64) (domain.Task, error) {
+	return s.task, nil
+}
+
+type fakeHookService struct {
+	hook.Service
+	deliveries []domain.HookDelivery
+	targets    [][]domain.HookTarget
+}
+
+func (s *fakeHookService) Deliver(_ context.Context, delivery domain.HookDelivery, targets []domain.HookTarget) error {
+	s.deliveries = append(s.deliveries, delivery)
+	s.targets = append(s.targets, targets)
+	return nil
+}
+
+// memDeadLetterRepo 基于内存的死信仓储，按执行记录ID和尝试次数去重
+type memDeadLetterRepo struct {
+	repository.DeadLetterRepository
+
+	mu      sync.Mutex
+	letters []domain.DeadLetter
+}
+
+func (r *memDeadLetterRepo) Create(_ context.Context, letter domain.DeadLetter) (domain.DeadLetter, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, l := range r.letters {
+		if l.ExecutionID == letter.ExecutionID && l.Attempt == letter.Attempt {
+			return l, false, nil
+		}
+	}
+	letter.ID = int64(len(r.letters) + 1)
+	r.letters = append(r.letters, letter)
+	return letter, true, nil
+}
+
+func (r *memDeadLetterRepo) GetByID(_ context.Context, id int64) (domain.DeadLetter, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.letters[id-1], nil
+}
+
+func (r *memDeadLetterRepo) List(_ context.Context, status domain.DeadLetterStatus, _, _ int) ([]domain.DeadLetter, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var letters []domain.DeadLetter
+	for _, l := range r.letters {
+		if status == "" || l.Status == status {
+			letters = append(letters, l)
+		}
+	}
+	return letters, int64(len(letters)), nil
+}
+
+func (r *memDeadLetterRepo) CASStatus(_ context.Context, id int64, from, to domain.DeadLetterStatus) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.letters[id-1].Status != from {
+		return false, nil
+	}
+	r.letters[id-1].Status = to
+	return true, nil
+}
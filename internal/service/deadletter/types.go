@@ -0,0 +1,44 @@
+package deadletter
+
+import (
+	"github.com/Duke1616/ework-runner/internal/domain"
+)
+
+// Alert 执行最终失败的告警内容，作为 DEAD_LETTER 事件的消息体通过生命周期事件的投递通道发送
+type Alert struct {
+	DeliveryID     int64    `json:"delivery_id"` // 投递记录ID，由投递通道填充，重试投递时不变
+	DeadLetterID   int64    `json:"dead_letter_id"`
+	ExecutionID    int64    `json:"execution_id"`
+	TaskID         int64    `json:"task_id"`
+	TaskName       string   `json:"task_name"`
+	ExecutorNodeID string   `json:"executor_node_id"`
+	FailedNodeIDs  []string `json:"failed_node_ids"`
+	RetryCount     int64    `json:"retry_count"`
+	Attempts       int64    `json:"attempts"`
+	ErrorMessage   string   `json:"error_message"`
+	ErrorCode      string   `json:"error_code"`
+	ErrorCategory  string   `json:"error_category"`
+	ExitCode       int32    `json:"exit_code"`
+	StartTime      int64    `json:"start_time"`
+	EndTime        int64    `json:"end_time"`
+}
+
+func newAlert(letter domain.DeadLetter) Alert {
+	execution := letter.Execution
+	return Alert{
+		DeadLetterID:   letter.ID,
+		ExecutionID:    letter.ExecutionID,
+		TaskID:         letter.TaskID,
+		TaskName:       letter.TaskName,
+		ExecutorNodeID: execution.ExecutorNodeID,
+		FailedNodeIDs:  execution.FailedNodeIDs,
+		RetryCount:     execution.RetryCount,
+		Attempts:       execution.Attempts,
+		ErrorMessage:   execution.Result.ErrorMessage,
+		ErrorCode:      execution.Result.ErrorCode,
+		ErrorCategory:  execution.Result.ErrorCategory,
+		ExitCode:       execution.Result.ExitCode,
+		StartTime:      execution.StartTime,
+		EndTime:        execution.EndTime,
+	}
+}
@@ -0,0 +1,55 @@
+package hook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/Duke1616/ework-runner/internal/domain"
+)
+
+var _ Sender = &CallbackSender{}
+
+// CallbackSender 调用进程内按名称注册的回调，回调返回错误或 panic 时视为投递失败
+type CallbackSender struct {
+	mu        sync.RWMutex
+	callbacks map[string]Callback
+}
+
+func NewCallbackSender() *CallbackSender {
+	return &CallbackSender{
+		callbacks: make(map[string]Callback),
+	}
+}
+
+// Register 注册回调，同名回调会被覆盖
+func (s *CallbackSender) Register(name string, callback Callback) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.callbacks[name] = callback
+}
+
+func (s *CallbackSender) Channel() domain.HookChannel {
+	return domain.HookChannelCallback
+}
+
+func (s *CallbackSender) Send(ctx context.Context, target domain.HookTarget, msg Message) (err error) {
+	s.mu.RLock()
+	callback, ok := s.callbacks[target.Target]
+	s.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("回调 %s 未注册", target.Target)
+	}
+	var evt Event
+	if err = json.Unmarshal(msg.Payload, &evt); err != nil {
+		return fmt.Errorf("反序列化事件失败: %w", err)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("回调 %s panic: %v", target.Target, r)
+		}
+	}()
+	return callback.OnEvent(ctx, evt)
+}
@@ -0,0 +1,93 @@
+package hook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/smtp"
+	"strings"
+
+	"github.com/Duke1616/ework-runner/internal/domain"
+)
+
+var _ Sender = &EmailSender{}
+
+// SMTPConfig 发送邮件的 SMTP 配置，Username 为空时不做认证，可以直接对接本地的 SMTP 服务（如 MailHog）
+type SMTPConfig struct {
+	Addr     string `yaml:"addr"` // host:port
+	From     string `yaml:"from"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+// EmailSender 通过 SMTP 发送邮件，每个收件人是一个单独的投递目标
+// 主题按 RFC 2047 编码，任务名称等内容不会被当作邮件头解析；正文为格式化后的 JSON 消息
+type EmailSender struct {
+	cfg SMTPConfig
+}
+
+func NewEmailSender(cfg SMTPConfig) *EmailSender {
+	return &EmailSender{cfg: cfg}
+}
+
+func (s *EmailSender) Channel() domain.HookChannel {
+	return domain.HookChannelEmail
+}
+
+func (s *EmailSender) Send(_ context.Context, target domain.HookTarget, msg Message) error {
+	if s.cfg.Addr == "" {
+		return fmt.Errorf("未配置 SMTP 服务地址，无法发送邮件")
+	}
+	to, err := mail.ParseAddress(target.Target)
+	if err != nil {
+		return fmt.Errorf("收件人地址 %q 不合法: %w", target.Target, err)
+	}
+	body, err := s.message(to.Address, msg)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if s.cfg.Username != "" {
+		host := s.cfg.Addr
+		if i := strings.LastIndex(host, ":"); i > 0 {
+			host = host[:i]
+		}
+		auth = smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, host)
+	}
+	return smtp.SendMail(s.cfg.Addr, auth, s.cfg.From, []string{to.Address}, body)
+}
+
+func (s *EmailSender) message(to string, msg Message) ([]byte, error) {
+	var content bytes.Buffer
+	if err := json.Indent(&content, msg.Payload, "", "  "); err != nil {
+		return nil, fmt.Errorf("格式化邮件正文失败: %w", err)
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", s.cfg.From)
+	fmt.Fprintf(&b, "To: %s\r\n", to)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("UTF-8", subject(msg)))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+	w := quotedprintable.NewWriter(&b)
+	if _, err := w.Write(content.Bytes()); err != nil {
+		return nil, fmt.Errorf("编码邮件正文失败: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("编码邮件正文失败: %w", err)
+	}
+	return b.Bytes(), nil
+}
+
+func subject(msg Message) string {
+	if msg.Event == domain.HookEventDeadLetter {
+		return fmt.Sprintf("[ework-runner] 任务 %s 执行失败", msg.TaskName)
+	}
+	return fmt.Sprintf("[ework-runner] 任务 %s 事件 %s", msg.TaskName, msg.Event)
+}
@@ -0,0 +1,36 @@
+//go:build unit
+
+package hook
+
+import (
+	"mime"
+	"net/mail"
+	"strings"
+	"testing"
+
+	"github.com/Duke1616/ework-runner/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 任务名称中的换行不能注入邮件头，主题按 RFC 2047 编码
+func TestEmailSender_Message(t *testing.T) {
+	t.Parallel()
+
+	s := NewEmailSender(SMTPConfig{Addr: "localhost:1025", From: "ework@example.com"})
+	body, err := s.message("ops@example.com", Message{
+		DeliveryID: 1,
+		Event:      domain.HookEventDeadLetter,
+		TaskName:   "nightly\r\nBcc: attacker@example.com",
+		Payload:    []byte(`{"task_name":"nightly","error_message":"下游服务不可用"}`),
+	})
+	require.NoError(t, err)
+
+	msg, err := mail.ReadMessage(strings.NewReader(string(body)))
+	require.NoError(t, err)
+	assert.Empty(t, msg.Header.Get("Bcc"))
+	assert.Equal(t, []string{"ops@example.com"}, msg.Header["To"])
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, "[ework-runner] 任务 nightly\r\nBcc: attacker@example.com 执行失败", subject)
+}
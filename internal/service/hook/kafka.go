@@ -0,0 +1,34 @@
+package hook
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/Duke1616/ework-runner/internal/domain"
+	mqx "github.com/Duke1616/ework-runner/pkg/mpx"
+	"github.com/ecodeclub/mq-api"
+)
+
+var _ Sender = &KafkaSender{}
+
+// KafkaSender 将消息原样发送到目标 topic，首次使用某个 topic 时创建生产者
+type KafkaSender struct {
+	producer *mqx.MultipleProducer[json.RawMessage]
+}
+
+func NewKafkaSender(q mq.MQ) *KafkaSender {
+	return &KafkaSender{
+		producer: mqx.NewMultipleProducer[json.RawMessage](q),
+	}
+}
+
+func (s *KafkaSender) Channel() domain.HookChannel {
+	return domain.HookChannelKafka
+}
+
+func (s *KafkaSender) Send(ctx context.Context, target domain.HookTarget, msg Message) error {
+	if err := s.producer.EnsureProducer(target.Target); err != nil {
+		return err
+	}
+	return s.producer.Produce(ctx, target.Target, msg.Payload)
+}
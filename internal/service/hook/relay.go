@@ -0,0 +1,63 @@
+package hook
+
+import (
+	"context"
+	"time"
+
+	"github.com/gotomicro/ego/core/elog"
+)
+
+// RelayConfig 投递中继配置
+type RelayConfig struct {
+	BatchSize   int           `yaml:"batchSize"`   // 批量处理大小
+	MinDuration time.Duration `yaml:"minDuration"` // 最小等待时间，防止空转
+}
+
+// Relay 投递中继，按投递记录重新投递失败待重试、或认领节点宕机的生命周期事件
+// 多个调度节点可以同时运行，由 Service.RedeliverDue 以 CAS 方式认领投递记录
+type Relay struct {
+	svc    Service
+	config RelayConfig
+	logger *elog.Component
+}
+
+// NewRelay 创建投递中继
+func NewRelay(svc Service, config RelayConfig) *Relay {
+	return &Relay{
+		svc:    svc,
+		config: config,
+		logger: elog.DefaultLogger.With(elog.FieldComponentName("service.hook.relay")),
+	}
+}
+
+// Start 启动中继
+func (r *Relay) Start(ctx context.Context) {
+	r.logger.Info("生命周期事件投递中继启动")
+
+	for {
+		select {
+		case <-ctx.Done():
+			r.logger.Info("生命周期事件投递中继停止")
+			return
+		default:
+			startTime := time.Now()
+
+			count, err := r.svc.RedeliverDue(ctx, r.config.BatchSize)
+			if err != nil {
+				r.logger.Error("重新投递生命周期事件失败", elog.FieldErr(err))
+			} else if count > 0 {
+				r.logger.Info("重新投递生命周期事件", elog.Int("count", count))
+			}
+
+			// 防空转：确保最小等待时间
+			elapsed := time.Since(startTime)
+			if elapsed < r.config.MinDuration {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(r.config.MinDuration - elapsed):
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,285 @@
+package hook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Duke1616/ework-runner/internal/domain"
+	"github.com/Duke1616/ework-runner/internal/repository"
+	"github.com/Duke1616/ework-runner/pkg/retry/strategy"
+	"github.com/gotomicro/ego/core/elog"
+	"go.uber.org/multierr"
+)
+
+// deliveryLease 认领投递记录后独占的时间，远大于单次投递的超时时间，超过后其他节点可以重新认领
+const deliveryLease = time.Minute
+
+type service struct {
+	taskRepo  repository.TaskRepository
+	repo      repository.HookDeliveryRepository
+	retry     strategy.Strategy
+	senders   map[domain.HookChannel]Sender
+	callbacks *CallbackSender
+	logger    *elog.Component
+}
+
+// NewService 创建生命周期事件服务，retry 为单个目标投递失败后的重试策略，进程内回调通道默认启用
+func NewService(
+	taskRepo repository.TaskRepository,
+	repo repository.HookDeliveryRepository,
+	retry strategy.Strategy,
+	senders ...Sender,
+) Service {
+	callbacks := NewCallbackSender()
+	svc := &service{
+		taskRepo:  taskRepo,
+		repo:      repo,
+		retry:     retry,
+		senders:   map[domain.HookChannel]Sender{callbacks.Channel(): callbacks},
+		callbacks: callbacks,
+		logger:    elog.DefaultLogger.With(elog.FieldComponentName("service.hook")),
+	}
+	for _, sender := range senders {
+		svc.senders[sender.Channel()] = sender
+	}
+	return svc
+}
+
+func (s *service) RegisterCallback(name string, callback Callback) {
+	s.callbacks.Register(name, callback)
+}
+
+func (s *service) FindDeliveries(ctx context.Context, executionID int64) ([]domain.HookDelivery, error) {
+	return s.repo.FindByExecutionID(ctx, executionID)
+}
+
+func (s *service) Publish(ctx context.Context, typ domain.HookEventType, execution domain.TaskExecution) {
+	// 投递不阻塞状态迁移
+	go s.publish(context.WithoutCancel(ctx), typ, execution)
+}
+
+func (s *service) publish(ctx context.Context, typ domain.HookEventType, execution domain.TaskExecution) {
+	// 订阅配置以任务当前配置为准
+	task, err := s.taskRepo.GetByID(ctx, execution.Task.ID)
+	if err != nil {
+		s.logger.Error("查询任务订阅配置失败",
+			elog.Int64("taskID", execution.Task.ID),
+			elog.String("event", typ.String()),
+			elog.FieldErr(err))
+		return
+	}
+	s.publishTo(ctx, task.HookConfig, typ, execution)
+}
+
+// publishTo 按订阅配置写入投递记录并投递
+func (s *service) publishTo(ctx context.Context, cfg *domain.HookConfig, typ domain.HookEventType, execution domain.TaskExecution) {
+	if !cfg.Subscribed(typ) {
+		return
+	}
+	if typ == domain.HookEventLongRunning && !s.isLongRunning(cfg, execution) {
+		return
+	}
+
+	attempt := execution.Attempts
+	if typ == domain.HookEventSuccess || typ == domain.HookEventFailure {
+		// 执行的最终结果与尝试无关
+		attempt = 0
+	}
+	payload, err := json.Marshal(newEvent(typ, execution))
+	if err != nil {
+		s.logger.Error("序列化生命周期事件失败",
+			elog.Int64("executionID", execution.ID),
+			elog.String("event", typ.String()),
+			elog.FieldErr(err))
+		return
+	}
+	if err = s.Deliver(ctx, domain.HookDelivery{
+		ExecutionID: execution.ID,
+		Attempt:     attempt,
+		TaskID:      execution.Task.ID,
+		TaskName:    execution.Task.Name,
+		Event:       typ,
+		Payload:     payload,
+	}, cfg.Targets()); err != nil {
+		s.logger.Error("投递生命周期事件失败",
+			elog.Int64("executionID", execution.ID),
+			elog.String("event", typ.String()),
+			elog.FieldErr(err))
+	}
+}
+
+func (s *service) CheckLongRunning(ctx context.Context, executions []domain.TaskExecution) error {
+	// 同一任务的执行记录共用一次订阅配置查询
+	configs := make(map[int64]*domain.HookConfig)
+	var errs error
+	for _, execution := range executions {
+		cfg, ok := configs[execution.Task.ID]
+		if !ok {
+			task, err := s.taskRepo.GetByID(ctx, execution.Task.ID)
+			if err != nil {
+				errs = multierr.Append(errs, fmt.Errorf("查询任务 %d 的订阅配置失败: %w", execution.Task.ID, err))
+				continue
+			}
+			cfg = task.HookConfig
+			configs[execution.Task.ID] = cfg
+		}
+		s.publishTo(ctx, cfg, domain.HookEventLongRunning, execution)
+	}
+	return errs
+}
+
+func (s *service) isLongRunning(cfg *domain.HookConfig, execution domain.TaskExecution) bool {
+	threshold := cfg.LongRunningThreshold(execution.Task.MaxExecutionSeconds)
+	if threshold <= 0 || execution.StartTime <= 0 {
+		return false
+	}
+	return time.Since(time.UnixMilli(execution.StartTime)) >= threshold
+}
+
+func (s *service) Deliver(ctx context.Context, delivery domain.HookDelivery, targets []domain.HookTarget) error {
+	ctx = context.WithoutCancel(ctx)
+	var errs error
+	for _, target := range targets {
+		d := delivery
+		d.Channel = target.Channel
+		d.Target = target.Target
+		d.Status = domain.HookDeliveryStatusPending
+		// 创建即由当前节点认领，当前节点在租约内宕机时由投递中继接手
+		d.NextRetryTime = time.Now().Add(deliveryLease).UnixMilli()
+		created, ok, err := s.repo.Create(ctx, d)
+		if err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("创建投递记录失败 %s %s: %w", target.Channel, target.Target, err))
+			continue
+		}
+		if !ok {
+			// 已经投递过
+			continue
+		}
+		go s.deliver(ctx, created, target)
+	}
+	return errs
+}
+
+func (s *service) RedeliverDue(ctx context.Context, limit int) (int, error) {
+	now := time.Now()
+	deliveries, err := s.repo.FindDue(ctx, now.UnixMilli(), limit)
+	if err != nil {
+		return 0, fmt.Errorf("查找待重新投递的记录失败: %w", err)
+	}
+	for _, delivery := range deliveries {
+		claimed, err1 := s.repo.Claim(ctx, delivery.ID, delivery.NextRetryTime, now.Add(deliveryLease).UnixMilli())
+		if err1 != nil {
+			s.logger.Error("认领投递记录失败",
+				elog.Int64("deliveryID", delivery.ID),
+				elog.FieldErr(err1))
+			continue
+		}
+		if !claimed {
+			// 已经被其他节点认领
+			continue
+		}
+		target, err1 := s.target(ctx, delivery)
+		if err1 != nil {
+			s.fail(ctx, delivery, delivery.Tries+1, err1)
+			continue
+		}
+		s.deliver(ctx, delivery, target)
+	}
+	return len(deliveries), nil
+}
+
+// target 还原投递目标，WEBHOOK 的签名密钥不落库，按任务当前的订阅配置查找
+func (s *service) target(ctx context.Context, delivery domain.HookDelivery) (domain.HookTarget, error) {
+	target := domain.HookTarget{Channel: delivery.Channel, Target: delivery.Target}
+	if delivery.Channel != domain.HookChannelWebhook || delivery.Event == domain.HookEventDeadLetter {
+		return target, nil
+	}
+	task, err := s.taskRepo.GetByID(ctx, delivery.TaskID)
+	if err != nil {
+		return domain.HookTarget{}, fmt.Errorf("查询任务订阅配置失败: %w", err)
+	}
+	for _, t := range task.HookConfig.Targets() {
+		if t.Channel == target.Channel && t.Target == target.Target {
+			target.Secret = t.Secret
+			break
+		}
+	}
+	return target, nil
+}
+
+// deliver 投递一次，结果记录到投递记录上；失败时按重试策略计算下次投递时间，由投递中继到期后重新投递
+func (s *service) deliver(ctx context.Context, delivery domain.HookDelivery, target domain.HookTarget) {
+	tries := delivery.Tries + 1
+	sender, ok := s.senders[target.Channel]
+	if !ok {
+		s.finish(ctx, delivery, domain.HookDeliveryStatusFailed, tries, 0,
+			fmt.Errorf("不支持的投递通道 %s", target.Channel))
+		return
+	}
+	msg, err := newMessage(delivery)
+	if err != nil {
+		// 消息无法编码时重试也不会成功
+		s.finish(ctx, delivery, domain.HookDeliveryStatusFailed, tries, 0, err)
+		return
+	}
+
+	if err = sender.Send(ctx, target, msg); err != nil {
+		s.fail(ctx, delivery, tries, err)
+		return
+	}
+	s.finish(ctx, delivery, domain.HookDeliveryStatusSuccess, tries, 0, nil)
+}
+
+// fail 记录一次投递失败，重试次数用尽时结束投递
+func (s *service) fail(ctx context.Context, delivery domain.HookDelivery, tries int32, err error) {
+	interval, shouldRetry := s.retry.NextWithRetries(tries)
+	if !shouldRetry {
+		s.finish(ctx, delivery, domain.HookDeliveryStatusFailed, tries, 0, err)
+		return
+	}
+	s.finish(ctx, delivery, domain.HookDeliveryStatusPending, tries, time.Now().Add(interval).UnixMilli(), err)
+}
+
+// newMessage 在消息中写入投递记录ID，重试投递时不变，订阅方可据此去重
+func newMessage(delivery domain.HookDelivery) (Message, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(delivery.Payload, &fields); err != nil {
+		return Message{}, fmt.Errorf("解析投递内容失败: %w", err)
+	}
+	fields["delivery_id"] = json.RawMessage(strconv.FormatInt(delivery.ID, 10))
+	payload, err := json.Marshal(fields)
+	if err != nil {
+		return Message{}, fmt.Errorf("序列化投递内容失败: %w", err)
+	}
+	return Message{
+		DeliveryID: delivery.ID,
+		Event:      delivery.Event,
+		TaskName:   delivery.TaskName,
+		Payload:    payload,
+	}, nil
+}
+
+func (s *service) finish(ctx context.Context, delivery domain.HookDelivery, status domain.HookDeliveryStatus,
+	tries int32, nextRetryTime int64, err error) {
+	var lastError string
+	if err != nil {
+		lastError = err.Error()
+		s.logger.Warn("投递生命周期事件失败",
+			elog.Int64("deliveryID", delivery.ID),
+			elog.Int64("executionID", delivery.ExecutionID),
+			elog.String("event", delivery.Event.String()),
+			elog.String("channel", delivery.Channel.String()),
+			elog.String("target", delivery.Target),
+			elog.Any("tries", tries),
+			elog.String("status", status.String()),
+			elog.FieldErr(err))
+	}
+	if err1 := s.repo.UpdateResult(ctx, delivery.ID, status, tries, lastError, nextRetryTime); err1 != nil {
+		s.logger.Error("更新投递记录失败",
+			elog.Int64("deliveryID", delivery.ID),
+			elog.FieldErr(err1))
+	}
+}
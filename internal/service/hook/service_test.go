@@ -0,0 +1,277 @@
+//go:build unit
+
+package hook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Duke1616/ework-runner/internal/domain"
+	"github.com/Duke1616/ework-runner/internal/repository"
+	"github.com/Duke1616/ework-runner/pkg/retry/strategy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_Publish(t *testing.T) {
+	t.Parallel()
+
+	// webhook 第一次返回 500，重试后成功
+	var calls atomic.Int32
+	events := make(chan Event, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, Sign("secret", r.Header.Get(HeaderTimestamp), body), r.Header.Get(HeaderSignature))
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		var evt Event
+		assert.NoError(t, json.Unmarshal(body, &evt))
+		events <- evt
+	}))
+	t.Cleanup(server.Close)
+
+	taskRepo := &fakeTaskRepo{task: domain.Task{
+		ID:                  10,
+		Name:                "nightly-report",
+		MaxExecutionSeconds: 100,
+		HookConfig: &domain.HookConfig{
+			Events:    []domain.HookEventType{domain.HookEventStart, domain.HookEventLongRunning},
+			Webhooks:  []domain.WebhookTarget{{URL: server.URL, Secret: "secret"}},
+			Callbacks: []string{"audit"},
+		},
+	}}
+	repo := newMemDeliveryRepo()
+	svc := NewService(taskRepo, repo, strategy.NewFixedIntervalRetryStrategy(time.Millisecond, 3),
+		NewWebhookSender(time.Second))
+
+	callbackEvents := make(chan Event, 4)
+	svc.RegisterCallback("audit", CallbackFunc(func(_ context.Context, evt Event) error {
+		callbackEvents <- evt
+		return nil
+	}))
+
+	execution := domain.TaskExecution{
+		ID:             1,
+		Attempts:       1,
+		Status:         domain.TaskExecutionStatusRunning,
+		ExecutorNodeID: "node-a",
+		StartTime:      time.Now().UnixMilli(),
+		Task:           domain.Task{ID: 10, Name: "nightly-report", MaxExecutionSeconds: 100},
+	}
+	ctx := context.Background()
+
+	// 1. 开始事件投递到 webhook 和回调，webhook 失败后由投递记录重新投递成功，签名密钥按任务配置还原
+	svc.Publish(ctx, domain.HookEventStart, execution)
+	assert.Equal(t, domain.HookEventStart, (<-callbackEvents).Type)
+	require.Eventually(t, func() bool {
+		deliveries := repo.find(1)
+		return len(deliveries) == 2 && deliveries[0].Tries == 1
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, domain.HookDeliveryStatusPending, repo.find(1)[0].Status)
+
+	require.Eventually(t, func() bool {
+		_, err := svc.RedeliverDue(ctx, 10)
+		require.NoError(t, err)
+		deliveries := repo.find(1)
+		return deliveries[0].Status == domain.HookDeliveryStatusSuccess &&
+			deliveries[1].Status == domain.HookDeliveryStatusSuccess
+	}, time.Second, 5*time.Millisecond)
+	select {
+	case evt := <-events:
+		assert.Equal(t, domain.HookEventStart, evt.Type)
+		assert.Equal(t, int64(1), evt.DeliveryID)
+		assert.Equal(t, int64(1), evt.ExecutionID)
+		assert.Equal(t, "node-a", evt.ExecutorNodeID)
+	case <-time.After(time.Second):
+		require.FailNow(t, "等待 webhook 超时")
+	}
+	deliveries := repo.find(1)
+	assert.Equal(t, int32(2), deliveries[0].Tries)
+	assert.Equal(t, int32(1), deliveries[1].Tries)
+
+	// 2. 同一尝试重复发布不会重复投递
+	svc.Publish(ctx, domain.HookEventStart, execution)
+	// 3. 未订阅的事件不投递，未达到长时间运行阈值时不投递
+	svc.Publish(ctx, domain.HookEventSuccess, execution)
+	require.NoError(t, svc.CheckLongRunning(ctx, []domain.TaskExecution{execution}))
+
+	// 4. 运行时间超过 MaxExecutionSeconds 的 80% 后投递长时间运行事件，同一尝试重复检查只投递一次
+	execution.StartTime = time.Now().Add(-81 * time.Second).UnixMilli()
+	require.NoError(t, svc.CheckLongRunning(ctx, []domain.TaskExecution{execution}))
+	require.NoError(t, svc.CheckLongRunning(ctx, []domain.TaskExecution{execution}))
+	select {
+	case evt := <-events:
+		assert.Equal(t, domain.HookEventLongRunning, evt.Type)
+	case <-time.After(time.Second):
+		require.FailNow(t, "等待 webhook 超时")
+	}
+	assert.Equal(t, domain.HookEventLongRunning, (<-callbackEvents).Type)
+	require.Eventually(t, func() bool {
+		return len(repo.find(1)) == 4
+	}, time.Second, 5*time.Millisecond)
+	assert.Empty(t, events, "重复或未订阅的事件不应投递")
+	assert.Empty(t, callbackEvents, "重复或未订阅的事件不应投递")
+}
+
+func TestService_DeliveryExhausted(t *testing.T) {
+	t.Parallel()
+
+	taskRepo := &fakeTaskRepo{task: domain.Task{
+		ID:         10,
+		HookConfig: &domain.HookConfig{Callbacks: []string{"missing"}},
+	}}
+	repo := newMemDeliveryRepo()
+	svc := NewService(taskRepo, repo, strategy.NewFixedIntervalRetryStrategy(time.Millisecond, 2))
+
+	ctx := context.Background()
+	svc.Publish(ctx, domain.HookEventFailure, domain.TaskExecution{ID: 1, Attempts: 3, Task: domain.Task{ID: 10}})
+	require.Eventually(t, func() bool {
+		_, err := svc.RedeliverDue(ctx, 10)
+		require.NoError(t, err)
+		deliveries := repo.find(1)
+		return len(deliveries) == 1 && deliveries[0].Status == domain.HookDeliveryStatusFailed
+	}, time.Second, 5*time.Millisecond)
+
+	delivery := repo.find(1)[0]
+	assert.Equal(t, int32(3), delivery.Tries)
+	assert.Equal(t, int64(0), delivery.Attempt, "最终结果事件与尝试无关")
+	assert.Contains(t, delivery.LastError, "未注册")
+}
+
+func TestService_RedeliverDue(t *testing.T) {
+	t.Parallel()
+
+	taskRepo := &fakeTaskRepo{task: domain.Task{ID: 10, HookConfig: &domain.HookConfig{Callbacks: []string{"audit"}}}}
+	repo := newMemDeliveryRepo()
+	svc := NewService(taskRepo, repo, strategy.NewFixedIntervalRetryStrategy(time.Millisecond, 3))
+	var calls atomic.Int32
+	svc.RegisterCallback("audit", CallbackFunc(func(_ context.Context, _ Event) error {
+		calls.Add(1)
+		return nil
+	}))
+
+	payload, err := json.Marshal(newEvent(domain.HookEventStart, domain.TaskExecution{ID: 1, Task: domain.Task{ID: 10}}))
+	require.NoError(t, err)
+	now := time.Now()
+	// 创建后节点宕机、租约已经到期的投递记录，以及仍在其他节点租约内的投递记录
+	for i, next := range []time.Time{now.Add(-time.Second), now.Add(time.Minute)} {
+		_, _, err = repo.Create(context.Background(), domain.HookDelivery{
+			ExecutionID:   1,
+			Attempt:       int64(i + 1),
+			TaskID:        10,
+			Event:         domain.HookEventStart,
+			Channel:       domain.HookChannelCallback,
+			Target:        "audit",
+			Status:        domain.HookDeliveryStatusPending,
+			Payload:       payload,
+			NextRetryTime: next.UnixMilli(),
+		})
+		require.NoError(t, err)
+	}
+
+	count, err := svc.RedeliverDue(context.Background(), 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, int32(1), calls.Load())
+	deliveries := repo.find(1)
+	assert.Equal(t, domain.HookDeliveryStatusSuccess, deliveries[0].Status)
+	assert.Equal(t, domain.HookDeliveryStatusPending, deliveries[1].Status)
+
+	// 已经投递成功的记录不会再被重新投递
+	count, err = svc.RedeliverDue(context.Background(), 10)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+type fakeTaskRepo struct {
+	repository.TaskRepository
+	task domain.Task
+}
+
+func (r *fakeTaskRepo) GetByID(_ context.Context, _ int64) (domain.Task, error) {
+	return r.task, nil
+}
+
+// memDeliveryRepo 基于内存的投递记录仓储，按执行记录、尝试、事件和目标去重
+type memDeliveryRepo struct {
+	mu         sync.Mutex
+	deliveries []domain.HookDelivery
+}
+
+func newMemDeliveryRepo() *memDeliveryRepo {
+	return &memDeliveryRepo{}
+}
+
+func (r *memDeliveryRepo) Create(_ context.Context, delivery domain.HookDelivery) (domain.HookDelivery, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, d := range r.deliveries {
+		if d.ExecutionID == delivery.ExecutionID && d.Attempt == delivery.Attempt &&
+			d.Event == delivery.Event && d.Channel == delivery.Channel && d.Target == delivery.Target {
+			return d, false, nil
+		}
+	}
+	delivery.ID = int64(len(r.deliveries) + 1)
+	r.deliveries = append(r.deliveries, delivery)
+	return delivery, true, nil
+}
+
+func (r *memDeliveryRepo) UpdateResult(_ context.Context, id int64, status domain.HookDeliveryStatus, tries int32, lastError string, nextRetryTime int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deliveries[id-1].Status = status
+	r.deliveries[id-1].Tries = tries
+	r.deliveries[id-1].LastError = lastError
+	r.deliveries[id-1].NextRetryTime = nextRetryTime
+	return nil
+}
+
+func (r *memDeliveryRepo) FindDue(_ context.Context, now int64, limit int) ([]domain.HookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var deliveries []domain.HookDelivery
+	for _, d := range r.deliveries {
+		if len(deliveries) < limit && d.Status == domain.HookDeliveryStatusPending && d.NextRetryTime <= now {
+			deliveries = append(deliveries, d)
+		}
+	}
+	return deliveries, nil
+}
+
+func (r *memDeliveryRepo) Claim(_ context.Context, id, nextRetryTime, newNextRetryTime int64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	d := &r.deliveries[id-1]
+	if d.Status != domain.HookDeliveryStatusPending || d.NextRetryTime != nextRetryTime {
+		return false, nil
+	}
+	d.NextRetryTime = newNextRetryTime
+	return true, nil
+}
+
+func (r *memDeliveryRepo) FindByExecutionID(_ context.Context, executionID int64) ([]domain.HookDelivery, error) {
+	return r.find(executionID), nil
+}
+
+func (r *memDeliveryRepo) find(executionID int64) []domain.HookDelivery {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var deliveries []domain.HookDelivery
+	for _, d := range r.deliveries {
+		if d.ExecutionID == executionID {
+			deliveries = append(deliveries, d)
+		}
+	}
+	return deliveries
+}
@@ -0,0 +1,91 @@
+package hook
+
+import (
+	"context"
+	"time"
+
+	"github.com/Duke1616/ework-runner/internal/domain"
+)
+
+// Service 执行生命周期事件服务：按任务的订阅配置投递事件，投递失败后按重试策略推迟下次投递时间，
+// 由投递中继（Relay）从投递记录中重新投递，调度节点重启后未完成的投递同样会继续
+type Service interface {
+	// Publish 异步发布生命周期事件，execution 需要是状态迁移后的执行记录
+	// 同一执行记录的同一次尝试、同一事件对同一目标只投递一次，重复发布会被忽略
+	Publish(ctx context.Context, typ domain.HookEventType, execution domain.TaskExecution)
+	// Deliver 向调用方指定的目标投递已经编码好的消息，不检查订阅配置，用于死信告警
+	// delivery 为投递记录模板，需要填充执行记录、尝试、任务、事件和 Payload；投递记录同步写入，投递异步进行，
+	// 与 Publish 一样同一执行记录的同一次尝试、同一事件对同一目标只投递一次
+	Deliver(ctx context.Context, delivery domain.HookDelivery, targets []domain.HookTarget) error
+	// RedeliverDue 重新投递到达下次投递时间的投递记录，返回本轮查询到的数量
+	// 多个调度节点可以同时调用，投递前以 CAS 方式推迟下次投递时间来认领，同一条记录同一时间只有一个节点投递
+	RedeliverDue(ctx context.Context, limit int) (int, error)
+	// CheckLongRunning 检查运行中的执行记录，运行时间超过订阅配置的阈值时发布长时间运行事件，每次尝试只发布一次
+	CheckLongRunning(ctx context.Context, executions []domain.TaskExecution) error
+	// RegisterCallback 注册进程内回调，任务通过 HookConfig.Callbacks 按名称订阅
+	RegisterCallback(name string, callback Callback)
+	// FindDeliveries 查询执行记录的所有投递记录
+	FindDeliveries(ctx context.Context, executionID int64) ([]domain.HookDelivery, error)
+}
+
+// Sender 一种投递通道，生命周期事件和死信告警共用
+type Sender interface {
+	Channel() domain.HookChannel
+	Send(ctx context.Context, target domain.HookTarget, msg Message) error
+}
+
+// Message 一次投递的内容
+type Message struct {
+	DeliveryID int64
+	Event      domain.HookEventType
+	TaskName   string
+	Payload    []byte // JSON 编码的事件或告警，已经写入 delivery_id
+}
+
+// Callback 进程内回调
+type Callback interface {
+	OnEvent(ctx context.Context, evt Event) error
+}
+
+// CallbackFunc 函数形式的进程内回调
+type CallbackFunc func(ctx context.Context, evt Event) error
+
+func (f CallbackFunc) OnEvent(ctx context.Context, evt Event) error {
+	return f(ctx, evt)
+}
+
+// Event 投递给订阅方的生命周期事件
+type Event struct {
+	DeliveryID      int64                `json:"delivery_id"` // 投递记录ID，重试投递时不变，订阅方可据此去重
+	Type            domain.HookEventType `json:"type"`
+	ExecutionID     int64                `json:"execution_id"`
+	Attempt         int64                `json:"attempt"`
+	TaskID          int64                `json:"task_id"`
+	TaskName        string               `json:"task_name"`
+	Status          string               `json:"status"`
+	ExecutorNodeID  string               `json:"executor_node_id"`
+	RunningProgress int32                `json:"running_progress"`
+	StartTime       int64                `json:"start_time"`
+	Deadline        int64                `json:"deadline"`
+	ErrorMessage    string               `json:"error_message"`
+	ErrorCategory   string               `json:"error_category"`
+	Timestamp       int64                `json:"timestamp"`
+}
+
+func newEvent(typ domain.HookEventType, execution domain.TaskExecution) Event {
+	return Event{
+		Type:            typ,
+		ExecutionID:     execution.ID,
+		Attempt:         execution.Attempts,
+		TaskID:          execution.Task.ID,
+		TaskName:        execution.Task.Name,
+		Status:          execution.Status.String(),
+		ExecutorNodeID:  execution.ExecutorNodeID,
+		RunningProgress: execution.RunningProgress,
+		StartTime:       execution.StartTime,
+		Deadline:        execution.Deadline,
+		ErrorMessage:    execution.Result.ErrorMessage,
+		ErrorCategory:   execution.Result.ErrorCategory,
+		Timestamp:       time.Now().UnixMilli(),
+	}
+}
@@ -0,0 +1,76 @@
+package hook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Duke1616/ework-runner/internal/domain"
+)
+
+const (
+	HeaderEvent     = "X-Ework-Event"
+	HeaderDelivery  = "X-Ework-Delivery"
+	HeaderTimestamp = "X-Ework-Timestamp"
+	HeaderSignature = "X-Ework-Signature"
+)
+
+var _ Sender = &WebhookSender{}
+
+// WebhookSender 以 POST JSON 的方式投递事件，响应状态码不是 2xx 时视为失败
+// 配置了 Secret 时，X-Ework-Signature 为 "sha256=" 加上对 "时间戳.请求体" 的 HMAC-SHA256 十六进制签名，
+// 时间戳即 X-Ework-Timestamp（毫秒），订阅方可据此校验来源并拒绝重放
+type WebhookSender struct {
+	client *http.Client
+}
+
+func NewWebhookSender(timeout time.Duration) *WebhookSender {
+	return &WebhookSender{
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (s *WebhookSender) Channel() domain.HookChannel {
+	return domain.HookChannelWebhook
+}
+
+func (s *WebhookSender) Send(ctx context.Context, target domain.HookTarget, msg Message) error {
+	body := msg.Payload
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target.Target, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("创建回调请求失败: %w", err)
+	}
+	timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, msg.Event.String())
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(msg.DeliveryID, 10))
+	req.Header.Set(HeaderTimestamp, timestamp)
+	if target.Secret != "" {
+		req.Header.Set(HeaderSignature, Sign(target.Secret, timestamp, body))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("发送回调请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("回调响应状态码 %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Sign 计算回调请求的签名，订阅方用同样的方式计算后与 X-Ework-Signature 比较
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -2,8 +2,13 @@ package invoker
 
 import (
 	"context"
+	"time"
 
 	"github.com/Duke1616/ework-runner/internal/domain"
+	"github.com/Duke1616/ework-runner/internal/metrics"
+	"github.com/Duke1616/ework-runner/pkg/tracex"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var _ Invoker = &Dispatcher{}
@@ -27,27 +32,30 @@ func (r *Dispatcher) Name() string {
 }
 
 func (r *Dispatcher) Run(ctx context.Context, execution domain.TaskExecution) (domain.ExecutionState, error) {
-	// 根据配置发送执行请求
-	switch {
-	case execution.Task.GrpcConfig != nil:
-		return r.grpc.Run(ctx, execution)
-	case execution.Task.HTTPConfig != nil:
-		return r.http.Run(ctx, execution)
-	default:
-		// 都没有就假定是本地的了
-		return r.local.Run(ctx, execution)
-	}
+	inv := r.invoker(execution)
+	ctx, span := tracex.Start(ctx, "Invoker.Run", trace.WithAttributes(
+		attribute.String("ework.invoker", inv.Name()),
+		attribute.Int64("ework.execution_id", execution.ID)))
+	start := time.Now()
+	state, err := inv.Run(ctx, execution)
+	metrics.InvokeDuration.Observe(time.Since(start).Seconds(), inv.Name(), metrics.Result(err))
+	tracex.End(span, err)
+	return state, err
 }
 
 func (r *Dispatcher) Prepare(ctx context.Context, execution domain.TaskExecution) (map[string]string, error) {
-	// 根据配置发送执行请求
+	return r.invoker(execution).Prepare(ctx, execution)
+}
+
+// invoker 根据配置选择发送执行请求的 Invoker
+func (r *Dispatcher) invoker(execution domain.TaskExecution) Invoker {
 	switch {
 	case execution.Task.GrpcConfig != nil:
-		return r.grpc.Prepare(ctx, execution)
+		return r.grpc
 	case execution.Task.HTTPConfig != nil:
-		return r.http.Prepare(ctx, execution)
+		return r.http
 	default:
 		// 都没有就假定是本地的了
-		return r.local.Prepare(ctx, execution)
+		return r.local
 	}
 }
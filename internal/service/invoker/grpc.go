@@ -7,11 +7,15 @@ import (
 	executorv1 "github.com/Duke1616/ework-runner/api/proto/gen/executor/v1"
 	"github.com/Duke1616/ework-runner/internal/domain"
 	"github.com/Duke1616/ework-runner/pkg/grpc"
+	"github.com/Duke1616/ework-runner/pkg/grpc/balancer"
 	"github.com/gotomicro/ego/core/elog"
 )
 
 var _ Invoker = &GRPCInvoker{}
 
+// maxRejectedAttempts 执行节点拒绝（繁忙、排空中）时，最多尝试的执行节点数量
+const maxRejectedAttempts = 3
+
 // GRPCInvoker 远程执行器
 type GRPCInvoker struct {
 	grpcClients *grpc.Clients[executorv1.ExecutorServiceClient] // gRPC客户端池
@@ -32,21 +36,38 @@ func (r *GRPCInvoker) Name() string {
 	return "GRPC"
 }
 
+// Run 发送执行请求，执行节点拒绝时排除该节点后转移到其他执行节点，
+// 全部尝试都被拒绝时返回最后一次的可重调度状态，交由重调度补偿任务稍后处理
 func (r *GRPCInvoker) Run(ctx context.Context, exec domain.TaskExecution) (domain.ExecutionState, error) {
 	client := r.grpcClients.Get(exec.Task.GrpcConfig.ServiceName)
-
-	// 发送执行请求
-	resp, err := client.Execute(ctx, &executorv1.ExecuteRequest{
+	req := &executorv1.ExecuteRequest{
 		Eid:             exec.ID,
 		TaskId:          exec.Task.ID,
 		TaskName:        exec.Task.Name,
 		TaskHandlerName: exec.Task.GrpcConfig.HandlerName,
 		Params:          exec.GRPCParams(),
-	})
-	if err != nil {
-		return domain.ExecutionState{}, fmt.Errorf("发送gRPC请求失败: %w", err)
+		Attempt:         exec.Attempts,
+	}
+
+	var state domain.ExecutionState
+	for i := 0; i < maxRejectedAttempts; i++ {
+		// 发送执行请求
+		resp, err := client.Execute(ctx, req)
+		if err != nil {
+			return domain.ExecutionState{}, fmt.Errorf("发送gRPC请求失败: %w", err)
+		}
+		state = domain.ExecutionStateFromProto(resp.GetExecutionState())
+		if !state.IsRejected() {
+			return state, nil
+		}
+
+		r.logger.Warn("执行节点拒绝执行，尝试其他执行节点",
+			elog.Int64("executionId", exec.ID),
+			elog.String("nodeId", state.ExecutorNodeID),
+			elog.String("reason", state.RejectReason))
+		ctx = balancer.WithExcludedNodeID(balancer.WithoutSpecificNodeID(ctx), state.ExecutorNodeID)
 	}
-	return domain.ExecutionStateFromProto(resp.GetExecutionState()), nil
+	return state, nil
 }
 
 func (r *GRPCInvoker) Prepare(ctx context.Context, exec domain.TaskExecution) (map[string]string, error) {
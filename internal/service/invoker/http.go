@@ -11,6 +11,8 @@ import (
 
 	"github.com/Duke1616/ework-runner/internal/domain"
 	"github.com/gotomicro/ego/core/elog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
 )
 
 var _ Invoker = &HTTPInvoker{}
@@ -60,6 +62,8 @@ func (i *HTTPInvoker) Run(ctx context.Context, exec domain.TaskExecution) (domai
 		return domain.ExecutionState{}, fmt.Errorf("创建HTTP请求失败: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
+	// 通过 traceparent 请求头传递链路上下文
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
 
 	// 发送POST请求到执行节点
 	resp, err := i.client.Do(req)
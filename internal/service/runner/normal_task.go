@@ -2,20 +2,31 @@ package runner
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/Duke1616/ework-runner/internal/domain"
+	"github.com/Duke1616/ework-runner/internal/errs"
 	"github.com/Duke1616/ework-runner/internal/event"
+	"github.com/Duke1616/ework-runner/internal/metrics"
 	"github.com/Duke1616/ework-runner/internal/service/acquirer"
 	"github.com/Duke1616/ework-runner/internal/service/invoker"
 	"github.com/Duke1616/ework-runner/internal/service/task"
 	"github.com/Duke1616/ework-runner/pkg/grpc/balancer"
+	"github.com/Duke1616/ework-runner/pkg/tracex"
 	"github.com/gotomicro/ego/core/elog"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 var _ Runner = &NormalTaskRunner{}
 
+// DispatchFailedCategory 调用执行节点失败时记录的错误分类
+const DispatchFailedCategory = "DISPATCH_FAILED"
+
 type NormalTaskRunner struct {
 	nodeID       string                 // 当前调度节点ID
 	taskSvc      task.Service           // 任务服务
@@ -46,7 +57,12 @@ func NewNormalTaskRunner(
 	}
 }
 
-func (s *NormalTaskRunner) Run(ctx context.Context, task domain.Task) error {
+func (s *NormalTaskRunner) Run(ctx context.Context, task domain.Task) (err error) {
+	ctx, span := tracex.Start(ctx, "NormalTaskRunner.Run")
+	defer func() {
+		tracex.End(span, err)
+	}()
+
 	// 抢占任务
 	acquiredTask, err := s.acquireTask(ctx, task)
 	if err != nil {
@@ -65,8 +81,15 @@ func (s *NormalTaskRunner) acquireTask(ctx context.Context, task domain.Task) (d
 	// 抢占任务
 	acquiredTask, err := s.taskAcquirer.Acquire(ctx, task.ID, task.Version, s.nodeID)
 	if err != nil {
+		if errors.Is(err, errs.ErrTaskPreemptFailed) {
+			// 任务已经被其他调度节点抢占
+			metrics.AcquireTotal.Inc(metrics.ResultConflict)
+		} else {
+			metrics.AcquireTotal.Inc(metrics.ResultFailed)
+		}
 		return domain.Task{}, fmt.Errorf("任务抢占失败: %w", err)
 	}
+	metrics.AcquireTotal.Inc(metrics.ResultSuccess)
 	// 抢占成功
 	return acquiredTask, nil
 }
@@ -78,6 +101,8 @@ func (s *NormalTaskRunner) handleNormalTask(ctx context.Context, task domain.Tas
 		// 可以认为开始执行了，防止执行节点直接返回"终态"状态Failed，Success等
 		StartTime: time.Now().UnixMilli(),
 		Status:    domain.TaskExecutionStatusPrepare,
+		// 保存链路上下文，之后的重试、重调度、上报和完成事件都归入这条链路
+		TraceContext: tracex.Inject(ctx),
 	})
 	if err != nil {
 		s.logger.Error("创建任务执行记录失败",
@@ -88,13 +113,30 @@ func (s *NormalTaskRunner) handleNormalTask(ctx context.Context, task domain.Tas
 		s.releaseTask(ctx, task)
 		return err
 	}
+	// 尝试记录只用于查看历史，记录失败不影响首次执行
+	attempt, err := s.execSvc.StartAttempt(ctx, execution.ID, domain.AttemptKindRun, "")
+	if err != nil {
+		s.logger.Error("记录首次执行尝试失败",
+			elog.Int64("executionID", execution.ID),
+			elog.FieldErr(err))
+	}
+	// 尝试序号随执行请求下发，执行节点上报时原样回传
+	execution.Attempts = attempt
 
 	// 抢占和创建都成功，异步触发任务
 	go func() {
+		ctx, span := s.startDispatch(ctx, execution, domain.AttemptKindRun)
+		var err1 error
+		defer func() {
+			tracex.End(span, err1)
+		}()
+
 		// 执行任务
 		state, err1 := s.invoker.Run(ctx, execution)
+		metrics.DispatchTotal.Inc(domain.AttemptKindRun.String(), metrics.Result(err1))
 		if err1 != nil {
-			s.logger.Error("执行器执行任务失败", elog.FieldErr(err))
+			s.logger.Error("执行器执行任务失败", elog.FieldErr(err1))
+			s.dispatchFailed(ctx, execution, err1)
 			return
 		}
 
@@ -109,9 +151,40 @@ func (s *NormalTaskRunner) handleNormalTask(ctx context.Context, task domain.Tas
 	return nil
 }
 
+// startDispatch 为一次异步分发创建 span，覆盖调用执行节点和处理返回状态的全过程
+// 重试、重调度由补偿任务触发，调用方需要先从执行记录中恢复链路上下文
+func (s *NormalTaskRunner) startDispatch(ctx context.Context, execution domain.TaskExecution, kind domain.AttemptKind) (context.Context, trace.Span) {
+	return tracex.Start(ctx, "NormalTaskRunner.Dispatch", trace.WithAttributes(
+		attribute.Int64("ework.execution_id", execution.ID),
+		attribute.Int64("ework.task_id", execution.Task.ID),
+		attribute.String("ework.attempt_kind", kind.String())))
+}
+
+// dispatchFailed 调用执行节点失败时立即按可重试失败写回执行记录，由重试补偿器按任务的重试配置重新分发；
+// 没有配置重试或者重试次数用尽时直接失败，由完成事件释放任务。执行记录因此不会停留在 PREPARE 状态
+func (s *NormalTaskRunner) dispatchFailed(ctx context.Context, execution domain.TaskExecution, cause error) {
+	err := s.execSvc.UpdateState(ctx, domain.ExecutionState{
+		ID:       execution.ID,
+		TaskID:   execution.Task.ID,
+		TaskName: execution.Task.Name,
+		Status:   domain.TaskExecutionStatusFailedRetryable,
+		Attempt:  execution.Attempts,
+		Result: domain.ExecutionResult{
+			ErrorMessage:  fmt.Sprintf("调用执行节点失败：%s", cause),
+			ErrorCategory: DispatchFailedCategory,
+		},
+	})
+	if err != nil {
+		s.logger.Error("调用执行节点失败后更新执行记录失败",
+			elog.Int64("executionID", execution.ID),
+			elog.String("taskName", execution.Task.Name),
+			elog.FieldErr(err))
+	}
+}
+
 // releaseTask 释放任务
 func (s *NormalTaskRunner) releaseTask(ctx context.Context, task domain.Task) {
-	if err := s.taskAcquirer.Release(ctx, task.ID, s.nodeID); err != nil {
+	if err := s.taskAcquirer.Release(ctx, task.ID, task.Version, s.nodeID); err != nil {
 		s.logger.Error("释放任务失败",
 			elog.Int64("taskID", task.ID),
 			elog.String("taskName", task.Name),
@@ -128,12 +201,27 @@ func (s *NormalTaskRunner) WithSpecificNodeIDContext(ctx context.Context, execut
 
 // Retry 重试
 func (s *NormalTaskRunner) Retry(ctx context.Context, execution domain.TaskExecution) error {
+	// 先记录尝试并累加重试次数，失败时本轮不重试，等待下一轮补偿
+	attempt, err := s.execSvc.StartAttempt(ctx, execution.ID, domain.AttemptKindRetry, "")
+	if err != nil {
+		return fmt.Errorf("记录重试尝试失败: %w", err)
+	}
+	execution.Attempts = attempt
+
 	// 抢占和创建都成功，异步触发任务
 	go func() {
-		// 执行任务，并在 context 中设置要排除的执行节点 ID，避免重调度到同一个节点
-		state, err1 := s.invoker.Run(s.WithExcludedNodeIDContext(ctx, execution.ExecutorNodeID), execution)
+		ctx, span := s.startDispatch(tracex.Extract(ctx, execution.TraceContext), execution, domain.AttemptKindRetry)
+		var err1 error
+		defer func() {
+			tracex.End(span, err1)
+		}()
+
+		// 执行任务，并在 context 中设置要排除的执行节点 ID 列表，避免重试到失败过的节点
+		state, err1 := s.invoker.Run(s.WithExcludedNodeIDsContext(ctx, execution.ExcludedNodeIDs()), execution)
+		metrics.DispatchTotal.Inc(domain.AttemptKindRetry.String(), metrics.Result(err1))
 		if err1 != nil {
 			s.logger.Error("执行器执行任务失败", elog.FieldErr(err1))
+			s.dispatchFailed(ctx, execution, err1)
 			return
 		}
 
@@ -148,21 +236,43 @@ func (s *NormalTaskRunner) Retry(ctx context.Context, execution domain.TaskExecu
 	return nil
 }
 
-func (s *NormalTaskRunner) WithExcludedNodeIDContext(ctx context.Context, executorNodeID string) context.Context {
-	if executorNodeID != "" {
-		return balancer.WithExcludedNodeID(ctx, executorNodeID)
+func (s *NormalTaskRunner) WithExcludedNodeIDsContext(ctx context.Context, executorNodeIDs []string) context.Context {
+	if len(executorNodeIDs) > 0 {
+		return balancer.WithExcludedNodeIDs(ctx, executorNodeIDs...)
 	}
 	return ctx
 }
 
 // Reschedule 重新调度
 func (s *NormalTaskRunner) Reschedule(ctx context.Context, execution domain.TaskExecution) error {
+	attempt, err := s.execSvc.StartAttempt(ctx, execution.ID, domain.AttemptKindReschedule, execution.ExecutorNodeID)
+	if err != nil {
+		return fmt.Errorf("记录重调度尝试失败: %w", err)
+	}
+	execution.Attempts = attempt
+
 	// 抢占和创建都成功，异步触发任务
 	go func() {
+		ctx, span := s.startDispatch(tracex.Extract(ctx, execution.TraceContext), execution, domain.AttemptKindReschedule)
+		var err1 error
+		defer func() {
+			tracex.End(span, err1)
+		}()
+
 		// 执行任务，并在 context 中设置要指定的执行节点ID
 		state, err1 := s.invoker.Run(s.WithSpecificNodeIDContext(ctx, execution.ExecutorNodeID), execution)
+		if err1 != nil && execution.ExecutorNodeID != "" && status.Code(err1) == codes.Unavailable {
+			// 指定节点已下线（如滚动发布排空），将任务转移到其他执行节点
+			s.logger.Warn("指定的执行节点不可用，转移到其他执行节点",
+				elog.Int64("executionID", execution.ID),
+				elog.String("executorNodeID", execution.ExecutorNodeID),
+				elog.FieldErr(err1))
+			state, err1 = s.invoker.Run(s.WithExcludedNodeIDsContext(ctx, execution.ExcludedNodeIDs()), execution)
+		}
+		metrics.DispatchTotal.Inc(domain.AttemptKindReschedule.String(), metrics.Result(err1))
 		if err1 != nil {
 			s.logger.Error("执行器执行任务失败", elog.FieldErr(err1))
+			s.dispatchFailed(ctx, execution, err1)
 			return
 		}
 
@@ -0,0 +1,164 @@
+//go:build unit
+
+package runner
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Duke1616/ework-runner/internal/domain"
+	"github.com/Duke1616/ework-runner/internal/errs"
+	"github.com/Duke1616/ework-runner/internal/service/task"
+	"github.com/Duke1616/ework-runner/internal/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 可重试失败完整链路：UpdateState → updateRetryState → FindRetryableExecutions → NormalTaskRunner.Retry
+func TestNormalTaskRunner_RetryableFailure(t *testing.T) {
+	t.Parallel()
+
+	repo := test.NewMemExecutionRepo(domain.TaskExecution{
+		ID:             1,
+		Status:         domain.TaskExecutionStatusRunning,
+		ExecutorNodeID: "node-a",
+		Attempts:       1,
+		Task: domain.Task{
+			ID:   10,
+			Name: "sync-user",
+			RetryConfig: &domain.RetryConfig{
+				MaxRetries:      2,
+				InitialInterval: 1,
+				MaxInterval:     1,
+			},
+		},
+	})
+	producer := test.NewChanProducer()
+	execSvc := task.NewExecutionService("scheduler-1", repo, repo, nil, nil, producer, nil, nil, 0)
+	inv := &test.StubInvoker{State: domain.ExecutionState{
+		ID:             1,
+		Status:         domain.TaskExecutionStatusSuccess,
+		ExecutorNodeID: "node-b",
+	}}
+	runner := NewNormalTaskRunner("scheduler-1", nil, execSvc, nil, inv, producer)
+	ctx := context.Background()
+
+	repo.AddAttempts(domain.ExecutionAttempt{ExecutionID: 1, Attempt: 1, Kind: domain.AttemptKindRun})
+
+	// 1. 执行节点上报可重试失败，记录下次重试时间以及失败节点，重试次数在真正发起重试时才累加
+	err := execSvc.UpdateState(ctx, domain.ExecutionState{
+		ID:             1,
+		Status:         domain.TaskExecutionStatusFailedRetryable,
+		ExecutorNodeID: "node-a",
+		Result:         domain.ExecutionResult{ErrorMessage: "下游服务不可用", ErrorCategory: "HANDLER_ERROR"},
+	})
+	require.NoError(t, err)
+
+	execution := repo.Get(1)
+	assert.Equal(t, domain.TaskExecutionStatusFailedRetryable, execution.Status)
+	assert.Equal(t, int64(0), execution.RetryCount)
+	assert.Equal(t, []string{"node-a"}, execution.FailedNodeIDs)
+	assert.Equal(t, "HANDLER_ERROR", execution.Result.ErrorCategory)
+
+	// 2. 到达下次重试时间后可以被重试补偿器查询到
+	var executions []domain.TaskExecution
+	require.Eventually(t, func() bool {
+		executions, err = execSvc.FindRetryableExecutions(ctx, 10)
+		return err == nil && len(executions) == 1
+	}, time.Second, 5*time.Millisecond)
+
+	// 3. 重试时排除失败过的节点，重试成功后发送完成事件
+	require.NoError(t, runner.Retry(ctx, executions[0]))
+	evt := producer.Wait(t)
+	assert.Equal(t, int64(1), evt.ExecID)
+	assert.Equal(t, domain.TaskExecutionStatusSuccess, evt.ExecStatus)
+	assert.Equal(t, []string{"node-a"}, inv.ExcludedNodeIDs())
+	assert.Equal(t, int64(1), repo.Get(1).RetryCount)
+
+	// 4. 每次尝试各自保留执行节点、状态和错误
+	require.Eventually(t, func() bool {
+		attempts := repo.Attempts(1)
+		return len(attempts) == 2 && attempts[1].Status.IsSuccess()
+	}, time.Second, 5*time.Millisecond)
+	attempts := repo.Attempts(1)
+	assert.Equal(t, domain.ExecutionAttempt{
+		ExecutionID:    1,
+		Attempt:        1,
+		Kind:           domain.AttemptKindRun,
+		ExecutorNodeID: "node-a",
+		Status:         domain.TaskExecutionStatusFailedRetryable,
+		ErrorMessage:   "下游服务不可用",
+		ErrorCategory:  "HANDLER_ERROR",
+	}, withoutTime(attempts[0]))
+	assert.Equal(t, domain.ExecutionAttempt{
+		ExecutionID:    1,
+		Attempt:        2,
+		Kind:           domain.AttemptKindRetry,
+		ExecutorNodeID: "node-b",
+		Status:         domain.TaskExecutionStatusSuccess,
+	}, withoutTime(attempts[1]))
+}
+
+// 调用执行节点失败时结束当前尝试，没有执行节点参与的结束同样记录在尝试上
+func TestNormalTaskRunner_DispatchFailed(t *testing.T) {
+	t.Parallel()
+
+	repo := test.NewMemExecutionRepo(domain.TaskExecution{
+		ID:       1,
+		Status:   domain.TaskExecutionStatusFailedRetryable,
+		Attempts: 1,
+		Task: domain.Task{
+			ID:          10,
+			Name:        "sync-user",
+			RetryConfig: &domain.RetryConfig{MaxRetries: 3, InitialInterval: 1000, MaxInterval: 1000},
+		},
+	})
+	repo.AddAttempts(domain.ExecutionAttempt{ExecutionID: 1, Attempt: 1, Kind: domain.AttemptKindRun, EndTime: 1})
+	execSvc := task.NewExecutionService("scheduler-1", repo, repo, nil, nil, test.NewChanProducer(), nil, nil, 0)
+	inv := &test.StubInvoker{Err: errors.New("执行节点不可用")}
+	runner := NewNormalTaskRunner("scheduler-1", nil, execSvc, nil, inv, nil)
+
+	require.NoError(t, runner.Retry(context.Background(), repo.Get(1)))
+	require.Eventually(t, func() bool {
+		attempts := repo.Attempts(1)
+		return len(attempts) == 2 && attempts[1].EndTime > 0
+	}, time.Second, 5*time.Millisecond)
+	attempt := repo.Attempts(1)[1]
+	assert.Equal(t, domain.TaskExecutionStatusFailedRetryable, attempt.Status)
+	assert.Equal(t, DispatchFailedCategory, attempt.ErrorCategory)
+}
+
+// 尝试次数达到上限后不再分发，直接按 FAILED 结束并发送完成事件
+func TestNormalTaskRunner_MaxAttemptsExceeded(t *testing.T) {
+	t.Parallel()
+
+	repo := test.NewMemExecutionRepo(domain.TaskExecution{
+		ID:             1,
+		Status:         domain.TaskExecutionStatusFailedRescheduled,
+		ExecutorNodeID: "node-a",
+		Attempts:       3,
+		Task:           domain.Task{ID: 10, Name: "sync-user"},
+	})
+	producer := test.NewChanProducer()
+	execSvc := task.NewExecutionService("scheduler-1", repo, repo, nil, nil, producer, nil, nil, 3)
+	inv := &test.StubInvoker{}
+	runner := NewNormalTaskRunner("scheduler-1", nil, execSvc, nil, inv, producer)
+
+	err := runner.Reschedule(context.Background(), repo.Get(1))
+	assert.ErrorIs(t, err, errs.ErrExecutionMaxAttemptsExceeded)
+
+	evt := producer.Wait(t)
+	assert.Equal(t, domain.TaskExecutionStatusFailed, evt.ExecStatus)
+	execution := repo.Get(1)
+	assert.Equal(t, domain.TaskExecutionStatusFailed, execution.Status)
+	assert.Equal(t, int64(3), execution.Attempts)
+	assert.Equal(t, task.MaxAttemptsExceededCategory, execution.Result.ErrorCategory)
+}
+
+func withoutTime(attempt domain.ExecutionAttempt) domain.ExecutionAttempt {
+	attempt.StartTime = 0
+	attempt.EndTime = 0
+	return attempt
+}
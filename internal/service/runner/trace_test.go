@@ -0,0 +1,71 @@
+//go:build unit
+
+package runner
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Duke1616/ework-runner/internal/domain"
+	"github.com/Duke1616/ework-runner/internal/service/task"
+	"github.com/Duke1616/ework-runner/internal/test"
+	"github.com/Duke1616/ework-runner/pkg/tracex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// 重试由补偿任务触发，分发、状态处理和完成事件都要归入执行记录保存的链路
+// NOTE: 替换了全局 TracerProvider，不能并行执行
+func TestNormalTaskRunner_RetryJoinsExecutionTrace(t *testing.T) {
+	exporter, tp := tracex.SetupInMemory()
+	t.Cleanup(func() {
+		_ = tp.Shutdown(context.Background())
+	})
+
+	rootCtx, root := tracex.Start(context.Background(), "Scheduler.Schedule")
+	root.End()
+	traceID := root.SpanContext().TraceID()
+
+	repo := test.NewMemExecutionRepo(domain.TaskExecution{
+		ID:           1,
+		Status:       domain.TaskExecutionStatusFailedRetryable,
+		Task:         domain.Task{ID: 10, Name: "sync-user"},
+		TraceContext: tracex.Inject(rootCtx),
+	})
+	producer := test.NewChanProducer()
+	execSvc := task.NewExecutionService("scheduler-1", repo, repo, nil, nil, producer, nil, nil, 0)
+	inv := &test.StubInvoker{State: domain.ExecutionState{
+		ID:             1,
+		Status:         domain.TaskExecutionStatusSuccess,
+		ExecutorNodeID: "node-b",
+	}}
+	runner := NewNormalTaskRunner("scheduler-1", nil, execSvc, nil, inv, producer)
+
+	require.NoError(t, runner.Retry(context.Background(), repo.Get(1)))
+
+	// 完成事件携带链路上下文，消费者从消息头恢复后仍然在同一条链路上
+	evt := producer.Wait(t)
+	ctx := tracex.Extract(context.Background(), evt.TraceContext)
+	assert.Equal(t, traceID, trace.SpanContextFromContext(ctx).TraceID())
+
+	var spans tracetest.SpanStubs
+	require.Eventually(t, func() bool {
+		spans = exporter.GetSpans()
+		return len(spans) == 3
+	}, time.Second, 5*time.Millisecond)
+
+	names := make(map[string]tracetest.SpanStub, len(spans))
+	for _, span := range spans {
+		assert.Equal(t, traceID, span.SpanContext.TraceID(), span.Name)
+		names[span.Name] = span
+	}
+	dispatch, ok := names["NormalTaskRunner.Dispatch"]
+	require.True(t, ok)
+	assert.Equal(t, root.SpanContext().SpanID(), dispatch.Parent.SpanID())
+	updateState, ok := names["ExecutionService.UpdateState"]
+	require.True(t, ok)
+	assert.Equal(t, dispatch.SpanContext.SpanID(), updateState.Parent.SpanID())
+}
@@ -3,17 +3,23 @@ package scheduler
 import (
 	"context"
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	"github.com/Duke1616/ework-runner/internal/domain"
+	"github.com/Duke1616/ework-runner/internal/metrics"
 	"github.com/Duke1616/ework-runner/internal/service/acquirer"
+	"github.com/Duke1616/ework-runner/internal/service/cluster"
 	"github.com/Duke1616/ework-runner/internal/service/picker"
 	"github.com/Duke1616/ework-runner/internal/service/runner"
 	"github.com/Duke1616/ework-runner/internal/service/task"
 	"github.com/Duke1616/ework-runner/pkg/grpc/balancer"
+	"github.com/Duke1616/ework-runner/pkg/tracex"
 	"github.com/gotomicro/ego/core/constant"
 	"github.com/gotomicro/ego/core/elog"
 	"github.com/gotomicro/ego/server"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var _ server.Server = &Scheduler{}
@@ -27,9 +33,15 @@ type Scheduler struct {
 	acquirer           acquirer.TaskAcquirer     // 任务抢占、续约、释放器
 	config             Config                    // 配置
 	executorNodePicker picker.ExecutorNodePicker // 智能节点选择器
+	clusterSvc         cluster.Service           // 上报调度节点心跳
 	ctx                context.Context
 	cancel             context.CancelFunc
 	logger             *elog.Component
+
+	// 以下字段随心跳写入注册中心，用于观察调度节点是否健康
+	startTime        int64
+	lastRenewTime    atomic.Int64
+	lastScheduleTime atomic.Int64
 }
 
 // Config 调度器配置
@@ -50,6 +62,7 @@ func NewScheduler(
 	acquirer acquirer.TaskAcquirer,
 	config Config,
 	executorNodePicker picker.ExecutorNodePicker,
+	clusterSvc cluster.Service,
 ) *Scheduler {
 	ctx, cancel := context.WithCancel(context.Background())
 	return &Scheduler{
@@ -60,6 +73,7 @@ func NewScheduler(
 		acquirer:           acquirer,
 		config:             config,
 		executorNodePicker: executorNodePicker,
+		clusterSvc:         clusterSvc,
 		ctx:                ctx,
 		cancel:             cancel,
 		logger:             elog.DefaultLogger.With(elog.FieldComponentName("Scheduler")),
@@ -85,6 +99,8 @@ func (s *Scheduler) Init() error {
 // Start 启动调度器
 func (s *Scheduler) Start() error {
 	s.logger.Info("启动分布式任务调度器", elog.String("nodeID", s.nodeID))
+	s.startTime = time.Now().UnixMilli()
+	s.heartbeat()
 
 	// 启动调度循环
 	go s.scheduleLoop()
@@ -108,12 +124,15 @@ func (s *Scheduler) scheduleLoop() {
 		cancelFunc()
 		if err != nil {
 			s.logger.Error("获取可调度任务失败", elog.FieldErr(err))
+		} else {
+			metrics.ScheduleBatchSize.Observe(float64(len(tasks)))
 		}
 		// 没有可以调度的任务就睡一会
 		if len(tasks) == 0 {
 			s.logger.Info("没有可调度的任务")
 			// 睡眠一下
 			time.Sleep(s.config.ScheduleInterval)
+			s.lastScheduleTime.Store(time.Now().UnixMilli())
 			continue
 		}
 
@@ -121,7 +140,15 @@ func (s *Scheduler) scheduleLoop() {
 		// 开始调度
 		successCount := 0
 		for i := range tasks {
-			err1 := s.runner.Run(s.newContext(tasks[i]), tasks[i])
+			if tasks[i].NextTime > 0 {
+				metrics.ScheduleLag.Observe(metrics.SinceMilli(tasks[i].NextTime))
+			}
+			// 每次调度是一条链路的起点
+			ctx, span := tracex.Start(s.newContext(tasks[i]), "Scheduler.Schedule", trace.WithAttributes(
+				attribute.Int64("ework.task_id", tasks[i].ID),
+				attribute.String("ework.task_name", tasks[i].Name)))
+			err1 := s.runner.Run(ctx, tasks[i])
+			tracex.End(span, err1)
 			if err1 != nil {
 				s.logger.Error("调度任务失败",
 					elog.Int64("taskID", tasks[i].ID),
@@ -134,6 +161,7 @@ func (s *Scheduler) scheduleLoop() {
 		s.logger.Info("本次调度信息",
 			elog.Int("success", successCount),
 			elog.Int("total", len(tasks)))
+		s.lastScheduleTime.Store(time.Now().UnixMilli())
 
 	}
 }
@@ -166,22 +194,53 @@ func (s *Scheduler) renewLoop() {
 			err := s.acquirer.Renew(s.ctx, s.nodeID)
 			if err != nil {
 				s.logger.Error("批量续约失败", elog.FieldErr(err))
+			} else {
+				s.lastRenewTime.Store(time.Now().UnixMilli())
 			}
+			s.heartbeat()
 		}
 	}
 }
 
+// heartbeat 写入调度节点心跳，失败时只记录日志，等待下一次续约时重试
+func (s *Scheduler) heartbeat() {
+	err := s.clusterSvc.Heartbeat(s.ctx, domain.SchedulerNode{
+		NodeID:           s.nodeID,
+		StartTime:        s.startTime,
+		LastRenewTime:    s.lastRenewTime.Load(),
+		LastScheduleTime: s.lastScheduleTime.Load(),
+		ScheduleInterval: s.config.ScheduleInterval,
+		BatchTimeout:     s.config.BatchTimeout,
+		RenewInterval:    s.config.RenewInterval,
+	})
+	if err != nil {
+		s.logger.Error("写入调度节点心跳失败", elog.FieldErr(err))
+	}
+}
+
+// unregister 删除调度节点心跳
+func (s *Scheduler) unregister() {
+	const unregisterTimeout = 3 * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), unregisterTimeout)
+	defer cancel()
+	if err := s.clusterSvc.Unregister(ctx, s.nodeID); err != nil {
+		s.logger.Error("删除调度节点心跳失败", elog.FieldErr(err))
+	}
+}
+
 // Stop 停止调度器
 func (s *Scheduler) Stop() error {
 	s.logger.Info("停止分布式任务调度器", elog.String("nodeID", s.nodeID))
 	// 取消上下文
 	s.cancel()
+	s.unregister()
 	return nil
 }
 
 func (s *Scheduler) GracefulStop(_ context.Context) error {
 	s.logger.Info("停止分布式任务调度器", elog.String("nodeID", s.nodeID))
 	s.cancel()
+	s.unregister()
 	return nil
 }
 
@@ -2,6 +2,7 @@ package task
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"time"
@@ -9,12 +10,16 @@ import (
 	"github.com/Duke1616/ework-runner/internal/domain"
 	"github.com/Duke1616/ework-runner/internal/errs"
 	"github.com/Duke1616/ework-runner/internal/event"
+	"github.com/Duke1616/ework-runner/internal/metrics"
 	"github.com/Duke1616/ework-runner/internal/repository"
 	"github.com/Duke1616/ework-runner/internal/service/acquirer"
+	"github.com/Duke1616/ework-runner/internal/service/hook"
 	"github.com/Duke1616/ework-runner/pkg/grpc/registry"
 	"github.com/Duke1616/ework-runner/pkg/retry"
+	"github.com/Duke1616/ework-runner/pkg/tracex"
 	"github.com/gotomicro/ego/core/elog"
-	"go.uber.org/multierr"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // ExecutionService 任务执行服务接口
@@ -28,32 +33,70 @@ type ExecutionService interface {
 	FindRetryableExecutions(ctx context.Context, limit int) ([]domain.TaskExecution, error)
 	// FindReschedulableExecutions 查找所有可以重调度的执行记录
 	FindReschedulableExecutions(ctx context.Context, limit int) ([]domain.TaskExecution, error)
+	// ClaimDispatch 补偿器分发重试、重调度前认领执行记录，认领成功后 lease 内不会再被补偿器查询到
+	// 执行记录已被其他补偿器认领，或者状态已经不是查询时的状态时返回 false
+	ClaimDispatch(ctx context.Context, execution domain.TaskExecution, lease time.Duration) (bool, error)
 	FindExecutionByTaskIDAndPlanExecID(ctx context.Context, taskID int64, planExecID int64) (domain.TaskExecution, error)
 	// FindTimeoutExecutions 查找超时的执行记录
 	FindTimeoutExecutions(ctx context.Context, limit int) ([]domain.TaskExecution, error)
-
-	// SetRunningState 设置任务为运行状态并更新进度
-	SetRunningState(ctx context.Context, id int64, progress int32, executorNodeID string) error
+	// FindStaleRunningExecutions 查找超过 threshold 没有收到任何上报、且尚未超时的运行中执行记录
+	FindStaleRunningExecutions(ctx context.Context, threshold time.Duration, limit int) ([]domain.TaskExecution, error)
+	// FindStalePrepareExecutions 查找超过 window 仍然停留在 PREPARE 状态的执行记录，即分发后没有任何执行节点接手
+	FindStalePrepareExecutions(ctx context.Context, window time.Duration, limit int) ([]domain.TaskExecution, error)
+	// FindRunningExecutions 按ID升序分页查找ID大于 afterID 的运行中执行记录
+	FindRunningExecutions(ctx context.Context, afterID int64, limit int) ([]domain.TaskExecution, error)
+	// FindActiveByTaskID 查找任务尚未结束的执行记录
+	FindActiveByTaskID(ctx context.Context, taskID int64) ([]domain.TaskExecution, error)
+
+	// SetRunningState 以读取到的状态 from 为条件设置任务为运行状态并更新进度
+	SetRunningState(ctx context.Context, id int64, from domain.TaskExecutionStatus, progress int32, executorNodeID string) error
 	// UpdateRunningProgress 更新任务执行进度（仅在RUNNING状态下有效）
 	UpdateRunningProgress(ctx context.Context, id int64, progress int32) error
-	// UpdateRetryResult 更新重试结果
-	UpdateRetryResult(ctx context.Context, id, retryCount, nextRetryTime int64, status domain.TaskExecutionStatus, progress int32, endTime int64, scheduleParams map[string]string, executorNodeID string) error
-	// UpdateScheduleResult 更新调度结果
-	UpdateScheduleResult(ctx context.Context, id int64, status domain.TaskExecutionStatus, progress int32, endTime int64, scheduleParams map[string]string, executorNodeID string) error
-
-	// HandleReports 处理执行节点上报的执行状态
-	HandleReports(ctx context.Context, reports []*domain.Report) error
+	// UpdateRetryResult 以读取到的状态 from 为条件更新重试结果，执行结果与状态在同一个条件更新中写入
+	UpdateRetryResult(ctx context.Context, id, retryCount, nextRetryTime int64, from, status domain.TaskExecutionStatus, progress int32, endTime int64, result domain.ExecutionResult, scheduleParams map[string]string, executorNodeID string, failedNodeIDs []string) error
+	// UpdateScheduleResult 以读取到的状态 from 为条件更新调度结果，执行结果与状态在同一个条件更新中写入
+	UpdateScheduleResult(ctx context.Context, id int64, from, status domain.TaskExecutionStatus, progress int32, endTime int64, result domain.ExecutionResult, scheduleParams map[string]string, executorNodeID string) error
+
+	// HandleReports 处理执行节点上报的执行状态，返回与 reports 一一对应的处理结果，nil 表示处理成功
+	// 单个上报处理失败不影响同批次的其他上报，执行节点据此只重发处理失败的上报
+	HandleReports(ctx context.Context, reports []*domain.Report) []error
 	// UpdateState 更新执行节点上报的执行状态
 	UpdateState(ctx context.Context, state domain.ExecutionState) error
+
+	// StartAttempt 在发起首次执行、重试或重调度前调用，记录一次新的尝试并返回尝试序号
+	// 重试时同时累加重试次数，RetryCount 因此表示实际发起过的重试次数
+	// 尝试次数达到上限时直接按 FAILED 结束执行并返回 errs.ErrExecutionMaxAttemptsExceeded
+	StartAttempt(ctx context.Context, id int64, kind domain.AttemptKind, executorNodeID string) (int64, error)
+	// FindAttempts 查询执行记录的所有尝试
+	FindAttempts(ctx context.Context, id int64) ([]domain.ExecutionAttempt, error)
+	// RequeueFailed 将不可重试失败（FAILED）的执行记录重新放回重试队列，由重试补偿器重新发起
+	// 重试次数和失败节点清零，重新按任务的重试配置计算，只有 FAILED 状态的执行记录可以放回
+	RequeueFailed(ctx context.Context, id int64) error
+	// MarkCompletionHandled 标记执行记录的完成事件已经被处理，消费者处理成功后调用
+	// 完成事件可能被重复投递，已经处理过的完成事件再次投递时直接丢弃
+	MarkCompletionHandled(ctx context.Context, id int64) error
 }
 
+const (
+	// outboxGracePeriod 完成事件写入发件箱后留给当前节点直接发送的时间，超过后才由发件箱中继补发
+	outboxGracePeriod = 30 * time.Second
+	// DefaultMaxAttempts 单次执行默认的最大尝试次数，首次执行、重试、重调度、重新分发都计入
+	// MaxRetryCount 只限制重试，重调度和重新分发没有上限，由尝试次数兜底，防止执行记录被无限次分发
+	DefaultMaxAttempts int64 = 20
+	// MaxAttemptsExceededCategory 尝试次数达到上限时记录的错误分类
+	MaxAttemptsExceededCategory = "MAX_ATTEMPTS_EXCEEDED"
+)
+
 type executionService struct {
 	nodeID       string
 	repo         repository.TaskExecutionRepository
+	outboxRepo   repository.OutboxRepository
 	taskSvc      Service
 	taskAcquirer acquirer.TaskAcquirer  // 任务抢占器
 	producer     event.CompleteProducer // 任务完成事件生产者
 	registry     registry.Registry
+	hookSvc      hook.Service // 生命周期事件，为 nil 时不发布
+	maxAttempts  int64        // 单次执行的最大尝试次数，整个集群共享同一个上限
 	logger       *elog.Component
 }
 
@@ -61,18 +104,27 @@ type executionService struct {
 func NewExecutionService(
 	nodeID string,
 	repo repository.TaskExecutionRepository,
+	outboxRepo repository.OutboxRepository,
 	taskSvc Service,
 	taskAcquirer acquirer.TaskAcquirer,
 	producer event.CompleteProducer,
 	registry registry.Registry,
+	hookSvc hook.Service,
+	maxAttempts int64,
 ) ExecutionService {
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultMaxAttempts
+	}
 	return &executionService{
 		nodeID:       nodeID,
 		repo:         repo,
+		outboxRepo:   outboxRepo,
 		taskSvc:      taskSvc,
 		taskAcquirer: taskAcquirer,
 		producer:     producer,
 		registry:     registry,
+		hookSvc:      hookSvc,
+		maxAttempts:  maxAttempts,
 		logger:       elog.DefaultLogger.With(elog.FieldComponentName("service.execution")),
 	}
 }
@@ -93,6 +145,10 @@ func (s *executionService) FindReschedulableExecutions(ctx context.Context, limi
 	return s.repo.FindReschedulableExecutions(ctx, limit)
 }
 
+func (s *executionService) ClaimDispatch(ctx context.Context, execution domain.TaskExecution, lease time.Duration) (bool, error) {
+	return s.repo.ClaimDispatch(ctx, execution.ID, execution.Status, time.Now().Add(lease).UnixMilli())
+}
+
 func (s *executionService) FindExecutionByTaskIDAndPlanExecID(ctx context.Context, taskID, planExecID int64) (domain.TaskExecution, error) {
 	return s.repo.FindExecutionByTaskIDAndPlanExecID(ctx, taskID, planExecID)
 }
@@ -101,29 +157,45 @@ func (s *executionService) FindTimeoutExecutions(ctx context.Context, limit int)
 	return s.repo.FindTimeoutExecutions(ctx, limit)
 }
 
-func (s *executionService) SetRunningState(ctx context.Context, id int64, progress int32, executorNodeID string) error {
-	return s.repo.SetRunningState(ctx, id, progress, executorNodeID)
+func (s *executionService) FindStaleRunningExecutions(ctx context.Context, threshold time.Duration, limit int) ([]domain.TaskExecution, error) {
+	return s.repo.FindStaleRunningExecutions(ctx, time.Now().Add(-threshold).UnixMilli(), limit)
+}
+
+func (s *executionService) FindRunningExecutions(ctx context.Context, afterID int64, limit int) ([]domain.TaskExecution, error) {
+	return s.repo.FindRunningExecutions(ctx, afterID, limit)
+}
+
+func (s *executionService) FindActiveByTaskID(ctx context.Context, taskID int64) ([]domain.TaskExecution, error) {
+	return s.repo.FindActiveByTaskID(ctx, taskID)
+}
+
+func (s *executionService) FindStalePrepareExecutions(ctx context.Context, window time.Duration, limit int) ([]domain.TaskExecution, error) {
+	return s.repo.FindStalePrepareExecutions(ctx, time.Now().Add(-window).UnixMilli(), limit)
+}
+
+func (s *executionService) SetRunningState(ctx context.Context, id int64, from domain.TaskExecutionStatus, progress int32, executorNodeID string) error {
+	return s.repo.SetRunningState(ctx, id, from, progress, executorNodeID)
 }
 
 func (s *executionService) UpdateRunningProgress(ctx context.Context, id int64, progress int32) error {
 	return s.repo.UpdateRunningProgress(ctx, id, progress)
 }
 
-func (s *executionService) UpdateRetryResult(ctx context.Context, id, retryCount, nextRetryTime int64, status domain.TaskExecutionStatus, progress int32, endTime int64, scheduleParams map[string]string, executorNodeID string) error {
-	return s.repo.UpdateRetryResult(ctx, id, retryCount, nextRetryTime, status, progress, endTime, scheduleParams, executorNodeID)
+func (s *executionService) UpdateRetryResult(ctx context.Context, id, retryCount, nextRetryTime int64, from, status domain.TaskExecutionStatus, progress int32, endTime int64, result domain.ExecutionResult, scheduleParams map[string]string, executorNodeID string, failedNodeIDs []string) error {
+	return s.repo.UpdateRetryResult(ctx, id, retryCount, nextRetryTime, from, status, progress, endTime, result, scheduleParams, executorNodeID, failedNodeIDs)
 }
 
-func (s *executionService) UpdateScheduleResult(ctx context.Context, id int64, status domain.TaskExecutionStatus, progress int32, endTime int64, scheduleParams map[string]string, executorNodeID string) error {
-	return s.repo.UpdateScheduleResult(ctx, id, status, progress, endTime, scheduleParams, executorNodeID)
+func (s *executionService) UpdateScheduleResult(ctx context.Context, id int64, from, status domain.TaskExecutionStatus, progress int32, endTime int64, result domain.ExecutionResult, scheduleParams map[string]string, executorNodeID string) error {
+	return s.repo.UpdateScheduleResult(ctx, id, from, status, progress, endTime, result, scheduleParams, executorNodeID)
 }
 
-func (s *executionService) HandleReports(ctx context.Context, reports []*domain.Report) error {
+func (s *executionService) HandleReports(ctx context.Context, reports []*domain.Report) []error {
 	if len(reports) == 0 {
 		return nil
 	}
 	s.logger.Debug("开始处理执行状态上报", elog.Int("count", len(reports)))
 
-	var err error
+	results := make([]error, len(reports))
 	processedCount := 0
 	skippedCount := 0
 
@@ -135,9 +207,8 @@ func (s *executionService) HandleReports(ctx context.Context, reports []*domain.
 				elog.Any("result", reports[i].ExecutionState),
 				elog.FieldErr(err1))
 			// 包装错误，添加上报场景的特定信息
-			err = multierr.Append(err,
-				fmt.Errorf("处理执行节点上报的结果失败: taskID=%d, executionID=%d: %w",
-					reports[i].ExecutionState.TaskID, reports[i].ExecutionState.ID, err1))
+			results[i] = fmt.Errorf("处理执行节点上报的结果失败: taskID=%d, executionID=%d: %w",
+				reports[i].ExecutionState.TaskID, reports[i].ExecutionState.ID, err1)
 			continue
 		}
 		processedCount++
@@ -148,41 +219,298 @@ func (s *executionService) HandleReports(ctx context.Context, reports []*domain.
 		elog.Int("total", len(reports)),
 		elog.Int("processed", processedCount),
 		elog.Int("skipped", skippedCount))
-	return err
+	return results
 }
 
-func (s *executionService) UpdateState(ctx context.Context, state domain.ExecutionState) error {
+// IsRetryableReportErr 判断上报处理失败后重发是否可能成功
+// 执行记录不存在、状态迁移非法属于确定性失败，重发也不会成功
+func IsRetryableReportErr(err error) bool {
+	return err != nil &&
+		!errors.Is(err, errs.ErrExecutionNotFound) &&
+		!errors.Is(err, errs.ErrInvalidTaskExecutionStatus)
+}
+
+func (s *executionService) UpdateState(ctx context.Context, state domain.ExecutionState) (err error) {
 	execution, err := s.FindByID(ctx, state.ID)
 	if err != nil {
 		return errs.ErrExecutionNotFound
 	}
 
-	// 已处于终止状态的的执行记录不允许再进行状态迁移
-	if execution.Status.IsTerminalStatus() {
+	// 状态处理归入执行记录所在的链路，同时关联触发本次处理的请求（如上报请求）所在的链路
+	ctx, link := tracex.Join(ctx, execution.TraceContext)
+	ctx, span := tracex.Start(ctx, "ExecutionService.UpdateState", link,
+		trace.WithAttributes(
+			attribute.Int64("ework.execution_id", state.ID),
+			attribute.String("ework.status", state.Status.String()),
+			attribute.String("ework.executor_node_id", state.ExecutorNodeID)))
+	defer func() {
+		tracex.End(span, err)
+	}()
+
+	// 重复或乱序到达的旧状态直接丢弃（执行节点发件箱重试、批量上报都可能导致）
+	if execution.IsStaleReport(state) {
+		s.logger.Warn("丢弃过期的上报状态",
+			elog.Int64("executionID", state.ID),
+			elog.String("executorNodeID", state.ExecutorNodeID),
+			elog.Int64("sequence", state.Sequence),
+			elog.String("status", state.Status.String()))
+		return nil
+	}
+	// 已经被新尝试取代的旧尝试迟到的上报（如重调度前的执行节点恢复后上报）只记录到它所属的尝试上，不改变执行记录的状态
+	if execution.IsSupersededReport(state) {
+		s.logger.Warn("丢弃已经被取代的尝试的上报状态",
+			elog.Int64("executionID", state.ID),
+			elog.String("executorNodeID", state.ExecutorNodeID),
+			elog.Int64("attempt", state.Attempt),
+			elog.Int64("currentAttempt", execution.Attempts),
+			elog.String("status", state.Status.String()))
+		s.recordAttempt(ctx, execution, state)
+		return nil
+	}
+	// 重复上报已经生效的终止状态（如执行节点没有收到确认后重发）不需要再处理
+	if execution.IsDuplicateReport(state) {
+		s.logger.Info("忽略重复上报的终止状态",
+			elog.Int64("executionID", state.ID),
+			elog.String("status", state.Status.String()))
+		return nil
+	}
+
+	// 上报所属的尝试已经以失败结束（执行节点上报失败、分发失败或者放弃中断），重复上报的失败不再重新计算下次重试时间和失败节点，
+	// 放弃中断后失联的执行节点恢复后迟到的上报也不能再改变执行记录的状态
+	redispatched, ended, err := s.classifyAttempt(ctx, execution, state)
+	if err != nil {
+		return err
+	}
+	if ended {
+		s.logger.Info("忽略已经结束的尝试的上报状态",
+			elog.Int64("executionID", state.ID),
+			elog.String("executorNodeID", state.ExecutorNodeID),
+			elog.Int64("attempt", state.Attempt),
+			elog.String("status", state.Status.String()))
+		return nil
+	}
+
+	// 执行结果与状态在同一个条件更新中写入，被拒绝的状态迁移不会覆盖已经结束的执行记录的结果
+	state.Result = state.Result.Bounded()
+	applied, err := s.transit(ctx, execution, state, redispatched)
+	if errors.Is(err, errs.ErrInvalidTaskExecutionStatus) && s.isConcurrentDuplicate(ctx, state) {
+		// 读取执行记录之后，同一终止状态已经被并发处理的上报写入
+		return nil
+	}
+	if err == nil && state.Sequence > 0 {
+		// 状态处理成功后才记录序号，处理失败时执行节点重试的同一状态不会被当作重复丢弃
+		s.acceptReport(ctx, state)
+	}
+	if err == nil {
+		// 按实际写入的状态计数，重试次数用尽的可重试失败记为 FAILED
+		metrics.ExecutionStatusTotal.Inc(applied.String())
+		s.recordAttempt(ctx, execution, state)
+		s.publishHooks(ctx, execution, state)
+	}
+	return err
+}
+
+// classifyAttempt 执行记录处于可重试、重调度失败时，按上报所属的尝试区分状态迁移的触发方式
+// 失败后发起的重试、重调度通过 StartAttempt 开始新的尝试，新尝试还没有结束时它的结果按重新分发迁移（redispatched）；
+// 上报所属的尝试已经结束时不再处理（ended），不带尝试序号的上报无法判断来自哪次尝试，只把与当前状态相同的失败当作重复上报；
+// 其余（如调度节点放弃重试）按当前尝试迁移，只能迁移到 FAILED
+func (s *executionService) classifyAttempt(ctx context.Context, execution domain.TaskExecution, state domain.ExecutionState) (redispatched, ended bool, err error) {
+	if !execution.Status.IsFailedRetryable() && !execution.Status.IsFailedRescheduled() {
+		return false, false, nil
+	}
+	number := state.Attempt
+	if number <= 0 {
+		number = execution.Attempts
+	}
+	attempts, err := s.repo.FindAttempts(ctx, execution.ID)
+	if err != nil {
+		return false, false, fmt.Errorf("查询执行记录的尝试失败: %w", err)
+	}
+	for _, attempt := range attempts {
+		if attempt.Attempt != number {
+			continue
+		}
+		if attempt.EndTime == 0 {
+			return true, false, nil
+		}
+		return false, state.Attempt > 0 || state.Status == execution.Status, nil
+	}
+	return false, false, nil
+}
+
+// isConcurrentDuplicate 条件更新失败后重新读取执行记录，判断是否已经被并发处理的相同上报迁移到了同一终止状态
+func (s *executionService) isConcurrentDuplicate(ctx context.Context, state domain.ExecutionState) bool {
+	execution, err := s.FindByID(ctx, state.ID)
+	if err != nil {
+		return false
+	}
+	if !execution.IsDuplicateReport(state) {
+		return false
+	}
+	s.logger.Info("终止状态已经被并发的上报写入，忽略",
+		elog.Int64("executionID", state.ID),
+		elog.String("status", state.Status.String()))
+	return true
+}
+
+func (s *executionService) StartAttempt(ctx context.Context, id int64, kind domain.AttemptKind, executorNodeID string) (int64, error) {
+	attempt, err := s.repo.StartAttempt(ctx, id, kind, executorNodeID, s.maxAttempts)
+	if errors.Is(err, errs.ErrExecutionMaxAttemptsExceeded) {
+		s.failMaxAttemptsExceeded(ctx, id)
+	}
+	return attempt, err
+}
+
+// failMaxAttemptsExceeded 尝试次数达到上限后直接按 FAILED 结束执行，由完成事件释放任务，补偿器不会再拉取到它
+func (s *executionService) failMaxAttemptsExceeded(ctx context.Context, id int64) {
+	execution, err := s.repo.GetByID(ctx, id)
+	if err == nil {
+		err = s.UpdateState(ctx, domain.ExecutionState{
+			ID:       execution.ID,
+			TaskID:   execution.Task.ID,
+			TaskName: execution.Task.Name,
+			Status:   domain.TaskExecutionStatusFailed,
+			Result: domain.ExecutionResult{
+				ErrorMessage:  fmt.Sprintf("尝试次数达到上限 %d", s.maxAttempts),
+				ErrorCategory: MaxAttemptsExceededCategory,
+			},
+		})
+	}
+	if err != nil {
+		s.logger.Error("尝试次数达到上限后结束执行失败",
+			elog.Int64("executionID", id),
+			elog.FieldErr(err))
+	}
+}
+
+func (s *executionService) FindAttempts(ctx context.Context, id int64) ([]domain.ExecutionAttempt, error) {
+	return s.repo.FindAttempts(ctx, id)
+}
+
+func (s *executionService) RequeueFailed(ctx context.Context, id int64) error {
+	execution, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	// 是否允许放回由迁移表决定，更新时以读取到的状态为条件
+	if !execution.Status.CanRequeueTo(domain.TaskExecutionStatusFailedRetryable) {
+		return fmt.Errorf("%w: %s 状态的执行记录不能重新放回重试队列", errs.ErrInvalidTaskExecutionStatus, execution.Status)
+	}
+	return s.repo.RequeueFailed(ctx, id, execution.Status)
+}
+
+func (s *executionService) MarkCompletionHandled(ctx context.Context, id int64) error {
+	return s.repo.MarkCompletionHandled(ctx, id)
+}
+
+// recordAttempt 将上报的状态记录到它所属的尝试上，尝试记录只用于查看历史，更新失败不影响状态迁移
+// 执行节点回传了尝试序号时按序号更新，迟到的旧尝试上报不会覆盖当前尝试；没有回传时记录到当前尝试上。
+// 分发失败、重试次数用尽、PREPARE 超时等没有执行节点参与的结束同样会结束当前尝试
+func (s *executionService) recordAttempt(ctx context.Context, execution domain.TaskExecution, state domain.ExecutionState) {
+	number := state.Attempt
+	if number <= 0 {
+		number = execution.Attempts
+	}
+	if number <= 0 {
+		return
+	}
+
+	attempt := domain.ExecutionAttempt{
+		ExecutionID:    execution.ID,
+		Attempt:        number,
+		ExecutorNodeID: state.ExecutorNodeID,
+		Status:         state.Status,
+		ErrorMessage:   state.Result.ErrorMessage,
+		ErrorCategory:  state.Result.ErrorCategory,
+	}
+	if !state.Status.IsRunning() {
+		attempt.EndTime = time.Now().UnixMilli()
+	}
+	if err := s.repo.UpdateAttempt(ctx, attempt); err != nil {
+		s.logger.Error("更新尝试记录失败",
+			elog.Int64("executionID", execution.ID),
+			elog.Int64("attempt", number),
+			elog.FieldErr(err))
+	}
+}
+
+// publishHooks 按状态迁移发布开始和超时事件，长时间运行事件由长时间运行补偿器发布，执行的最终结果由完成事件的消费者发布
+func (s *executionService) publishHooks(ctx context.Context, execution domain.TaskExecution, state domain.ExecutionState) {
+	if s.hookSvc == nil {
+		return
+	}
+
+	now := time.Now()
+	wasRunning := execution.Status.IsRunning()
+	timedOut := execution.IsTimedOut(state, now)
+	execution.Status = state.Status
+	execution.RunningProgress = state.RunningProgress
+	if state.ExecutorNodeID != "" {
+		execution.ExecutorNodeID = state.ExecutorNodeID
+	}
+	if !state.Result.IsEmpty() {
+		execution.Result = state.Result
+	}
+
+	switch {
+	case state.Status.IsRunning() && !wasRunning:
+		// 每次尝试开始执行时都会重新设置开始时间和截止时间
+		execution.StartTime = now.UnixMilli()
+		execution.Deadline = now.Add(time.Duration(execution.Task.MaxExecutionSeconds) * time.Second).UnixMilli()
+		s.hookSvc.Publish(ctx, domain.HookEventStart, execution)
+	case timedOut:
+		s.hookSvc.Publish(ctx, domain.HookEventTimeout, execution)
+	}
+}
+
+func (s *executionService) acceptReport(ctx context.Context, state domain.ExecutionState) {
+	accepted, err := s.repo.AcceptReport(ctx, state.ID, state.ExecutorNodeID, state.Sequence)
+	if err != nil {
+		s.logger.Error("记录上报序号失败",
+			elog.Int64("executionID", state.ID),
+			elog.Int64("sequence", state.Sequence),
+			elog.FieldErr(err))
+		return
+	}
+	if !accepted {
+		s.logger.Warn("并发处理了同一执行节点的上报，保留更大的序号",
+			elog.Int64("executionID", state.ID),
+			elog.Int64("sequence", state.Sequence))
+	}
+}
+
+// transit 按上报状态迁移执行记录，返回实际写入的状态
+func (s *executionService) transit(ctx context.Context, execution domain.TaskExecution, state domain.ExecutionState, redispatched bool) (domain.TaskExecutionStatus, error) {
+	// 按迁移表校验，数据库更新时还会以当前状态作为条件，防止读取之后状态被并发修改
+	allowed := execution.Status.CanTransitTo(state.Status)
+	if redispatched {
+		allowed = execution.Status.CanRedispatchTo(state.Status)
+	}
+	if !allowed {
 		s.logger.Error("错乱的状态迁移",
 			elog.Int64("taskID", execution.Task.ID),
 			elog.String("taskName", execution.Task.Name),
 			elog.String("currentStatus", execution.Status.String()),
 			elog.String("targetStatus", state.Status.String()))
-		return errs.ErrInvalidTaskExecutionStatus
+		return state.Status, errs.ErrInvalidTaskExecutionStatus
 	}
 
 	switch {
 	case state.Status.IsRunning():
 		if execution.Status.IsRunning() {
 			// 仅更新进度
-			return s.updateRunningProgress(ctx, state)
+			return state.Status, s.updateRunningProgress(ctx, state)
 		}
 		// 设置为RUNNING状态的同时设置开始时间
-		return s.setRunningState(ctx, state)
+		return state.Status, s.setRunningState(ctx, execution, state)
 	case state.Status.IsFailedRetryable():
-		err = s.updateRetryState(ctx, execution, state)
+		err := s.updateRetryState(ctx, execution, state)
 		if err != nil {
 			// 达到最大重试次数
 			if errors.Is(err, errs.ErrExecutionMaxRetriesExceeded) {
-				// NOTE: 只发送完成事件,由消费者统一更新终止状态
-				s.sendCompletedEvent(ctx, state, execution)
-				return nil
+				// NOTE: 重试次数用尽后按不可重试失败处理
+				state.Status = domain.TaskExecutionStatusFailed
+				return state.Status, s.complete(ctx, state, execution)
 			}
 			// 其他错误才记录并返回
 			s.logger.Error("更新任务执行记录的重试结果失败",
@@ -190,30 +518,28 @@ func (s *executionService) UpdateState(ctx context.Context, state domain.Executi
 				elog.String("taskName", state.TaskName),
 				elog.Any("state", state),
 				elog.FieldErr(err))
-			return err
+			return state.Status, err
 		}
-		return nil
+		return state.Status, nil
 	case state.Status.IsFailedRescheduled():
 		if state.RequestReschedule {
 			// 更新调度信息
 			execution.MergeTaskScheduleParams(state.RescheduleParams)
 		}
-		err = s.updateState(ctx, execution, state)
-		if err != nil {
-			return fmt.Errorf("更新任务执行记录的重调度结果失败：%w", err)
+		if err := s.updateState(ctx, execution, state); err != nil {
+			return state.Status, fmt.Errorf("更新任务执行记录的重调度结果失败：%w", err)
 		}
-		return nil
+		return state.Status, nil
 	case state.Status.IsTerminalStatus():
-		// NOTE: 只发送完成事件,由消费者统一更新终止状态,避免重复更新
-		s.sendCompletedEvent(ctx, state, execution)
-		return nil
+		// NOTE: 终止状态与完成事件在同一个事务中写入，完成事件由消费者处理任务的后续调度
+		return state.Status, s.complete(ctx, state, execution)
 	default:
 		s.logger.Error("非法上报状态",
 			elog.Int64("taskID", execution.Task.ID),
 			elog.String("taskName", execution.Task.Name),
 			elog.String("currentStatus", execution.Status.String()),
 			elog.String("targetStatus", state.Status.String()))
-		return errs.ErrInvalidTaskExecutionStatus
+		return state.Status, errs.ErrInvalidTaskExecutionStatus
 	}
 }
 
@@ -230,8 +556,8 @@ func (s *executionService) updateRunningProgress(ctx context.Context, state doma
 	return nil
 }
 
-func (s *executionService) setRunningState(ctx context.Context, state domain.ExecutionState) error {
-	err := s.SetRunningState(ctx, state.ID, state.RunningProgress, state.ExecutorNodeID)
+func (s *executionService) setRunningState(ctx context.Context, execution domain.TaskExecution, state domain.ExecutionState) error {
+	err := s.SetRunningState(ctx, state.ID, execution.Status, state.RunningProgress, state.ExecutorNodeID)
 	if err != nil {
 		s.logger.Error("更新为运行状态失败",
 			elog.Int64("taskID", state.TaskID),
@@ -244,28 +570,45 @@ func (s *executionService) setRunningState(ctx context.Context, state domain.Exe
 }
 
 func (s *executionService) updateRetryState(ctx context.Context, execution domain.TaskExecution, state domain.ExecutionState) error {
-	// 计算出下次重试时间
-	retryStrategy, _ := retry.NewRetry(execution.Task.RetryConfig.ToRetryComponentConfig())
+	// 未配置重试时直接视为重试次数用尽
+	if !execution.Task.RetryConfig.Enabled() {
+		return errs.ErrExecutionMaxRetriesExceeded
+	}
+
+	// 计算出下次重试时间，重试配置非法时不再重试
+	retryStrategy, err := retry.NewRetry(execution.Task.RetryConfig.ToRetryComponentConfig())
+	if err != nil {
+		s.logger.Error("重试配置非法，不再重试",
+			elog.Int64("taskID", execution.Task.ID),
+			elog.String("taskName", execution.Task.Name),
+			elog.FieldErr(err))
+		return errs.ErrExecutionMaxRetriesExceeded
+	}
 	duration, shouldRetry := retryStrategy.NextWithRetries(int32(execution.RetryCount + 1))
 
 	if !shouldRetry {
-		// NOTE: 达到最大重试次数,状态更新交由消费者统一处理,这里只返回标记错误
+		// NOTE: 达到最大重试次数,由调用方按不可重试失败结束执行,这里只返回标记错误
 		return errs.ErrExecutionMaxRetriesExceeded
 	}
 
-	// 还可以重试:计算下次重试时间并更新重试计数
+	// 还可以重试:计算下次重试时间，重试计数在真正发起重试时累加
 	execution.NextRetryTime = time.Now().Add(duration).UnixMilli()
-	execution.RetryCount++
+	// 记录本次失败的节点，后续重试时排除所有失败过的节点
+	execution.AddFailedNodeID(execution.ExecutorNodeID)
+	execution.AddFailedNodeID(state.ExecutorNodeID)
 
-	err := s.UpdateRetryResult(ctx,
+	err = s.UpdateRetryResult(ctx,
 		state.ID,
 		execution.RetryCount,
 		execution.NextRetryTime,
+		execution.Status,
 		state.Status,
 		state.RunningProgress,
 		time.Now().UnixMilli(),
+		state.Result,
 		execution.Task.ScheduleParams,
-		state.ExecutorNodeID)
+		state.ExecutorNodeID,
+		execution.FailedNodeIDs)
 	if err != nil {
 		s.logger.Error("更新执行计划重试结果失败",
 			elog.Int64("taskID", execution.Task.ID),
@@ -285,9 +628,11 @@ func (s *executionService) updateRetryState(ctx context.Context, execution domai
 func (s *executionService) updateState(ctx context.Context, execution domain.TaskExecution, state domain.ExecutionState) error {
 	err := s.UpdateScheduleResult(ctx,
 		state.ID,
+		execution.Status,
 		state.Status,
 		state.RunningProgress,
 		time.Now().UnixMilli(),
+		state.Result,
 		execution.Task.ScheduleParams,
 		state.ExecutorNodeID)
 	if err != nil {
@@ -306,7 +651,7 @@ func (s *executionService) updateState(ctx context.Context, execution domain.Tas
 }
 
 func (s *executionService) releaseTask(ctx context.Context, task domain.Task) {
-	if err := s.taskAcquirer.Release(ctx, task.ID, s.nodeID); err != nil {
+	if err := s.taskAcquirer.Release(ctx, task.ID, task.Version, s.nodeID); err != nil {
 		s.logger.Error("释放任务失败",
 			elog.Int64("taskID", task.ID),
 			elog.String("taskName", task.Name),
@@ -314,19 +659,55 @@ func (s *executionService) releaseTask(ctx context.Context, task domain.Task) {
 	}
 }
 
-func (s *executionService) sendCompletedEvent(ctx context.Context, state domain.ExecutionState, execution domain.TaskExecution) {
-	if !state.Status.IsTerminalStatus() {
-		// 非终止状态不用做处理
-		return
-	}
-	err := s.producer.Produce(ctx, event.Event{
+// complete 将执行记录迁移到终止状态，同时把完成事件写入发件箱
+// 写入成功后直接发送一次，发送成功即删除发件箱中的消息，失败时由发件箱中继在宽限期后补发
+func (s *executionService) complete(ctx context.Context, state domain.ExecutionState, execution domain.TaskExecution) error {
+	evt := event.Event{
 		ExecID:         execution.ID,
 		ScheduleNodeID: execution.Task.ScheduleNodeID,
 		ExecStatus:     state.Status,
 		TaskID:         execution.Task.ID,
 		Name:           execution.Task.Name,
+		Result:         state.Result,
+		// 完成事件经过发件箱中继补发时不再有调用方的 context，链路上下文随事件一起保存
+		TraceContext: tracex.Inject(ctx),
+	}
+	payload, err := json.Marshal(evt)
+	if err != nil {
+		return fmt.Errorf("序列化完成事件失败: %w", err)
+	}
+
+	var progress int32
+	if state.Status.IsSuccess() {
+		progress = 100
+	}
+	now := time.Now()
+	msg, err := s.repo.Complete(ctx, execution.ID, execution.Status, state.Status, progress, now.UnixMilli(), state.Result, domain.OutboxMessage{
+		Payload:  payload,
+		NextTime: now.Add(outboxGracePeriod).UnixMilli(),
 	})
 	if err != nil {
-		s.logger.Error("发送完成事件失败", elog.Int64("taskID", execution.Task.ID), elog.FieldErr(err))
+		s.logger.Error("更新终止状态并写入完成事件失败",
+			elog.Int64("taskID", execution.Task.ID),
+			elog.String("taskName", execution.Task.Name),
+			elog.Any("state", state),
+			elog.FieldErr(err))
+		return err
 	}
+
+	if err = s.producer.Produce(ctx, evt); err != nil {
+		s.logger.Warn("发送完成事件失败，等待发件箱中继补发",
+			elog.Int64("taskID", execution.Task.ID),
+			elog.Int64("executionID", execution.ID),
+			elog.FieldErr(err))
+		return nil
+	}
+	if err = s.outboxRepo.Delete(ctx, msg.ID); err != nil {
+		// 删除失败时中继会再发送一次，由消费者按执行记录去重
+		s.logger.Warn("删除已发送的完成事件失败",
+			elog.Int64("executionID", execution.ID),
+			elog.Int64("messageID", msg.ID),
+			elog.FieldErr(err))
+	}
+	return nil
 }
@@ -0,0 +1,304 @@
+//go:build unit
+
+package task
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Duke1616/ework-runner/internal/domain"
+	"github.com/Duke1616/ework-runner/internal/errs"
+	"github.com/Duke1616/ework-runner/internal/metrics"
+	"github.com/Duke1616/ework-runner/internal/test"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 上报按回传的尝试序号记录，重调度后旧尝试的执行节点迟到的上报只记录在旧尝试上，不会结束正在其他节点上执行的当前尝试
+func TestExecutionService_RecordAttempt(t *testing.T) {
+	t.Parallel()
+
+	repo := test.NewMemExecutionRepo(domain.TaskExecution{
+		ID:             1,
+		Status:         domain.TaskExecutionStatusRunning,
+		ExecutorNodeID: "node-b",
+		Attempts:       2,
+		Task:           domain.Task{ID: 10, Name: "sync-user"},
+	})
+	repo.AddAttempts(
+		domain.ExecutionAttempt{ExecutionID: 1, Attempt: 1, Kind: domain.AttemptKindRun, ExecutorNodeID: "node-a"},
+		domain.ExecutionAttempt{ExecutionID: 1, Attempt: 2, Kind: domain.AttemptKindReschedule, ExecutorNodeID: "node-b"},
+	)
+	producer := test.NewChanProducer()
+	execSvc := NewExecutionService("scheduler-1", repo, repo, nil, nil, producer, nil, nil, 0)
+
+	err := execSvc.UpdateState(context.Background(), domain.ExecutionState{
+		ID:             1,
+		Status:         domain.TaskExecutionStatusSuccess,
+		ExecutorNodeID: "node-a",
+		Attempt:        1,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, domain.TaskExecutionStatusRunning, repo.Get(1).Status)
+	assert.Empty(t, producer.Events)
+
+	attempts := repo.Attempts(1)
+	assert.Equal(t, domain.TaskExecutionStatusSuccess, attempts[0].Status)
+	assert.Greater(t, attempts[0].EndTime, int64(0))
+	assert.Equal(t, domain.ExecutionAttempt{
+		ExecutionID:    1,
+		Attempt:        2,
+		Kind:           domain.AttemptKindReschedule,
+		ExecutorNodeID: "node-b",
+	}, attempts[1])
+}
+
+func TestExecutionService_RetryableFailureExhausted(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name        string
+		retryConfig *domain.RetryConfig
+		retryCount  int64
+	}{
+		{
+			name:        "达到最大重试次数",
+			retryConfig: &domain.RetryConfig{MaxRetries: 1, InitialInterval: 1, MaxInterval: 1},
+			retryCount:  1,
+		},
+		{
+			name:        "未配置重试",
+			retryConfig: nil,
+		},
+		{
+			name:        "最大重试次数为 0",
+			retryConfig: &domain.RetryConfig{MaxRetries: 0},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := test.NewMemExecutionRepo(domain.TaskExecution{
+				ID:             1,
+				Status:         domain.TaskExecutionStatusRunning,
+				ExecutorNodeID: "node-a",
+				RetryCount:     tc.retryCount,
+				Task:           domain.Task{ID: 10, Name: "sync-user", RetryConfig: tc.retryConfig},
+			})
+			producer := test.NewChanProducer()
+			execSvc := NewExecutionService("scheduler-1", repo, repo, nil, nil, producer, nil, nil, 0)
+
+			err := execSvc.UpdateState(context.Background(), domain.ExecutionState{
+				ID:             1,
+				Status:         domain.TaskExecutionStatusFailedRetryable,
+				ExecutorNodeID: "node-a",
+			})
+			require.NoError(t, err)
+
+			// 不再重试，以不可重试失败发送完成事件
+			evt := producer.Wait(t)
+			assert.Equal(t, domain.TaskExecutionStatusFailed, evt.ExecStatus)
+			assert.Equal(t, tc.retryCount, repo.Get(1).RetryCount)
+		})
+	}
+}
+
+// 重试次数用尽的可重试失败按实际写入的 FAILED 计数
+// NOTE: 指标是全局的，不能并行执行
+func TestExecutionService_StatusMetrics(t *testing.T) {
+	repo := test.NewMemExecutionRepo(domain.TaskExecution{
+		ID:             1,
+		Status:         domain.TaskExecutionStatusRunning,
+		ExecutorNodeID: "node-a",
+		Task:           domain.Task{ID: 10, Name: "sync-user"},
+	})
+	producer := test.NewChanProducer()
+	execSvc := NewExecutionService("scheduler-1", repo, repo, nil, nil, producer, nil, nil, 0)
+
+	counter := func(status domain.TaskExecutionStatus) float64 {
+		return testutil.ToFloat64(metrics.ExecutionStatusTotal.WithLabelValues(status.String()))
+	}
+	failed, retryable := counter(domain.TaskExecutionStatusFailed), counter(domain.TaskExecutionStatusFailedRetryable)
+
+	err := execSvc.UpdateState(context.Background(), domain.ExecutionState{
+		ID:             1,
+		Status:         domain.TaskExecutionStatusFailedRetryable,
+		ExecutorNodeID: "node-a",
+	})
+	require.NoError(t, err)
+	producer.Wait(t)
+
+	assert.Equal(t, failed+1, counter(domain.TaskExecutionStatusFailed))
+	assert.Equal(t, retryable, counter(domain.TaskExecutionStatusFailedRetryable))
+}
+
+func TestExecutionService_TerminalReport(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name      string
+		current   domain.TaskExecutionStatus
+		report    domain.TaskExecutionStatus
+		wantErr   error
+		wantEvent bool
+	}{
+		{
+			name:      "运行中上报成功",
+			current:   domain.TaskExecutionStatusRunning,
+			report:    domain.TaskExecutionStatusSuccess,
+			wantEvent: true,
+		},
+		{
+			name:    "重复上报成功",
+			current: domain.TaskExecutionStatusSuccess,
+			report:  domain.TaskExecutionStatusSuccess,
+		},
+		{
+			name:    "成功后上报运行中",
+			current: domain.TaskExecutionStatusSuccess,
+			report:  domain.TaskExecutionStatusRunning,
+			wantErr: errs.ErrInvalidTaskExecutionStatus,
+		},
+		{
+			name:    "失败后上报成功",
+			current: domain.TaskExecutionStatusFailed,
+			report:  domain.TaskExecutionStatusSuccess,
+			wantErr: errs.ErrInvalidTaskExecutionStatus,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			current := domain.ExecutionResult{Payload: []byte(`{"synced":3}`)}
+			repo := test.NewMemExecutionRepo(domain.TaskExecution{
+				ID:     1,
+				Status: tc.current,
+				Task:   domain.Task{ID: 10, Name: "sync-user"},
+				Result: current,
+			})
+			producer := test.NewChanProducer()
+			execSvc := NewExecutionService("scheduler-1", repo, repo, nil, nil, producer, nil, nil, 0)
+
+			report := domain.ExecutionResult{ErrorMessage: "迟到的上报"}
+			err := execSvc.UpdateState(context.Background(), domain.ExecutionState{
+				ID:     1,
+				Status: tc.report,
+				Result: report,
+			})
+			assert.ErrorIs(t, err, tc.wantErr)
+			assert.Equal(t, tc.wantEvent, len(producer.Events) == 1)
+			if tc.wantEvent {
+				assert.Equal(t, tc.report, repo.Get(1).Status)
+				assert.Equal(t, report, repo.Get(1).Result)
+			} else {
+				// 被拒绝或重复的上报不会覆盖已经结束的执行记录的结果
+				assert.Equal(t, tc.current, repo.Get(1).Status)
+				assert.Equal(t, current, repo.Get(1).Result)
+			}
+		})
+	}
+}
+
+// 重复上报同一尝试的可重试失败不会重新计算下次重试时间，也不会重复记录失败节点
+func TestExecutionService_DuplicateRetryableFailure(t *testing.T) {
+	t.Parallel()
+
+	repo := test.NewMemExecutionRepo(domain.TaskExecution{
+		ID:             1,
+		Status:         domain.TaskExecutionStatusRunning,
+		ExecutorNodeID: "node-a",
+		Attempts:       1,
+		Task: domain.Task{
+			ID:          10,
+			Name:        "sync-user",
+			RetryConfig: &domain.RetryConfig{MaxRetries: 3, InitialInterval: 1000, MaxInterval: 1000},
+		},
+	})
+	repo.AddAttempts(domain.ExecutionAttempt{ExecutionID: 1, Attempt: 1, Kind: domain.AttemptKindRun, ExecutorNodeID: "node-a"})
+	execSvc := NewExecutionService("scheduler-1", repo, repo, nil, nil, test.NewChanProducer(), nil, nil, 0)
+
+	state := domain.ExecutionState{
+		ID:             1,
+		Status:         domain.TaskExecutionStatusFailedRetryable,
+		ExecutorNodeID: "node-a",
+		Attempt:        1,
+	}
+	require.NoError(t, execSvc.UpdateState(context.Background(), state))
+	first := repo.Get(1)
+	assert.Equal(t, domain.TaskExecutionStatusFailedRetryable, first.Status)
+	assert.Equal(t, []string{"node-a"}, first.FailedNodeIDs)
+
+	require.NoError(t, execSvc.UpdateState(context.Background(), state))
+	assert.Equal(t, first, repo.Get(1))
+}
+
+// 可重试失败之后，只有重试开始的新尝试能迁移到其他状态，没有新尝试时只能放弃重试迁移到 FAILED
+func TestExecutionService_RedispatchTransition(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name       string
+		redispatch bool
+		report     domain.TaskExecutionStatus
+
+		wantErr    error
+		wantStatus domain.TaskExecutionStatus
+	}{
+		{
+			name:       "没有开始新尝试时上报运行中",
+			report:     domain.TaskExecutionStatusRunning,
+			wantErr:    errs.ErrInvalidTaskExecutionStatus,
+			wantStatus: domain.TaskExecutionStatusFailedRetryable,
+		},
+		{
+			name:       "没有开始新尝试时上报成功",
+			report:     domain.TaskExecutionStatusSuccess,
+			wantErr:    errs.ErrInvalidTaskExecutionStatus,
+			wantStatus: domain.TaskExecutionStatusFailedRetryable,
+		},
+		{
+			name:       "没有开始新尝试时放弃重试",
+			report:     domain.TaskExecutionStatusFailed,
+			wantStatus: domain.TaskExecutionStatusFailed,
+		},
+		{
+			name:       "新尝试上报成功",
+			redispatch: true,
+			report:     domain.TaskExecutionStatusSuccess,
+			wantStatus: domain.TaskExecutionStatusSuccess,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := test.NewMemExecutionRepo(domain.TaskExecution{
+				ID:       1,
+				Status:   domain.TaskExecutionStatusFailedRetryable,
+				Attempts: 1,
+				Task:     domain.Task{ID: 10, Name: "sync-user"},
+			})
+			repo.AddAttempts(domain.ExecutionAttempt{ExecutionID: 1, Attempt: 1, Kind: domain.AttemptKindRun, EndTime: 1})
+			execSvc := NewExecutionService("scheduler-1", repo, repo, nil, nil, test.NewChanProducer(), nil, nil, 0)
+			if tc.redispatch {
+				_, err := execSvc.StartAttempt(context.Background(), 1, domain.AttemptKindRetry, "")
+				require.NoError(t, err)
+			}
+
+			// 不带尝试序号的上报按执行记录当前的尝试判断
+			err := execSvc.UpdateState(context.Background(), domain.ExecutionState{
+				ID:             1,
+				Status:         tc.report,
+				ExecutorNodeID: "node-b",
+			})
+			assert.ErrorIs(t, err, tc.wantErr)
+			assert.Equal(t, tc.wantStatus, repo.Get(1).Status)
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package task
+
+import (
+	"context"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Duke1616/ework-runner/internal/domain"
+	"github.com/Duke1616/ework-runner/internal/repository"
+	"github.com/gotomicro/ego/core/elog"
+)
+
+const (
+	// DefaultMaxLogSizePerExecution 单次执行默认最多存储的日志字节数
+	DefaultMaxLogSizePerExecution = 10 * 1024 * 1024
+	// LogSettleWindow 日志块写入后等待并发写入的批次提交的时间
+	// 并发写入的批次不一定按 ID 顺序提交，较小的 ID 可能在较大的 ID 之后才可见，实时推送只推送写入超过该时间的日志块
+	LogSettleWindow = 2 * time.Second
+)
+
+// LogService 任务执行日志服务接口
+type LogService interface {
+	// Append 追加执行节点上报的日志块，单次执行的日志超出上限后截断，后续日志直接丢弃
+	Append(ctx context.Context, logs []domain.ExecutionLog) error
+	// List 按写入顺序查询指定执行 ID 大于 afterID 的日志块
+	List(ctx context.Context, executionID, afterID int64, limit int) ([]domain.ExecutionLog, error)
+	// Tail 按写入顺序查询指定执行 ID 大于 afterID 并且写入超过 LogSettleWindow 的日志块
+	// 实时推送以最后推送的 ID 作为游标，只推送已经稳定的日志块不会跳过后提交的较小 ID
+	Tail(ctx context.Context, executionID, afterID int64, limit int) ([]domain.ExecutionLog, error)
+}
+
+type logService struct {
+	repo    repository.ExecutionLogRepository
+	maxSize int64
+	logger  *elog.Component
+}
+
+// NewLogService 创建任务执行日志服务实例
+func NewLogService(repo repository.ExecutionLogRepository) LogService {
+	return &logService{
+		repo:    repo,
+		maxSize: DefaultMaxLogSizePerExecution,
+		logger:  elog.DefaultLogger.With(elog.FieldComponentName("service.execution_log")),
+	}
+}
+
+func (s *logService) Append(ctx context.Context, logs []domain.ExecutionLog) error {
+	// 同一批次内按执行累计大小，避免每个日志块都查询一次
+	sizes := make(map[int64]int64)
+	accepted := make([]domain.ExecutionLog, 0, len(logs))
+	for _, log := range logs {
+		size, ok := sizes[log.ExecutionID]
+		if !ok {
+			total, err := s.repo.TotalSize(ctx, log.ExecutionID)
+			if err != nil {
+				return err
+			}
+			size = total
+		}
+
+		remaining := s.maxSize - size
+		switch {
+		case remaining <= 0:
+			continue
+		case int64(len(log.Content)) > remaining:
+			log.Content = truncateUTF8(log.Content, int(remaining)) + domain.ExecutionLogTruncatedMarker
+			s.logger.Warn("任务执行日志超出存储上限，已截断", elog.Int64("executionId", log.ExecutionID))
+		}
+		sizes[log.ExecutionID] = size + int64(len(log.Content))
+		accepted = append(accepted, log)
+	}
+	return s.repo.BatchCreate(ctx, accepted)
+}
+
+func (s *logService) List(ctx context.Context, executionID, afterID int64, limit int) ([]domain.ExecutionLog, error) {
+	return s.repo.FindByExecutionID(ctx, executionID, afterID, limit)
+}
+
+func (s *logService) Tail(ctx context.Context, executionID, afterID int64, limit int) ([]domain.ExecutionLog, error) {
+	return s.repo.FindCreatedBefore(ctx, executionID, afterID, time.Now().Add(-LogSettleWindow).UnixMilli(), limit)
+}
+
+// truncateUTF8 按字节数截断字符串，截断位置回退到字符边界，避免把多字节字符（如中文）截成非法的 UTF-8
+func truncateUTF8(s string, n int) string {
+	if n >= len(s) {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
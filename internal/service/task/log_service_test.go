@@ -0,0 +1,82 @@
+//go:build unit
+
+package task
+
+import (
+	"context"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/Duke1616/ework-runner/internal/domain"
+	"github.com/Duke1616/ework-runner/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 超出存储上限的日志按字节截断，截断位置落在多字节字符中间时回退到字符边界
+func TestLogService_AppendTruncate(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		stored  int64
+		content string
+		want    string
+	}{
+		{
+			name:    "没有超出上限",
+			content: "同步完成",
+			want:    "同步完成",
+		},
+		{
+			name:    "截断位置在字符边界上",
+			stored:  6,
+			content: "同步完成",
+			want:    "同步" + domain.ExecutionLogTruncatedMarker,
+		},
+		{
+			name:    "截断位置在多字节字符中间",
+			stored:  4,
+			content: "同步完成",
+			want:    "同步" + domain.ExecutionLogTruncatedMarker,
+		},
+		{
+			name:    "剩余空间不足一个字符",
+			stored:  10,
+			content: "同步完成",
+			want:    domain.ExecutionLogTruncatedMarker,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := &memLogRepo{stored: tc.stored}
+			svc := NewLogService(repo).(*logService)
+			svc.maxSize = 12
+
+			err := svc.Append(context.Background(), []domain.ExecutionLog{{ExecutionID: 1, Content: tc.content}})
+			require.NoError(t, err)
+			require.Len(t, repo.logs, 1)
+			assert.Equal(t, tc.want, repo.logs[0].Content)
+			assert.True(t, utf8.ValidString(repo.logs[0].Content))
+		})
+	}
+}
+
+// memLogRepo 已经存储了 stored 字节日志的执行
+type memLogRepo struct {
+	repository.ExecutionLogRepository
+	stored int64
+	logs   []domain.ExecutionLog
+}
+
+func (r *memLogRepo) TotalSize(_ context.Context, _ int64) (int64, error) {
+	return r.stored, nil
+}
+
+func (r *memLogRepo) BatchCreate(_ context.Context, logs []domain.ExecutionLog) error {
+	r.logs = append(r.logs, logs...)
+	return nil
+}
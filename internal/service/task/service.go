@@ -2,12 +2,14 @@ package task
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/Duke1616/ework-runner/internal/domain"
 	"github.com/Duke1616/ework-runner/internal/errs"
 	"github.com/Duke1616/ework-runner/internal/repository"
+	"github.com/Duke1616/ework-runner/pkg/retry"
 )
 
 // Service 任务服务接口
@@ -16,12 +18,15 @@ type Service interface {
 	Create(ctx context.Context, task domain.Task) (domain.Task, error)
 	// SchedulableTasks 获取可调度的任务列表，preemptedTimeoutMs 表示处于 PREEMPTED 状态任务的超时时间（毫秒）
 	SchedulableTasks(ctx context.Context, preemptedTimeoutMs int64, limit int) ([]domain.Task, error)
-	// UpdateNextTime 更新任务的下次执行时间
+	// UpdateNextTime 更新任务的下次执行时间，版本号冲突时重新读取任务后重试
 	UpdateNextTime(ctx context.Context, id int64) (domain.Task, error)
 	// GetByID 根据ID获取task
 	GetByID(ctx context.Context, id int64) (domain.Task, error)
 }
 
+// maxUpdateNextTimeAttempts 更新下次执行时间遇到版本号冲突时最多尝试的次数
+const maxUpdateNextTimeAttempts = 3
+
 type service struct {
 	repo repository.TaskRepository
 }
@@ -43,6 +48,13 @@ func (s *service) Create(ctx context.Context, task domain.Task) (domain.Task, er
 		return domain.Task{}, errs.ErrInvalidTaskCronExpr
 	}
 	task.NextTime = nextTime.UnixMilli()
+
+	// 校验重试配置，避免非法配置在任务失败时才被发现
+	if task.RetryConfig != nil {
+		if _, err = retry.NewRetry(task.RetryConfig.ToRetryComponentConfig()); err != nil {
+			return domain.Task{}, fmt.Errorf("%w: %w", errs.ErrInvalidTaskRetryConfig, err)
+		}
+	}
 	return s.repo.Create(ctx, task)
 }
 
@@ -51,6 +63,19 @@ func (s *service) SchedulableTasks(ctx context.Context, preemptedTimeoutMs int64
 }
 
 func (s *service) UpdateNextTime(ctx context.Context, id int64) (domain.Task, error) {
+	var err error
+	for i := 0; i < maxUpdateNextTimeAttempts; i++ {
+		var task domain.Task
+		task, err = s.updateNextTime(ctx, id)
+		// 版本号冲突（如并发续约）时重新读取任务后重试
+		if !errors.Is(err, errs.ErrTaskUpdateNextTimeFailed) {
+			return task, err
+		}
+	}
+	return domain.Task{}, err
+}
+
+func (s *service) updateNextTime(ctx context.Context, id int64) (domain.Task, error) {
 	task, err := s.GetByID(ctx, id)
 	if err != nil {
 		return domain.Task{}, err
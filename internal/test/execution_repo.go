@@ -0,0 +1,181 @@
+package test
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Duke1616/ework-runner/internal/domain"
+	"github.com/Duke1616/ework-runner/internal/errs"
+	"github.com/Duke1616/ework-runner/internal/repository"
+)
+
+// MemExecutionRepo 基于内存的执行记录仓储，同时充当发件箱，供各层的单元测试共用
+// 条件更新与数据库一样以读取到的状态为条件，认领按状态和租约做 CAS，未实现的方法调用时 panic
+type MemExecutionRepo struct {
+	repository.TaskExecutionRepository
+	repository.OutboxRepository
+
+	mu         sync.Mutex
+	executions map[int64]domain.TaskExecution
+	attempts   map[int64][]domain.ExecutionAttempt
+	outbox     map[int64]domain.OutboxMessage
+}
+
+func NewMemExecutionRepo(executions ...domain.TaskExecution) *MemExecutionRepo {
+	repo := &MemExecutionRepo{
+		executions: make(map[int64]domain.TaskExecution),
+		attempts:   make(map[int64][]domain.ExecutionAttempt),
+		outbox:     make(map[int64]domain.OutboxMessage),
+	}
+	for _, execution := range executions {
+		repo.executions[execution.ID] = execution
+	}
+	return repo
+}
+
+// Get 返回执行记录的当前快照
+func (r *MemExecutionRepo) Get(id int64) domain.TaskExecution {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.executions[id]
+}
+
+// Attempts 返回执行记录所有尝试的快照
+func (r *MemExecutionRepo) Attempts(id int64) []domain.ExecutionAttempt {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]domain.ExecutionAttempt(nil), r.attempts[id]...)
+}
+
+// AddAttempts 预置执行记录已有的尝试
+func (r *MemExecutionRepo) AddAttempts(attempts ...domain.ExecutionAttempt) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, attempt := range attempts {
+		r.attempts[attempt.ExecutionID] = append(r.attempts[attempt.ExecutionID], attempt)
+	}
+}
+
+func (r *MemExecutionRepo) GetByID(_ context.Context, id int64) (domain.TaskExecution, error) {
+	return r.Get(id), nil
+}
+
+func (r *MemExecutionRepo) StartAttempt(_ context.Context, id int64, kind domain.AttemptKind, executorNodeID string, maxAttempts int64) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	execution := r.executions[id]
+	if execution.Attempts >= maxAttempts {
+		return 0, errs.ErrExecutionMaxAttemptsExceeded
+	}
+	execution.Attempts++
+	if kind == domain.AttemptKindRetry {
+		execution.RetryCount++
+	}
+	r.executions[id] = execution
+	r.attempts[id] = append(r.attempts[id], domain.ExecutionAttempt{
+		ExecutionID:    id,
+		Attempt:        execution.Attempts,
+		Kind:           kind,
+		ExecutorNodeID: executorNodeID,
+		StartTime:      time.Now().UnixMilli(),
+	})
+	return execution.Attempts, nil
+}
+
+func (r *MemExecutionRepo) UpdateAttempt(_ context.Context, attempt domain.ExecutionAttempt) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, a := range r.attempts[attempt.ExecutionID] {
+		if a.Attempt == attempt.Attempt && a.EndTime == 0 {
+			if attempt.ExecutorNodeID != "" {
+				a.ExecutorNodeID = attempt.ExecutorNodeID
+			}
+			a.Status = attempt.Status
+			a.EndTime = attempt.EndTime
+			a.ErrorMessage = attempt.ErrorMessage
+			a.ErrorCategory = attempt.ErrorCategory
+			r.attempts[attempt.ExecutionID][i] = a
+		}
+	}
+	return nil
+}
+
+func (r *MemExecutionRepo) FindAttempts(_ context.Context, id int64) ([]domain.ExecutionAttempt, error) {
+	return r.Attempts(id), nil
+}
+
+func (r *MemExecutionRepo) UpdateRetryResult(_ context.Context, id, retryCount, nextRetryTime int64,
+	from, status domain.TaskExecutionStatus, progress int32, endTime int64, result domain.ExecutionResult, _ map[string]string,
+	executorNodeID string, failedNodeIDs []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	execution := r.executions[id]
+	if execution.Status != from || !from.CanUpdateTo(status) {
+		return errs.ErrInvalidTaskExecutionStatus
+	}
+	execution.RetryCount = retryCount
+	execution.NextRetryTime = nextRetryTime
+	execution.Status = status
+	execution.RunningProgress = progress
+	execution.EndTime = endTime
+	execution.Result = result
+	execution.ExecutorNodeID = executorNodeID
+	execution.FailedNodeIDs = failedNodeIDs
+	execution.DispatchingUntil = 0
+	r.executions[id] = execution
+	return nil
+}
+
+func (r *MemExecutionRepo) FindRetryableExecutions(_ context.Context, limit int) ([]domain.TaskExecution, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now().UnixMilli()
+	var executions []domain.TaskExecution
+	for _, execution := range r.executions {
+		if execution.Status.IsFailedRetryable() && execution.NextRetryTime <= now &&
+			execution.DispatchingUntil <= now && len(executions) < limit {
+			executions = append(executions, execution)
+		}
+	}
+	return executions, nil
+}
+
+func (r *MemExecutionRepo) ClaimDispatch(_ context.Context, id int64, status domain.TaskExecutionStatus, leaseUntil int64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	execution := r.executions[id]
+	if execution.Status != status || execution.DispatchingUntil > time.Now().UnixMilli() {
+		return false, nil
+	}
+	execution.DispatchingUntil = leaseUntil
+	r.executions[id] = execution
+	return true, nil
+}
+
+func (r *MemExecutionRepo) Complete(_ context.Context, id int64, from, status domain.TaskExecutionStatus, progress int32,
+	endTime int64, result domain.ExecutionResult, msg domain.OutboxMessage) (domain.OutboxMessage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	execution := r.executions[id]
+	if execution.Status != from || !from.CanUpdateTo(status) {
+		return domain.OutboxMessage{}, errs.ErrInvalidTaskExecutionStatus
+	}
+	execution.Status = status
+	execution.RunningProgress = progress
+	execution.EndTime = endTime
+	execution.Result = result
+	execution.CompletionHandled = false
+	r.executions[id] = execution
+	msg.ID = int64(len(r.outbox) + 1)
+	msg.ExecutionID = id
+	r.outbox[msg.ID] = msg
+	return msg, nil
+}
+
+func (r *MemExecutionRepo) Delete(_ context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.outbox, id)
+	return nil
+}
@@ -0,0 +1,49 @@
+package test
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Duke1616/ework-runner/internal/domain"
+	"github.com/Duke1616/ework-runner/pkg/grpc/balancer"
+)
+
+// StubInvoker 返回固定的执行状态，并记录调用次数以及调用时排除的执行节点
+type StubInvoker struct {
+	State domain.ExecutionState
+	Err   error
+
+	mu       sync.Mutex
+	calls    int
+	excluded []string
+}
+
+func (i *StubInvoker) Name() string {
+	return "stub"
+}
+
+func (i *StubInvoker) Run(ctx context.Context, _ domain.TaskExecution) (domain.ExecutionState, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.calls++
+	i.excluded, _ = balancer.GetExcludedNodeIDs(ctx)
+	return i.State, i.Err
+}
+
+func (i *StubInvoker) Prepare(_ context.Context, _ domain.TaskExecution) (map[string]string, error) {
+	return nil, nil
+}
+
+// Calls 返回 Run 被调用的次数
+func (i *StubInvoker) Calls() int {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.calls
+}
+
+// ExcludedNodeIDs 返回最近一次调用时排除的执行节点
+func (i *StubInvoker) ExcludedNodeIDs() []string {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.excluded
+}
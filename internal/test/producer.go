@@ -0,0 +1,36 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Duke1616/ework-runner/internal/event"
+	"github.com/stretchr/testify/require"
+)
+
+// ChanProducer 把完成事件写入通道
+type ChanProducer struct {
+	Events chan event.Event
+}
+
+func NewChanProducer() *ChanProducer {
+	return &ChanProducer{Events: make(chan event.Event, 1)}
+}
+
+func (p *ChanProducer) Produce(_ context.Context, evt event.Event) error {
+	p.Events <- evt
+	return nil
+}
+
+// Wait 等待下一个完成事件，超时后测试失败
+func (p *ChanProducer) Wait(t *testing.T) event.Event {
+	t.Helper()
+	select {
+	case evt := <-p.Events:
+		return evt
+	case <-time.After(time.Second):
+		require.FailNow(t, "等待完成事件超时")
+		return event.Event{}
+	}
+}
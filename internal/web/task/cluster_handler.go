@@ -0,0 +1,90 @@
+package task
+
+import (
+	"time"
+
+	"github.com/Duke1616/ework-runner/internal/domain"
+	"github.com/Duke1616/ework-runner/internal/service/cluster"
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/ecodeclub/ginx"
+	"github.com/gin-gonic/gin"
+)
+
+var _ ginx.Handler = &ClusterHandler{}
+
+// ClusterHandler 调度集群状态相关接口
+type ClusterHandler struct {
+	svc cluster.Service
+}
+
+func NewClusterHandler(svc cluster.Service) *ClusterHandler {
+	return &ClusterHandler{svc: svc}
+}
+
+func (h *ClusterHandler) PublicRoutes(_ *gin.Engine) {
+}
+
+func (h *ClusterHandler) PrivateRoutes(server *gin.Engine) {
+	g := server.Group("/api/cluster")
+	g.POST("/state", ginx.B[ClusterStateReq](h.State))
+	g.POST("/release", ginx.B[ForceReleaseReq](h.ForceRelease))
+}
+
+// State 查询调度节点、节点抢占的任务以及执行节点
+func (h *ClusterHandler) State(ctx *ginx.Context, req ClusterStateReq) (ginx.Result, error) {
+	state, err := h.svc.State(ctx, req.ExecutorServices)
+	if err != nil {
+		return systemErrorResult, err
+	}
+
+	now := time.Now().UnixMilli()
+	executorNodes := make(map[string][]ExecutorNodeVO, len(state.ExecutorNodes))
+	for name, nodes := range state.ExecutorNodes {
+		executorNodes[name] = slice.Map(nodes, func(_ int, src domain.ExecutorNode) ExecutorNodeVO {
+			return ExecutorNodeVO{
+				NodeID:  src.NodeID,
+				Address: src.Address,
+			}
+		})
+	}
+	return ginx.Result{
+		Data: ClusterStateVO{
+			SchedulerNodes: slice.Map(state.SchedulerNodes, func(_ int, src domain.SchedulerNodeState) SchedulerNodeVO {
+				return toSchedulerNodeVO(src, now)
+			}),
+			ExecutorNodes: executorNodes,
+		},
+		Msg: "success",
+	}, nil
+}
+
+// ForceRelease 强制释放卡在 PREEMPTED 状态的任务
+func (h *ClusterHandler) ForceRelease(ctx *ginx.Context, req ForceReleaseReq) (ginx.Result, error) {
+	if err := h.svc.ForceRelease(ctx, req.TaskID); err != nil {
+		return systemErrorResult, err
+	}
+	return ginx.Result{
+		Msg: "success",
+	}, nil
+}
+
+func toSchedulerNodeVO(src domain.SchedulerNodeState, now int64) SchedulerNodeVO {
+	return SchedulerNodeVO{
+		NodeID:              src.Node.NodeID,
+		Online:              src.Online,
+		StartTime:           src.Node.StartTime,
+		LastRenewTime:       src.Node.LastRenewTime,
+		LastScheduleTime:    src.Node.LastScheduleTime,
+		RenewHealthy:        src.Online && src.Node.IsRenewHealthy(now),
+		ScheduleLoopHealthy: src.Online && src.Node.IsScheduleLoopHealthy(now),
+		PreemptedTasks: slice.Map(src.PreemptedTasks, func(_ int, task domain.Task) PreemptedTaskVO {
+			return PreemptedTaskVO{
+				ID:       task.ID,
+				Name:     task.Name,
+				Version:  task.Version,
+				NextTime: task.NextTime,
+				Utime:    task.UTime,
+			}
+		}),
+	}
+}
@@ -0,0 +1,74 @@
+package task
+
+import (
+	"github.com/Duke1616/ework-runner/internal/domain"
+	"github.com/Duke1616/ework-runner/internal/service/deadletter"
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/ecodeclub/ginx"
+	"github.com/gin-gonic/gin"
+)
+
+// defaultDeadLetterLimit 单次查询死信的默认数量
+const defaultDeadLetterLimit = 20
+
+var _ ginx.Handler = &DeadLetterHandler{}
+
+// DeadLetterHandler 死信相关接口
+type DeadLetterHandler struct {
+	svc deadletter.Service
+}
+
+func NewDeadLetterHandler(svc deadletter.Service) *DeadLetterHandler {
+	return &DeadLetterHandler{svc: svc}
+}
+
+func (h *DeadLetterHandler) PublicRoutes(_ *gin.Engine) {
+}
+
+func (h *DeadLetterHandler) PrivateRoutes(server *gin.Engine) {
+	g := server.Group("/api/dead-letter")
+	g.POST("/list", ginx.B[ListDeadLettersReq](h.List))
+	g.POST("/retry", ginx.B[RetryDeadLetterReq](h.Retry))
+}
+
+// List 分页查询死信
+func (h *DeadLetterHandler) List(ctx *ginx.Context, req ListDeadLettersReq) (ginx.Result, error) {
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultDeadLetterLimit
+	}
+	letters, total, err := h.svc.List(ctx, domain.DeadLetterStatus(req.Status), req.Offset, limit)
+	if err != nil {
+		return systemErrorResult, err
+	}
+	return ginx.Result{
+		Data: DeadLettersVO{
+			Total:       total,
+			DeadLetters: slice.Map(letters, toDeadLetterVO),
+		},
+		Msg: "success",
+	}, nil
+}
+
+// Retry 将死信对应的执行记录重新放回重试队列
+func (h *DeadLetterHandler) Retry(ctx *ginx.Context, req RetryDeadLetterReq) (ginx.Result, error) {
+	if err := h.svc.Retry(ctx, req.ID); err != nil {
+		return systemErrorResult, err
+	}
+	return ginx.Result{
+		Msg: "success",
+	}, nil
+}
+
+func toDeadLetterVO(_ int, src domain.DeadLetter) DeadLetterVO {
+	return DeadLetterVO{
+		ID:          src.ID,
+		ExecutionID: src.ExecutionID,
+		TaskID:      src.TaskID,
+		TaskName:    src.TaskName,
+		Status:      src.Status.String(),
+		Execution:   toExecutionDetailVO(src.Execution, nil),
+		Ctime:       src.CTime,
+		Utime:       src.UTime,
+	}
+}
@@ -0,0 +1,214 @@
+package task
+
+import (
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/Duke1616/ework-runner/internal/domain"
+	"github.com/Duke1616/ework-runner/internal/service/hook"
+	"github.com/Duke1616/ework-runner/internal/service/task"
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/ecodeclub/ginx"
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	"github.com/gotomicro/ego/core/elog"
+)
+
+const (
+	// defaultLogLimit 单次查询日志块的默认数量
+	defaultLogLimit = 100
+	// tailPollInterval 实时日志轮询间隔
+	tailPollInterval = time.Second
+)
+
+var _ ginx.Handler = &ExecutionHandler{}
+
+// ExecutionHandler 任务执行相关接口
+type ExecutionHandler struct {
+	execSvc task.ExecutionService
+	logSvc  task.LogService
+	hookSvc hook.Service
+	logger  *elog.Component
+}
+
+func NewExecutionHandler(execSvc task.ExecutionService, logSvc task.LogService, hookSvc hook.Service) *ExecutionHandler {
+	return &ExecutionHandler{
+		execSvc: execSvc,
+		logSvc:  logSvc,
+		hookSvc: hookSvc,
+		logger:  elog.DefaultLogger.With(elog.FieldComponentName("web.ExecutionHandler")),
+	}
+}
+
+func (h *ExecutionHandler) PublicRoutes(_ *gin.Engine) {
+}
+
+func (h *ExecutionHandler) PrivateRoutes(server *gin.Engine) {
+	g := server.Group("/api/execution")
+	g.POST("/detail", ginx.B[ExecutionDetailReq](h.Detail))
+	g.POST("/logs", ginx.B[ListLogsReq](h.ListLogs))
+	g.GET("/logs/tail", h.TailLogs)
+	g.POST("/hook-deliveries", ginx.B[ListHookDeliveriesReq](h.ListHookDeliveries))
+}
+
+// Detail 查询执行记录详情，包括每一次尝试
+func (h *ExecutionHandler) Detail(ctx *ginx.Context, req ExecutionDetailReq) (ginx.Result, error) {
+	execution, err := h.execSvc.FindByID(ctx, req.ID)
+	if err != nil {
+		return systemErrorResult, err
+	}
+	attempts, err := h.execSvc.FindAttempts(ctx, req.ID)
+	if err != nil {
+		return systemErrorResult, err
+	}
+	return ginx.Result{
+		Data: toExecutionDetailVO(execution, attempts),
+		Msg:  "success",
+	}, nil
+}
+
+// ListHookDeliveries 查询执行记录的生命周期事件投递记录
+func (h *ExecutionHandler) ListHookDeliveries(ctx *ginx.Context, req ListHookDeliveriesReq) (ginx.Result, error) {
+	deliveries, err := h.hookSvc.FindDeliveries(ctx, req.ExecutionID)
+	if err != nil {
+		return systemErrorResult, err
+	}
+	return ginx.Result{
+		Data: slice.Map(deliveries, func(_ int, src domain.HookDelivery) HookDeliveryVO {
+			return HookDeliveryVO{
+				ID:        src.ID,
+				Attempt:   src.Attempt,
+				Event:     src.Event.String(),
+				Channel:   src.Channel.String(),
+				Target:    src.Target,
+				Status:    src.Status.String(),
+				Tries:     src.Tries,
+				LastError: src.LastError,
+				Ctime:     src.CTime,
+				Utime:     src.UTime,
+			}
+		}),
+		Msg: "success",
+	}, nil
+}
+
+// ListLogs 分页查询任务执行日志
+func (h *ExecutionHandler) ListLogs(ctx *ginx.Context, req ListLogsReq) (ginx.Result, error) {
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultLogLimit
+	}
+	logs, err := h.logSvc.List(ctx, req.ExecutionID, req.AfterID, limit)
+	if err != nil {
+		return systemErrorResult, err
+	}
+	return ginx.Result{
+		Data: slice.Map(logs, toExecutionLogVO),
+		Msg:  "success",
+	}, nil
+}
+
+// TailLogs 通过 SSE 实时推送任务执行日志，执行结束且日志推送完毕后发送 end 事件并关闭连接
+// 参数 execution_id 必填，after_id 为已经收到的最后一个日志块 ID，断线重连时也可以通过 Last-Event-ID 请求头传递
+// 只推送写入超过 task.LogSettleWindow 的日志块，推送顺序与 ListLogs 一致，不会因为并发写入的批次乱序提交而跳过日志块
+func (h *ExecutionHandler) TailLogs(c *gin.Context) {
+	executionID, err := strconv.ParseInt(c.Query("execution_id"), 10, 64)
+	if err != nil {
+		c.JSON(400, ginx.Result{Code: SystemErrorCode, Msg: "execution_id 非法"})
+		return
+	}
+	afterID, _ := strconv.ParseInt(c.DefaultQuery("after_id", c.GetHeader("Last-Event-ID")), 10, 64)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+
+	ticker := time.NewTicker(tailPollInterval)
+	defer ticker.Stop()
+	c.Stream(func(w io.Writer) bool {
+		// 先查询执行状态再查询日志，保证执行结束前产生的日志都能推送出去
+		execution, err := h.execSvc.FindByID(c, executionID)
+		if err != nil {
+			h.logger.Error("查询任务执行记录失败", elog.Int64("executionId", executionID), elog.FieldErr(err))
+			c.SSEvent("error", err.Error())
+			return false
+		}
+		// 执行结束超过等待时间后，执行结束前写入的日志块都已经可以推送
+		settled := execution.Status.IsTerminalStatus() &&
+			time.Since(time.UnixMilli(execution.EndTime)) > task.LogSettleWindow
+		logs, err := h.logSvc.Tail(c, executionID, afterID, defaultLogLimit)
+		if err != nil {
+			h.logger.Error("查询任务执行日志失败", elog.Int64("executionId", executionID), elog.FieldErr(err))
+			c.SSEvent("error", err.Error())
+			return false
+		}
+		for _, log := range logs {
+			c.Render(-1, sse.Event{
+				Event: "log",
+				Id:    strconv.FormatInt(log.ID, 10),
+				Data:  toExecutionLogVO(0, log),
+			})
+			afterID = log.ID
+		}
+		if len(logs) == defaultLogLimit {
+			return true
+		}
+		if settled {
+			c.SSEvent("end", execution.Status.String())
+			return false
+		}
+
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case <-ticker.C:
+			return true
+		}
+	})
+}
+
+func toExecutionLogVO(_ int, src domain.ExecutionLog) ExecutionLogVO {
+	return ExecutionLogVO{
+		ID:             src.ID,
+		ExecutorNodeID: src.ExecutorNodeID,
+		Content:        src.Content,
+		Timestamp:      src.Timestamp,
+	}
+}
+
+func toExecutionDetailVO(execution domain.TaskExecution, attempts []domain.ExecutionAttempt) ExecutionDetailVO {
+	return ExecutionDetailVO{
+		ID:              execution.ID,
+		TaskID:          execution.Task.ID,
+		TaskName:        execution.Task.Name,
+		Status:          execution.Status.String(),
+		ExecutorNodeID:  execution.ExecutorNodeID,
+		FailedNodeIDs:   execution.FailedNodeIDs,
+		RunningProgress: execution.RunningProgress,
+		RetryCount:      execution.RetryCount,
+		NextRetryTime:   execution.NextRetryTime,
+		StartTime:       execution.StartTime,
+		EndTime:         execution.EndTime,
+		Result: ExecutionResultVO{
+			Payload:       string(execution.Result.Payload),
+			ErrorMessage:  execution.Result.ErrorMessage,
+			ErrorCode:     execution.Result.ErrorCode,
+			ErrorCategory: execution.Result.ErrorCategory,
+			ErrorDetail:   execution.Result.ErrorDetail,
+			ExitCode:      execution.Result.ExitCode,
+		},
+		Attempts: slice.Map(attempts, func(_ int, src domain.ExecutionAttempt) ExecutionAttemptVO {
+			return ExecutionAttemptVO{
+				Attempt:        src.Attempt,
+				Kind:           src.Kind.String(),
+				ExecutorNodeID: src.ExecutorNodeID,
+				Status:         src.Status.String(),
+				StartTime:      src.StartTime,
+				EndTime:        src.EndTime,
+				ErrorMessage:   src.ErrorMessage,
+				ErrorCategory:  src.ErrorCategory,
+			}
+		}),
+	}
+}
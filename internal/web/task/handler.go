@@ -3,6 +3,7 @@ package task
 import (
 	"github.com/Duke1616/ework-runner/internal/domain"
 	"github.com/Duke1616/ework-runner/internal/service/task"
+	"github.com/ecodeclub/ekit/slice"
 	"github.com/ecodeclub/ginx"
 	"github.com/gin-gonic/gin"
 )
@@ -40,6 +41,30 @@ func (h *Handler) Create(ctx *ginx.Context, req CreateTaskReq) (ginx.Result, err
 }
 
 func toDomain(req CreateTaskReq) domain.Task {
+	var alertConfig *domain.AlertConfig
+	if req.AlertConfig != nil {
+		alertConfig = &domain.AlertConfig{
+			Webhooks: req.AlertConfig.Webhooks,
+			Emails:   req.AlertConfig.Emails,
+			Topic:    req.AlertConfig.Topic,
+		}
+	}
+
+	var hookConfig *domain.HookConfig
+	if req.HookConfig != nil {
+		hookConfig = &domain.HookConfig{
+			Events: slice.Map(req.HookConfig.Events, func(_ int, src string) domain.HookEventType {
+				return domain.HookEventType(src)
+			}),
+			Webhooks: slice.Map(req.HookConfig.Webhooks, func(_ int, src WebhookTarget) domain.WebhookTarget {
+				return domain.WebhookTarget{URL: src.URL, Secret: src.Secret}
+			}),
+			Topics:             req.HookConfig.Topics,
+			Callbacks:          req.HookConfig.Callbacks,
+			LongRunningPercent: req.HookConfig.LongRunningPercent,
+		}
+	}
+
 	return domain.Task{
 		Name:                req.Name,
 		Type:                domain.TaskType(req.Type),
@@ -56,11 +81,15 @@ func toDomain(req CreateTaskReq) domain.Task {
 			Params:   req.HTTPConfig.Params,
 		},
 		RetryConfig: &domain.RetryConfig{
+			Type:            req.RetryConfig.Type,
 			MaxRetries:      req.RetryConfig.MaxRetries,
 			MaxInterval:     req.RetryConfig.MaxInterval,
 			InitialInterval: req.RetryConfig.InitialInterval,
+			Schedule:        req.RetryConfig.Schedule,
 		},
-		Status:  domain.TaskStatusActive,
-		Version: 1,
+		AlertConfig: alertConfig,
+		HookConfig:  hookConfig,
+		Status:      domain.TaskStatusActive,
+		Version:     1,
 	}
 }
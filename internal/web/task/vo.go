@@ -7,6 +7,8 @@ type CreateTaskReq struct {
 	GrpcConfig          *GrpcConfig       `json:"grpc_config"`
 	HTTPConfig          *HTTPConfig       `json:"http_config"`
 	RetryConfig         *RetryConfig      `json:"retry_config"`
+	AlertConfig         *AlertConfig      `json:"alert_config"`
+	HookConfig          *HookConfig       `json:"hook_config"`
 	MaxExecutionSeconds int64             `json:"max_execution_seconds"` // 最大执行秒数，默认24小时
 	ScheduleParams      map[string]string `json:"schedule_params"`       // 调度参数（如分页偏移量、处理进度等）
 }
@@ -23,7 +25,163 @@ type HTTPConfig struct {
 }
 
 type RetryConfig struct {
-	MaxRetries      int32 `json:"max_retries"`
-	InitialInterval int64 `json:"initial_interval"` // 毫秒
-	MaxInterval     int64 `json:"max_interval"`     // 毫秒
+	Type            string  `json:"type"` // 重试策略: fixed、exponential（默认）、exponential_jitter、schedule
+	MaxRetries      int32   `json:"max_retries"`
+	InitialInterval int64   `json:"initial_interval"` // 毫秒，fixed 策略下为固定的重试间隔
+	MaxInterval     int64   `json:"max_interval"`     // 毫秒
+	Schedule        []int64 `json:"schedule"`         // 毫秒，schedule 策略下每次重试的间隔，如 [10000, 60000, 600000]
+}
+
+// AlertConfig 执行重试用尽或不可重试失败进入死信后的告警通道，可同时配置多个
+type AlertConfig struct {
+	Webhooks []string `json:"webhooks"` // 以 POST JSON 方式回调的地址
+	Emails   []string `json:"emails"`   // 收件人邮箱
+	Topic    string   `json:"topic"`    // 消息队列 topic
+}
+
+// HookConfig 执行生命周期事件订阅
+type HookConfig struct {
+	Events             []string        `json:"events"`               // START、SUCCESS、FAILURE、TIMEOUT、LONG_RUNNING，为空时订阅全部
+	Webhooks           []WebhookTarget `json:"webhooks"`             // HTTP 回调
+	Topics             []string        `json:"topics"`               // Kafka topic
+	Callbacks          []string        `json:"callbacks"`            // 调度节点进程内注册的回调名称
+	LongRunningPercent int32           `json:"long_running_percent"` // 运行时间超过 max_execution_seconds 的百分比视为长时间运行，默认 80
+}
+
+type WebhookTarget struct {
+	URL    string `json:"url"`
+	Secret string `json:"secret"` // 不为空时对请求做 HMAC-SHA256 签名
+}
+
+type ListLogsReq struct {
+	ExecutionID int64 `json:"execution_id"`
+	AfterID     int64 `json:"after_id"` // 只返回 ID 大于该值的日志块，用于增量拉取
+	Limit       int   `json:"limit"`
+}
+
+type ExecutionLogVO struct {
+	ID             int64  `json:"id"`
+	ExecutorNodeID string `json:"executor_node_id"`
+	Content        string `json:"content"`
+	Timestamp      int64  `json:"timestamp"`
+}
+
+type ExecutionDetailReq struct {
+	ID int64 `json:"id"`
+}
+
+type ExecutionDetailVO struct {
+	ID              int64                `json:"id"`
+	TaskID          int64                `json:"task_id"`
+	TaskName        string               `json:"task_name"`
+	Status          string               `json:"status"`
+	ExecutorNodeID  string               `json:"executor_node_id"` // 最近一次尝试的执行节点
+	FailedNodeIDs   []string             `json:"failed_node_ids"`
+	RunningProgress int32                `json:"running_progress"`
+	RetryCount      int64                `json:"retry_count"` // 已发起的重试次数
+	NextRetryTime   int64                `json:"next_retry_time"`
+	StartTime       int64                `json:"start_time"`
+	EndTime         int64                `json:"end_time"`
+	Result          ExecutionResultVO    `json:"result"`
+	Attempts        []ExecutionAttemptVO `json:"attempts"` // 按尝试序号排列的历史尝试
+}
+
+type ExecutionResultVO struct {
+	Payload       string `json:"payload"`
+	ErrorMessage  string `json:"error_message"`
+	ErrorCode     string `json:"error_code"`
+	ErrorCategory string `json:"error_category"`
+	ErrorDetail   string `json:"error_detail"`
+	ExitCode      int32  `json:"exit_code"`
+}
+
+type ExecutionAttemptVO struct {
+	Attempt        int64  `json:"attempt"`
+	Kind           string `json:"kind"` // RUN、RETRY、RESCHEDULE
+	ExecutorNodeID string `json:"executor_node_id"`
+	Status         string `json:"status"`
+	StartTime      int64  `json:"start_time"`
+	EndTime        int64  `json:"end_time"`
+	ErrorMessage   string `json:"error_message"`
+	ErrorCategory  string `json:"error_category"`
+}
+
+type ListDeadLettersReq struct {
+	Status string `json:"status"` // PENDING、RETRIED，为空时查询全部
+	Offset int    `json:"offset"`
+	Limit  int    `json:"limit"`
+}
+
+type RetryDeadLetterReq struct {
+	ID int64 `json:"id"`
+}
+
+type DeadLetterVO struct {
+	ID          int64             `json:"id"`
+	ExecutionID int64             `json:"execution_id"`
+	TaskID      int64             `json:"task_id"`
+	TaskName    string            `json:"task_name"`
+	Status      string            `json:"status"`
+	Execution   ExecutionDetailVO `json:"execution"` // 进入死信时的执行记录快照
+	Ctime       int64             `json:"ctime"`
+	Utime       int64             `json:"utime"`
+}
+
+type DeadLettersVO struct {
+	Total       int64          `json:"total"`
+	DeadLetters []DeadLetterVO `json:"dead_letters"`
+}
+
+type ListHookDeliveriesReq struct {
+	ExecutionID int64 `json:"execution_id"`
+}
+
+type HookDeliveryVO struct {
+	ID        int64  `json:"id"`
+	Attempt   int64  `json:"attempt"`
+	Event     string `json:"event"`
+	Channel   string `json:"channel"`
+	Target    string `json:"target"`
+	Status    string `json:"status"` // PENDING、SUCCESS、FAILED
+	Tries     int32  `json:"tries"`
+	LastError string `json:"last_error"`
+	Ctime     int64  `json:"ctime"`
+	Utime     int64  `json:"utime"`
+}
+
+type ClusterStateReq struct {
+	ExecutorServices []string `json:"executor_services"` // 要查询的执行节点服务名，为空时查询所有任务配置的服务
+}
+
+type ClusterStateVO struct {
+	SchedulerNodes []SchedulerNodeVO           `json:"scheduler_nodes"`
+	ExecutorNodes  map[string][]ExecutorNodeVO `json:"executor_nodes"` // 按服务名分组
+}
+
+type SchedulerNodeVO struct {
+	NodeID              string            `json:"node_id"`
+	Online              bool              `json:"online"` // 注册中心中是否还有心跳
+	StartTime           int64             `json:"start_time"`
+	LastRenewTime       int64             `json:"last_renew_time"`
+	LastScheduleTime    int64             `json:"last_schedule_time"`
+	RenewHealthy        bool              `json:"renew_healthy"`
+	ScheduleLoopHealthy bool              `json:"schedule_loop_healthy"`
+	PreemptedTasks      []PreemptedTaskVO `json:"preempted_tasks"`
+}
+
+type PreemptedTaskVO struct {
+	ID       int64  `json:"id"`
+	Name     string `json:"name"`
+	Version  int64  `json:"version"`
+	NextTime int64  `json:"next_time"`
+	Utime    int64  `json:"utime"` // 最近一次续约时间
+}
+
+type ExecutorNodeVO struct {
+	NodeID  string `json:"node_id"`
+	Address string `json:"address"`
+}
+
+type ForceReleaseReq struct {
+	TaskID int64 `json:"task_id"`
 }
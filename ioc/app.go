@@ -8,6 +8,7 @@ import (
 	grpcpkg "github.com/Duke1616/ework-runner/pkg/grpc"
 	"github.com/gin-gonic/gin"
 	"github.com/gotomicro/ego/server/egin"
+	"github.com/gotomicro/ego/server/egovernor"
 	clientv3 "go.etcd.io/etcd/client/v3"
 )
 
@@ -26,6 +27,7 @@ type SchedulerApp struct {
 	Web       *egin.Component
 	Server    *grpcpkg.Server
 	Scheduler *scheduler.Scheduler
+	Governor  *egovernor.Component
 	Tasks     []Task
 }
 
@@ -0,0 +1,27 @@
+package ioc
+
+import (
+	"github.com/Duke1616/ework-runner/internal/repository/dao"
+	"github.com/spf13/viper"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// InitSchedulerNodeDAO 调度节点心跳与执行节点一样注册在 etcd 中，进程失联后随租约过期自动删除
+func InitSchedulerNodeDAO(etcdClient *clientv3.Client) dao.SchedulerNodeDAO {
+	type Config struct {
+		Prefix string `yaml:"prefix"`
+		TTL    int    `yaml:"ttl"` // 租约秒数
+	}
+	cfg := Config{
+		Prefix: "scheduler/nodes",
+		TTL:    15,
+	}
+	if err := viper.UnmarshalKey("cluster", &cfg); err != nil {
+		panic(err)
+	}
+	d, err := dao.NewEtcdSchedulerNodeDAO(etcdClient, cfg.Prefix, cfg.TTL)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
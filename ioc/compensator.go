@@ -1,12 +1,17 @@
 package ioc
 
 import (
+	"time"
+
 	executorv1 "github.com/Duke1616/ework-runner/api/proto/gen/executor/v1"
 	"github.com/Duke1616/ework-runner/internal/compensator"
+	"github.com/Duke1616/ework-runner/internal/service/hook"
 	"github.com/Duke1616/ework-runner/internal/service/runner"
 	"github.com/Duke1616/ework-runner/internal/service/task"
 	"github.com/Duke1616/ework-runner/pkg/grpc"
+	"github.com/Duke1616/ework-runner/pkg/grpc/registry"
 	"github.com/spf13/viper"
+	clientv3 "go.etcd.io/etcd/client/v3"
 )
 
 func InitRetryCompensator(
@@ -40,8 +45,29 @@ func InitRescheduleCompensator(
 		cfg)
 }
 
+func InitPrepareCompensator(
+	runner runner.Runner,
+	execSvc task.ExecutionService,
+) *compensator.PrepareCompensator {
+	var cfg compensator.PrepareConfig
+	err := viper.UnmarshalKey("compensator.prepare", &cfg)
+	if err != nil {
+		panic(err)
+	}
+	if cfg.PrepareTimeoutWindowMs == 0 {
+		// 兼容配置在重试补偿器下的 PREPARE 超时窗口
+		cfg.PrepareTimeoutWindowMs = viper.GetInt64("compensator.retry.prepareTimeoutWindowMs")
+	}
+	return compensator.NewPrepareCompensator(
+		runner,
+		execSvc,
+		cfg,
+	)
+}
+
 func InitInterruptCompensator(
 	grpcClients *grpc.Clients[executorv1.ExecutorServiceClient],
+	registry registry.Registry,
 	execSvc task.ExecutionService,
 ) *compensator.InterruptCompensator {
 	var cfg compensator.InterruptConfig
@@ -50,8 +76,77 @@ func InitInterruptCompensator(
 		panic(err)
 	}
 	return compensator.NewInterruptCompensator(
+		grpcClients,
+		registry,
+		execSvc,
+		cfg,
+	)
+}
+
+func InitReconcileCompensator(
+	grpcClients *grpc.Clients[executorv1.ExecutorServiceClient],
+	execSvc task.ExecutionService,
+) *compensator.ReconcileCompensator {
+	var cfg compensator.ReconcileConfig
+	err := viper.UnmarshalKey("compensator.reconcile", &cfg)
+	if err != nil {
+		panic(err)
+	}
+	return compensator.NewReconcileCompensator(
 		grpcClients,
 		execSvc,
 		cfg,
 	)
 }
+
+func InitLongRunningCompensator(
+	execSvc task.ExecutionService,
+	hookSvc hook.Service,
+) *compensator.LongRunningCompensator {
+	// 默认每轮扫描 100 条，每 30 秒扫描一次
+	cfg := compensator.LongRunningConfig{
+		BatchSize:   100,
+		MinDuration: 30 * time.Second,
+	}
+	err := viper.UnmarshalKey("compensator.longRunning", &cfg)
+	if err != nil {
+		panic(err)
+	}
+	return compensator.NewLongRunningCompensator(
+		execSvc,
+		hookSvc,
+		cfg,
+	)
+}
+
+// InitLeaderCompensator 补偿器只在通过 etcd 选出的主节点上运行，避免多个调度节点重复处理同一条执行记录
+func InitLeaderCompensator(
+	etcdClient *clientv3.Client,
+	nodeID string,
+	retry *compensator.RetryCompensator,
+	reschedule *compensator.RescheduleCompensator,
+	interrupt *compensator.InterruptCompensator,
+	reconcile *compensator.ReconcileCompensator,
+	prepare *compensator.PrepareCompensator,
+	longRunning *compensator.LongRunningCompensator,
+) *compensator.LeaderCompensator {
+	cfg := compensator.LeaderConfig{
+		Prefix: "scheduler/compensator/leader",
+		TTL:    15,
+	}
+	err := viper.UnmarshalKey("compensator.leader", &cfg)
+	if err != nil {
+		panic(err)
+	}
+	return compensator.NewLeaderCompensator(
+		etcdClient,
+		nodeID,
+		cfg,
+		retry,
+		reschedule,
+		interrupt,
+		reconcile,
+		prepare,
+		longRunning,
+	)
+}
@@ -0,0 +1,34 @@
+package ioc
+
+import (
+	"github.com/Duke1616/ework-runner/internal/event"
+	"github.com/Duke1616/ework-runner/internal/repository"
+	"github.com/Duke1616/ework-runner/internal/service/acquirer"
+	"github.com/Duke1616/ework-runner/internal/service/hook"
+	"github.com/Duke1616/ework-runner/internal/service/task"
+	"github.com/Duke1616/ework-runner/pkg/grpc/registry"
+	"github.com/spf13/viper"
+)
+
+func InitExecutionService(
+	nodeID string,
+	repo repository.TaskExecutionRepository,
+	outboxRepo repository.OutboxRepository,
+	taskSvc task.Service,
+	taskAcquirer acquirer.TaskAcquirer,
+	producer event.CompleteProducer,
+	registry registry.Registry,
+	hookSvc hook.Service,
+) task.ExecutionService {
+	type Config struct {
+		// MaxAttempts 单次执行的最大尝试次数，所有调度节点需要配置相同的值
+		MaxAttempts int64 `yaml:"maxAttempts"`
+	}
+	cfg := Config{
+		MaxAttempts: task.DefaultMaxAttempts,
+	}
+	if err := viper.UnmarshalKey("execution", &cfg); err != nil {
+		panic(err)
+	}
+	return task.NewExecutionService(nodeID, repo, outboxRepo, taskSvc, taskAcquirer, producer, registry, hookSvc, cfg.MaxAttempts)
+}
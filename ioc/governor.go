@@ -0,0 +1,34 @@
+package ioc
+
+import (
+	"github.com/gotomicro/ego/server/egovernor"
+	"github.com/spf13/viper"
+)
+
+// InitSchedulerGovernor 调度节点的治理服务，暴露 /metrics、pprof 等接口
+func InitSchedulerGovernor() *egovernor.Component {
+	const defaultPort = 9003
+	return initGovernor("governor.scheduler", defaultPort)
+}
+
+// InitExecutorGovernor 执行节点的治理服务，默认端口与调度节点错开，便于同机部署
+func InitExecutorGovernor() *egovernor.Component {
+	const defaultPort = 9004
+	return initGovernor("governor.executor", defaultPort)
+}
+
+func initGovernor(key string, defaultPort int) *egovernor.Component {
+	type Config struct {
+		Host string `yaml:"host"`
+		Port int    `yaml:"port"`
+	}
+	cfg := Config{Port: defaultPort}
+	if err := viper.UnmarshalKey(key, &cfg); err != nil {
+		panic(err)
+	}
+	opts := []egovernor.Option{egovernor.WithPort(cfg.Port)}
+	if cfg.Host != "" {
+		opts = append(opts, egovernor.WithHost(cfg.Host))
+	}
+	return egovernor.DefaultContainer().Build(opts...)
+}
@@ -1,12 +1,14 @@
 package ioc
 
 import (
+	"fmt"
 	"time"
 
 	executorv1 "github.com/Duke1616/ework-runner/api/proto/gen/executor/v1"
 	reporterv1 "github.com/Duke1616/ework-runner/api/proto/gen/reporter/v1"
 	grpcapi "github.com/Duke1616/ework-runner/internal/grpc"
 	grpcpkg "github.com/Duke1616/ework-runner/pkg/grpc"
+	"github.com/Duke1616/ework-runner/pkg/grpc/balancer"
 	registrysdk "github.com/Duke1616/ework-runner/pkg/grpc/registry"
 	"github.com/spf13/viper"
 	"google.golang.org/grpc"
@@ -27,10 +29,16 @@ func InitSchedulerNodeGRPCServer(registry registrysdk.Registry, reporter *grpcap
 
 func InitExecutorServiceGRPCClients(reg registrysdk.Registry) *grpcpkg.Clients[executorv1.ExecutorServiceClient] {
 	const defaultTimeout = time.Second
+	// 所有执行节点都被排除时的兜底策略: ignore（默认，忽略排除规则）、reject（直接失败）
+	fallback := balancer.ExcludeFallbackPolicy(viper.GetString("grpc.client.executor.excludeFallback"))
+	if !fallback.IsValid() {
+		panic(fmt.Errorf("未知的排除兜底策略: %s", fallback))
+	}
 	return grpcpkg.NewClients(
 		reg,
 		defaultTimeout,
 		func(conn *grpc.ClientConn) executorv1.ExecutorServiceClient {
 			return executorv1.NewExecutorServiceClient(conn)
-		})
+		},
+		grpcpkg.WithExcludeFallbackPolicy(fallback))
 }
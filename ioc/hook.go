@@ -0,0 +1,61 @@
+package ioc
+
+import (
+	"time"
+
+	"github.com/Duke1616/ework-runner/internal/repository"
+	"github.com/Duke1616/ework-runner/internal/service/hook"
+	"github.com/Duke1616/ework-runner/pkg/retry"
+	"github.com/ecodeclub/mq-api"
+	"github.com/spf13/viper"
+)
+
+func InitHookService(
+	taskRepo repository.TaskRepository,
+	deliveryRepo repository.HookDeliveryRepository,
+	q mq.MQ,
+) hook.Service {
+	type Config struct {
+		WebhookTimeout time.Duration   `yaml:"webhookTimeout"`
+		Retry          retry.Config    `yaml:"retry"`
+		SMTP           hook.SMTPConfig `yaml:"smtp"` // 死信告警邮件
+	}
+	// 默认单个目标最多重试 5 次，间隔 1s 起指数退避，最长 1 分钟
+	const maxRetries = 5
+	cfg := Config{
+		WebhookTimeout: 5 * time.Second,
+		Retry: retry.Config{
+			Type: retry.TypeExponential,
+			ExponentialBackoff: &retry.ExponentialBackoffConfig{
+				InitialInterval: time.Second,
+				MaxInterval:     time.Minute,
+				MaxRetries:      maxRetries,
+			},
+		},
+	}
+	if err := viper.UnmarshalKey("hook", &cfg); err != nil {
+		panic(err)
+	}
+	strategy, err := retry.NewRetry(cfg.Retry)
+	if err != nil {
+		panic(err)
+	}
+	return hook.NewService(taskRepo, deliveryRepo, strategy,
+		hook.NewWebhookSender(cfg.WebhookTimeout),
+		hook.NewKafkaSender(q),
+		hook.NewEmailSender(cfg.SMTP),
+	)
+}
+
+// InitHookRelay 初始化生命周期事件投递中继，所有调度节点都运行
+func InitHookRelay(svc hook.Service) *hook.Relay {
+	// 默认每秒扫描一次
+	cfg := hook.RelayConfig{
+		BatchSize:   100,
+		MinDuration: time.Second,
+	}
+	if err := viper.UnmarshalKey("hook.relay", &cfg); err != nil {
+		panic(err)
+	}
+	return hook.NewRelay(svc, cfg)
+}
@@ -3,23 +3,47 @@ package ioc
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/Duke1616/ework-runner/internal/event/complete"
+	"github.com/Duke1616/ework-runner/internal/event/report"
 	"github.com/Duke1616/ework-runner/internal/service/acquirer"
+	"github.com/Duke1616/ework-runner/internal/service/deadletter"
+	"github.com/Duke1616/ework-runner/internal/service/hook"
 	"github.com/Duke1616/ework-runner/internal/service/task"
 	mqx "github.com/Duke1616/ework-runner/pkg/mpx"
+	"github.com/Duke1616/ework-runner/pkg/retry"
 	"github.com/ecodeclub/mq-api"
+	"github.com/spf13/viper"
 )
 
+// CompleteDLQTopic 完成事件死信 topic 的默认值，通过 consumer.complete.dlqTopic 配置
+const CompleteDLQTopic = "complete_topic_dlq"
+
+// completeDLQTopic 读取完成事件的死信 topic，消费者和发件箱中继共用
+func completeDLQTopic() string {
+	if topic := viper.GetString("consumer.complete.dlqTopic"); topic != "" {
+		return topic
+	}
+	return CompleteDLQTopic
+}
+
 func InitCompleteEventConsumer(q mq.MQ,
 	taskSvc task.Service,
 	execSvc task.ExecutionService,
 	acquire acquirer.TaskAcquirer,
+	dlqSvc deadletter.Service,
+	hookSvc hook.Service,
 ) *CompleteConsumer {
+	dlqProducer, err := q.Producer(completeDLQTopic())
+	if err != nil {
+		panic(err)
+	}
+
 	topic := "complete_topic"
 	group := "reporter"
 	con := mqx.NewConsumer(name(topic, group), q, topic)
-	comConsumer := complete.NewConsumer(execSvc, taskSvc, acquire)
+	comConsumer := complete.NewConsumer(execSvc, taskSvc, acquire, dlqSvc, hookSvc, dlqProducer)
 	return &CompleteConsumer{
 		com:      con,
 		Consumer: comConsumer,
@@ -38,6 +62,94 @@ func (c *CompleteConsumer) Start(ctx context.Context) {
 	}
 }
 
+const (
+	// ReportTopic 执行节点通过消息队列上报执行状态的 topic
+	ReportTopic = "report_topic"
+	// ReportDLQTopic 执行状态上报死信 topic 的默认值，通过 consumer.report.dlqTopic 配置
+	ReportDLQTopic = "report_topic_dlq"
+)
+
+func InitReportEventConsumer(q mq.MQ,
+	execSvc task.ExecutionService,
+) *ReportConsumer {
+	type Config struct {
+		DLQTopic string       `yaml:"dlqTopic"`
+		Retry    retry.Config `yaml:"retry"`
+	}
+	// 默认处理失败后最多重试 3 次，间隔 200ms 起指数退避，最长 2s，之后转入死信 topic
+	cfg := Config{
+		DLQTopic: ReportDLQTopic,
+		Retry: retry.Config{
+			Type: retry.TypeExponential,
+			ExponentialBackoff: &retry.ExponentialBackoffConfig{
+				InitialInterval: 200 * time.Millisecond,
+				MaxInterval:     2 * time.Second,
+				MaxRetries:      3,
+			},
+		},
+	}
+	if err := viper.UnmarshalKey("consumer.report", &cfg); err != nil {
+		panic(err)
+	}
+	strategy, err := retry.NewRetry(cfg.Retry)
+	if err != nil {
+		panic(err)
+	}
+	dlqProducer, err := q.Producer(cfg.DLQTopic)
+	if err != nil {
+		panic(err)
+	}
+
+	topic := ReportTopic
+	group := "scheduler"
+	con := mqx.NewConsumer(name(topic, group), q, topic)
+	return &ReportConsumer{
+		com:      con,
+		Consumer: report.NewConsumer(execSvc, strategy, dlqProducer),
+	}
+}
+
+// ReportConsumer 消费执行节点通过消息队列上报的执行状态
+type ReportConsumer struct {
+	*report.Consumer
+	com *mqx.Consumer
+}
+
+func (c *ReportConsumer) Start(ctx context.Context) {
+	err := c.com.Start(ctx, c.Consume)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// ReportLogTopic 执行节点通过消息队列上报任务日志的 topic
+const ReportLogTopic = "report_log_topic"
+
+func InitReportLogEventConsumer(q mq.MQ,
+	logSvc task.LogService,
+) *ReportLogConsumer {
+	topic := ReportLogTopic
+	group := "scheduler"
+	con := mqx.NewConsumer(name(topic, group), q, topic)
+	return &ReportLogConsumer{
+		com:         con,
+		LogConsumer: report.NewLogConsumer(logSvc),
+	}
+}
+
+// ReportLogConsumer 消费执行节点通过消息队列上报的任务日志
+type ReportLogConsumer struct {
+	*report.LogConsumer
+	com *mqx.Consumer
+}
+
+func (c *ReportLogConsumer) Start(ctx context.Context) {
+	err := c.com.Start(ctx, c.Consume)
+	if err != nil {
+		panic(err)
+	}
+}
+
 func name(eventName, group string) string {
 	return fmt.Sprintf("%s-%s", eventName, group)
 }
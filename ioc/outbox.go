@@ -0,0 +1,51 @@
+package ioc
+
+import (
+	"time"
+
+	"github.com/Duke1616/ework-runner/internal/event"
+	"github.com/Duke1616/ework-runner/internal/event/outbox"
+	"github.com/Duke1616/ework-runner/internal/repository"
+	"github.com/Duke1616/ework-runner/pkg/retry"
+	"github.com/ecodeclub/mq-api"
+	"github.com/spf13/viper"
+)
+
+func InitOutboxRelay(
+	repo repository.OutboxRepository,
+	producer event.CompleteProducer,
+	q mq.MQ,
+) *outbox.Relay {
+	type Config struct {
+		Relay outbox.Config `yaml:"relay"`
+		Retry retry.Config  `yaml:"retry"`
+	}
+	// 默认每秒扫描一次，发送失败后 1s 起指数退避，最长 5 分钟，不限制重试次数
+	cfg := Config{
+		Relay: outbox.Config{
+			BatchSize:   100,
+			MinDuration: time.Second,
+			Lease:       time.Minute,
+		},
+		Retry: retry.Config{
+			Type: retry.TypeExponential,
+			ExponentialBackoff: &retry.ExponentialBackoffConfig{
+				InitialInterval: time.Second,
+				MaxInterval:     5 * time.Minute,
+			},
+		},
+	}
+	if err := viper.UnmarshalKey("outbox", &cfg); err != nil {
+		panic(err)
+	}
+	strategy, err := retry.NewRetry(cfg.Retry)
+	if err != nil {
+		panic(err)
+	}
+	// 无法解析的消息转入完成事件的死信 topic
+	dlqProducer, err := q.Producer(completeDLQTopic())
+	if err != nil {
+		panic(err)
+	}
+	return outbox.NewRelay(repo, producer, dlqProducer, strategy, cfg.Relay)
+}
@@ -2,6 +2,7 @@ package ioc
 
 import (
 	"github.com/Duke1616/ework-runner/internal/service/acquirer"
+	"github.com/Duke1616/ework-runner/internal/service/cluster"
 	"github.com/Duke1616/ework-runner/internal/service/picker"
 	"github.com/Duke1616/ework-runner/internal/service/runner"
 	"github.com/Duke1616/ework-runner/internal/service/scheduler"
@@ -26,6 +27,7 @@ func InitScheduler(
 	execSvc task.ExecutionService,
 	acquirer acquirer.TaskAcquirer,
 	nodePicker picker.ExecutorNodePicker,
+	clusterSvc cluster.Service,
 ) *scheduler.Scheduler {
 	var cfg scheduler.Config
 	err := viper.UnmarshalKey("scheduler", &cfg)
@@ -41,5 +43,6 @@ func InitScheduler(
 		acquirer,
 		cfg,
 		nodePicker,
+		clusterSvc,
 	)
 }
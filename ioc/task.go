@@ -2,18 +2,24 @@ package ioc
 
 import (
 	"github.com/Duke1616/ework-runner/internal/compensator"
+	"github.com/Duke1616/ework-runner/internal/event/outbox"
+	"github.com/Duke1616/ework-runner/internal/service/hook"
 )
 
 func InitTasks(
-	t1 *compensator.RetryCompensator,
-	t2 *compensator.RescheduleCompensator,
-	t3 *compensator.InterruptCompensator,
-	t4 *CompleteConsumer,
+	t1 *compensator.LeaderCompensator,
+	t2 *CompleteConsumer,
+	t3 *ReportConsumer,
+	t4 *outbox.Relay,
+	t5 *ReportLogConsumer,
+	t6 *hook.Relay,
 ) []Task {
 	return []Task{
 		t1,
 		t2,
 		t3,
 		t4,
+		t5,
+		t6,
 	}
 }
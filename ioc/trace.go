@@ -0,0 +1,62 @@
+package ioc
+
+import (
+	"context"
+	"time"
+
+	"github.com/gotomicro/ego/core/elog"
+	"github.com/gotomicro/ego/core/etrace"
+	"github.com/gotomicro/ego/core/etrace/otel"
+	"github.com/spf13/viper"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// InitTracer 按 trace 配置初始化全局 TracerProvider，通过 OTLP 导出 span，返回的函数用于退出前导出剩余的 span
+// NOTE: 需要在 ego.New 之后调用，覆盖 ego 按自身配置初始化的默认 TracerProvider；没有 trace 配置时保持 ego 的默认行为
+func InitTracer(serviceName string) func() {
+	type Config struct {
+		Disable     bool    `yaml:"disable"`
+		ServiceName string  `yaml:"serviceName"`
+		Endpoint    string  `yaml:"endpoint"` // OTLP gRPC 地址
+		Insecure    bool    `yaml:"insecure"`
+		Fraction    float64 `yaml:"fraction"` // 采样率，0 表示只跟随上游的采样决定
+	}
+	if !viper.IsSet("trace") {
+		return func() {}
+	}
+
+	// 默认全量采样
+	cfg := Config{ServiceName: serviceName, Insecure: true, Fraction: 1}
+	if err := viper.UnmarshalKey("trace", &cfg); err != nil {
+		panic(err)
+	}
+	if cfg.Disable {
+		return func() {}
+	}
+
+	container := otel.DefaultConfig()
+	container.ServiceName = cfg.ServiceName
+	container.Fraction = cfg.Fraction
+	container.Otlp.EnableInsecure = cfg.Insecure
+	if cfg.Endpoint != "" {
+		container.Otlp.Endpoint = cfg.Endpoint
+	}
+	tp := container.Build()
+	if tp == nil {
+		elog.Error("初始化链路追踪失败，保持默认配置")
+		return func() {}
+	}
+	etrace.SetGlobalTracer(tp)
+
+	return func() {
+		provider, ok := tp.(*sdktrace.TracerProvider)
+		if !ok {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := provider.Shutdown(ctx); err != nil {
+			elog.Error("导出剩余的 span 失败", elog.FieldErr(err))
+		}
+	}
+}
@@ -9,7 +9,8 @@ import (
 )
 
 func InitGinWebServer(mdls []gin.HandlerFunc, checkPolicyMiddleware *middleware.CheckPolicyMiddlewareBuilder,
-	sp session.Provider, taskHdl *task.Handler) *egin.Component {
+	sp session.Provider, taskHdl *task.Handler, execHdl *task.ExecutionHandler,
+	dlqHdl *task.DeadLetterHandler, clusterHdl *task.ClusterHandler) *egin.Component {
 	session.SetDefaultProvider(sp)
 
 	server := egin.DefaultContainer().Build(egin.WithPort(8765))
@@ -17,6 +18,9 @@ func InitGinWebServer(mdls []gin.HandlerFunc, checkPolicyMiddleware *middleware.
 
 	// 注册公开路由
 	taskHdl.PublicRoutes(server.Engine)
+	execHdl.PublicRoutes(server.Engine)
+	dlqHdl.PublicRoutes(server.Engine)
+	clusterHdl.PublicRoutes(server.Engine)
 
 	// 验证是否登录
 	server.Use(session.CheckLoginMiddleware())
@@ -25,6 +29,9 @@ func InitGinWebServer(mdls []gin.HandlerFunc, checkPolicyMiddleware *middleware.
 
 	// 注册私有路由
 	taskHdl.PrivateRoutes(server.Engine)
+	execHdl.PrivateRoutes(server.Engine)
+	dlqHdl.PrivateRoutes(server.Engine)
+	clusterHdl.PrivateRoutes(server.Engine)
 
 	return server
 }
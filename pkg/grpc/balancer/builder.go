@@ -1,9 +1,23 @@
 package balancer
 
 import (
+	"encoding/json"
+	"fmt"
+
 	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/serviceconfig"
 )
 
+var _ balancer.ConfigParser = &routingBalancerBuilder{}
+
+// routingConfig 路由式负载均衡器配置，通过 service config 的 loadBalancingConfig 下发
+type routingConfig struct {
+	serviceconfig.LoadBalancingConfig `json:"-"`
+
+	// ExcludeFallback 所有可用节点都被排除时的兜底策略
+	ExcludeFallback ExcludeFallbackPolicy `json:"excludeFallback"`
+}
+
 // routingBalancerBuilder 实现 BalancerBuilder 接口
 type routingBalancerBuilder struct{}
 
@@ -17,6 +31,26 @@ func (b *routingBalancerBuilder) Name() string {
 	return RoutingRoundRobinName
 }
 
+// ParseConfig 解析 service config 中的负载均衡器配置
+func (b *routingBalancerBuilder) ParseConfig(js json.RawMessage) (serviceconfig.LoadBalancingConfig, error) {
+	cfg := &routingConfig{}
+	if err := json.Unmarshal(js, cfg); err != nil {
+		return nil, fmt.Errorf("解析 %s 配置失败: %w", RoutingRoundRobinName, err)
+	}
+	if !cfg.ExcludeFallback.IsValid() {
+		return nil, fmt.Errorf("未知的排除兜底策略: %s", cfg.ExcludeFallback)
+	}
+	return cfg, nil
+}
+
+// ServiceConfig 生成使用路由式负载均衡器的 service config
+func ServiceConfig(fallback ExcludeFallbackPolicy) string {
+	if fallback == "" {
+		fallback = ExcludeFallbackIgnore
+	}
+	return fmt.Sprintf(`{"loadBalancingConfig":[{%q:{"excludeFallback":%q}}]}`, RoutingRoundRobinName, fallback)
+}
+
 // init 函数在包初始化时注册负载均衡器
 func init() {
 	balancer.Register(&routingBalancerBuilder{})
@@ -25,6 +25,8 @@ type routingBalancer struct {
 	// readySCs 维护了所有处于 READY 状态的子连接及其节点ID
 	// 这是构建 Picker 的唯一数据源，确保了只有健康的连接会被选中
 	readySCs map[balancer.SubConn]string
+	// excludeFallback 所有可用节点都被排除时的兜底策略
+	excludeFallback ExcludeFallbackPolicy
 }
 
 // newRoutingBalancer 创建新的排除式负载均衡器
@@ -35,6 +37,8 @@ func newRoutingBalancer(cc balancer.ClientConn) *routingBalancer {
 		scToAddrMap: make(map[balancer.SubConn]resolver.Address),
 		nodeIDMap:   make(map[resolver.Address]string),
 		readySCs:    make(map[balancer.SubConn]string),
+		// 默认保持兼容：全部被排除时忽略排除规则
+		excludeFallback: ExcludeFallbackIgnore,
 	}
 }
 
@@ -43,6 +47,15 @@ func (b *routingBalancer) UpdateClientConnState(state balancer.ClientConnState)
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
+	// 更新兜底策略，策略变化时需要重新生成 Picker
+	if cfg, ok := state.BalancerConfig.(*routingConfig); ok && cfg.ExcludeFallback != "" &&
+		cfg.ExcludeFallback != b.excludeFallback {
+		b.excludeFallback = cfg.ExcludeFallback
+		if len(b.readySCs) > 0 {
+			b.updatePicker()
+		}
+	}
+
 	// 将新的地址列表转换成 map，方便快速查找
 	newAddrs := make(map[resolver.Address]struct{})
 	for _, addr := range state.ResolverState.Addresses {
@@ -142,7 +155,7 @@ func (b *routingBalancer) updatePicker() {
 	// 创建新的 Picker，并更新客户端状态为就绪
 	b.cc.UpdateState(balancer.State{
 		ConnectivityState: connectivity.Ready,
-		Picker:            newRoutingPicker(readyConns, nodeIDs),
+		Picker:            newRoutingPicker(readyConns, nodeIDs, b.excludeFallback),
 	})
 }
 
@@ -1,6 +1,8 @@
 package balancer
 
 import (
+	"slices"
+	"strings"
 	"sync/atomic"
 
 	"google.golang.org/grpc/balancer"
@@ -16,13 +18,16 @@ type routingPicker struct {
 	nodeIDs []string
 	// next 用于轮询的计数器
 	next uint32
+	// excludeFallback 所有可用节点都被排除时的兜底策略
+	excludeFallback ExcludeFallbackPolicy
 }
 
 // newRoutingPicker 创建新的 routingPicker
-func newRoutingPicker(subConns []balancer.SubConn, nodeIDs []string) *routingPicker {
+func newRoutingPicker(subConns []balancer.SubConn, nodeIDs []string, excludeFallback ExcludeFallbackPolicy) *routingPicker {
 	return &routingPicker{
-		subConns: subConns,
-		nodeIDs:  nodeIDs,
+		subConns:        subConns,
+		nodeIDs:         nodeIDs,
+		excludeFallback: excludeFallback,
 	}
 }
 
@@ -46,31 +51,30 @@ func (p *routingPicker) Pick(info balancer.PickInfo) (balancer.PickResult, error
 			"指定的节点不可用: %s", specificNodeID)
 	}
 
-	// 优先级2：检查排除节点ID
-	excludeNodeID, hasExclude := GetExcludeNode(info.Ctx)
+	// 优先级2：检查排除节点ID列表
+	excludeNodeIDs, hasExclude := GetExcludedNodeIDs(info.Ctx)
 
-	// 如果没有排除节点，或者只有一个连接，直接使用轮询
-	if !hasExclude || len(p.subConns) == 1 {
+	// 如果没有排除节点，直接使用轮询
+	if !hasExclude {
 		return p.pickRoundRobin(), nil
 	}
 
 	// 找出所有可用的 candidate 的索引
 	candidateIndexes := make([]int, 0, len(p.subConns))
 	for i, nodeID := range p.nodeIDs {
-		if nodeID != excludeNodeID {
+		if !slices.Contains(excludeNodeIDs, nodeID) {
 			candidateIndexes = append(candidateIndexes, i)
 		}
 	}
 
-	// 如果过滤后没有可用连接
+	// 如果过滤后没有可用连接，按兜底策略处理
 	if len(candidateIndexes) == 0 {
-		// 如果所有连接都被排除了，作为最后的手段，可以忽略排除规则，任选一个
-		// 或者返回错误，取决于业务需求
-		if len(p.subConns) > 0 {
-			return p.pickRoundRobin(), nil
+		if p.excludeFallback == ExcludeFallbackReject {
+			return balancer.PickResult{}, status.Errorf(codes.Unavailable,
+				"所有可用节点都被排除，排除节点: %s", strings.Join(excludeNodeIDs, ","))
 		}
-		return balancer.PickResult{}, status.Errorf(codes.Unavailable,
-			"所有可用节点都被排除，排除节点: %s", excludeNodeID)
+		// 作为最后的手段，忽略排除规则，任选一个
+		return p.pickRoundRobin(), nil
 	}
 
 	// 在可用的索引中进行轮询
@@ -1,31 +1,72 @@
 package balancer
 
-import "context"
+import (
+	"context"
+	"slices"
+)
 
 // RoutingRoundRobinName 是路由式轮询负载均衡器的名称（支持排除+指定两种路由策略）
 const RoutingRoundRobinName = "routing_round_robin"
 
+// ExcludeFallbackPolicy 所有可用节点都被排除时的兜底策略
+type ExcludeFallbackPolicy string
+
+const (
+	// ExcludeFallbackIgnore 忽略排除规则，在全部可用节点中轮询选择（默认）
+	ExcludeFallbackIgnore ExcludeFallbackPolicy = "ignore"
+	// ExcludeFallbackReject 严格遵守排除规则，直接返回 Unavailable 错误
+	ExcludeFallbackReject ExcludeFallbackPolicy = "reject"
+)
+
+func (p ExcludeFallbackPolicy) String() string {
+	return string(p)
+}
+
+// IsValid 判断兜底策略是否合法，空值视为默认策略
+func (p ExcludeFallbackPolicy) IsValid() bool {
+	switch p {
+	case "", ExcludeFallbackIgnore, ExcludeFallbackReject:
+		return true
+	default:
+		return false
+	}
+}
+
 // contextKey 是用于在 context 中传递排除节点信息的 key 类型
 type contextKey string
 
-// ExcludedNodeIDContextKey 是在 context 中存储要排除的节点 ID 的 key
-const ExcludedNodeIDContextKey contextKey = "excluded_node_id"
+// ExcludedNodeIDsContextKey 是在 context 中存储要排除的节点 ID 列表的 key
+const ExcludedNodeIDsContextKey contextKey = "excluded_node_ids"
 
 // SpecificNodeIDContextKey 是在 context 中存储要指定的节点 ID 的 key
 const SpecificNodeIDContextKey contextKey = "specific_node_id"
 
-// WithExcludedNodeID 在 context 中设置要排除的节点 ID
+// WithExcludedNodeID 在 context 中追加一个要排除的节点 ID
 func WithExcludedNodeID(ctx context.Context, nodeID string) context.Context {
-	if nodeID == "" {
+	return WithExcludedNodeIDs(ctx, nodeID)
+}
+
+// WithExcludedNodeIDs 在 context 中追加要排除的节点 ID 列表，会与 context 中已有的排除节点合并
+func WithExcludedNodeIDs(ctx context.Context, nodeIDs ...string) context.Context {
+	existing, _ := GetExcludedNodeIDs(ctx)
+	merged := make([]string, 0, len(existing)+len(nodeIDs))
+	merged = append(merged, existing...)
+	for _, nodeID := range nodeIDs {
+		if nodeID == "" || slices.Contains(merged, nodeID) {
+			continue
+		}
+		merged = append(merged, nodeID)
+	}
+	if len(merged) == len(existing) {
 		return ctx
 	}
-	return context.WithValue(ctx, ExcludedNodeIDContextKey, nodeID)
+	return context.WithValue(ctx, ExcludedNodeIDsContextKey, merged)
 }
 
-// GetExcludeNode 从 context 中获取要排除的节点 ID
-func GetExcludeNode(ctx context.Context) (string, bool) {
-	nodeID, ok := ctx.Value(ExcludedNodeIDContextKey).(string)
-	return nodeID, ok && nodeID != ""
+// GetExcludedNodeIDs 从 context 中获取要排除的节点 ID 列表
+func GetExcludedNodeIDs(ctx context.Context) ([]string, bool) {
+	nodeIDs, ok := ctx.Value(ExcludedNodeIDsContextKey).([]string)
+	return nodeIDs, ok && len(nodeIDs) > 0
 }
 
 // WithSpecificNodeID 在 context 中设置要指定的节点 ID
@@ -36,6 +77,14 @@ func WithSpecificNodeID(ctx context.Context, nodeID string) context.Context {
 	return context.WithValue(ctx, SpecificNodeIDContextKey, nodeID)
 }
 
+// WithoutSpecificNodeID 清除 context 中指定的节点 ID，用于指定节点拒绝后改为按排除列表选择其他节点
+func WithoutSpecificNodeID(ctx context.Context) context.Context {
+	if _, ok := GetSpecificNodeID(ctx); !ok {
+		return ctx
+	}
+	return context.WithValue(ctx, SpecificNodeIDContextKey, "")
+}
+
 // GetSpecificNodeID 从 context 中获取要指定的节点 ID
 func GetSpecificNodeID(ctx context.Context) (string, bool) {
 	nodeID, ok := ctx.Value(SpecificNodeIDContextKey).(string)
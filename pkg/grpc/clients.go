@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/Duke1616/ework-runner/pkg/grpc/balancer"
+	"github.com/Duke1616/ework-runner/pkg/grpc/interceptors/tracing"
 	"github.com/Duke1616/ework-runner/pkg/grpc/registry"
 	"github.com/ecodeclub/ekit/syncx"
 	"google.golang.org/grpc"
@@ -12,21 +13,42 @@ import (
 )
 
 type Clients[T any] struct {
-	clientMap syncx.Map[string, T]
-	registry  registry.Registry
-	timeout   time.Duration
-	creator   func(conn *grpc.ClientConn) T
+	clientMap       syncx.Map[string, T]
+	registry        registry.Registry
+	timeout         time.Duration
+	creator         func(conn *grpc.ClientConn) T
+	excludeFallback balancer.ExcludeFallbackPolicy
+}
+
+// ClientsOption Clients 配置选项
+type ClientsOption func(*clientsOptions)
+
+type clientsOptions struct {
+	excludeFallback balancer.ExcludeFallbackPolicy
+}
+
+// WithExcludeFallbackPolicy 设置所有可用节点都被排除时的兜底策略
+func WithExcludeFallbackPolicy(policy balancer.ExcludeFallbackPolicy) ClientsOption {
+	return func(o *clientsOptions) {
+		o.excludeFallback = policy
+	}
 }
 
 func NewClients[T any](
 	registry registry.Registry,
 	timeout time.Duration,
 	creator func(conn *grpc.ClientConn) T,
+	opts ...ClientsOption,
 ) *Clients[T] {
+	o := &clientsOptions{excludeFallback: balancer.ExcludeFallbackIgnore}
+	for _, opt := range opts {
+		opt(o)
+	}
 	return &Clients[T]{
-		registry: registry,
-		timeout:  timeout,
-		creator:  creator,
+		registry:        registry,
+		timeout:         timeout,
+		creator:         creator,
+		excludeFallback: o.excludeFallback,
 	}
 }
 
@@ -44,8 +66,10 @@ func (c *Clients[T]) Get(serviceName string) T {
 		// 注入解析器
 		grpc.WithResolvers(NewResolverBuilder(c.registry, c.timeout)),
 		// 默认负载均衡器实现
-		grpc.WithDefaultServiceConfig(fmt.Sprintf(`{"loadBalancingPolicy":%q}`, balancer.RoutingRoundRobinName)),
+		grpc.WithDefaultServiceConfig(balancer.ServiceConfig(c.excludeFallback)),
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		// 通过 metadata 传递链路上下文
+		grpc.WithChainUnaryInterceptor(tracing.UnaryClientInterceptor()),
 	)
 	if err != nil {
 		panic(err)
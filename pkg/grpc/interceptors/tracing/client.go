@@ -0,0 +1,31 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/Duke1616/ework-runner/pkg/tracex"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// UnaryClientInterceptor 为每次调用创建客户端 span，并通过 metadata 将链路上下文传递给服务端
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx, span := tracex.Start(ctx, method, trace.WithSpanKind(trace.SpanKindClient))
+
+		// NOTE: 复制一份 metadata，避免修改调用方 context 中共享的 metadata
+		md, ok := metadata.FromOutgoingContext(ctx)
+		if ok {
+			md = md.Copy()
+		} else {
+			md = metadata.MD{}
+		}
+		otel.GetTextMapPropagator().Inject(ctx, metadataCarrier(md))
+
+		err := invoker(metadata.NewOutgoingContext(ctx, md), method, req, reply, cc, opts...)
+		tracex.End(span, err)
+		return err
+	}
+}
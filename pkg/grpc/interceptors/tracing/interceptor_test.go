@@ -0,0 +1,57 @@
+//go:build unit
+
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Duke1616/ework-runner/pkg/tracex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestInterceptors_Propagate(t *testing.T) {
+	exporter, tp := tracex.SetupInMemory()
+	t.Cleanup(func() {
+		_ = tp.Shutdown(context.Background())
+	})
+
+	const method = "/executor.v1.ExecutorService/Execute"
+	ctx, root := tracex.Start(context.Background(), "root")
+	// 调用方已经设置的 metadata 需要保留
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "token")
+
+	var outgoing metadata.MD
+	err := UnaryClientInterceptor()(ctx, method, nil, nil, nil,
+		func(ctx context.Context, _ string, _, _ any, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+			outgoing, _ = metadata.FromOutgoingContext(ctx)
+			return nil
+		})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"token"}, outgoing.Get("authorization"))
+	require.NotEmpty(t, outgoing.Get("traceparent"))
+
+	var serverSpan trace.SpanContext
+	_, err = UnaryServerInterceptor()(metadata.NewIncomingContext(context.Background(), outgoing), nil,
+		&grpc.UnaryServerInfo{FullMethod: method},
+		func(ctx context.Context, _ any) (any, error) {
+			serverSpan = trace.SpanContextFromContext(ctx)
+			return nil, nil
+		})
+	require.NoError(t, err)
+	root.End()
+
+	assert.Equal(t, root.SpanContext().TraceID(), serverSpan.TraceID())
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 3)
+	// 客户端 span 先于服务端 span 结束
+	client, server := spans[0], spans[1]
+	assert.Equal(t, trace.SpanKindServer, server.SpanKind)
+	assert.Equal(t, trace.SpanKindClient, client.SpanKind)
+	assert.Equal(t, client.SpanContext.SpanID(), server.Parent.SpanID())
+	assert.Equal(t, root.SpanContext().SpanID(), client.Parent.SpanID())
+}
@@ -0,0 +1,25 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/Duke1616/ework-runner/pkg/tracex"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// UnaryServerInterceptor 从 metadata 中恢复调用方的链路上下文，并为每次请求创建服务端 span
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			ctx = otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+		}
+
+		ctx, span := tracex.Start(ctx, info.FullMethod, trace.WithSpanKind(trace.SpanKindServer))
+		resp, err := handler(ctx, req)
+		tracex.End(span, err)
+		return resp, err
+	}
+}
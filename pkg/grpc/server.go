@@ -7,6 +7,7 @@ import (
 	"os"
 
 	jwtinterceptor "github.com/Duke1616/ework-runner/pkg/grpc/interceptors/jwt"
+	"github.com/Duke1616/ework-runner/pkg/grpc/interceptors/tracing"
 	"github.com/Duke1616/ework-runner/pkg/grpc/registry"
 	"github.com/Duke1616/ework-runner/pkg/netx"
 	"github.com/gotomicro/ego/core/constant"
@@ -54,6 +55,13 @@ type Server struct {
 	registeredAddr string // 注册到注册中心的地址
 	cancel         func()
 	logger         *elog.Component
+
+	// beforeGracefulStop 从注册中心注销之后、停止 gRPC Server 之前执行的钩子
+	beforeGracefulStop []func(ctx context.Context)
+	// afterStop gRPC Server 停止之后执行的钩子，此时已经不再处理请求
+	afterStop []func()
+	// interceptors 一元拦截器，按顺序执行
+	interceptors []grpc.UnaryServerInterceptor
 }
 
 // ServerOption Server 配置选项
@@ -65,24 +73,38 @@ func WithJWTAuth(authToken string) ServerOption {
 	return func(s *Server) {
 		if authToken != "" {
 			jwtAuth := jwtinterceptor.NewJwtAuth(authToken)
-			// 重新创建带 JWT interceptor 的 gRPC Server
-			s.Server = grpc.NewServer(
-				grpc.UnaryInterceptor(jwtAuth.JwtAuthInterceptor()),
-			)
+			s.interceptors = append(s.interceptors, jwtAuth.JwtAuthInterceptor())
 		}
 	}
 }
 
+// WithBeforeGracefulStop 注册优雅停止钩子
+// 钩子在服务从注册中心注销之后、gRPC Server 停止之前执行，此时仍可以处理请求，常用于排空运行中的任务
+func WithBeforeGracefulStop(fn func(ctx context.Context)) ServerOption {
+	return func(s *Server) {
+		s.beforeGracefulStop = append(s.beforeGracefulStop, fn)
+	}
+}
+
+// WithAfterStop 注册停止后钩子
+// 钩子在 gRPC Server 停止、不再处理请求之后执行，常用于关闭请求处理过程中使用的本地资源
+func WithAfterStop(fn func()) ServerOption {
+	return func(s *Server) {
+		s.afterStop = append(s.afterStop, fn)
+	}
+}
+
 // NewServer 创建 gRPC Server 实例
 func NewServer(cfg Config, reg registry.Registry, opts ...ServerOption) *Server {
 	s := &Server{
-		Server:        grpc.NewServer(),
 		registry:      reg,
 		serviceID:     cfg.ServiceId,
 		ServiceName:   cfg.ServiceName,
 		listenAddr:    cfg.ListenAddr,
 		advertiseAddr: cfg.AdvertiseAddr,
 		logger:        elog.DefaultLogger.With(elog.FieldComponentName(ComponentName)),
+		// 链路追踪放在最前面，认证失败的请求也能被追踪到
+		interceptors: []grpc.UnaryServerInterceptor{tracing.UnaryServerInterceptor()},
 	}
 
 	// 应用选项
@@ -90,6 +112,7 @@ func NewServer(cfg Config, reg registry.Registry, opts ...ServerOption) *Server
 		opt(s)
 	}
 
+	s.Server = grpc.NewServer(grpc.ChainUnaryInterceptor(s.interceptors...))
 	return s
 }
 
@@ -212,9 +235,17 @@ func (s *Server) Close() error {
 	}
 
 	s.Server.GracefulStop()
+	s.runAfterStop()
 	return nil
 }
 
+// runAfterStop 执行停止后钩子
+func (s *Server) runAfterStop() {
+	for _, fn := range s.afterStop {
+		fn()
+	}
+}
+
 // 以下方法实现 server.Server 接口，使其能被 ego 框架的 egoApp.Serve() 使用
 
 // Name 实现 server.Server 接口
@@ -265,6 +296,11 @@ func (s *Server) GracefulStop(ctx context.Context) error {
 		}
 	}
 
+	// 执行优雅停止钩子
+	for _, fn := range s.beforeGracefulStop {
+		fn(ctx)
+	}
+
 	// 取消续约
 	if s.cancel != nil {
 		s.cancel()
@@ -272,6 +308,7 @@ func (s *Server) GracefulStop(ctx context.Context) error {
 
 	// 优雅停止 gRPC Server
 	s.Server.GracefulStop()
+	s.runAfterStop()
 
 	return nil
 }
@@ -40,6 +40,28 @@ func (pm *MultipleProducer[T]) AddProducer(topic string) error {
 	return nil
 }
 
+// EnsureProducer 确保指定 topic 的 producer 存在，已经存在时直接返回
+func (pm *MultipleProducer[T]) EnsureProducer(topic string) error {
+	pm.mu.RLock()
+	_, exists := pm.producers[topic]
+	pm.mu.RUnlock()
+	if exists {
+		return nil
+	}
+
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	if _, exists = pm.producers[topic]; exists {
+		return nil
+	}
+	producer, err := NewGeneralProducer[T](pm.mq, topic)
+	if err != nil {
+		return err
+	}
+	pm.producers[topic] = producer
+	return nil
+}
+
 // Produce 发送消息到指定的 topic
 func (pm *MultipleProducer[T]) Produce(ctx context.Context, topic string, evt T) error {
 	pm.mu.RLock()
@@ -1,20 +1,54 @@
 package retry
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/Duke1616/ework-runner/pkg/retry/strategy"
 )
 
+const (
+	TypeFixed             = "fixed"              // 等间隔
+	TypeExponential       = "exponential"        // 指数退避
+	TypeExponentialJitter = "exponential_jitter" // 带完全抖动的指数退避
+	TypeSchedule          = "schedule"           // 自定义间隔列表
+)
+
 // NewRetry 当配置不对的时候报错
 func NewRetry(cfg Config) (strategy.Strategy, error) {
 	// 根据 config 中的字段来检测
 	switch cfg.Type {
-	case "fixed":
+	case TypeFixed:
+		if cfg.FixedInterval == nil {
+			return nil, errors.New("缺少等间隔重试配置")
+		}
+		if cfg.FixedInterval.Interval < 0 {
+			return nil, fmt.Errorf("重试间隔不能为负数: %s", cfg.FixedInterval.Interval)
+		}
 		return strategy.NewFixedIntervalRetryStrategy(cfg.FixedInterval.Interval, cfg.FixedInterval.MaxRetries), nil
-	case "exponential":
-		return strategy.NewExponentialBackoffRetryStrategy(cfg.ExponentialBackoff.InitialInterval, cfg.ExponentialBackoff.MaxInterval, cfg.ExponentialBackoff.MaxRetries), nil
+	case TypeExponential, TypeExponentialJitter:
+		c := cfg.ExponentialBackoff
+		if c == nil {
+			return nil, errors.New("缺少指数退避重试配置")
+		}
+		if c.InitialInterval < 0 || c.MaxInterval < 0 {
+			return nil, fmt.Errorf("重试间隔不能为负数: initialInterval=%s, maxInterval=%s", c.InitialInterval, c.MaxInterval)
+		}
+		if cfg.Type == TypeExponentialJitter {
+			return strategy.NewExponentialJitterRetryStrategy(c.InitialInterval, c.MaxInterval, c.MaxRetries), nil
+		}
+		return strategy.NewExponentialBackoffRetryStrategy(c.InitialInterval, c.MaxInterval, c.MaxRetries), nil
+	case TypeSchedule:
+		if cfg.Schedule == nil || len(cfg.Schedule.Intervals) == 0 {
+			return nil, errors.New("自定义重试间隔列表不能为空")
+		}
+		for _, interval := range cfg.Schedule.Intervals {
+			if interval < 0 {
+				return nil, fmt.Errorf("重试间隔不能为负数: %s", interval)
+			}
+		}
+		return strategy.NewScheduleRetryStrategy(cfg.Schedule.Intervals), nil
 	default:
 		return nil, fmt.Errorf("未知重试类型: %s", cfg.Type)
 	}
@@ -24,6 +58,7 @@ type Config struct {
 	Type               string                    `json:"type"`
 	FixedInterval      *FixedIntervalConfig      `json:"fixedInterval"`
 	ExponentialBackoff *ExponentialBackoffConfig `json:"exponentialBackoff"`
+	Schedule           *ScheduleConfig           `json:"schedule"`
 }
 
 type ExponentialBackoffConfig struct {
@@ -38,3 +73,8 @@ type FixedIntervalConfig struct {
 	MaxRetries int32         `json:"maxRetries"`
 	Interval   time.Duration `json:"interval"`
 }
+
+// ScheduleConfig 自定义间隔列表，第 n 次重试使用第 n 个间隔，列表的长度即最大重试次数
+type ScheduleConfig struct {
+	Intervals []time.Duration `json:"intervals"`
+}
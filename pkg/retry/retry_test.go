@@ -0,0 +1,107 @@
+//go:build unit
+
+package retry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRetry(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+		// 依次调用 NextWithRetries(1..n) 的期望结果，抖动策略只校验上限
+		want      []time.Duration
+		jitter    bool
+		exhausted int32 // 该次重试不再继续
+	}{
+		{
+			name: "等间隔",
+			cfg: Config{Type: TypeFixed, FixedInterval: &FixedIntervalConfig{
+				Interval: time.Second, MaxRetries: 2,
+			}},
+			want:      []time.Duration{time.Second, time.Second},
+			exhausted: 3,
+		},
+		{
+			name: "指数退避",
+			cfg: Config{Type: TypeExponential, ExponentialBackoff: &ExponentialBackoffConfig{
+				InitialInterval: time.Second, MaxInterval: 3 * time.Second, MaxRetries: 3,
+			}},
+			want:      []time.Duration{time.Second, 2 * time.Second, 3 * time.Second},
+			exhausted: 4,
+		},
+		{
+			name: "带抖动的指数退避",
+			cfg: Config{Type: TypeExponentialJitter, ExponentialBackoff: &ExponentialBackoffConfig{
+				InitialInterval: time.Second, MaxInterval: 3 * time.Second, MaxRetries: 3,
+			}},
+			want:      []time.Duration{time.Second, 2 * time.Second, 3 * time.Second},
+			jitter:    true,
+			exhausted: 4,
+		},
+		{
+			name: "自定义间隔列表",
+			cfg: Config{Type: TypeSchedule, Schedule: &ScheduleConfig{
+				Intervals: []time.Duration{10 * time.Second, time.Minute, 10 * time.Minute},
+			}},
+			want:      []time.Duration{10 * time.Second, time.Minute, 10 * time.Minute},
+			exhausted: 4,
+		},
+		{
+			name:    "未知类型",
+			cfg:     Config{Type: "linear"},
+			wantErr: true,
+		},
+		{
+			name:    "缺少配置",
+			cfg:     Config{Type: TypeExponential},
+			wantErr: true,
+		},
+		{
+			name:    "空的间隔列表",
+			cfg:     Config{Type: TypeSchedule, Schedule: &ScheduleConfig{}},
+			wantErr: true,
+		},
+		{
+			name: "负数间隔",
+			cfg: Config{Type: TypeSchedule, Schedule: &ScheduleConfig{
+				Intervals: []time.Duration{time.Second, -time.Second},
+			}},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			s, err := NewRetry(tc.cfg)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			for i, want := range tc.want {
+				interval, ok := s.NextWithRetries(int32(i + 1))
+				assert.True(t, ok)
+				if tc.jitter {
+					assert.GreaterOrEqual(t, interval, time.Duration(0))
+					assert.LessOrEqual(t, interval, want)
+				} else {
+					assert.Equal(t, want, interval)
+				}
+			}
+			_, ok := s.NextWithRetries(tc.exhausted)
+			assert.False(t, ok)
+		})
+	}
+}
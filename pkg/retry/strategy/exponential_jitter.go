@@ -0,0 +1,56 @@
+package strategy
+
+import (
+	"math"
+	"math/rand/v2"
+	"sync/atomic"
+	"time"
+)
+
+var _ Strategy = (*ExponentialJitterRetryStrategy)(nil)
+
+// ExponentialJitterRetryStrategy 带完全抖动（full jitter）的指数退避重试
+// 每次的重试间隔在 [0, min(maxInterval, initialInterval * 2^(retries-1))] 内随机取值，避免大量任务同时失败后同时重试
+type ExponentialJitterRetryStrategy struct {
+	initialInterval time.Duration // 初始重试间隔
+	maxInterval     time.Duration // 最大重试间隔
+	maxRetries      int32         // 最大重试次数，如果是 0 或负数，表示无限重试
+	retries         int32         // 当前重试次数
+}
+
+func NewExponentialJitterRetryStrategy(initialInterval, maxInterval time.Duration, maxRetries int32) *ExponentialJitterRetryStrategy {
+	return &ExponentialJitterRetryStrategy{
+		initialInterval: initialInterval,
+		maxInterval:     maxInterval,
+		maxRetries:      maxRetries,
+	}
+}
+
+func (s *ExponentialJitterRetryStrategy) NextWithRetries(retries int32) (time.Duration, bool) {
+	return s.nextWithRetries(retries)
+}
+
+func (s *ExponentialJitterRetryStrategy) nextWithRetries(retries int32) (time.Duration, bool) {
+	if s.maxRetries > 0 && retries > s.maxRetries {
+		return 0, false
+	}
+	const two = 2
+	ceiling := s.initialInterval * time.Duration(math.Pow(two, float64(retries-1)))
+	// 溢出或当前重试间隔大于最大重试间隔
+	if ceiling <= 0 || ceiling > s.maxInterval {
+		ceiling = s.maxInterval
+	}
+	if ceiling <= 0 {
+		return 0, true
+	}
+	return rand.N(ceiling + 1), true
+}
+
+func (s *ExponentialJitterRetryStrategy) Next() (time.Duration, bool) {
+	retries := atomic.AddInt32(&s.retries, 1)
+	return s.nextWithRetries(retries)
+}
+
+func (s *ExponentialJitterRetryStrategy) Report(_ error) Strategy {
+	return s
+}
@@ -0,0 +1,39 @@
+package strategy
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+var _ Strategy = (*ScheduleRetryStrategy)(nil)
+
+// ScheduleRetryStrategy 按自定义间隔列表重试，如 [10s, 1m, 10m]
+// 第 n 次重试使用列表中第 n 个间隔，重试次数用完列表后不再重试
+type ScheduleRetryStrategy struct {
+	intervals []time.Duration // 每次重试的间隔
+	retries   int32           // 当前重试次数
+}
+
+func NewScheduleRetryStrategy(intervals []time.Duration) *ScheduleRetryStrategy {
+	return &ScheduleRetryStrategy{intervals: intervals}
+}
+
+func (s *ScheduleRetryStrategy) NextWithRetries(retries int32) (time.Duration, bool) {
+	return s.nextWithRetries(retries)
+}
+
+func (s *ScheduleRetryStrategy) nextWithRetries(retries int32) (time.Duration, bool) {
+	if retries <= 0 || int(retries) > len(s.intervals) {
+		return 0, false
+	}
+	return s.intervals[retries-1], true
+}
+
+func (s *ScheduleRetryStrategy) Next() (time.Duration, bool) {
+	retries := atomic.AddInt32(&s.retries, 1)
+	return s.nextWithRetries(retries)
+}
+
+func (s *ScheduleRetryStrategy) Report(_ error) Strategy {
+	return s
+}
@@ -0,0 +1,69 @@
+package tracex
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentationName 本项目创建的 span 统一使用的 instrumentation 名称
+const InstrumentationName = "github.com/Duke1616/ework-runner"
+
+// NOTE: TracerProvider 与 TextMapPropagator 使用全局配置，由 ego 启动时根据 trace 配置初始化（默认通过 OTLP 导出），
+// 这里不持有 Tracer 实例，保证全局配置替换后（例如测试中替换为内存导出器）立即生效
+
+// Start 创建 span
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(InstrumentationName).Start(ctx, name, opts...)
+}
+
+// End 结束 span，err 不为空时记录错误并将 span 标记为失败
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Inject 将 ctx 中的链路上下文序列化为 map，用于跨进程、跨存储传递，例如写入执行记录和消息头
+// ctx 中没有有效的链路上下文时返回 nil
+func Inject(ctx context.Context) map[string]string {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return nil
+	}
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	if len(carrier) == 0 {
+		return nil
+	}
+	return carrier
+}
+
+// Extract 从 Inject 得到的 map 中恢复链路上下文，carrier 为空时原样返回 ctx
+func Extract(ctx context.Context, carrier map[string]string) context.Context {
+	if len(carrier) == 0 {
+		return ctx
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(carrier))
+}
+
+// Join 让 ctx 归入 carrier 所记录的链路，ctx 已经处于这条链路上时原样返回
+// 否则以 carrier 记录的 span 作为父 span，并返回关联到 ctx 当前 span 的选项，用于把外部请求（如执行节点的上报）关联到原有链路
+func Join(ctx context.Context, carrier map[string]string) (context.Context, trace.SpanStartOption) {
+	remote := trace.SpanContextFromContext(Extract(context.Background(), carrier))
+	current := trace.SpanContextFromContext(ctx)
+	if !remote.IsValid() || remote.TraceID() == current.TraceID() {
+		return ctx, trace.WithLinks()
+	}
+	return trace.ContextWithRemoteSpanContext(ctx, remote), trace.WithLinks(trace.LinkFromContext(ctx))
+}
+
+// Detach 保留 ctx 中的链路上下文，但不再继承 ctx 的取消和超时
+// 用于请求结束后仍在继续的异步处理，例如执行节点收到请求后异步执行任务
+func Detach(ctx context.Context) context.Context {
+	return trace.ContextWithSpanContext(context.Background(), trace.SpanContextFromContext(ctx))
+}
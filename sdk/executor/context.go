@@ -1,10 +1,18 @@
 package executor
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
 	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
 
-	reporterv1 "github.com/Duke1616/ework-runner/api/proto/gen/reporter/v1"
 	"github.com/gotomicro/ego/core/elog"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // TaskHandler 任务处理函数接口
@@ -14,7 +22,10 @@ type TaskHandler interface {
 }
 
 // Context 任务执行上下文
+// NOTE: 内嵌 context.Context，任务被中断或执行节点下线时会被取消，长任务应监听 Done()
 type Context struct {
+	context.Context
+
 	ExecutionID int64
 	TaskID      int64
 	TaskName    string
@@ -22,21 +33,50 @@ type Context struct {
 	Params      map[string]string
 
 	// 内部字段
-	reporter reporterv1.ReporterServiceClient
-	logger   *elog.Component
+	progress   func(c *Context, progress int32) error
+	logger     *elog.Component
+	taskLogger *TaskLogger
+	cancel     context.CancelFunc
+	// span 覆盖整个异步执行过程，执行结束时结束
+	span trace.Span
+
+	mu            sync.Mutex
+	checkpoint    map[string]string
+	result        []byte
+	maxResultSize int
+	// finished 最终状态是否已经上报，保证每次执行只上报一次最终状态
+	finished atomic.Bool
+	// reportMu 串行化进度和最终状态的上报，最终状态上报之后不会再有进度覆盖它
+	reportMu sync.Mutex
 }
 
 // newContext 创建上下文(内部使用)
-func newContext(eid, taskID int64, taskName, handlerName string, params map[string]string,
-	reporter reporterv1.ReporterServiceClient, logger *elog.Component) *Context {
+// timeout 大于 0 时，超时后上下文以 ErrExecutionTimeout 为原因取消
+func newContext(ctx context.Context, eid, taskID int64, taskName, handlerName string, params map[string]string,
+	timeout time.Duration, progress func(*Context, int32) error, logger *elog.Component, taskLogger *TaskLogger,
+	maxResultSize int) *Context {
+	runCtx, cancel := context.WithCancel(ctx)
+	if timeout > 0 {
+		var stop context.CancelFunc
+		runCtx, stop = context.WithTimeoutCause(runCtx, timeout, ErrExecutionTimeout)
+		cancelRun := cancel
+		cancel = func() {
+			stop()
+			cancelRun()
+		}
+	}
 	return &Context{
-		ExecutionID: eid,
-		TaskID:      taskID,
-		TaskName:    taskName,
-		HandlerName: handlerName,
-		Params:      params,
-		reporter:    reporter,
-		logger:      logger,
+		Context:       runCtx,
+		ExecutionID:   eid,
+		TaskID:        taskID,
+		TaskName:      taskName,
+		HandlerName:   handlerName,
+		Params:        params,
+		progress:      progress,
+		logger:        logger,
+		taskLogger:    taskLogger,
+		cancel:        cancel,
+		maxResultSize: maxResultSize,
 	}
 }
 
@@ -76,6 +116,7 @@ func (c *Context) ParamBool(key string) bool {
 }
 
 // ReportProgress 上报进度 (可选)
+// 进度与执行状态一样先持久化再经发件箱异步上报，调度节点据此更新执行记录的进度
 // NOTE: 对于没有进度的任务,不调用此方法也完全OK
 func (c *Context) ReportProgress(progress int) error {
 	if progress < 0 {
@@ -84,13 +125,68 @@ func (c *Context) ReportProgress(progress int) error {
 	if progress > 100 {
 		progress = 100
 	}
+	if c.progress == nil {
+		return nil
+	}
+	return c.progress(c, int32(progress))
+}
+
+// SaveCheckpoint 保存任务检查点 (可选)
+// 任务被中断或执行节点下线时，检查点会作为重调度参数上报，由其他执行节点从检查点继续执行
+func (c *Context) SaveCheckpoint(params map[string]string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.checkpoint == nil {
+		c.checkpoint = make(map[string]string, len(params))
+	}
+	maps.Copy(c.checkpoint, params)
+}
+
+// Checkpoint 获取当前保存的检查点副本
+func (c *Context) Checkpoint() map[string]string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return maps.Clone(c.checkpoint)
+}
 
-	// TODO: 实现进度上报
-	// 当前简化版本,可以后续增强
-	c.logger.Debug("进度上报", elog.Int("progress", progress))
+// SetResult 设置执行结果 (可选)，任务结束时随最终状态上报，并包含在调度节点的完成事件中
+// 超过大小上限时返回 ErrResultTooLarge，结果不会被设置
+func (c *Context) SetResult(payload []byte) error {
+	if len(payload) > c.maxResultSize {
+		return fmt.Errorf("%w: %d > %d", ErrResultTooLarge, len(payload), c.maxResultSize)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.result = slices.Clone(payload)
 	return nil
 }
 
+// SetResultJSON 将 v 编码为 JSON 后设置为执行结果
+func (c *Context) SetResultJSON(v any) error {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("序列化执行结果失败: %w", err)
+	}
+	return c.SetResult(payload)
+}
+
+// Result 获取当前设置的执行结果
+func (c *Context) Result() []byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.result
+}
+
+// finish 标记最终状态已上报，只有第一次调用返回 true
+func (c *Context) finish() bool {
+	return c.finished.CompareAndSwap(false, true)
+}
+
+// TaskLogger 获取任务日志，写入的内容会上报给调度节点，可以在调度节点按执行查看和实时追踪
+func (c *Context) TaskLogger() *TaskLogger {
+	return c.taskLogger
+}
+
 // Logger 获取日志组件
 func (c *Context) Logger() *elog.Component {
 	return c.logger.With(
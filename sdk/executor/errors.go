@@ -0,0 +1,115 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	executorv1 "github.com/Duke1616/ework-runner/api/proto/gen/executor/v1"
+)
+
+// ErrorCategory 任务失败的错误分类
+type ErrorCategory = executorv1.ErrorCategory
+
+const (
+	CategoryHandlerError    = executorv1.ErrorCategory_HANDLER_ERROR
+	CategoryTimeout         = executorv1.ErrorCategory_TIMEOUT
+	CategoryInterrupted     = executorv1.ErrorCategory_INTERRUPTED
+	CategoryHandlerNotFound = executorv1.ErrorCategory_HANDLER_NOT_FOUND
+	CategoryScriptExit      = executorv1.ErrorCategory_SCRIPT_EXIT
+	CategoryInvalidParams   = executorv1.ErrorCategory_INVALID_PARAMS
+	CategoryPanic           = executorv1.ErrorCategory_PANIC
+)
+
+var (
+	// ErrExecutionTimeout 任务超过最大执行时间（参数 max_execution_seconds）
+	ErrExecutionTimeout = errors.New("任务执行超时")
+	// ErrInterrupted 任务被调度节点中断或执行节点排空
+	ErrInterrupted = errors.New("任务被中断")
+)
+
+// TaskError 带分类的任务错误，处理函数返回它时分类和详情随执行结果上报
+// Retryable 决定失败的上报状态：true 为 FAILED_RETRYABLE，由调度节点按 RetryConfig 重试；false 为 FAILED
+type TaskError struct {
+	Category  ErrorCategory
+	Retryable bool
+	Detail    string // 执行节点侧的详情，如 panic 堆栈、脚本输出的末尾部分
+	ExitCode  int    // 脚本退出码，仅 CategoryScriptExit 时有意义
+	Err       error
+}
+
+// NewTaskError 创建带分类的任务错误
+func NewTaskError(category ErrorCategory, retryable bool, err error) *TaskError {
+	return &TaskError{Category: category, Retryable: retryable, Err: err}
+}
+
+// NewInvalidParamsError 创建参数非法的错误，不可重试
+func NewInvalidParamsError(err error) *TaskError {
+	return NewTaskError(CategoryInvalidParams, false, err)
+}
+
+// NewScriptExitError 创建脚本非零退出的错误，不可重试，detail 通常为脚本输出的末尾部分
+func NewScriptExitError(exitCode int, detail string, err error) *TaskError {
+	return &TaskError{Category: CategoryScriptExit, ExitCode: exitCode, Detail: detail, Err: err}
+}
+
+// Retryable 将处理函数的错误标记为可重试，任务以 FAILED_RETRYABLE 上报，由调度节点按 RetryConfig 重试
+// 已经是 TaskError 时保留其分类和详情
+func Retryable(err error) error {
+	if err == nil {
+		return nil
+	}
+	taskErr := &TaskError{Category: CategoryHandlerError, Retryable: true, Err: err}
+	var inner *TaskError
+	if errors.As(err, &inner) {
+		taskErr.Category = inner.Category
+		taskErr.Detail = inner.Detail
+		taskErr.ExitCode = inner.ExitCode
+	}
+	return taskErr
+}
+
+func (e *TaskError) Error() string {
+	if e.Err == nil {
+		return e.Category.String()
+	}
+	return e.Err.Error()
+}
+
+func (e *TaskError) Unwrap() error {
+	return e.Err
+}
+
+// classifyFailure 根据任务上下文和处理函数返回的错误确定最终状态，返回的错误总是包含 TaskError
+// 超时优先于处理函数的错误：超时后处理函数返回的通常只是 context 被取消
+func classifyFailure(taskCtx *Context, err error) (executorv1.ExecutionStatus, error) {
+	if cause := context.Cause(taskCtx); cause != nil {
+		if errors.Is(cause, ErrExecutionTimeout) {
+			return executorv1.ExecutionStatus_FAILED_RETRYABLE,
+				&TaskError{Category: CategoryTimeout, Retryable: true, Err: causeOr(err, ErrExecutionTimeout)}
+		}
+		return executorv1.ExecutionStatus_FAILED_RESCHEDULABLE,
+			&TaskError{Category: CategoryInterrupted, Err: causeOr(err, ErrInterrupted)}
+	}
+	if err == nil {
+		return executorv1.ExecutionStatus_SUCCESS, nil
+	}
+
+	var taskErr *TaskError
+	if !errors.As(err, &taskErr) {
+		taskErr = &TaskError{Category: CategoryHandlerError, Err: err}
+		err = taskErr
+	}
+	if taskErr.Retryable {
+		return executorv1.ExecutionStatus_FAILED_RETRYABLE, err
+	}
+	return executorv1.ExecutionStatus_FAILED, err
+}
+
+// causeOr 处理函数返回了错误时附带上原因，否则直接使用原因
+func causeOr(err, cause error) error {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return cause
+	}
+	return fmt.Errorf("%w: %w", cause, err)
+}
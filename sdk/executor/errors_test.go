@@ -0,0 +1,120 @@
+//go:build unit
+
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	executorv1 "github.com/Duke1616/ework-runner/api/proto/gen/executor/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyFailure(t *testing.T) {
+	t.Parallel()
+
+	handlerErr := errors.New("下游服务不可用")
+
+	testCases := []struct {
+		name         string
+		ctx          func() context.Context
+		err          error
+		wantStatus   executorv1.ExecutionStatus
+		wantCategory ErrorCategory
+		wantExitCode int32
+	}{
+		{
+			name:       "执行成功",
+			ctx:        context.Background,
+			err:        nil,
+			wantStatus: executorv1.ExecutionStatus_SUCCESS,
+		},
+		{
+			name:         "普通错误不可重试",
+			ctx:          context.Background,
+			err:          handlerErr,
+			wantStatus:   executorv1.ExecutionStatus_FAILED,
+			wantCategory: CategoryHandlerError,
+		},
+		{
+			name:         "Retryable 包装后可重试",
+			ctx:          context.Background,
+			err:          Retryable(handlerErr),
+			wantStatus:   executorv1.ExecutionStatus_FAILED_RETRYABLE,
+			wantCategory: CategoryHandlerError,
+		},
+		{
+			name:         "Retryable 保留原有分类",
+			ctx:          context.Background,
+			err:          Retryable(NewScriptExitError(2, "tail", handlerErr)),
+			wantStatus:   executorv1.ExecutionStatus_FAILED_RETRYABLE,
+			wantCategory: CategoryScriptExit,
+			wantExitCode: 2,
+		},
+		{
+			name:         "被包装的 Retryable 错误仍可重试",
+			ctx:          context.Background,
+			err:          fmt.Errorf("同步失败: %w", Retryable(handlerErr)),
+			wantStatus:   executorv1.ExecutionStatus_FAILED_RETRYABLE,
+			wantCategory: CategoryHandlerError,
+		},
+		{
+			name:         "参数非法不可重试",
+			ctx:          context.Background,
+			err:          NewInvalidParamsError(handlerErr),
+			wantStatus:   executorv1.ExecutionStatus_FAILED,
+			wantCategory: CategoryInvalidParams,
+		},
+		{
+			name: "超时可重试",
+			ctx: func() context.Context {
+				ctx, cancel := context.WithDeadlineCause(context.Background(), time.Now().Add(-time.Second), ErrExecutionTimeout)
+				t.Cleanup(cancel)
+				return ctx
+			},
+			err:          context.DeadlineExceeded,
+			wantStatus:   executorv1.ExecutionStatus_FAILED_RETRYABLE,
+			wantCategory: CategoryTimeout,
+		},
+		{
+			name: "被中断时可重调度",
+			ctx: func() context.Context {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				return ctx
+			},
+			err:          handlerErr,
+			wantStatus:   executorv1.ExecutionStatus_FAILED_RESCHEDULABLE,
+			wantCategory: CategoryInterrupted,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			taskCtx := &Context{Context: tc.ctx()}
+			status, err := classifyFailure(taskCtx, tc.err)
+			assert.Equal(t, tc.wantStatus, status)
+
+			result := buildResult(nil, err)
+			if tc.wantStatus == executorv1.ExecutionStatus_SUCCESS {
+				assert.Nil(t, result)
+				return
+			}
+			require.NotNil(t, result)
+			assert.Equal(t, tc.wantCategory, result.GetErrorCategory())
+			assert.Equal(t, tc.wantExitCode, result.GetExitCode())
+			assert.NotEmpty(t, result.GetErrorMessage())
+		})
+	}
+}
+
+func TestRetryable_Nil(t *testing.T) {
+	t.Parallel()
+	assert.NoError(t, Retryable(nil))
+}
@@ -4,16 +4,34 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"runtime/debug"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	executorv1 "github.com/Duke1616/ework-runner/api/proto/gen/executor/v1"
 	reporterv1 "github.com/Duke1616/ework-runner/api/proto/gen/reporter/v1"
 	grpcpkg "github.com/Duke1616/ework-runner/pkg/grpc"
+	"github.com/Duke1616/ework-runner/pkg/grpc/interceptors/tracing"
 	"github.com/Duke1616/ework-runner/pkg/grpc/registry"
+	"github.com/Duke1616/ework-runner/pkg/tracex"
 	"github.com/ecodeclub/ekit/syncx"
 	"github.com/gotomicro/ego/core/elog"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// DefaultDrainTimeout 默认排空超时时间
+	DefaultDrainTimeout = 30 * time.Second
+	// interruptGracePeriod 排空超时中断任务后，等待处理函数自行退出并保存检查点的时间
+	interruptGracePeriod = 3 * time.Second
 )
 
 // Executor 极简 Executor 实现
@@ -29,13 +47,108 @@ type Executor struct {
 	reporterClient reporterv1.ReporterServiceClient
 	logger         *elog.Component
 
-	// 状态管理 - 使用 syncx.Map
-	states  *syncx.Map[int64, *executorv1.ExecutionState]
-	cancels *syncx.Map[int64, context.CancelFunc]
+	// 上报管理：所有上报先进入发件箱，异步批量上报并在失败后重试
+	outbox      *outbox
+	logShipper  *logShipper
+	stopReport  context.CancelFunc
+	reportStore ReportStore
+	transport   ReportTransport
+	// seq 上报序号，以启动时间初始化，保证重启后仍单调递增
+	seq atomic.Int64
+
+	// 状态管理：执行状态可持久化，运行中的任务上下文仅在内存中
+	store   StateStore
+	running *syncx.Map[int64, *Context]
+
+	// 并发控制
+	limiter *limiter
+	// 结果数据的大小上限
+	maxResultSize int
+
+	// 排空管理
+	// drainMu 保护 draining 和 wg.Add，保证排空开始后不会再有任务登记，Drain 的 wg.Wait 不会漏掉任务
+	drainTimeout time.Duration
+	drainMu      sync.Mutex
+	draining     bool
+	wg           sync.WaitGroup
+}
+
+// Option Executor 配置选项
+type Option func(*Executor)
+
+// WithDrainTimeout 设置优雅退出时等待运行中任务结束的最长时间
+func WithDrainTimeout(timeout time.Duration) Option {
+	return func(e *Executor) {
+		if timeout > 0 {
+			e.drainTimeout = timeout
+		}
+	}
+}
+
+// WithStateStore 设置执行状态存储，默认使用内存存储（TTL 为 DefaultStateTTL）
+// 使用 BoltStateStore 等持久化存储时，重启后会将上次未结束的任务上报为可重调度
+func WithStateStore(store StateStore) Option {
+	return func(e *Executor) {
+		if store != nil {
+			e.store = store
+		}
+	}
+}
+
+// WithReportStore 设置待上报状态的持久化存储，执行节点重启后继续上报未送达的状态
+// BoltStateStore 同时实现了 StateStore 和 ReportStore，可以共用一个本地文件
+func WithReportStore(store ReportStore) Option {
+	return func(e *Executor) {
+		e.reportStore = store
+	}
+}
+
+// WithReportTransport 设置上报通道，默认通过调度节点的 ReporterService gRPC 接口上报
+// 执行节点无法直连调度节点时，可以使用 NewMQReportTransport 通过消息队列上报
+func WithReportTransport(transport ReportTransport) Option {
+	return func(e *Executor) {
+		e.transport = transport
+	}
+}
+
+// WithMaxResultSize 设置结果数据的大小上限，默认 DefaultMaxResultSize
+func WithMaxResultSize(size int) Option {
+	return func(e *Executor) {
+		if size > 0 {
+			e.maxResultSize = size
+		}
+	}
+}
+
+// WithMaxConcurrency 设置节点级最大并发任务数，<= 0 表示不限制
+func WithMaxConcurrency(n int) Option {
+	return func(e *Executor) {
+		if n > 0 {
+			e.limiter.global = make(chan struct{}, n)
+		}
+	}
+}
+
+// WithHandlerConcurrency 设置指定处理器的最大并发任务数，<= 0 表示不限制
+func WithHandlerConcurrency(handlerName string, n int) Option {
+	return func(e *Executor) {
+		if n > 0 {
+			e.limiter.handlers[handlerName] = make(chan struct{}, n)
+		}
+	}
+}
+
+// WithQueueSize 设置并发已满时的等待队列容量，队列也满时拒绝任务，由调度节点转移到其他执行节点
+func WithQueueSize(n int) Option {
+	return func(e *Executor) {
+		if n > 0 {
+			e.limiter.queueSize = int64(n)
+		}
+	}
 }
 
 // NewExecutor 创建 Executor
-func NewExecutor(cfg grpcpkg.Config, reg registry.Registry) (*Executor, error) {
+func NewExecutor(cfg grpcpkg.Config, reg registry.Registry, opts ...Option) (*Executor, error) {
 	if err := cfg.Validate(); err != nil {
 		return nil, err
 	}
@@ -44,14 +157,24 @@ func NewExecutor(cfg grpcpkg.Config, reg registry.Registry) (*Executor, error) {
 		return nil, errors.New("service_id is required")
 	}
 
-	return &Executor{
-		config:   cfg,
-		registry: reg,
-		handlers: make(map[string]TaskHandler),
-		logger:   elog.DefaultLogger.With(elog.FieldComponentName("executor")),
-		states:   &syncx.Map[int64, *executorv1.ExecutionState]{},
-		cancels:  &syncx.Map[int64, context.CancelFunc]{},
-	}, nil
+	e := &Executor{
+		config:        cfg,
+		registry:      reg,
+		handlers:      make(map[string]TaskHandler),
+		logger:        elog.DefaultLogger.With(elog.FieldComponentName("executor")),
+		running:       &syncx.Map[int64, *Context]{},
+		limiter:       newLimiter(),
+		maxResultSize: DefaultMaxResultSize,
+		drainTimeout:  DefaultDrainTimeout,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	if e.store == nil {
+		e.store = NewMemoryStateStore(DefaultStateTTL)
+	}
+	e.seq.Store(time.Now().UnixNano())
+	return e, nil
 }
 
 // RegisterHandler 注册任务处理函数
@@ -70,16 +193,33 @@ func (e *Executor) InitComponents() error {
 		grpc.WithResolvers(grpcpkg.NewResolverBuilder(e.registry, 10*time.Second)),
 		grpc.WithDefaultServiceConfig(`{"loadBalancingPolicy":"round_robin"}`),
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithChainUnaryInterceptor(tracing.UnaryClientInterceptor()),
 	)
 	if err != nil {
 		return fmt.Errorf("连接 reporter 失败: %w", err)
 	}
 	e.reporterClient = reporterv1.NewReporterServiceClient(reporterConn)
 
-	// 2. 创建 gRPC Server
-	e.server = grpcpkg.NewServer(e.config, e.registry, grpcpkg.WithJWTAuth(e.config.AuthToken))
-
-	// 3. 注册 Executor 服务
+	// 2. 启动上报发件箱和日志上报，恢复上次运行时未送达的上报和未结束的任务
+	if e.transport == nil {
+		e.transport = &grpcReportTransport{client: e.reporterClient}
+	}
+	e.outbox = newOutbox(e.transport, e.reportStore, e.logger)
+	e.outbox.recover(context.Background())
+	e.logShipper = newLogShipper(e.transport, e.config.ServiceId, e.logger)
+	reportCtx, stopReport := context.WithCancel(context.Background())
+	e.stopReport = stopReport
+	go e.outbox.Start(reportCtx)
+	go e.logShipper.Start(reportCtx)
+	e.recoverStates(context.Background())
+
+	// 3. 创建 gRPC Server，优雅退出时先从注册中心下线，再排空运行中的任务，停止后关闭状态存储
+	e.server = grpcpkg.NewServer(e.config, e.registry,
+		grpcpkg.WithJWTAuth(e.config.AuthToken),
+		grpcpkg.WithBeforeGracefulStop(e.Drain),
+		grpcpkg.WithAfterStop(e.closeStore))
+
+	// 4. 注册 Executor 服务
 	executorv1.RegisterExecutorServiceServer(e.server.Server, e)
 
 	return nil
@@ -94,12 +234,36 @@ func (e *Executor) Server() *grpcpkg.Server {
 func (e *Executor) Execute(ctx context.Context, req *executorv1.ExecuteRequest) (*executorv1.ExecuteResponse, error) {
 	eid := req.GetEid()
 
-	// 检查是否已经在执行
-	if state, ok := e.states.Load(eid); ok {
+	// 排空中的节点不再接收新任务，返回可重调度状态，由调度节点转移到其他执行节点
+	if !e.admit() {
+		e.logger.Warn("执行节点排空中，拒绝新任务", elog.Int64("eid", eid))
+		return e.reject(req, executorv1.RejectReason_DRAINING), nil
+	}
+	// 登记后没有启动任务的分支都要撤销登记
+	started := false
+	defer func() {
+		if !started {
+			e.wg.Done()
+		}
+	}()
+
+	// 检查是否已经在执行，已结束的执行（重试、重调度到本节点）允许重新执行
+	if state, ok := e.loadState(ctx, eid); ok && !isTerminal(state.GetStatus()) {
 		e.logger.Warn("任务已在执行中", elog.Int64("eid", eid))
 		return &executorv1.ExecuteResponse{ExecutionState: state}, nil
 	}
 
+	// 并发已满时进入等待队列，队列也满则拒绝
+	queued := false
+	if !e.limiter.tryAcquire(req.GetTaskHandlerName()) {
+		if !e.limiter.enqueue() {
+			e.logger.Warn("执行节点繁忙，拒绝新任务", elog.Int64("eid", eid),
+				elog.String("handler", req.GetTaskHandlerName()))
+			return e.reject(req, executorv1.RejectReason_BUSY), nil
+		}
+		queued = true
+	}
+
 	// 创建初始状态
 	state := &executorv1.ExecutionState{
 		Id:              eid,
@@ -108,76 +272,303 @@ func (e *Executor) Execute(ctx context.Context, req *executorv1.ExecuteRequest)
 		Status:          executorv1.ExecutionStatus_RUNNING,
 		RunningProgress: 0,
 		ExecutorNodeId:  e.config.ServiceId,
+		Sequence:        e.nextSeq(),
+		Attempt:         req.GetAttempt(),
+	}
+	if err := e.store.Save(ctx, state); err != nil {
+		e.limiter.releaseAdmission(req.GetTaskHandlerName(), queued)
+		return nil, status.Errorf(codes.Internal, "保存执行状态失败: %v", err)
 	}
-	e.states.Store(eid, state)
-
-	// 创建任务上下文
-	taskCtx := newContext(eid, req.GetTaskId(), req.GetTaskName(), req.GetTaskHandlerName(),
-		req.GetParams(), e.reporterClient, e.logger)
-
-	//创建可取消上下文
-	runCtx, cancel := context.WithCancel(context.Background())
-	e.cancels.Store(eid, cancel)
 
-	e.logger.Info("启动异步任务执行", elog.Int64("eid", eid))
+	// 创建可取消的任务上下文
+	// 超过最大执行时间后取消上下文，最终以 TIMEOUT 分类上报
+	timeout := time.Duration(0)
+	if seconds, _ := strconv.ParseInt(req.GetParams()["max_execution_seconds"], 10, 64); seconds > 0 {
+		timeout = time.Duration(seconds) * time.Second
+	}
+	// 任务在请求返回后异步执行，沿用调度节点传递过来的链路上下文，但不继承请求的取消
+	spanCtx, span := tracex.Start(tracex.Detach(ctx), "executor.executeTask", trace.WithAttributes(
+		attribute.Int64("ework.execution_id", eid),
+		attribute.String("ework.task_handler", req.GetTaskHandlerName()),
+		attribute.Bool("ework.queued", queued)))
+	taskCtx := newContext(spanCtx, eid, req.GetTaskId(), req.GetTaskName(), req.GetTaskHandlerName(),
+		req.GetParams(), timeout, e.reportProgress, e.logger, e.logShipper.newTaskLogger(eid), e.maxResultSize)
+	taskCtx.span = span
+	e.running.Store(eid, taskCtx)
+
+	e.logger.Info("启动异步任务执行", elog.Int64("eid", eid), elog.Any("queued", queued))
 	// 异步执行任务
-	go e.executeTask(runCtx, taskCtx, eid)
+	started = true
+	go e.executeTask(taskCtx, queued)
 
 	return &executorv1.ExecuteResponse{ExecutionState: state}, nil
 }
 
-// executeTask 执行用户任务
-func (e *Executor) executeTask(runCtx context.Context, taskCtx *Context, eid int64) {
+// admit 在排空开始前登记一个运行中的任务，排空开始后返回 false
+func (e *Executor) admit() bool {
+	e.drainMu.Lock()
+	defer e.drainMu.Unlock()
+	if e.draining {
+		return false
+	}
+	e.wg.Add(1)
+	return true
+}
+
+// reject 拒绝执行请求，返回可重调度状态并携带拒绝原因，调度节点据此排除本节点后转移
+func (e *Executor) reject(req *executorv1.ExecuteRequest, reason executorv1.RejectReason) *executorv1.ExecuteResponse {
+	return &executorv1.ExecuteResponse{ExecutionState: &executorv1.ExecutionState{
+		Id:             req.GetEid(),
+		TaskId:         req.GetTaskId(),
+		TaskName:       req.GetTaskName(),
+		Status:         executorv1.ExecutionStatus_FAILED_RESCHEDULABLE,
+		ExecutorNodeId: e.config.ServiceId,
+		RejectReason:   reason,
+		Sequence:       e.nextSeq(),
+		Attempt:        req.GetAttempt(),
+	}}
+}
+
+// nextSeq 生成下一个上报序号
+func (e *Executor) nextSeq() int64 {
+	return e.seq.Add(1)
+}
+
+// executeTask 执行用户任务，queued 表示任务在等待队列中，需要先等待并发槽位
+func (e *Executor) executeTask(taskCtx *Context, queued bool) {
+	var err error
 	defer func() {
-		e.cancels.Delete(eid)
+		tracex.End(taskCtx.span, err)
+		e.running.Delete(taskCtx.ExecutionID)
+		taskCtx.cancel()
+		e.wg.Done()
 	}()
 
 	logger := taskCtx.Logger()
 
+	if queued {
+		// 排队期间被中断（调度节点中断或排空超时），直接以可重调度状态上报
+		if err = e.limiter.acquire(taskCtx, taskCtx.HandlerName); err != nil {
+			logger.Warn("任务排队期间被中断")
+			taskCtx.taskLogger.Close()
+			var finalStatus executorv1.ExecutionStatus
+			finalStatus, err = classifyFailure(taskCtx, nil)
+			e.reportFinalResult(taskCtx, finalStatus, err)
+			return
+		}
+	}
+	defer e.limiter.release(taskCtx.HandlerName)
+
 	// 查找处理函数
 	handler, exists := e.handlers[taskCtx.HandlerName]
 
-	var err error
 	if !exists {
-		err = fmt.Errorf("未找到任务处理器: %s", taskCtx.TaskName)
+		err = NewTaskError(CategoryHandlerNotFound, false, fmt.Errorf("未找到任务处理器: %s", taskCtx.HandlerName))
 	} else {
 		// 调用用户处理函数
-		err = handler.Run(taskCtx)
+		err = runHandler(handler, taskCtx)
 	}
+	// 先送出剩余的任务日志，再上报最终状态
+	taskCtx.taskLogger.Close()
 
 	// 确定最终状态
-	var finalStatus executorv1.ExecutionStatus
-	if runCtx.Err() != nil {
-		finalStatus = executorv1.ExecutionStatus_FAILED_RESCHEDULABLE
-		logger.Warn("任务被中断")
-	} else if err != nil {
-		finalStatus = executorv1.ExecutionStatus_FAILED
-		logger.Error("任务执行失败", elog.FieldErr(err))
-	} else {
-		finalStatus = executorv1.ExecutionStatus_SUCCESS
+	finalStatus, err := classifyFailure(taskCtx, err)
+	switch finalStatus {
+	case executorv1.ExecutionStatus_SUCCESS:
 		logger.Info("任务执行成功")
+	case executorv1.ExecutionStatus_FAILED_RESCHEDULABLE:
+		logger.Warn("任务被中断", elog.FieldErr(err))
+	default:
+		logger.Error("任务执行失败", elog.String("status", finalStatus.String()), elog.FieldErr(err))
 	}
 
 	// 更新并上报最终状态
-	e.reportFinalResult(eid, finalStatus)
+	e.reportFinalResult(taskCtx, finalStatus, err)
+}
+
+// runHandler 调用处理函数，处理函数 panic 时转换为带堆栈的错误，避免整个执行节点崩溃
+func runHandler(handler TaskHandler, taskCtx *Context) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &TaskError{
+				Category: CategoryPanic,
+				Detail:   string(debug.Stack()),
+				Err:      fmt.Errorf("处理函数 panic: %v", r),
+			}
+		}
+	}()
+	return handler.Run(taskCtx)
 }
 
-// reportFinalResult 上报最终结果
-func (e *Executor) reportFinalResult(eid int64, status executorv1.ExecutionStatus) {
-	state, exists := e.states.Load(eid)
+// reportFinalResult 上报最终结果，handlerErr 为处理函数返回的错误
+func (e *Executor) reportFinalResult(taskCtx *Context, status executorv1.ExecutionStatus, handlerErr error) {
+	taskCtx.reportMu.Lock()
+	defer taskCtx.reportMu.Unlock()
+	// 排空超时时可能已经代为上报过，这里不再重复上报
+	if !taskCtx.finish() {
+		return
+	}
+
+	state, exists := e.loadState(context.Background(), taskCtx.ExecutionID)
 	if exists {
 		state.Status = status
 		if status == executorv1.ExecutionStatus_SUCCESS {
 			state.RunningProgress = 100
 		}
-		e.states.Store(eid, state)
+		// 被中断的任务携带检查点，请求调度节点从检查点重调度
+		if status == executorv1.ExecutionStatus_FAILED_RESCHEDULABLE {
+			if checkpoint := taskCtx.Checkpoint(); len(checkpoint) > 0 {
+				state.RequestReschedule = true
+				state.RescheduledParams = checkpoint
+			}
+		}
+		state.Result = buildResult(taskCtx.Result(), handlerErr)
+		state.Sequence = e.nextSeq()
+		if err := e.store.Save(context.Background(), state); err != nil {
+			e.logger.Error("保存最终状态失败", elog.Int64("eid", taskCtx.ExecutionID), elog.FieldErr(err))
+		}
+
+		// 放入发件箱，由发件箱负责上报和失败重试
+		e.outbox.Add(state)
+	}
+}
+
+// reportProgress 保存并上报运行中任务的进度，最终状态上报之后的进度直接忽略
+func (e *Executor) reportProgress(taskCtx *Context, progress int32) error {
+	taskCtx.reportMu.Lock()
+	defer taskCtx.reportMu.Unlock()
+	if taskCtx.finished.Load() {
+		return nil
+	}
+
+	state, exists := e.loadState(context.Background(), taskCtx.ExecutionID)
+	if !exists || state.GetRunningProgress() == progress {
+		return nil
+	}
+	state.RunningProgress = progress
+	state.Sequence = e.nextSeq()
+	if err := e.store.Save(context.Background(), state); err != nil {
+		return fmt.Errorf("保存执行进度失败: %w", err)
+	}
+	e.outbox.Add(state)
+	return nil
+}
 
-		// 上报给 Reporter
-		_, err := e.reporterClient.Report(context.Background(), &reporterv1.ReportRequest{
-			ExecutionState: state,
-		})
-		if err != nil {
-			e.logger.Error("上报最终状态失败", elog.FieldErr(err))
+// loadState 从状态存储中读取执行状态，读取失败视为不存在
+func (e *Executor) loadState(ctx context.Context, eid int64) (*executorv1.ExecutionState, bool) {
+	state, ok, err := e.store.Get(ctx, eid)
+	if err != nil {
+		e.logger.Error("读取执行状态失败", elog.Int64("eid", eid), elog.FieldErr(err))
+		return nil, false
+	}
+	return state, ok
+}
+
+// recoverStates 恢复上次运行时未结束的任务
+// 进程重启后这些任务已经不在运行，立即以可重调度状态上报，避免调度节点等到超时补偿才发现
+func (e *Executor) recoverStates(ctx context.Context) {
+	states, err := e.store.List(ctx)
+	if err != nil {
+		e.logger.Error("读取待恢复的执行状态失败", elog.FieldErr(err))
+		return
+	}
+
+	for _, state := range states {
+		if isTerminal(state.GetStatus()) {
+			continue
+		}
+
+		// 先落盘再上报：上报失败时，调度节点仍可以通过 Query 得到正确的状态
+		state.Status = executorv1.ExecutionStatus_FAILED_RESCHEDULABLE
+		state.ExecutorNodeId = e.config.ServiceId
+		state.Sequence = e.nextSeq()
+		if err = e.store.Save(ctx, state); err != nil {
+			e.logger.Error("保存恢复状态失败", elog.Int64("eid", state.GetId()), elog.FieldErr(err))
+			continue
+		}
+		e.outbox.Add(state)
+		e.logger.Warn("重启前未结束的任务将上报为可重调度", elog.Int64("eid", state.GetId()))
+	}
+}
+
+// Drain 排空执行节点，用于滚动发布时交接运行中的任务
+// 1. 拒绝新的执行请求（返回可重调度状态）
+// 2. 在排空超时时间内等待运行中的任务自然结束
+// 3. 超时后中断剩余任务，并以 FAILED_RESCHEDULABLE 携带检查点上报，由调度节点转移到其他执行节点
+func (e *Executor) Drain(ctx context.Context) {
+	e.drainMu.Lock()
+	if e.draining {
+		e.drainMu.Unlock()
+		return
+	}
+	e.draining = true
+	e.drainMu.Unlock()
+	e.logger.Info("开始排空执行节点", elog.String("drainTimeout", e.drainTimeout.String()))
+	// 排空结束后尽力送达发件箱中剩余的上报
+	defer e.closeOutbox()
+
+	ctx, cancel := context.WithTimeout(ctx, e.drainTimeout)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		e.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		e.logger.Info("运行中的任务已全部结束，排空完成")
+		return
+	case <-ctx.Done():
+	}
+
+	// 超时：中断剩余任务，给处理函数一点时间保存检查点并自行上报
+	e.running.Range(func(_ int64, taskCtx *Context) bool {
+		taskCtx.cancel()
+		return true
+	})
+	select {
+	case <-done:
+		e.logger.Info("被中断的任务已全部退出，排空完成")
+		return
+	case <-time.After(interruptGracePeriod):
+	}
+
+	// 仍未退出的任务由 SDK 代为上报，处理函数之后的结果将被忽略
+	e.running.Range(func(eid int64, taskCtx *Context) bool {
+		e.logger.Warn("任务未响应中断，代为上报可重调度状态", elog.Int64("eid", eid))
+		e.reportFinalResult(taskCtx, executorv1.ExecutionStatus_FAILED_RESCHEDULABLE,
+			NewTaskError(CategoryInterrupted, false, ErrInterrupted))
+		return true
+	})
+}
+
+// closeOutbox 同步上报发件箱中剩余的状态和任务日志并停止上报，未送达的状态在持久化存储中保留到下次启动
+func (e *Executor) closeOutbox() {
+	ctx, cancel := context.WithTimeout(context.Background(), reportTimeout)
+	defer cancel()
+	e.logShipper.sealAll()
+	e.logShipper.Flush(ctx)
+	if !e.outbox.Flush(ctx) {
+		e.logger.Warn("退出前仍有未送达的上报")
+	}
+	e.stopReport()
+}
+
+// closeStore 停止上报并关闭状态存储，释放 BoltStateStore 的文件锁和后台清理协程
+// 在 gRPC Server 停止后执行，此时不会再有请求读写状态存储
+func (e *Executor) closeStore() {
+	if e.stopReport != nil {
+		e.stopReport()
+	}
+	if err := e.store.Close(); err != nil {
+		e.logger.Error("关闭状态存储失败", elog.FieldErr(err))
+	}
+	// 待上报状态使用单独的存储时一并关闭
+	if closer, ok := e.reportStore.(io.Closer); ok && closer != e.store {
+		if err := closer.Close(); err != nil {
+			e.logger.Error("关闭待上报状态存储失败", elog.FieldErr(err))
 		}
 	}
 }
@@ -186,7 +577,7 @@ func (e *Executor) reportFinalResult(eid int64, status executorv1.ExecutionStatu
 func (e *Executor) Query(ctx context.Context, req *executorv1.QueryRequest) (*executorv1.QueryResponse, error) {
 	eid := req.GetEid()
 
-	if state, ok := e.states.Load(eid); ok {
+	if state, ok := e.loadState(ctx, eid); ok {
 		return &executorv1.QueryResponse{ExecutionState: state}, nil
 	}
 
@@ -202,10 +593,10 @@ func (e *Executor) Query(ctx context.Context, req *executorv1.QueryRequest) (*ex
 func (e *Executor) Interrupt(ctx context.Context, req *executorv1.InterruptRequest) (*executorv1.InterruptResponse, error) {
 	eid := req.GetEid()
 
-	if cancel, ok := e.cancels.Load(eid); ok {
-		cancel()
+	if taskCtx, ok := e.running.Load(eid); ok {
+		taskCtx.cancel()
 
-		if state, exist := e.states.Load(eid); exist {
+		if state, exist := e.loadState(ctx, eid); exist {
 			return &executorv1.InterruptResponse{
 				Success:        true,
 				ExecutionState: state,
@@ -0,0 +1,190 @@
+//go:build unit
+
+package executor
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	executorv1 "github.com/Duke1616/ework-runner/api/proto/gen/executor/v1"
+	grpcpkg "github.com/Duke1616/ework-runner/pkg/grpc"
+	"github.com/gotomicro/ego/core/elog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 停止后关闭状态存储，释放文件锁，重启的执行节点可以立即打开同一个状态文件
+func TestExecutor_CloseStore(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "state.db")
+	store, err := NewBoltStateStore(path, time.Minute)
+	require.NoError(t, err)
+	require.NoError(t, store.Save(context.Background(), &executorv1.ExecutionState{
+		Id:     1,
+		Status: executorv1.ExecutionStatus_RUNNING,
+	}))
+
+	e := &Executor{store: store, reportStore: store, logger: elog.DefaultLogger}
+	e.closeStore()
+
+	reopened, err := NewBoltStateStore(path, time.Minute)
+	require.NoError(t, err)
+	defer reopened.Close()
+	state, ok, err := reopened.Get(context.Background(), 1)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, executorv1.ExecutionStatus_RUNNING, state.GetStatus())
+}
+
+// 处理函数上报的进度经发件箱送达，最终状态之后的进度被忽略
+func TestExecutor_ReportProgress(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+	var taskCtx *Context
+	e, transport := newTestExecutor(t, &funcHandler{name: "sync", run: func(ctx *Context) error {
+		taskCtx = ctx
+		require.NoError(t, ctx.ReportProgress(50))
+		<-release
+		return nil
+	}})
+
+	_, err := e.Execute(context.Background(), &executorv1.ExecuteRequest{Eid: 1, TaskHandlerName: "sync"})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		state, ok := e.loadState(context.Background(), 1)
+		return ok && state.GetRunningProgress() == 50
+	}, time.Second, 5*time.Millisecond)
+	require.True(t, e.outbox.Flush(context.Background()))
+	assert.Equal(t, []executorv1.ExecutionStatus{executorv1.ExecutionStatus_RUNNING}, transport.sentStatuses())
+
+	close(release)
+	e.Drain(context.Background())
+	require.NoError(t, taskCtx.ReportProgress(80))
+	state, ok := e.loadState(context.Background(), 1)
+	require.True(t, ok)
+	assert.Equal(t, executorv1.ExecutionStatus_SUCCESS, state.GetStatus())
+	assert.Equal(t, int32(100), state.GetRunningProgress())
+	assert.Equal(t, []executorv1.ExecutionStatus{
+		executorv1.ExecutionStatus_RUNNING,
+		executorv1.ExecutionStatus_SUCCESS,
+	}, transport.sentStatuses())
+}
+
+// 排空与执行请求并发时，被接收的任务都在 Drain 返回前结束，排空开始后的请求被拒绝
+func TestExecutor_DrainRace(t *testing.T) {
+	t.Parallel()
+
+	e, _ := newTestExecutor(t, &funcHandler{name: "sync", run: func(ctx *Context) error {
+		time.Sleep(time.Millisecond)
+		return nil
+	}})
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		accepted  []int64
+		drainDone = make(chan struct{})
+	)
+	for eid := int64(1); eid <= 100; eid++ {
+		wg.Add(1)
+		go func(eid int64) {
+			defer wg.Done()
+			resp, err := e.Execute(context.Background(), &executorv1.ExecuteRequest{Eid: eid, TaskHandlerName: "sync"})
+			if assert.NoError(t, err) && resp.GetExecutionState().GetStatus() == executorv1.ExecutionStatus_RUNNING {
+				mu.Lock()
+				accepted = append(accepted, eid)
+				mu.Unlock()
+			} else {
+				assert.Equal(t, executorv1.RejectReason_DRAINING, resp.GetExecutionState().GetRejectReason())
+			}
+		}(eid)
+	}
+	go func() {
+		e.Drain(context.Background())
+		close(drainDone)
+	}()
+
+	<-drainDone
+	// Drain 返回时被接收的任务都已经写入最终状态
+	mu.Lock()
+	for _, eid := range accepted {
+		state, ok := e.loadState(context.Background(), eid)
+		if assert.True(t, ok) {
+			assert.Equal(t, executorv1.ExecutionStatus_SUCCESS, state.GetStatus(), "eid=%d", eid)
+		}
+	}
+	mu.Unlock()
+	wg.Wait()
+
+	resp, err := e.Execute(context.Background(), &executorv1.ExecuteRequest{Eid: 101, TaskHandlerName: "sync"})
+	require.NoError(t, err)
+	assert.Equal(t, executorv1.RejectReason_DRAINING, resp.GetExecutionState().GetRejectReason())
+}
+
+// 并发和等待队列都满时拒绝新任务，由调度节点转移到其他执行节点
+func TestExecutor_Busy(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+	e, _ := newTestExecutor(t, &funcHandler{name: "sync", run: func(ctx *Context) error {
+		<-release
+		return nil
+	}}, WithMaxConcurrency(1), WithQueueSize(1))
+
+	statuses := make([]*executorv1.ExecutionState, 0, 3)
+	for eid := int64(1); eid <= 3; eid++ {
+		resp, err := e.Execute(context.Background(), &executorv1.ExecuteRequest{Eid: eid, TaskHandlerName: "sync"})
+		require.NoError(t, err)
+		statuses = append(statuses, resp.GetExecutionState())
+	}
+	assert.Equal(t, executorv1.ExecutionStatus_RUNNING, statuses[0].GetStatus())
+	assert.Equal(t, executorv1.ExecutionStatus_RUNNING, statuses[1].GetStatus())
+	assert.Equal(t, executorv1.ExecutionStatus_FAILED_RESCHEDULABLE, statuses[2].GetStatus())
+	assert.Equal(t, executorv1.RejectReason_BUSY, statuses[2].GetRejectReason())
+
+	// 排队的任务在槽位释放后执行
+	close(release)
+	e.Drain(context.Background())
+	for eid := int64(1); eid <= 2; eid++ {
+		state, ok := e.loadState(context.Background(), eid)
+		require.True(t, ok)
+		assert.Equal(t, executorv1.ExecutionStatus_SUCCESS, state.GetStatus())
+	}
+}
+
+// newTestExecutor 创建不连接调度节点的 Executor，上报记录在返回的 resultTransport 中
+func newTestExecutor(t *testing.T, handler TaskHandler, opts ...Option) (*Executor, *resultTransport) {
+	e, err := NewExecutor(grpcpkg.Config{
+		ServiceId:   "executor-1",
+		ServiceName: "executor",
+		ListenAddr:  "127.0.0.1:0",
+	}, nil, opts...)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = e.store.Close() })
+
+	transport := &resultTransport{}
+	e.transport = transport
+	e.outbox = newOutbox(transport, nil, e.logger)
+	e.logShipper = newLogShipper(transport, e.config.ServiceId, e.logger)
+	e.stopReport = func() {}
+	e.RegisterHandler(handler)
+	return e, transport
+}
+
+type funcHandler struct {
+	name string
+	run  func(ctx *Context) error
+}
+
+func (h *funcHandler) Name() string {
+	return h.name
+}
+
+func (h *funcHandler) Run(ctx *Context) error {
+	return h.run(ctx)
+}
@@ -0,0 +1,99 @@
+package executor
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// limiter 执行节点并发控制
+// 节点级、处理器级两层并发上限，超出上限的任务进入有界等待队列，队列满时拒绝
+type limiter struct {
+	global    chan struct{}            // 节点级并发槽位，nil 表示不限制
+	handlers  map[string]chan struct{} // 处理器级并发槽位，未配置的处理器不限制
+	queueSize int64                    // 等待队列容量，0 表示不排队
+	queued    atomic.Int64
+}
+
+func newLimiter() *limiter {
+	return &limiter{handlers: make(map[string]chan struct{})}
+}
+
+// tryAcquire 非阻塞地获取节点级和处理器级槽位，任一获取失败都不占用槽位
+func (l *limiter) tryAcquire(handlerName string) bool {
+	if l.global != nil {
+		select {
+		case l.global <- struct{}{}:
+		default:
+			return false
+		}
+	}
+	if slots, ok := l.handlers[handlerName]; ok {
+		select {
+		case slots <- struct{}{}:
+		default:
+			if l.global != nil {
+				<-l.global
+			}
+			return false
+		}
+	}
+	return true
+}
+
+// enqueue 占用一个等待队列位置，队列已满时返回 false
+func (l *limiter) enqueue() bool {
+	for {
+		n := l.queued.Load()
+		if n >= l.queueSize {
+			return false
+		}
+		if l.queued.CompareAndSwap(n, n+1) {
+			return true
+		}
+	}
+}
+
+// acquire 阻塞等待槽位，仅用于已经 enqueue 成功的任务，返回时释放队列位置
+// 按处理器级、节点级的固定顺序获取，tryAcquire 从不阻塞，因此不会死锁
+func (l *limiter) acquire(ctx context.Context, handlerName string) error {
+	defer l.queued.Add(-1)
+
+	slots, limited := l.handlers[handlerName]
+	if limited {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if l.global != nil {
+		select {
+		case l.global <- struct{}{}:
+		case <-ctx.Done():
+			if limited {
+				<-slots
+			}
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// release 归还槽位
+func (l *limiter) release(handlerName string) {
+	if slots, ok := l.handlers[handlerName]; ok {
+		<-slots
+	}
+	if l.global != nil {
+		<-l.global
+	}
+}
+
+// releaseAdmission 撤销 Execute 中的准入：已排队的释放队列位置，否则释放已获取的槽位
+func (l *limiter) releaseAdmission(handlerName string, queued bool) {
+	if queued {
+		l.queued.Add(-1)
+		return
+	}
+	l.release(handlerName)
+}
@@ -0,0 +1,67 @@
+//go:build unit
+
+package executor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 处理器级槽位不足时不占用节点级槽位
+func TestLimiter_TryAcquire(t *testing.T) {
+	t.Parallel()
+
+	l := newLimiter()
+	l.global = make(chan struct{}, 2)
+	l.handlers["script"] = make(chan struct{}, 1)
+
+	assert.True(t, l.tryAcquire("script"))
+	assert.False(t, l.tryAcquire("script"))
+	assert.Len(t, l.global, 1)
+
+	assert.True(t, l.tryAcquire("sync"))
+	assert.False(t, l.tryAcquire("sync"))
+
+	l.release("script")
+	assert.True(t, l.tryAcquire("script"))
+}
+
+// 等待队列有界，排队的任务被取消时归还队列位置且不占用槽位
+func TestLimiter_Queue(t *testing.T) {
+	t.Parallel()
+
+	l := newLimiter()
+	l.global = make(chan struct{}, 1)
+	l.handlers["script"] = make(chan struct{}, 1)
+	l.queueSize = 1
+	require.True(t, l.tryAcquire("sync"))
+
+	require.True(t, l.enqueue())
+	assert.False(t, l.enqueue())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, l.acquire(ctx, "script"), context.DeadlineExceeded)
+	assert.Zero(t, l.queued.Load())
+	assert.Empty(t, l.handlers["script"])
+
+	// 槽位释放后排队的任务获取到槽位
+	require.True(t, l.enqueue())
+	done := make(chan error, 1)
+	go func() {
+		done <- l.acquire(context.Background(), "script")
+	}()
+	l.release("sync")
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("排队的任务没有获取到槽位")
+	}
+	assert.Len(t, l.global, 1)
+	assert.Len(t, l.handlers["script"], 1)
+}
@@ -0,0 +1,209 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	reporterv1 "github.com/Duke1616/ework-runner/api/proto/gen/reporter/v1"
+	"github.com/gotomicro/ego/core/elog"
+)
+
+const (
+	// logChunkSize 单个日志块的大小上限
+	logChunkSize = 32 * 1024
+	// logFlushInterval 日志上报间隔
+	logFlushInterval = time.Second
+	// maxBufferedLogSize 未送达日志的内存上限，超出后丢弃最旧的日志块
+	maxBufferedLogSize = 4 * 1024 * 1024
+)
+
+// TaskLogger 任务日志，写入的内容按行缓冲，打包成日志块后异步上报给调度节点
+// 实现了 io.Writer，可以直接作为脚本进程的 Stdout/Stderr
+type TaskLogger struct {
+	eid     int64
+	shipper *logShipper
+
+	mu      sync.Mutex
+	partial []byte // 尚未遇到换行符的半行
+}
+
+// Write 写入日志，完整的行进入上报缓冲，不完整的行等待后续写入或 Close
+func (l *TaskLogger) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	data := append(l.partial, p...)
+	idx := bytes.LastIndexByte(data, '\n')
+	if idx < 0 {
+		l.partial = data
+		return len(p), nil
+	}
+	l.shipper.append(l.eid, data[:idx+1])
+	l.partial = append([]byte(nil), data[idx+1:]...)
+	return len(p), nil
+}
+
+// Printf 按格式写入一行日志
+func (l *TaskLogger) Printf(format string, args ...any) {
+	line := fmt.Sprintf(format, args...)
+	if len(line) == 0 || line[len(line)-1] != '\n' {
+		line += "\n"
+	}
+	_, _ = l.Write([]byte(line))
+}
+
+// Close 写入剩余的半行并立即上报，任务结束时由 SDK 调用
+func (l *TaskLogger) Close() {
+	l.mu.Lock()
+	if len(l.partial) > 0 {
+		l.shipper.append(l.eid, append(l.partial, '\n'))
+		l.partial = nil
+	}
+	l.mu.Unlock()
+	l.shipper.seal(l.eid)
+}
+
+// logShipper 日志上报器，每个执行节点一个
+// 按执行聚合日志行，达到块大小或上报间隔时打包成日志块，通过上报通道批量上报，失败的日志块在下个周期重试
+type logShipper struct {
+	transport ReportTransport
+	nodeID    string
+	logger    *elog.Component
+
+	mu       sync.Mutex
+	building map[int64]*bytes.Buffer
+	started  map[int64]int64 // 正在构建的日志块中第一行的时间
+	sealed   []*reporterv1.LogChunk
+	size     int
+	seq      int64
+	notify   chan struct{}
+}
+
+func newLogShipper(transport ReportTransport, nodeID string, logger *elog.Component) *logShipper {
+	return &logShipper{
+		transport: transport,
+		nodeID:    nodeID,
+		logger:    logger,
+		building:  make(map[int64]*bytes.Buffer),
+		started:   make(map[int64]int64),
+		seq:       time.Now().UnixNano(),
+		notify:    make(chan struct{}, 1),
+	}
+}
+
+// newTaskLogger 创建指定执行的任务日志
+func (s *logShipper) newTaskLogger(eid int64) *TaskLogger {
+	return &TaskLogger{eid: eid, shipper: s}
+}
+
+func (s *logShipper) append(eid int64, lines []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	buf, ok := s.building[eid]
+	if !ok {
+		buf = &bytes.Buffer{}
+		s.building[eid] = buf
+		s.started[eid] = time.Now().UnixMilli()
+	}
+	buf.Write(lines)
+	if buf.Len() >= logChunkSize {
+		s.sealLocked(eid)
+		s.wakeup()
+	}
+}
+
+// seal 将指定执行正在构建的日志块打包并尽快上报
+func (s *logShipper) seal(eid int64) {
+	s.mu.Lock()
+	s.sealLocked(eid)
+	s.mu.Unlock()
+	s.wakeup()
+}
+
+func (s *logShipper) sealLocked(eid int64) {
+	buf, ok := s.building[eid]
+	if !ok {
+		return
+	}
+	delete(s.building, eid)
+	s.seq++
+	s.sealed = append(s.sealed, &reporterv1.LogChunk{
+		Eid:            eid,
+		Sequence:       s.seq,
+		ExecutorNodeId: s.nodeID,
+		Content:        buf.String(),
+		Timestamp:      s.started[eid],
+	})
+	delete(s.started, eid)
+	s.size += buf.Len()
+
+	// 超出内存上限时丢弃最旧的日志块
+	for s.size > maxBufferedLogSize && len(s.sealed) > 1 {
+		dropped := s.sealed[0]
+		s.sealed = s.sealed[1:]
+		s.size -= len(dropped.GetContent())
+		s.logger.Warn("未送达的任务日志过多，丢弃最旧的日志块", elog.Int64("eid", dropped.GetEid()))
+	}
+}
+
+func (s *logShipper) wakeup() {
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Start 周期性上报日志，直到 ctx 结束
+func (s *logShipper) Start(ctx context.Context) {
+	ticker := time.NewTicker(logFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.sealAll()
+		case <-s.notify:
+		}
+		s.Flush(ctx)
+	}
+}
+
+func (s *logShipper) sealAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for eid := range s.building {
+		s.sealLocked(eid)
+	}
+}
+
+// Flush 上报所有已打包的日志块，失败的日志块保留到下次上报
+func (s *logShipper) Flush(ctx context.Context) {
+	s.mu.Lock()
+	chunks := s.sealed
+	s.sealed = nil
+	s.size = 0
+	s.mu.Unlock()
+	if len(chunks) == 0 {
+		return
+	}
+
+	sendCtx, cancel := context.WithTimeout(ctx, reportTimeout)
+	defer cancel()
+	err := s.transport.ReportLogs(sendCtx, chunks)
+	if err == nil {
+		return
+	}
+
+	s.logger.Warn("上报任务日志失败，稍后重试", elog.Int("chunks", len(chunks)), elog.FieldErr(err))
+	s.mu.Lock()
+	s.sealed = append(chunks, s.sealed...)
+	for _, chunk := range chunks {
+		s.size += len(chunk.GetContent())
+	}
+	s.mu.Unlock()
+}
@@ -0,0 +1,51 @@
+//go:build unit
+
+package executor
+
+import (
+	"context"
+	"testing"
+
+	reporterv1 "github.com/Duke1616/ework-runner/api/proto/gen/reporter/v1"
+	"github.com/gotomicro/ego/core/elog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 任务日志通过配置的上报通道送达，上报失败的日志块保留到下次上报
+func TestLogShipper_Transport(t *testing.T) {
+	t.Parallel()
+
+	transport := &logTransport{err: assert.AnError}
+	shipper := newLogShipper(transport, "executor-1", elog.DefaultLogger)
+	logger := shipper.newTaskLogger(1)
+	logger.Printf("开始同步 %d 个用户", 3)
+	_, _ = logger.Write([]byte("同步完成"))
+	logger.Close()
+
+	shipper.Flush(context.Background())
+	assert.Empty(t, transport.chunks)
+
+	transport.err = nil
+	shipper.Flush(context.Background())
+	require.Len(t, transport.chunks, 1)
+	chunk := transport.chunks[0]
+	assert.Equal(t, int64(1), chunk.GetEid())
+	assert.Equal(t, "executor-1", chunk.GetExecutorNodeId())
+	assert.Equal(t, "开始同步 3 个用户\n同步完成\n", chunk.GetContent())
+}
+
+// logTransport 只记录上报的日志块
+type logTransport struct {
+	resultTransport
+	err    error
+	chunks []*reporterv1.LogChunk
+}
+
+func (t *logTransport) ReportLogs(_ context.Context, chunks []*reporterv1.LogChunk) error {
+	if t.err != nil {
+		return t.err
+	}
+	t.chunks = append(t.chunks, chunks...)
+	return nil
+}
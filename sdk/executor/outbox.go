@@ -0,0 +1,259 @@
+package executor
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	executorv1 "github.com/Duke1616/ework-runner/api/proto/gen/executor/v1"
+	reporterv1 "github.com/Duke1616/ework-runner/api/proto/gen/reporter/v1"
+	"github.com/Duke1616/ework-runner/pkg/retry/strategy"
+	"github.com/gotomicro/ego/core/elog"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// defaultReportBatchSize 单次 BatchReport 的最大上报数量
+	defaultReportBatchSize = 100
+	// defaultReportMaxAttempts 单个状态的最大上报次数，超过后放弃，由调度节点的对账补偿器兜底
+	defaultReportMaxAttempts = 30
+	// reportTimeout 单次上报的超时时间
+	reportTimeout = 5 * time.Second
+)
+
+// ReportStore 待上报状态的持久化存储，执行节点重启后继续上报
+type ReportStore interface {
+	// PutPending 保存待上报状态，同一执行只保留最新的状态
+	PutPending(ctx context.Context, state *executorv1.ExecutionState) error
+	// DeletePending 删除已上报成功的状态，仅当存储中的序号与 sequence 一致时删除
+	DeletePending(ctx context.Context, eid, sequence int64) error
+	// ListPending 列出所有待上报状态
+	ListPending(ctx context.Context) ([]*executorv1.ExecutionState, error)
+}
+
+type pendingReport struct {
+	state    *executorv1.ExecutionState
+	attempts int32
+}
+
+// outbox 上报发件箱
+// 状态先进入发件箱再异步上报：同一执行只保留序号最大的状态，批量通过上报通道发送，
+// 失败后按指数退避重试，可选持久化到本地，保证调度节点短暂不可用时最终状态不丢失
+type outbox struct {
+	transport   ReportTransport
+	store       ReportStore // 可选，nil 表示仅在内存中
+	backoff     strategy.Strategy
+	batchSize   int
+	maxAttempts int32
+	logger      *elog.Component
+
+	mu      sync.Mutex
+	pending map[int64]*pendingReport
+	notify  chan struct{}
+}
+
+func newOutbox(transport ReportTransport, store ReportStore, logger *elog.Component) *outbox {
+	return &outbox{
+		transport:   transport,
+		store:       store,
+		backoff:     strategy.NewExponentialBackoffRetryStrategy(100*time.Millisecond, 30*time.Second, 0),
+		batchSize:   defaultReportBatchSize,
+		maxAttempts: defaultReportMaxAttempts,
+		logger:      logger,
+		pending:     make(map[int64]*pendingReport),
+		notify:      make(chan struct{}, 1),
+	}
+}
+
+// recover 加载上次未上报完成的状态
+func (o *outbox) recover(ctx context.Context) {
+	if o.store == nil {
+		return
+	}
+	states, err := o.store.ListPending(ctx)
+	if err != nil {
+		o.logger.Error("读取待上报状态失败", elog.FieldErr(err))
+		return
+	}
+	o.mu.Lock()
+	for _, state := range states {
+		o.pending[state.GetId()] = &pendingReport{state: state}
+	}
+	o.mu.Unlock()
+	if len(states) > 0 {
+		o.logger.Info("恢复待上报状态", elog.Int("count", len(states)))
+		o.wakeup()
+	}
+}
+
+// Add 将状态放入发件箱，序号不大于已有状态的旧状态直接丢弃
+func (o *outbox) Add(state *executorv1.ExecutionState) {
+	state = proto.Clone(state).(*executorv1.ExecutionState)
+
+	o.mu.Lock()
+	if old, ok := o.pending[state.GetId()]; ok && old.state.GetSequence() >= state.GetSequence() {
+		o.mu.Unlock()
+		return
+	}
+	// NOTE: 持久化与放入内存在同一临界区内，避免与上报成功后的删除交错，残留已上报的状态
+	if o.store != nil {
+		if err := o.store.PutPending(context.Background(), state); err != nil {
+			o.logger.Error("持久化待上报状态失败", elog.Int64("eid", state.GetId()), elog.FieldErr(err))
+		}
+	}
+	o.pending[state.GetId()] = &pendingReport{state: state}
+	o.mu.Unlock()
+	o.wakeup()
+}
+
+func (o *outbox) wakeup() {
+	select {
+	case o.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Start 持续上报发件箱中的状态，直到 ctx 结束
+func (o *outbox) Start(ctx context.Context) {
+	var failures int32
+	for {
+		if failures == 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-o.notify:
+			}
+		} else {
+			// 上报失败后按指数退避重试，期间到达的新状态在下一次重试时一并上报
+			interval, _ := o.backoff.NextWithRetries(failures)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(interval):
+			}
+		}
+
+		if o.Flush(ctx) {
+			failures = 0
+		} else {
+			failures++
+		}
+	}
+}
+
+// Flush 上报发件箱中的所有状态，全部成功时返回 true
+func (o *outbox) Flush(ctx context.Context) bool {
+	for {
+		batch := o.nextBatch()
+		if len(batch) == 0 {
+			return true
+		}
+		if !o.send(ctx, batch) {
+			return false
+		}
+	}
+}
+
+// nextBatch 取出一批待上报状态，状态放入发件箱后不再修改，可以直接发送
+func (o *outbox) nextBatch() []*executorv1.ExecutionState {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	batch := make([]*executorv1.ExecutionState, 0, min(len(o.pending), o.batchSize))
+	for _, p := range o.pending {
+		if len(batch) >= o.batchSize {
+			break
+		}
+		batch = append(batch, p.state)
+	}
+	return batch
+}
+
+func (o *outbox) send(ctx context.Context, batch []*executorv1.ExecutionState) bool {
+	reports := make([]*reporterv1.ReportRequest, 0, len(batch))
+	for _, state := range batch {
+		reports = append(reports, &reporterv1.ReportRequest{ExecutionState: state})
+	}
+
+	sendCtx, cancel := context.WithTimeout(ctx, reportTimeout)
+	defer cancel()
+	results, err := o.transport.BatchReport(sendCtx, reports)
+	if err != nil {
+		o.logger.Warn("批量上报失败，稍后重试", elog.Int("count", len(batch)), elog.FieldErr(err))
+		o.markFailed(batch)
+		return false
+	}
+
+	// 按逐条结果确认：处理成功和确定性失败的上报移出发件箱，只重发可以重试的失败
+	done := make([]*executorv1.ExecutionState, 0, len(batch))
+	var retry []*executorv1.ExecutionState
+	for i, state := range batch {
+		if i >= len(results) || results[i].GetSuccess() {
+			done = append(done, state)
+			continue
+		}
+		if results[i].GetRetryable() {
+			retry = append(retry, state)
+			continue
+		}
+		o.logger.Error("上报被调度节点拒绝，不再重发",
+			elog.Int64("eid", state.GetId()),
+			elog.String("status", state.GetStatus().String()),
+			elog.String("error", results[i].GetError()))
+		done = append(done, state)
+	}
+	o.markSent(done)
+	if len(retry) > 0 {
+		o.logger.Warn("部分上报处理失败，稍后重试", elog.Int("count", len(retry)))
+		o.markFailed(retry)
+		return false
+	}
+	return true
+}
+
+// markSent 移除已上报成功的状态，期间有更新的状态则保留新状态
+func (o *outbox) markSent(batch []*executorv1.ExecutionState) {
+	o.mu.Lock()
+	for _, state := range batch {
+		if p, ok := o.pending[state.GetId()]; ok && p.state.GetSequence() == state.GetSequence() {
+			delete(o.pending, state.GetId())
+		}
+	}
+	o.deletePersisted(batch)
+	o.mu.Unlock()
+}
+
+// markFailed 增加失败次数，超过最大上报次数的状态被放弃
+func (o *outbox) markFailed(batch []*executorv1.ExecutionState) {
+	var dropped []*executorv1.ExecutionState
+	o.mu.Lock()
+	for _, state := range batch {
+		p, ok := o.pending[state.GetId()]
+		if !ok || p.state.GetSequence() != state.GetSequence() {
+			continue
+		}
+		p.attempts++
+		if p.attempts >= o.maxAttempts {
+			delete(o.pending, state.GetId())
+			dropped = append(dropped, state)
+		}
+	}
+	o.deletePersisted(dropped)
+	o.mu.Unlock()
+
+	for _, state := range dropped {
+		o.logger.Error("超过最大上报次数，放弃上报",
+			elog.Int64("eid", state.GetId()),
+			elog.String("status", state.GetStatus().String()))
+	}
+}
+
+func (o *outbox) deletePersisted(states []*executorv1.ExecutionState) {
+	if o.store == nil {
+		return
+	}
+	for _, state := range states {
+		if err := o.store.DeletePending(context.Background(), state.GetId(), state.GetSequence()); err != nil {
+			o.logger.Error("删除已上报状态失败", elog.Int64("eid", state.GetId()), elog.FieldErr(err))
+		}
+	}
+}
@@ -0,0 +1,135 @@
+//go:build unit
+
+package executor
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	executorv1 "github.com/Duke1616/ework-runner/api/proto/gen/executor/v1"
+	reporterv1 "github.com/Duke1616/ework-runner/api/proto/gen/reporter/v1"
+	"github.com/gotomicro/ego/core/elog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 批量上报中单个上报处理失败时只重发该上报，确定性失败的上报不再重发
+func TestOutbox_PartialAck(t *testing.T) {
+	t.Parallel()
+
+	transport := &resultTransport{results: map[int64]*reporterv1.ReportResult{
+		2: {Eid: 2, Retryable: true, Error: "数据库不可用"},
+		3: {Eid: 3, Error: "非法的状态迁移"},
+	}}
+	o := newOutbox(transport, nil, elog.DefaultLogger)
+	for eid := int64(1); eid <= 3; eid++ {
+		o.Add(&executorv1.ExecutionState{Id: eid, Status: executorv1.ExecutionStatus_SUCCESS, Sequence: 1})
+	}
+
+	assert.False(t, o.Flush(context.Background()))
+	assert.Equal(t, []int64{2}, o.pendingIDs())
+	assert.Equal(t, int32(1), o.pending[2].attempts)
+
+	// 恢复后只重发失败的上报
+	transport.reset()
+	assert.True(t, o.Flush(context.Background()))
+	assert.Empty(t, o.pendingIDs())
+	assert.Equal(t, []int64{2}, transport.sentIDs())
+}
+
+// 整批上报失败时全部保留，等待重试
+func TestOutbox_BatchFailed(t *testing.T) {
+	t.Parallel()
+
+	transport := &resultTransport{err: assert.AnError}
+	o := newOutbox(transport, nil, elog.DefaultLogger)
+	o.Add(&executorv1.ExecutionState{Id: 1, Status: executorv1.ExecutionStatus_SUCCESS, Sequence: 1})
+	o.Add(&executorv1.ExecutionState{Id: 2, Status: executorv1.ExecutionStatus_FAILED, Sequence: 1})
+
+	assert.False(t, o.Flush(context.Background()))
+	assert.ElementsMatch(t, []int64{1, 2}, o.pendingIDs())
+}
+
+// 序号更大的状态覆盖发件箱中的旧状态，旧状态不会再被上报
+func TestOutbox_KeepLatest(t *testing.T) {
+	t.Parallel()
+
+	transport := &resultTransport{}
+	o := newOutbox(transport, nil, elog.DefaultLogger)
+	o.Add(&executorv1.ExecutionState{Id: 1, Status: executorv1.ExecutionStatus_RUNNING, Sequence: 2})
+	o.Add(&executorv1.ExecutionState{Id: 1, Status: executorv1.ExecutionStatus_SUCCESS, Sequence: 3})
+	o.Add(&executorv1.ExecutionState{Id: 1, Status: executorv1.ExecutionStatus_RUNNING, Sequence: 1})
+
+	require.True(t, o.Flush(context.Background()))
+	require.Len(t, transport.sent, 1)
+	assert.Equal(t, executorv1.ExecutionStatus_SUCCESS, transport.sent[0].GetStatus())
+}
+
+func (o *outbox) pendingIDs() []int64 {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	ids := make([]int64, 0, len(o.pending))
+	for eid := range o.pending {
+		ids = append(ids, eid)
+	}
+	return ids
+}
+
+// resultTransport 按执行 ID 返回预设的处理结果，未预设的视为处理成功
+type resultTransport struct {
+	mu      sync.Mutex
+	err     error
+	results map[int64]*reporterv1.ReportResult
+	sent    []*executorv1.ExecutionState
+}
+
+func (t *resultTransport) BatchReport(_ context.Context, reports []*reporterv1.ReportRequest) ([]*reporterv1.ReportResult, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.err != nil {
+		return nil, t.err
+	}
+	results := make([]*reporterv1.ReportResult, 0, len(reports))
+	for _, report := range reports {
+		t.sent = append(t.sent, report.GetExecutionState())
+		eid := report.GetExecutionState().GetId()
+		if result, ok := t.results[eid]; ok {
+			results = append(results, result)
+			continue
+		}
+		results = append(results, &reporterv1.ReportResult{Eid: eid, Success: true})
+	}
+	return results, nil
+}
+
+func (t *resultTransport) reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.results = nil
+	t.sent = nil
+}
+
+func (t *resultTransport) sentIDs() []int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	ids := make([]int64, 0, len(t.sent))
+	for _, state := range t.sent {
+		ids = append(ids, state.GetId())
+	}
+	return ids
+}
+
+func (t *resultTransport) ReportLogs(_ context.Context, _ []*reporterv1.LogChunk) error {
+	return nil
+}
+
+func (t *resultTransport) sentStatuses() []executorv1.ExecutionStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	statuses := make([]executorv1.ExecutionStatus, 0, len(t.sent))
+	for _, state := range t.sent {
+		statuses = append(statuses, state.GetStatus())
+	}
+	return statuses
+}
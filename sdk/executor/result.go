@@ -0,0 +1,76 @@
+package executor
+
+import (
+	"errors"
+	"unicode/utf8"
+
+	executorv1 "github.com/Duke1616/ework-runner/api/proto/gen/executor/v1"
+)
+
+const (
+	// DefaultMaxResultSize 结果数据的默认大小上限
+	DefaultMaxResultSize = 64 * 1024
+	// maxErrorMessageSize 错误信息的大小上限，超出部分被截断
+	maxErrorMessageSize = 1024
+	// maxErrorDetailSize 错误详情的大小上限，超出部分被截断
+	maxErrorDetailSize = 4 * 1024
+)
+
+// ErrResultTooLarge 结果数据超过大小上限
+var ErrResultTooLarge = errors.New("执行结果超过大小上限")
+
+// CodedError 带错误码的错误，处理函数返回它时错误码会随执行结果上报
+type CodedError struct {
+	Code string
+	Err  error
+}
+
+// NewCodedError 创建带错误码的错误
+func NewCodedError(code string, err error) *CodedError {
+	return &CodedError{Code: code, Err: err}
+}
+
+func (e *CodedError) Error() string {
+	if e.Err == nil {
+		return e.Code
+	}
+	return e.Err.Error()
+}
+
+func (e *CodedError) Unwrap() error {
+	return e.Err
+}
+
+// buildResult 由处理函数设置的结果数据和返回的错误构建执行结果，两者都为空时返回 nil
+func buildResult(payload []byte, err error) *executorv1.ExecutionResult {
+	if len(payload) == 0 && err == nil {
+		return nil
+	}
+	result := &executorv1.ExecutionResult{Payload: payload}
+	if err != nil {
+		result.ErrorMessage = truncate(err.Error(), maxErrorMessageSize)
+		var codedErr *CodedError
+		if errors.As(err, &codedErr) {
+			result.ErrorCode = codedErr.Code
+		}
+		var taskErr *TaskError
+		if errors.As(err, &taskErr) {
+			result.ErrorCategory = taskErr.Category
+			result.ErrorDetail = truncate(taskErr.Detail, maxErrorDetailSize)
+			result.ExitCode = int32(taskErr.ExitCode)
+		}
+	}
+	return result
+}
+
+// truncate 按字节截断字符串，保证不截断多字节字符
+func truncate(s string, size int) string {
+	if len(s) <= size {
+		return s
+	}
+	s = s[:size]
+	for len(s) > 0 && !utf8.ValidString(s) {
+		s = s[:len(s)-1]
+	}
+	return s
+}
@@ -0,0 +1,137 @@
+package executor
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	executorv1 "github.com/Duke1616/ework-runner/api/proto/gen/executor/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// DefaultStateTTL 已结束执行状态的默认保留时间，保留期内调度节点仍可查询到最终状态
+	DefaultStateTTL = 24 * time.Hour
+	// evictInterval 过期状态的清理间隔
+	evictInterval = time.Minute
+)
+
+// StateStore 执行状态存储
+// 运行中的状态一直保留，进入终态后按 TTL 过期清理
+type StateStore interface {
+	// Save 保存执行状态，终态的状态在 TTL 之后被清理
+	Save(ctx context.Context, state *executorv1.ExecutionState) error
+	// Get 获取执行状态，不存在时返回 false
+	Get(ctx context.Context, eid int64) (*executorv1.ExecutionState, bool, error)
+	// List 列出所有未过期的执行状态，用于重启后恢复
+	List(ctx context.Context) ([]*executorv1.ExecutionState, error)
+	// Close 关闭存储，停止后台清理
+	Close() error
+}
+
+// isTerminal 是否为终态（不再变化，可以按 TTL 清理）
+func isTerminal(status executorv1.ExecutionStatus) bool {
+	return status != executorv1.ExecutionStatus_RUNNING &&
+		status != executorv1.ExecutionStatus_UNKNOWN
+}
+
+// expireAt 计算状态的过期时间，零值表示不过期
+func expireAt(state *executorv1.ExecutionState, ttl time.Duration) time.Time {
+	if !isTerminal(state.GetStatus()) {
+		return time.Time{}
+	}
+	return time.Now().Add(ttl)
+}
+
+type memoryEntry struct {
+	state    *executorv1.ExecutionState
+	expireAt time.Time
+}
+
+var _ StateStore = &MemoryStateStore{}
+
+// MemoryStateStore 内存执行状态存储，默认实现，重启后状态丢失
+type MemoryStateStore struct {
+	ttl time.Duration
+
+	mu      sync.RWMutex
+	entries map[int64]memoryEntry
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewMemoryStateStore 创建内存执行状态存储
+func NewMemoryStateStore(ttl time.Duration) *MemoryStateStore {
+	if ttl <= 0 {
+		ttl = DefaultStateTTL
+	}
+	s := &MemoryStateStore{
+		ttl:     ttl,
+		entries: make(map[int64]memoryEntry),
+		stop:    make(chan struct{}),
+	}
+	go s.evictLoop()
+	return s
+}
+
+func (s *MemoryStateStore) Save(_ context.Context, state *executorv1.ExecutionState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[state.GetId()] = memoryEntry{
+		state:    proto.Clone(state).(*executorv1.ExecutionState),
+		expireAt: expireAt(state, s.ttl),
+	}
+	return nil
+}
+
+func (s *MemoryStateStore) Get(_ context.Context, eid int64) (*executorv1.ExecutionState, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	entry, ok := s.entries[eid]
+	if !ok || entry.expired(time.Now()) {
+		return nil, false, nil
+	}
+	return proto.Clone(entry.state).(*executorv1.ExecutionState), true, nil
+}
+
+func (s *MemoryStateStore) List(_ context.Context) ([]*executorv1.ExecutionState, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	now := time.Now()
+	states := make([]*executorv1.ExecutionState, 0, len(s.entries))
+	for _, entry := range s.entries {
+		if !entry.expired(now) {
+			states = append(states, proto.Clone(entry.state).(*executorv1.ExecutionState))
+		}
+	}
+	return states, nil
+}
+
+func (s *MemoryStateStore) Close() error {
+	s.stopOnce.Do(func() { close(s.stop) })
+	return nil
+}
+
+func (s *MemoryStateStore) evictLoop() {
+	ticker := time.NewTicker(evictInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case now := <-ticker.C:
+			s.mu.Lock()
+			for eid, entry := range s.entries {
+				if entry.expired(now) {
+					delete(s.entries, eid)
+				}
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+func (e memoryEntry) expired(now time.Time) bool {
+	return !e.expireAt.IsZero() && now.After(e.expireAt)
+}
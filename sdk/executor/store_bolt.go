@@ -0,0 +1,215 @@
+package executor
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"time"
+
+	executorv1 "github.com/Duke1616/ework-runner/api/proto/gen/executor/v1"
+	bolt "go.etcd.io/bbolt"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	stateBucket   = []byte("execution_states")
+	pendingBucket = []byte("pending_reports")
+)
+
+var (
+	_ StateStore  = &BoltStateStore{}
+	_ ReportStore = &BoltStateStore{}
+)
+
+// BoltStateStore 基于本地 bbolt 文件的执行状态存储，执行节点重启后仍能查询和恢复状态
+// 存储格式：key 为 8 字节大端 eid，value 为 8 字节过期时间（UnixNano，0 表示不过期）+ proto 编码的状态
+// 同时实现 ReportStore，待上报状态保存在独立的 bucket 中，value 为 proto 编码的状态
+type BoltStateStore struct {
+	db  *bolt.DB
+	ttl time.Duration
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewBoltStateStore 打开（不存在时创建）本地状态文件
+func NewBoltStateStore(path string, ttl time.Duration) (*BoltStateStore, error) {
+	if ttl <= 0 {
+		ttl = DefaultStateTTL
+	}
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("打开状态文件失败: %w", err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{stateBucket, pendingBucket} {
+			if _, err1 := tx.CreateBucketIfNotExists(name); err1 != nil {
+				return err1
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("初始化状态文件失败: %w", err)
+	}
+
+	s := &BoltStateStore{
+		db:   db,
+		ttl:  ttl,
+		stop: make(chan struct{}),
+	}
+	go s.evictLoop()
+	return s, nil
+}
+
+func (s *BoltStateStore) Save(_ context.Context, state *executorv1.ExecutionState) error {
+	data, err := proto.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("编码执行状态失败: %w", err)
+	}
+	value := make([]byte, 8, 8+len(data))
+	if expire := expireAt(state, s.ttl); !expire.IsZero() {
+		binary.BigEndian.PutUint64(value, uint64(expire.UnixNano()))
+	}
+	value = append(value, data...)
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(stateBucket).Put(boltKey(state.GetId()), value)
+	})
+}
+
+func (s *BoltStateStore) Get(_ context.Context, eid int64) (*executorv1.ExecutionState, bool, error) {
+	var (
+		state *executorv1.ExecutionState
+		found bool
+	)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		value := tx.Bucket(stateBucket).Get(boltKey(eid))
+		if value == nil || boltExpired(value, time.Now()) {
+			return nil
+		}
+		var err error
+		state, err = decodeBoltState(value)
+		found = err == nil
+		return err
+	})
+	return state, found, err
+}
+
+func (s *BoltStateStore) List(_ context.Context) ([]*executorv1.ExecutionState, error) {
+	var states []*executorv1.ExecutionState
+	now := time.Now()
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(stateBucket).ForEach(func(_, value []byte) error {
+			if boltExpired(value, now) {
+				return nil
+			}
+			state, err := decodeBoltState(value)
+			if err != nil {
+				return err
+			}
+			states = append(states, state)
+			return nil
+		})
+	})
+	return states, err
+}
+
+func (s *BoltStateStore) PutPending(_ context.Context, state *executorv1.ExecutionState) error {
+	data, err := proto.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("编码执行状态失败: %w", err)
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(pendingBucket).Put(boltKey(state.GetId()), data)
+	})
+}
+
+func (s *BoltStateStore) DeletePending(_ context.Context, eid, sequence int64) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(pendingBucket)
+		value := bucket.Get(boltKey(eid))
+		if value == nil {
+			return nil
+		}
+		state := &executorv1.ExecutionState{}
+		if err := proto.Unmarshal(value, state); err == nil && state.GetSequence() != sequence {
+			// 已被更新的状态覆盖，保留新状态
+			return nil
+		}
+		return bucket.Delete(boltKey(eid))
+	})
+}
+
+func (s *BoltStateStore) ListPending(_ context.Context) ([]*executorv1.ExecutionState, error) {
+	var states []*executorv1.ExecutionState
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(pendingBucket).ForEach(func(_, value []byte) error {
+			state := &executorv1.ExecutionState{}
+			if err := proto.Unmarshal(value, state); err != nil {
+				return fmt.Errorf("解码待上报状态失败: %w", err)
+			}
+			states = append(states, state)
+			return nil
+		})
+	})
+	return states, err
+}
+
+func (s *BoltStateStore) Close() error {
+	s.stopOnce.Do(func() { close(s.stop) })
+	return s.db.Close()
+}
+
+func (s *BoltStateStore) evictLoop() {
+	ticker := time.NewTicker(evictInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case now := <-ticker.C:
+			_ = s.db.Update(func(tx *bolt.Tx) error {
+				bucket := tx.Bucket(stateBucket)
+				// NOTE: 遍历过程中删除会导致游标跳过元素，先收集再删除
+				var expired [][]byte
+				_ = bucket.ForEach(func(k, v []byte) error {
+					if boltExpired(v, now) {
+						expired = append(expired, append([]byte(nil), k...))
+					}
+					return nil
+				})
+				for _, k := range expired {
+					if err := bucket.Delete(k); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+		}
+	}
+}
+
+func boltKey(eid int64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(eid))
+	return key
+}
+
+func boltExpired(value []byte, now time.Time) bool {
+	if len(value) < 8 {
+		return true
+	}
+	expire := int64(binary.BigEndian.Uint64(value[:8]))
+	return expire != 0 && now.UnixNano() > expire
+}
+
+func decodeBoltState(value []byte) (*executorv1.ExecutionState, error) {
+	state := &executorv1.ExecutionState{}
+	if err := proto.Unmarshal(value[8:], state); err != nil {
+		return nil, fmt.Errorf("解码执行状态失败: %w", err)
+	}
+	return state, nil
+}
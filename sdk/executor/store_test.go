@@ -0,0 +1,118 @@
+//go:build unit
+
+package executor
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	executorv1 "github.com/Duke1616/ework-runner/api/proto/gen/executor/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStateStore(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		newStore func(t *testing.T, ttl time.Duration) StateStore
+	}{
+		{
+			name: "内存存储",
+			newStore: func(t *testing.T, ttl time.Duration) StateStore {
+				return NewMemoryStateStore(ttl)
+			},
+		},
+		{
+			name: "本地文件存储",
+			newStore: func(t *testing.T, ttl time.Duration) StateStore {
+				store, err := NewBoltStateStore(filepath.Join(t.TempDir(), "state.db"), ttl)
+				require.NoError(t, err)
+				return store
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			t.Run("并发读写", func(t *testing.T) {
+				store := tc.newStore(t, time.Minute)
+				defer store.Close()
+
+				var wg sync.WaitGroup
+				for eid := int64(1); eid <= 20; eid++ {
+					wg.Add(1)
+					go func(eid int64) {
+						defer wg.Done()
+						for progress := int32(0); progress <= 10; progress++ {
+							assert.NoError(t, store.Save(context.Background(), &executorv1.ExecutionState{
+								Id:              eid,
+								Status:          executorv1.ExecutionStatus_RUNNING,
+								RunningProgress: progress,
+							}))
+							_, ok, err := store.Get(context.Background(), eid)
+							assert.NoError(t, err)
+							assert.True(t, ok)
+						}
+					}(eid)
+				}
+				wg.Wait()
+
+				states, err := store.List(context.Background())
+				require.NoError(t, err)
+				assert.Len(t, states, 20)
+				for _, state := range states {
+					assert.Equal(t, int32(10), state.GetRunningProgress())
+				}
+			})
+
+			t.Run("终态按TTL过期", func(t *testing.T) {
+				store := tc.newStore(t, 10*time.Millisecond)
+				defer store.Close()
+
+				require.NoError(t, store.Save(context.Background(), &executorv1.ExecutionState{
+					Id: 1, Status: executorv1.ExecutionStatus_RUNNING}))
+				require.NoError(t, store.Save(context.Background(), &executorv1.ExecutionState{
+					Id: 2, Status: executorv1.ExecutionStatus_SUCCESS}))
+				time.Sleep(20 * time.Millisecond)
+
+				_, ok, err := store.Get(context.Background(), 1)
+				require.NoError(t, err)
+				assert.True(t, ok)
+				_, ok, err = store.Get(context.Background(), 2)
+				require.NoError(t, err)
+				assert.False(t, ok)
+			})
+		})
+	}
+}
+
+// 待上报状态只有序号一致时才删除，上报期间写入的新状态被保留
+func TestBoltStateStore_Pending(t *testing.T) {
+	t.Parallel()
+
+	store, err := NewBoltStateStore(filepath.Join(t.TempDir(), "state.db"), time.Minute)
+	require.NoError(t, err)
+	defer store.Close()
+
+	ctx := context.Background()
+	require.NoError(t, store.PutPending(ctx, &executorv1.ExecutionState{Id: 1, Sequence: 1}))
+	require.NoError(t, store.PutPending(ctx, &executorv1.ExecutionState{Id: 1, Sequence: 2}))
+	require.NoError(t, store.DeletePending(ctx, 1, 1))
+
+	states, err := store.ListPending(ctx)
+	require.NoError(t, err)
+	require.Len(t, states, 1)
+	assert.Equal(t, int64(2), states[0].GetSequence())
+
+	require.NoError(t, store.DeletePending(ctx, 1, 2))
+	states, err = store.ListPending(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, states)
+}
@@ -0,0 +1,98 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	reporterv1 "github.com/Duke1616/ework-runner/api/proto/gen/reporter/v1"
+	"github.com/ecodeclub/mq-api"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// ReportTransport 上报通道，发件箱和日志上报器通过它把执行状态和任务日志送达调度节点
+type ReportTransport interface {
+	// BatchReport 批量上报，返回与 reports 按顺序一一对应的处理结果
+	// 返回错误表示整批都没有送达；结果为空表示全部处理成功（兼容不返回逐条结果的调度节点）
+	BatchReport(ctx context.Context, reports []*reporterv1.ReportRequest) ([]*reporterv1.ReportResult, error)
+	// ReportLogs 批量上报任务日志块，返回错误时整批保留到下次上报，调度节点按执行节点和块序号去重
+	ReportLogs(ctx context.Context, chunks []*reporterv1.LogChunk) error
+}
+
+var _ ReportTransport = &grpcReportTransport{}
+
+// grpcReportTransport 通过调度节点的 ReporterService 同步上报，默认实现
+type grpcReportTransport struct {
+	client reporterv1.ReporterServiceClient
+}
+
+func (t *grpcReportTransport) BatchReport(ctx context.Context, reports []*reporterv1.ReportRequest) ([]*reporterv1.ReportResult, error) {
+	resp, err := t.client.BatchReport(ctx, &reporterv1.BatchReportRequest{Reports: reports})
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetResults(), nil
+}
+
+func (t *grpcReportTransport) ReportLogs(ctx context.Context, chunks []*reporterv1.LogChunk) error {
+	_, err := t.client.ReportLogs(ctx, &reporterv1.ReportLogsRequest{Chunks: chunks})
+	return err
+}
+
+var _ ReportTransport = &MQReportTransport{}
+
+// MQReportTransport 通过消息队列异步上报，适用于执行节点无法直连调度节点 gRPC 端口、但可以访问 Kafka 的场景
+// 每个 ReportRequest 以 protojson 编码为一条消息，key 为执行 ID，保证同一执行的状态进入同一分区
+// 每个 LogChunk 以 protojson 编码为一条消息发送到日志 topic，key 同样为执行 ID
+type MQReportTransport struct {
+	producer    mq.Producer
+	logProducer mq.Producer
+}
+
+// NewMQReportTransport 创建基于消息队列的上报通道，producer 发送执行状态，logProducer 发送任务日志
+func NewMQReportTransport(producer, logProducer mq.Producer) *MQReportTransport {
+	return &MQReportTransport{producer: producer, logProducer: logProducer}
+}
+
+// BatchReport 消息发送成功即视为送达，发送失败的上报由发件箱重发，已经发送的不再重复发送
+func (t *MQReportTransport) BatchReport(ctx context.Context, reports []*reporterv1.ReportRequest) ([]*reporterv1.ReportResult, error) {
+	results := make([]*reporterv1.ReportResult, 0, len(reports))
+	for _, report := range reports {
+		eid := report.GetExecutionState().GetId()
+		data, err := protojson.Marshal(report)
+		if err != nil {
+			// 无法编码的上报重发也不会成功
+			results = append(results, &reporterv1.ReportResult{Eid: eid,
+				Error: fmt.Sprintf("序列化上报消息失败: %v", err)})
+			continue
+		}
+		_, err = t.producer.Produce(ctx, &mq.Message{
+			Key:   []byte(strconv.FormatInt(eid, 10)),
+			Value: data,
+		})
+		if err != nil {
+			results = append(results, &reporterv1.ReportResult{Eid: eid, Retryable: true,
+				Error: fmt.Sprintf("发送上报消息失败: %v", err)})
+			continue
+		}
+		results = append(results, &reporterv1.ReportResult{Eid: eid, Success: true})
+	}
+	return results, nil
+}
+
+func (t *MQReportTransport) ReportLogs(ctx context.Context, chunks []*reporterv1.LogChunk) error {
+	for _, chunk := range chunks {
+		data, err := protojson.Marshal(chunk)
+		if err != nil {
+			return fmt.Errorf("序列化日志消息失败: %w", err)
+		}
+		_, err = t.logProducer.Produce(ctx, &mq.Message{
+			Key:   []byte(strconv.FormatInt(chunk.GetEid(), 10)),
+			Value: data,
+		})
+		if err != nil {
+			return fmt.Errorf("发送日志消息失败: %w", err)
+		}
+	}
+	return nil
+}